@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/resources"
+	"github.com/activecm/rita/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:  "analyze",
+		Usage: "Rebuild analysis results from the data stored in a database using the current config",
+		UsageText: "rita analyze [command options] <database>\n\n" +
+			"Results such as beacons are recomputed from the stored connections, hostnames, " +
+			"and beacon timestamps, so scoring changes take effect without re-importing the logs. " +
+			"The modules which can be re-analyzed are: " + strings.Join(reanalyzableNames(), ", ") + ". " +
+			"The other modules are built from log details which aren't stored and need the " +
+			"logs to be re-imported.",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			modulesFlag,
+		},
+		Action: analyze,
+	}
+
+	bootstrapCommands(command)
+}
+
+//reanalyzableNames lists the registered modules which can be rebuilt from
+//the data stored in a database
+func reanalyzableNames() []string {
+	var names []string
+	for _, module := range analysis.Registered() {
+		if module.Reanalyzable() {
+			names = append(names, module.Name())
+		}
+	}
+	return names
+}
+
+func analyze(c *cli.Context) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+
+	exists, err := res.MetaDB.DBExists(db)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	if !exists {
+		return cli.NewExitError("Database "+db+" does not exist", -1)
+	}
+
+	info, err := res.MetaDB.GetDBMetaInfo(db)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	res.DB.SelectDB(db)
+	// credit the results to the latest chunk so they are replaced with it
+	res.Config.S.Rolling.CurrentChunk = info.CurrentChunk
+
+	var names []string
+	if c.String("modules") != "" {
+		for _, name := range strings.Split(c.String("modules"), ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+
+	modules, err := analysis.ReanalysisModules(res.Config, names)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	if len(modules) == 0 {
+		return cli.NewExitError("No enabled modules can be re-analyzed", -1)
+	}
+
+	var moduleNames []string
+	for _, module := range modules {
+		moduleNames = append(moduleNames, module.Name())
+	}
+	fmt.Println("\t[-] Re-analyzing " + db + ": " + strings.Join(moduleNames, ", "))
+
+	// the results are incomplete until every module finishes
+	start := time.Now()
+	res.MetaDB.MarkDBAnalyzed(db, false)
+
+	err = analysis.Reanalyze(res, modules)
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err.Error(), -1)
+	}
+
+	fmt.Println("\t[-] Updating metadatabase ... ")
+	res.MetaDB.MarkDBAnalyzed(db, true)
+
+	progTime := time.Now()
+	res.Log.WithFields(
+		log.Fields{
+			"database":     db,
+			"modules":      moduleNames,
+			"current_time": progTime.Format(util.TimeFormat),
+			"total_time":   progTime.Sub(start).String(),
+		},
+	).Info("Finished re-analyzing database")
+
+	fmt.Println("\t[-] Done!")
+	return nil
+}
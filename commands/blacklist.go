@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/pkg/blacklist"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:  "blacklist",
+		Usage: "Manage blacklist snapshots for offline use",
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Fetch the configured blacklists and save them to a snapshot bundle",
+				ArgsUsage: "<bundle file>",
+				Flags:     []cli.Flag{ConfigFlag},
+				Before:    SetConfigFilePath,
+				Action:    exportBlacklists,
+			},
+			{
+				Name:      "import",
+				Usage:     "Load a snapshot bundle into the snapshot directory for offline mode",
+				ArgsUsage: "<bundle file>",
+				Flags:     []cli.Flag{ConfigFlag},
+				Before:    SetConfigFilePath,
+				Action:    importBlacklists,
+			},
+		},
+	}
+
+	allCommands = append(allCommands, command)
+}
+
+func exportBlacklists(c *cli.Context) error {
+	bundlePath := c.Args().Get(0)
+	if bundlePath == "" {
+		return cli.NewExitError("\n\t[!] Specify a bundle file to export to", -1)
+	}
+
+	conf, err := config.LoadConfig(getConfigFilePath(c))
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+
+	fmt.Println("\t[-] Fetching blacklists ...")
+	manifest, err := blacklist.ExportSnapshot(conf, bundlePath)
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("\n\t[!] Could not export blacklists: %v", err), -1)
+	}
+
+	showSnapshotFeeds(manifest)
+	fmt.Printf("\t[+] Blacklist snapshot written to %s\n", bundlePath)
+	return nil
+}
+
+func importBlacklists(c *cli.Context) error {
+	bundlePath := c.Args().Get(0)
+	if bundlePath == "" {
+		return cli.NewExitError("\n\t[!] Specify a bundle file to import", -1)
+	}
+
+	conf, err := config.LoadConfig(getConfigFilePath(c))
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+
+	manifest, err := blacklist.ImportSnapshot(conf, bundlePath)
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("\n\t[!] Could not import blacklists: %v", err), -1)
+	}
+
+	showSnapshotFeeds(manifest)
+	fmt.Printf("\t[+] Blacklist snapshot from %s imported to %s\n",
+		time.Unix(manifest.Created, 0).Format(time.RFC1123), conf.S.Blacklisted.SnapshotDirectory)
+	if !conf.S.Blacklisted.Offline {
+		fmt.Println("\t[!] Set Offline to true in the BlackListed config section to use this snapshot")
+	}
+	return nil
+}
+
+func showSnapshotFeeds(manifest blacklist.SnapshotManifest) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Blacklist", "Type", "Entries", "Fetched", "Version"})
+	for _, feed := range manifest.Feeds {
+		table.Append([]string{
+			feed.Name,
+			feed.Type,
+			strconv.Itoa(feed.Entries),
+			time.Unix(feed.Fetched, 0).Format(time.RFC1123),
+			feed.Version[:12],
+		})
+	}
+	table.Render()
+}
@@ -125,6 +125,65 @@ var (
 		Name:  "no-browser, nb",
 		Usage: "Prevent auto-launching of default browser.",
 	}
+
+	// show the ASN and country of external IPs recorded by GeoIP enrichment
+	geoFlag = cli.BoolFlag{
+		Name:  "geo, g",
+		Usage: "Show the ASN, AS organization, and country of external IPs",
+	}
+
+	asnFlag = cli.UintFlag{
+		Name:  "asn",
+		Usage: "Only show external IPs in autonomous system `ASN`",
+	}
+
+	countryFlag = cli.StringFlag{
+		Name:  "country",
+		Usage: "Only show external IPs in the country with ISO code `CC`",
+	}
+
+	hostnamesFlag = cli.BoolFlag{
+		Name:  "hostnames, hn",
+		Usage: "Show the hostnames which resolved to each destination IP",
+	}
+
+	// show results which match an active suppression instead of hiding them
+	showSuppressedFlag = cli.BoolFlag{
+		Name:  "show-suppressed, ss",
+		Usage: "Include results hidden by suppressions (see the suppress command)",
+	}
+
+	// summarize how each result's score changed across chunks
+	trendFlag = cli.BoolFlag{
+		Name:  "trend, tr",
+		Usage: "Show the chunk each result was first scored in, its latest score, the change since the previous chunk, and a sparkline of its scores",
+	}
+
+	// print machine readable output
+	jsonFlag = cli.BoolFlag{
+		Name:  "json, j",
+		Usage: "Print the results as JSON",
+	}
+
+	// minimum change in score reported by diff
+	scoreChangeFlag = cli.Float64Flag{
+		Name:  "score-change",
+		Usage: "Report beacons whose score changed by at least `DELTA`",
+		Value: 0.1,
+	}
+
+	// minimum relative change in counts and durations reported by diff
+	countChangeFlag = cli.Float64Flag{
+		Name:  "count-change",
+		Usage: "Report other results whose value changed by at least `FRACTION` of the larger value",
+		Value: 0.5,
+	}
+
+	// select the analysis modules to re-analyze
+	modulesFlag = cli.StringFlag{
+		Name:  "modules, M",
+		Usage: "Only re-analyze the comma separated `MODULES` and the modules which depend on them",
+	}
 )
 
 // SetConfigFilePath reads config file path from cli context and stores it in app metadata
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/activecm/rita/pkg/diff"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:      "diff",
+		Usage:     "Print results which are new, gone, or changed in score between two databases",
+		ArgsUsage: "<database A> <database B>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			jsonFlag,
+			scoreChangeFlag,
+			countChangeFlag,
+		},
+		Action: diffDatabases,
+	}
+
+	bootstrapCommands(command)
+}
+
+//diffReport is the JSON output of the diff command
+type diffReport struct {
+	Before   string         `json:"before"`
+	After    string         `json:"after"`
+	Sections []diff.Section `json:"sections"`
+}
+
+func diffDatabases(c *cli.Context) error {
+	dbA, dbB := c.Args().Get(0), c.Args().Get(1)
+	if dbA == "" || dbB == "" {
+		return cli.NewExitError("Specify two databases", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+
+	for _, db := range []string{dbA, dbB} {
+		exists, err := res.MetaDB.DBExists(db)
+		if err != nil {
+			return cli.NewExitError(err.Error(), -1)
+		}
+		if !exists {
+			return cli.NewExitError("Database "+db+" does not exist", -1)
+		}
+	}
+
+	sections, err := diff.Datasets(res, dbA, dbB, diff.Options{
+		ScoreChange: c.Float64("score-change"),
+		CountChange: c.Float64("count-change"),
+	})
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	if c.Bool("json") {
+		err = showDiffJSON(diffReport{Before: dbA, After: dbB, Sections: sections})
+	} else {
+		showDiffHuman(dbA, dbB, sections)
+	}
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	return nil
+}
+
+func showDiffJSON(report diffReport) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func showDiffHuman(dbA string, dbB string, sections []diff.Section) {
+	for _, section := range sections {
+		fmt.Printf("%s (%s)\n", section.Name, section.Metric)
+		if len(section.Changes) == 0 {
+			fmt.Println("No changes")
+			fmt.Println()
+			continue
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Result", "Status", dbA, dbB, "Change"})
+		for _, change := range section.Changes {
+			table.Append([]string{
+				change.Result, change.Status,
+				f(change.Before), f(change.After), fmt.Sprintf("%+.3f", change.Delta),
+			})
+		}
+		table.Render()
+		fmt.Println()
+	}
+}
@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"strconv"
+
+	"github.com/activecm/rita/pkg/geoip"
+	"github.com/activecm/rita/pkg/host"
+	"github.com/activecm/rita/resources"
+	"github.com/urfave/cli"
+)
+
+//geoHeaders are the columns added to the output by --geo
+var geoHeaders = []string{"ASN", "AS Org", "Country"}
+
+//geoColumns formats the GeoIP details of an external IP for output
+func geoColumns(info geoip.Info) []string {
+	asn := ""
+	if info.ASN != 0 {
+		asn = "AS" + strconv.FormatUint(uint64(info.ASN), 10)
+	}
+	return []string{asn, info.ASOrg, info.Country}
+}
+
+//geoFilter reads the GeoIP filters given on the command line
+func geoFilter(c *cli.Context) geoip.Filter {
+	return geoip.Filter{
+		ASN:     c.Uint("asn"),
+		Country: c.String("country"),
+	}
+}
+
+//loadGeo reads the GeoIP details recorded for the given IPs if they are
+//shown with --geo or filtered on with --asn or --country. Otherwise, nil is
+//returned.
+func loadGeo(c *cli.Context, res *resources.Resources, ips []string) (map[string]geoip.Info, error) {
+	if !c.Bool("geo") && !geoFilter(c).Active() {
+		return nil, nil
+	}
+	return host.GeoResults(res, ips)
+}
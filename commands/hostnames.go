@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/activecm/rita/resources"
+	"github.com/urfave/cli"
+)
+
+//hostnamesHeader is the column added to the output by --hostnames
+const hostnamesHeader = "Resolved Hostnames"
+
+//loadResolutions reads the hostnames which resolved to the given IPs if
+//they are shown with --hostnames. Otherwise, nil is returned.
+func loadResolutions(c *cli.Context, res *resources.Resources, ips []string) (*hostname.Resolutions, error) {
+	if !c.Bool("hostnames") {
+		return nil, nil
+	}
+	return hostname.ResolvedHostnames(res, ips)
+}
+
+//hostnamesColumn formats the hostnames the source of a connection resolved
+//to its destination for output
+func hostnamesColumn(resolved *hostname.Resolutions, pair data.UniqueIPPair) string {
+	return strings.Join(resolved.Hostnames(pair.UniqueSrcIP.Unpair(), pair.UniqueDstIP.Unpair()), " ")
+}
@@ -0,0 +1,232 @@
+// +build integration
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+const backendTestDB = "RITA-TEST-backends"
+
+const backendTestConfig = `
+Storage:
+    Backend: %s
+    Path: %s
+MongoDB:
+    ConnectionString: %s
+    MetaDB: RITA-TEST-MetaDatabase
+LogConfig:
+    LogLevel: 0
+    LogToFile: false
+    LogToDB: false
+UserConfig:
+    UpdateCheckFrequency: 0
+BlackListed:
+    MalwareDomains.com: false
+    feodotracker.abuse.ch: false
+    BlacklistDatabase: RITA-TEST-rita-bl
+    CustomIPBlacklists: [%s]
+    CustomHostnameBlacklists: [%s]
+Filtering:
+    InternalSubnets: ["10.0.0.0/8"]
+`
+
+var connFields = []string{
+	"ts", "uid", "id.orig_h", "id.orig_p", "id.resp_h", "id.resp_p", "proto", "service", "duration",
+	"orig_bytes", "resp_bytes", "conn_state", "local_orig", "local_resp", "missed_bytes", "history",
+	"orig_pkts", "orig_ip_bytes", "resp_pkts", "resp_ip_bytes", "tunnel_parents",
+}
+
+var connTypes = []string{
+	"time", "string", "addr", "port", "addr", "port", "enum", "string", "interval",
+	"count", "count", "string", "bool", "bool", "count", "string",
+	"count", "count", "count", "count", "set[string]",
+}
+
+var dnsFields = []string{
+	"ts", "uid", "id.orig_h", "id.orig_p", "id.resp_h", "id.resp_p", "proto", "trans_id", "rtt",
+	"query", "qclass", "qclass_name", "qtype", "qtype_name", "rcode", "rcode_name",
+	"AA", "TC", "RD", "RA", "Z", "answers", "TTLs", "rejected",
+}
+
+var dnsTypes = []string{
+	"time", "string", "addr", "port", "addr", "port", "enum", "count", "interval",
+	"string", "count", "string", "count", "string", "count", "string",
+	"bool", "bool", "bool", "bool", "count", "vector[string]", "vector[interval]", "bool",
+}
+
+//writeZeekLog writes a zeek TSV log holding the given rows
+func writeZeekLog(t *testing.T, path string, name string, fields []string, types []string, rows []string) {
+	var log strings.Builder
+	log.WriteString("#separator \\x09\n#set_separator\t,\n#empty_field\t(empty)\n#unset_field\t-\n")
+	fmt.Fprintf(&log, "#path\t%s\n#open\t2020-09-13-12-00-00\n", name)
+	fmt.Fprintf(&log, "#fields\t%s\n#types\t%s\n", strings.Join(fields, "\t"), strings.Join(types, "\t"))
+	for _, row := range rows {
+		log.WriteString(row + "\n")
+	}
+	log.WriteString("#close\t2020-09-14-12-00-00\n")
+	require.NoError(t, ioutil.WriteFile(path, []byte(log.String()), 0644))
+}
+
+//writeChunkLogs writes an hour of conn and dns logs starting at start.
+//Every hour holds a beacon resolved through DNS and a long connection while
+//blacklisted selects the internal host which talks to the blacklisted IP.
+func writeChunkLogs(t *testing.T, dir string, start int64, blacklisted string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	var conns, queries []string
+	conn := func(ts int64, src, dst string, port int, duration float64, bytes int) {
+		conns = append(conns, fmt.Sprintf(
+			"%d.000000\tC%d\t%s\t%d\t%s\t%d\ttcp\t-\t%f\t%d\t%d\tSF\t-\t-\t0\tShADadFf\t10\t%d\t10\t%d\t(empty)",
+			ts, len(conns), src, 40000+len(conns)%20000, dst, port, duration, bytes, bytes*2, bytes+400, bytes*2+400,
+		))
+	}
+
+	for i := int64(0); i < 60; i++ {
+		conn(start+i*60, "10.0.0.5", "203.0.113.10", 443, 0.5, 512)
+		queries = append(queries, fmt.Sprintf(
+			"%d.000000\tD%d\t10.0.0.5\t%d\t10.0.0.1\t53\tudp\t%d\t0.010000\tbeacon.example.com\t1\tC_INTERNET\t1\tA\t0\tNOERROR\tF\tF\tT\tT\t0\t203.0.113.10\t60.000000\tF",
+			start+i*60, i, 50000+i, i,
+		))
+	}
+	conn(start+30, "10.0.0.7", "192.0.2.50", 22, 3000, 1000000)
+	for i := int64(0); i < 5; i++ {
+		conn(start+100+i*300, blacklisted, "198.51.100.7", 80, 2, 4096)
+	}
+	for i := int64(0); i < 3; i++ {
+		conn(start+200+i*600, "10.0.0.6", "198.51.100.20", 443, 1, 2048)
+		queries = append(queries, fmt.Sprintf(
+			"%d.000000\tE%d\t10.0.0.6\t%d\t10.0.0.1\t53\tudp\t%d\t0.010000\tc2.bad.example.net\t1\tC_INTERNET\t1\tA\t0\tNOERROR\tF\tF\tT\tT\t0\t198.51.100.20\t60.000000\tF",
+			start+199+i*600, i, 51000+i, 100+i,
+		))
+	}
+
+	writeZeekLog(t, filepath.Join(dir, "conn.log"), "conn", connFields, connTypes, conns)
+	writeZeekLog(t, filepath.Join(dir, "dns.log"), "dns", dnsFields, dnsTypes, queries)
+}
+
+//runRITA runs the RITA command line with args and returns what it printed
+func runRITA(t *testing.T, configFile string, args ...string) (string, error) {
+	out, err := ioutil.TempFile("", "rita-output")
+	require.NoError(t, err)
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	app := cli.NewApp()
+	app.Name = "rita"
+	app.Flags = []cli.Flag{ConfigFlag}
+	app.Commands = Commands()
+	app.Before = SetConfigFilePath
+
+	stdout := os.Stdout
+	os.Stdout = out
+	err = app.Run(append([]string{"rita", "-c", configFile}, args...))
+	os.Stdout = stdout
+
+	printed, readErr := ioutil.ReadFile(out.Name())
+	require.NoError(t, readErr)
+	return string(printed), err
+}
+
+//TestBackendsMatch imports the same rolling dataset into MongoDB and the
+//embedded backend and checks the show commands print the same results.
+//Replacing the first chunk runs the remover against both backends.
+func TestBackendsMatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	mongoURI := os.Args[len(os.Args)-1]
+	if !strings.Contains(mongoURI, "mongodb://") {
+		t.Fatal("-args [MongoDB URI] is required to run RITA integration tests with go test")
+	}
+
+	// keep errors from exiting the test binary
+	exiter := cli.OsExiter
+	cli.OsExiter = func(int) {}
+	defer func() { cli.OsExiter = exiter }()
+
+	// the version is normally set by the build process
+	version, exactVersion := config.Version, config.ExactVersion
+	config.Version, config.ExactVersion = "v0.0.0+testing", "v0.0.0+testing"
+	defer func() { config.Version, config.ExactVersion = version, exactVersion }()
+
+	dir, err := ioutil.TempDir("", "rita-backends")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ipList := filepath.Join(dir, "ips.txt")
+	require.NoError(t, ioutil.WriteFile(ipList, []byte("198.51.100.7\n"), 0644))
+	hostnameList := filepath.Join(dir, "hostnames.txt")
+	require.NoError(t, ioutil.WriteFile(hostnameList, []byte("c2.bad.example.net\n"), 0644))
+
+	chunks := []string{filepath.Join(dir, "chunk0"), filepath.Join(dir, "chunk1"), filepath.Join(dir, "chunk2")}
+	writeChunkLogs(t, chunks[0], 1600000000, "10.0.0.8")
+	writeChunkLogs(t, chunks[1], 1600003600, "10.0.0.8")
+	writeChunkLogs(t, chunks[2], 1600007200, "10.0.0.9")
+
+	configs := make(map[string]string)
+	for _, backend := range []string{"mongodb", "embedded"} {
+		configs[backend] = filepath.Join(dir, backend+".yaml")
+		conf := fmt.Sprintf(backendTestConfig, backend, filepath.Join(dir, "rita.db"), mongoURI, ipList, hostnameList)
+		require.NoError(t, ioutil.WriteFile(configs[backend], []byte(conf), 0644))
+	}
+
+	shows := [][]string{
+		{"show-beacons", backendTestDB},
+		{"show-beacons-fqdn", backendTestDB},
+		{"show-long-connections", backendTestDB},
+		{"show-exploded-dns", backendTestDB},
+		{"show-bl-dest-ips", backendTestDB},
+		{"show-bl-hostnames", backendTestDB},
+		{"show-threats", backendTestDB},
+	}
+
+	results := make(map[string][]string)
+	for _, backend := range []string{"mongodb", "embedded"} {
+		conf := configs[backend]
+		// clear out a database left behind by an earlier run
+		runRITA(t, conf, "delete", "-f", backendTestDB)
+
+		imports := [][]string{
+			{"import", "--rolling", "--numchunks", "2", "--chunk", "0", chunks[0], backendTestDB},
+			{"import", "--rolling", "--numchunks", "2", "--chunk", "1", chunks[1], backendTestDB},
+			// replace the first chunk, removing its data before importing the third hour
+			{"import", "--delete", "--rolling", "--chunk", "0", chunks[2], backendTestDB},
+		}
+		for _, args := range imports {
+			_, err := runRITA(t, conf, args...)
+			require.NoError(t, err, "%s: rita %s", backend, strings.Join(args, " "))
+		}
+
+		for _, show := range shows {
+			printed, err := runRITA(t, conf, show...)
+			require.NoError(t, err, "%s: rita %s", backend, show[0])
+			results[backend] = append(results[backend], printed)
+		}
+
+		_, err := runRITA(t, conf, "delete", "-f", backendTestDB)
+		require.NoError(t, err)
+	}
+
+	// MongoDB doesn't order results with equal sort keys consistently
+	lines := func(printed string) []string {
+		sorted := strings.Split(printed, "\n")
+		sort.Strings(sorted)
+		return sorted
+	}
+	for i, show := range shows {
+		require.NotEmpty(t, results["mongodb"][i], show[0])
+		require.Equal(t, lines(results["mongodb"][i]), lines(results["embedded"][i]), show[0])
+	}
+}
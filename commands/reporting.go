@@ -15,8 +15,11 @@ func init() {
 			"If no database is specified, a report will be created for every database.",
 		Flags: []cli.Flag{
 			ConfigFlag,
+			showSuppressedFlag,
 			netNamesFlag,
 			noBrowserFlag,
+			asnFlag,
+			countryFlag,
 		},
 		Action: func(c *cli.Context) error {
 			res := resources.InitResources(getConfigFilePath(c))
@@ -27,7 +30,7 @@ func init() {
 			} else {
 				databases = res.MetaDB.GetAnalyzedDatabases()
 			}
-			err := reporting.PrintHTML(databases, c.Bool("network-names"), c.Bool("no-browser"), res)
+			err := reporting.PrintHTML(databases, c.Bool("network-names"), c.Bool("no-browser"), suppressionMatcher(c, res), geoFilter(c), res)
 			if err != nil {
 				return cli.NewExitError(err.Error(), -1)
 			}
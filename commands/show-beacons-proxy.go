@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/activecm/rita/pkg/beaconproxy"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/resources"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli"
@@ -18,6 +19,7 @@ func init() {
 		ArgsUsage: "<database>",
 		Flags: []cli.Flag{
 			ConfigFlag,
+			showSuppressedFlag,
 			humanFlag,
 			delimFlag,
 			netNamesFlag,
@@ -43,6 +45,10 @@ func showBeaconsProxy(c *cli.Context) error {
 		return cli.NewExitError(err, -1)
 	}
 
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Src: data[i].SrcIP, Dst: data[i].DstIP, FQDN: data[i].FQDN}
+	})
+
 	if !(len(data) > 0) {
 		return cli.NewExitError("No results were found for "+db, -1)
 	}
@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/activecm/rita/pkg/beaconsni"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:      "show-beacons-sni",
+		Usage:     "Print hosts which show signs of C2 software (internal -> TLS server name + JA3)",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			delimFlag,
+			netNamesFlag,
+		},
+		Action: showBeaconsSNI,
+	}
+
+	bootstrapCommands(command)
+}
+
+func showBeaconsSNI(c *cli.Context) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+	res.DB.SelectDB(db)
+
+	data, err := beaconsni.Results(res, 0)
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Src: data[i].SrcIP, FQDN: data[i].SNI, JA3: data[i].JA3}
+	})
+
+	if !(len(data) > 0) {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	showNetNames := c.Bool("network-names")
+
+	if c.Bool("human-readable") {
+		err := showBeaconsSNIHuman(data, showNetNames)
+		if err != nil {
+			return cli.NewExitError(err.Error(), -1)
+		}
+		return nil
+	}
+
+	err = showBeaconsSNIDelim(data, c.String("delimiter"), showNetNames)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	return nil
+}
+
+func showBeaconsSNIHuman(data []beaconsni.Result, showNetNames bool) error {
+	table := tablewriter.NewWriter(os.Stdout)
+	var headerFields []string
+	if showNetNames {
+		headerFields = []string{
+			"Score", "Source Network", "Source IP", "SNI", "JA3",
+			"Connections", "Destination IPs", "Intvl Range", "Top Intvl",
+			"Top Intvl Count", "Intvl Skew", "Intvl Dispersion",
+		}
+	} else {
+		headerFields = []string{
+			"Score", "Source IP", "SNI", "JA3",
+			"Connections", "Destination IPs", "Intvl Range", "Top Intvl",
+			"Top Intvl Count", "Intvl Skew", "Intvl Dispersion",
+		}
+	}
+
+	table.SetHeader(headerFields)
+
+	for _, d := range data {
+		var row []string
+
+		if showNetNames {
+			row = []string{
+				f(d.Score), d.SrcNetworkName,
+				d.SrcIP, d.SNI, d.JA3, i(d.Connections), i(d.DstCount),
+				i(d.Ts.Range), i(d.Ts.Mode),
+				i(d.Ts.ModeCount), f(d.Ts.Skew),
+				i(d.Ts.Dispersion),
+			}
+		} else {
+			row = []string{
+				f(d.Score), d.SrcIP, d.SNI, d.JA3, i(d.Connections), i(d.DstCount),
+				i(d.Ts.Range), i(d.Ts.Mode),
+				i(d.Ts.ModeCount), f(d.Ts.Skew),
+				i(d.Ts.Dispersion),
+			}
+		}
+		table.Append(row)
+	}
+	table.Render()
+	return nil
+}
+
+func showBeaconsSNIDelim(data []beaconsni.Result, delim string, showNetNames bool) error {
+	var headerFields []string
+	if showNetNames {
+		headerFields = []string{
+			"Score", "Source Network", "Source IP", "SNI", "JA3",
+			"Connections", "Destination IPs", "Intvl Range", "Top Intvl",
+			"Top Intvl Count", "Intvl Skew", "Intvl Dispersion",
+		}
+	} else {
+		headerFields = []string{
+			"Score", "Source IP", "SNI", "JA3",
+			"Connections", "Destination IPs", "Intvl Range", "Top Intvl",
+			"Top Intvl Count", "Intvl Skew", "Intvl Dispersion",
+		}
+	}
+
+	// Print the headers and analytic values, separated by a delimiter
+	fmt.Println(strings.Join(headerFields, delim))
+	for _, d := range data {
+
+		var row []string
+		if showNetNames {
+			row = []string{
+				f(d.Score), d.SrcNetworkName,
+				d.SrcIP, d.SNI, d.JA3, i(d.Connections), i(d.DstCount),
+				i(d.Ts.Range), i(d.Ts.Mode),
+				i(d.Ts.ModeCount), f(d.Ts.Skew),
+				i(d.Ts.Dispersion),
+			}
+		} else {
+			row = []string{
+				f(d.Score), d.SrcIP, d.SNI, d.JA3, i(d.Connections), i(d.DstCount),
+				i(d.Ts.Range), i(d.Ts.Mode),
+				i(d.Ts.ModeCount), f(d.Ts.Skew),
+				i(d.Ts.Dispersion),
+			}
+		}
+
+		fmt.Println(strings.Join(row, delim))
+	}
+	return nil
+}
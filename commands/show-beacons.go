@@ -6,6 +6,9 @@ import (
 	"strings"
 
 	"github.com/activecm/rita/pkg/beacon"
+	"github.com/activecm/rita/pkg/geoip"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/resources"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli"
@@ -18,9 +21,15 @@ func init() {
 		ArgsUsage: "<database>",
 		Flags: []cli.Flag{
 			ConfigFlag,
+			showSuppressedFlag,
 			humanFlag,
 			delimFlag,
 			netNamesFlag,
+			geoFlag,
+			asnFlag,
+			countryFlag,
+			hostnamesFlag,
+			trendFlag,
 		},
 		Action: showBeacons,
 	}
@@ -43,28 +52,61 @@ func showBeacons(c *cli.Context) error {
 		return cli.NewExitError(err, -1)
 	}
 
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Src: data[i].SrcIP, Dst: data[i].DstIP}
+	})
+
+	var ips []string
+	for _, d := range data {
+		ips = append(ips, d.DstIP)
+	}
+	geo, err := loadGeo(c, res, ips)
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+	filter := geoFilter(c)
+	n := 0
+	for _, d := range data {
+		if filter.Matches(geo[d.DstIP]) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+	if !c.Bool("geo") {
+		geo = nil
+	}
+
+	resolved, err := loadResolutions(c, res, ips)
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
 	if !(len(data) > 0) {
 		return cli.NewExitError("No results were found for "+db, -1)
 	}
 
 	showNetNames := c.Bool("network-names")
+	trend := c.Bool("trend")
 
 	if c.Bool("human-readable") {
-		err := showBeaconsHuman(data, showNetNames)
+		err := showBeaconsHuman(data, showNetNames, geo, resolved, trend)
 		if err != nil {
 			return cli.NewExitError(err.Error(), -1)
 		}
 		return nil
 	}
 
-	err = showBeaconsDelim(data, c.String("delimiter"), showNetNames)
+	err = showBeaconsDelim(data, c.String("delimiter"), showNetNames, geo, resolved, trend)
 	if err != nil {
 		return cli.NewExitError(err.Error(), -1)
 	}
 	return nil
 }
 
-func showBeaconsHuman(data []beacon.Result, showNetNames bool) error {
+func showBeaconsHuman(data []beacon.Result, showNetNames bool, geo map[string]geoip.Info, resolved *hostname.Resolutions, trend bool) error {
 	table := tablewriter.NewWriter(os.Stdout)
 	var headerFields []string
 	if showNetNames {
@@ -83,6 +125,15 @@ func showBeaconsHuman(data []beacon.Result, showNetNames bool) error {
 		}
 	}
 
+	if geo != nil {
+		headerFields = append(headerFields, geoHeaders...)
+	}
+	if resolved != nil {
+		headerFields = append(headerFields, hostnamesHeader)
+	}
+	if trend {
+		headerFields = append(headerFields, trendHeaders...)
+	}
 	table.SetHeader(headerFields)
 
 	for _, d := range data {
@@ -103,13 +154,22 @@ func showBeaconsHuman(data []beacon.Result, showNetNames bool) error {
 				i(d.Ts.Dispersion), i(d.Ds.Dispersion), i(d.TotalBytes),
 			}
 		}
+		if geo != nil {
+			row = append(row, geoColumns(geo[d.DstIP])...)
+		}
+		if resolved != nil {
+			row = append(row, hostnamesColumn(resolved, d.UniqueIPPair))
+		}
+		if trend {
+			row = append(row, trendColumns(d.History)...)
+		}
 		table.Append(row)
 	}
 	table.Render()
 	return nil
 }
 
-func showBeaconsDelim(data []beacon.Result, delim string, showNetNames bool) error {
+func showBeaconsDelim(data []beacon.Result, delim string, showNetNames bool, geo map[string]geoip.Info, resolved *hostname.Resolutions, trend bool) error {
 	var headerFields []string
 	if showNetNames {
 		headerFields = []string{
@@ -127,6 +187,15 @@ func showBeaconsDelim(data []beacon.Result, delim string, showNetNames bool) err
 		}
 	}
 
+	if geo != nil {
+		headerFields = append(headerFields, geoHeaders...)
+	}
+	if resolved != nil {
+		headerFields = append(headerFields, hostnamesHeader)
+	}
+	if trend {
+		headerFields = append(headerFields, trendHeaders...)
+	}
 	// Print the headers and analytic values, separated by a delimiter
 	fmt.Println(strings.Join(headerFields, delim))
 	for _, d := range data {
@@ -149,6 +218,15 @@ func showBeaconsDelim(data []beacon.Result, delim string, showNetNames bool) err
 			}
 		}
 
+		if geo != nil {
+			row = append(row, geoColumns(geo[d.DstIP])...)
+		}
+		if resolved != nil {
+			row = append(row, hostnamesColumn(resolved, d.UniqueIPPair))
+		}
+		if trend {
+			row = append(row, trendColumns(d.History)...)
+		}
 		fmt.Println(strings.Join(row, delim))
 	}
 	return nil
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/activecm/rita/pkg/blacklist"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/resources"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli"
@@ -20,6 +21,7 @@ func init() {
 		ArgsUsage: "<database>",
 		Flags: []cli.Flag{
 			ConfigFlag,
+			showSuppressedFlag,
 			humanFlag,
 			limitFlag,
 			noLimitFlag,
@@ -50,6 +52,10 @@ func printBLHostnames(c *cli.Context) error {
 		return cli.NewExitError(err, -1)
 	}
 
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{FQDN: data[i].Host}
+	})
+
 	if len(data) == 0 {
 		return cli.NewExitError("No results were found for "+db, -1)
 	}
@@ -70,7 +76,7 @@ func printBLHostnames(c *cli.Context) error {
 }
 
 func showBLHostnames(hostnames []blacklist.HostnameResult, delim string, showNetNames bool) error {
-	headers := []string{"Host", "Connections", "Unique Connections", "Total Bytes", "Sources"}
+	headers := []string{"Host", "Connections", "Unique Connections", "Total Bytes", "Sources", "Blacklisted By"}
 
 	// Print the headers and analytic values, separated by a delimiter
 	fmt.Println(strings.Join(headers, delim))
@@ -99,6 +105,7 @@ func showBLHostnames(hostnames []blacklist.HostnameResult, delim string, showNet
 
 		sort.Strings(sourceIPs)
 		serialized = append(serialized, strings.Join(sourceIPs, " "))
+		serialized = append(serialized, blReasonsString(entry.Reasons))
 
 		fmt.Println(
 			strings.Join(
@@ -113,7 +120,7 @@ func showBLHostnames(hostnames []blacklist.HostnameResult, delim string, showNet
 
 func showBLHostnamesHuman(hostnames []blacklist.HostnameResult, showNetNames bool) error {
 	table := tablewriter.NewWriter(os.Stdout)
-	headers := []string{"Hostname", "Connections", "Unique Connections", "Total Bytes", "Sources"}
+	headers := []string{"Hostname", "Connections", "Unique Connections", "Total Bytes", "Sources", "Blacklisted By"}
 
 	table.SetHeader(headers)
 	for _, entry := range hostnames {
@@ -141,6 +148,7 @@ func showBLHostnamesHuman(hostnames []blacklist.HostnameResult, showNetNames boo
 
 		sort.Strings(sourceIPs)
 		serialized = append(serialized, strings.Join(sourceIPs, " "))
+		serialized = append(serialized, blReasonsString(entry.Reasons))
 
 		table.Append(serialized)
 	}
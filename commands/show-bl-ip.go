@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/activecm/rita/pkg/blacklist"
+	"github.com/activecm/rita/pkg/geoip"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/resources"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli"
@@ -19,6 +21,7 @@ func init() {
 		ArgsUsage: "<database>",
 		Flags: []cli.Flag{
 			ConfigFlag,
+			showSuppressedFlag,
 			humanFlag,
 			blConnFlag,
 			blSortFlag,
@@ -26,6 +29,9 @@ func init() {
 			noLimitFlag,
 			delimFlag,
 			netNamesFlag,
+			geoFlag,
+			asnFlag,
+			countryFlag,
 		},
 		Usage:  "Print blacklisted IPs which initiated connections",
 		Action: printBLSourceIPs,
@@ -36,6 +42,7 @@ func init() {
 		ArgsUsage: "<database>",
 		Flags: []cli.Flag{
 			ConfigFlag,
+			showSuppressedFlag,
 			humanFlag,
 			blConnFlag,
 			blSortFlag,
@@ -43,6 +50,9 @@ func init() {
 			noLimitFlag,
 			delimFlag,
 			netNamesFlag,
+			geoFlag,
+			asnFlag,
+			countryFlag,
 		},
 		Usage:  "Print blacklisted IPs which received connections",
 		Action: printBLDestIPs,
@@ -81,17 +91,43 @@ func printBLSourceIPs(c *cli.Context) error {
 		return cli.NewExitError(err, -1)
 	}
 
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Src: data[i].Host.IP}
+	})
+
+	var ips []string
+	for _, d := range data {
+		ips = append(ips, d.Host.IP)
+	}
+	geo, err := loadGeo(c, res, ips)
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+	filter := geoFilter(c)
+	n := 0
+	for _, d := range data {
+		if filter.Matches(geo[d.Host.IP]) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+	if !c.Bool("geo") {
+		geo = nil
+	}
+
 	if len(data) == 0 {
 		return cli.NewExitError("No results were found for "+db, -1)
 	}
 
 	if human {
-		err = showBLIPsHuman(data, connected, showNetNames, true)
+		err = showBLIPsHuman(data, connected, showNetNames, true, geo)
 		if err != nil {
 			return cli.NewExitError(err.Error(), -1)
 		}
 	} else {
-		err = showBLIPs(data, connected, showNetNames, true, c.String("delimiter"))
+		err = showBLIPs(data, connected, showNetNames, true, c.String("delimiter"), geo)
 		if err != nil {
 			return cli.NewExitError(err.Error(), -1)
 		}
@@ -115,17 +151,43 @@ func printBLDestIPs(c *cli.Context) error {
 		return cli.NewExitError(err, -1)
 	}
 
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Dst: data[i].Host.IP}
+	})
+
+	var ips []string
+	for _, d := range data {
+		ips = append(ips, d.Host.IP)
+	}
+	geo, err := loadGeo(c, res, ips)
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+	filter := geoFilter(c)
+	n := 0
+	for _, d := range data {
+		if filter.Matches(geo[d.Host.IP]) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+	if !c.Bool("geo") {
+		geo = nil
+	}
+
 	if len(data) == 0 {
 		return cli.NewExitError("No results were found for "+db, -1)
 	}
 
 	if human {
-		err = showBLIPsHuman(data, connected, showNetNames, false)
+		err = showBLIPsHuman(data, connected, showNetNames, false, geo)
 		if err != nil {
 			return cli.NewExitError(err.Error(), -1)
 		}
 	} else {
-		err = showBLIPs(data, connected, showNetNames, false, c.String("delimiter"))
+		err = showBLIPs(data, connected, showNetNames, false, c.String("delimiter"), geo)
 		if err != nil {
 			return cli.NewExitError(err.Error(), -1)
 		}
@@ -133,7 +195,7 @@ func printBLDestIPs(c *cli.Context) error {
 	return nil
 }
 
-func showBLIPs(ips []blacklist.IPResult, connectedHosts, showNetNames, source bool, delim string) error {
+func showBLIPs(ips []blacklist.IPResult, connectedHosts, showNetNames, source bool, delim string, geo map[string]geoip.Info) error {
 	var headerFields []string
 	if !showNetNames && !connectedHosts {
 		headerFields = []string{"IP", "Connections", "Unique Connections", "Total Bytes"}
@@ -148,6 +210,10 @@ func showBLIPs(ips []blacklist.IPResult, connectedHosts, showNetNames, source bo
 	} else if showNetNames && connectedHosts && !source {
 		headerFields = []string{"IP", "Network", "Connections", "Unique Connections", "Total Bytes", "Sources"}
 	}
+	if geo != nil {
+		headerFields = append(headerFields, geoHeaders...)
+	}
+	headerFields = append(headerFields, "Blacklisted By")
 
 	// Print the headerFields and analytic values, separated by a delimiter
 	fmt.Println(strings.Join(headerFields, delim))
@@ -183,6 +249,10 @@ func showBLIPs(ips []blacklist.IPResult, connectedHosts, showNetNames, source bo
 			sort.Strings(connectedHostsIPs)
 			serialized = append(serialized, strings.Join(connectedHostsIPs, " "))
 		}
+		if geo != nil {
+			serialized = append(serialized, geoColumns(geo[entry.Host.IP])...)
+		}
+		serialized = append(serialized, blIPReasonsString(entry))
 		fmt.Println(
 			strings.Join(
 				serialized,
@@ -193,7 +263,7 @@ func showBLIPs(ips []blacklist.IPResult, connectedHosts, showNetNames, source bo
 	return nil
 }
 
-func showBLIPsHuman(ips []blacklist.IPResult, connectedHosts, showNetNames, source bool) error {
+func showBLIPsHuman(ips []blacklist.IPResult, connectedHosts, showNetNames, source bool, geo map[string]geoip.Info) error {
 	table := tablewriter.NewWriter(os.Stdout)
 	var headerFields []string
 
@@ -210,6 +280,10 @@ func showBLIPsHuman(ips []blacklist.IPResult, connectedHosts, showNetNames, sour
 	} else if showNetNames && connectedHosts && !source {
 		headerFields = []string{"IP", "Network", "Connections", "Unique Connections", "Total Bytes", "Sources"}
 	}
+	if geo != nil {
+		headerFields = append(headerFields, geoHeaders...)
+	}
+	headerFields = append(headerFields, "Blacklisted By")
 
 	table.SetHeader(headerFields)
 	for _, entry := range ips {
@@ -245,8 +319,59 @@ func showBLIPsHuman(ips []blacklist.IPResult, connectedHosts, showNetNames, sour
 			sort.Strings(connectedHostsIPs)
 			serialized = append(serialized, strings.Join(connectedHostsIPs, " "))
 		}
+		if geo != nil {
+			serialized = append(serialized, geoColumns(geo[entry.Host.IP])...)
+		}
+		serialized = append(serialized, blIPReasonsString(entry))
 		table.Append(serialized)
 	}
 	table.Render()
 	return nil
 }
+
+//blIPReasonsString explains why an IP was blacklisted. Matches on a
+//blacklisted range are prefixed with the range.
+func blIPReasonsString(entry blacklist.IPResult) string {
+	reasons := blReasonsString(entry.Reasons)
+	for _, ipRange := range entry.Ranges {
+		if reasons != "" {
+			reasons += " | "
+		}
+		reasons += ipRange.Range + ": " + blReasonString(ipRange.Reason)
+	}
+	return reasons
+}
+
+//blReasonsString joins the reasons an entry was blacklisted
+func blReasonsString(reasons []blacklist.Reason) string {
+	var reasonStrs []string
+	for _, reason := range reasons {
+		reasonStrs = append(reasonStrs, blReasonString(reason))
+	}
+	return strings.Join(reasonStrs, " | ")
+}
+
+//blReasonString names the source of a blacklist entry along with any
+//confidence, tags, and description the source provided
+func blReasonString(reason blacklist.Reason) string {
+	name := reason.List
+	if reason.Source != "" {
+		name = reason.Source
+	}
+
+	var details []string
+	if reason.Confidence != 0 {
+		details = append(details, "confidence "+strconv.Itoa(reason.Confidence))
+	}
+	if len(reason.Tags) > 0 {
+		details = append(details, "tags "+strings.Join(reason.Tags, "/"))
+	}
+	if reason.Description != "" {
+		details = append(details, strings.Join(strings.Fields(reason.Description), " "))
+	}
+
+	if len(details) == 0 {
+		return name
+	}
+	return name + " (" + strings.Join(details, "; ") + ")"
+}
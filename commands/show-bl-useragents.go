@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/activecm/rita/pkg/blacklist"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+
+	blUserAgents := cli.Command{
+		Name:      "show-bl-useragents",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			limitFlag,
+			noLimitFlag,
+			delimFlag,
+			netNamesFlag,
+		},
+		Usage:  "Print blacklisted JA3 hashes and user agents",
+		Action: printBLUserAgents,
+	}
+
+	bootstrapCommands(blUserAgents)
+}
+
+func printBLUserAgents(c *cli.Context) error {
+	db := c.Args().Get(0)
+
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+
+	res := resources.InitResources(getConfigFilePath(c))
+	res.DB.SelectDB(db)
+
+	data, err := blacklist.UserAgentResults(res, c.Int("limit"), c.Bool("no-limit"))
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.UserAgentFinding(data[i].UserAgent, data[i].JA3)
+	})
+
+	if len(data) == 0 {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	showNetNames := c.Bool("network-names")
+
+	if c.Bool("human-readable") {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader(blUserAgentHeaders())
+		for _, entry := range data {
+			table.Append(blUserAgentRow(entry, showNetNames))
+		}
+		table.Render()
+		return nil
+	}
+
+	// Print the headers and analytic values, separated by a delimiter
+	delim := c.String("delimiter")
+	fmt.Println(strings.Join(blUserAgentHeaders(), delim))
+	for _, entry := range data {
+		fmt.Println(strings.Join(blUserAgentRow(entry, showNetNames), delim))
+	}
+	return nil
+}
+
+func blUserAgentHeaders() []string {
+	return []string{"Signature", "Type", "Times Used", "Sources", "Blacklisted By"}
+}
+
+func blUserAgentRow(entry blacklist.UserAgentResult, showNetNames bool) []string {
+	signatureType := "User Agent"
+	if entry.JA3 {
+		signatureType = "JA3"
+	}
+
+	var sourceIPs []string
+	for _, connectedUniqIP := range entry.OrigIps {
+		if showNetNames {
+			escapedNetName := strings.ReplaceAll(connectedUniqIP.NetworkName, " ", "_")
+			escapedNetName = strings.ReplaceAll(escapedNetName, ":", "_")
+			sourceIPs = append(sourceIPs, escapedNetName+":"+connectedUniqIP.IP)
+		} else {
+			sourceIPs = append(sourceIPs, connectedUniqIP.IP)
+		}
+	}
+	sort.Strings(sourceIPs)
+
+	return []string{
+		entry.UserAgent,
+		signatureType,
+		i(entry.TimesUsed),
+		strings.Join(sourceIPs, " "),
+		blReasonsString(entry.Reasons),
+	}
+}
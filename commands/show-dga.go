@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:      "show-dga",
+		Usage:     "Print hostnames which appear to be generated by a domain generation algorithm",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			limitFlag,
+			noLimitFlag,
+			delimFlag,
+		},
+		Action: showDGA,
+	}
+
+	bootstrapCommands(command)
+}
+
+func showDGA(c *cli.Context) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+	res.DB.SelectDB(db)
+
+	data, err := hostname.DGAResults(res, c.Int("limit"), c.Bool("no-limit"))
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{FQDN: data[i].Host}
+	})
+
+	if !(len(data) > 0) {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	if c.Bool("human-readable") {
+		err := showDGAHuman(data)
+		if err != nil {
+			return cli.NewExitError(err.Error(), -1)
+		}
+		return nil
+	}
+
+	err = showDGADelim(data, c.String("delimiter"))
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	return nil
+}
+
+func dgaClientIPs(clients []data.UniqueIP) []string {
+	var clientIPs []string
+	for _, client := range clients {
+		clientIPs = append(clientIPs, client.IP)
+	}
+	return clientIPs
+}
+
+func showDGAHuman(data []hostname.DGAResult) error {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	table.SetHeader([]string{"Score", "Hostname", "Queries", "NXDOMAIN Ratio", "Clients"})
+
+	for _, d := range data {
+		table.Append([]string{
+			f(d.Score), d.Host, i(d.QueryCount), f(d.NXDomainRatio),
+			strings.Join(dgaClientIPs(d.Clients), "\n"),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+func showDGADelim(data []hostname.DGAResult, delim string) error {
+	headers := []string{"Score", "Hostname", "Queries", "NXDOMAIN Ratio", "Clients"}
+
+	// Print the headers and analytic values, separated by a delimiter
+	fmt.Println(strings.Join(headers, delim))
+	for _, d := range data {
+		fmt.Println(strings.Join([]string{
+			f(d.Score), d.Host, i(d.QueryCount), f(d.NXDomainRatio),
+			strings.Join(dgaClientIPs(d.Clients), " "),
+		}, delim))
+	}
+	return nil
+}
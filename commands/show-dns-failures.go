@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/activecm/rita/pkg/dnsfailure"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:      "show-dns-failures",
+		Usage:     "Print DNS clients and domains ranked by their NXDOMAIN and SERVFAIL responses",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			limitFlag,
+			noLimitFlag,
+			delimFlag,
+			netNamesFlag,
+		},
+		Action: showDNSFailures,
+	}
+
+	bootstrapCommands(command)
+}
+
+func showDNSFailures(c *cli.Context) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+	res.DB.SelectDB(db)
+
+	data, err := dnsfailure.Results(res, c.Int("limit"), c.Bool("no-limit"))
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Src: data[i].Client.IP, FQDN: data[i].Domain}
+	})
+
+	if !(len(data) > 0) {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	showNetNames := c.Bool("network-names")
+
+	if c.Bool("human-readable") {
+		err := showDNSFailuresHuman(data, showNetNames)
+		if err != nil {
+			return cli.NewExitError(err.Error(), -1)
+		}
+		return nil
+	}
+
+	err = showDNSFailuresDelim(data, c.String("delimiter"), showNetNames)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	return nil
+}
+
+func dnsFailuresHeaders(showNetNames bool) []string {
+	headerFields := []string{"Score", "Type"}
+	if showNetNames {
+		headerFields = append(headerFields, "Client Network", "Client IP")
+	} else {
+		headerFields = append(headerFields, "Client IP")
+	}
+	return append(headerFields,
+		"Domain", "Queries", "NXDOMAIN", "SERVFAIL", "Failure Ratio", "Failed Domains", "Clients",
+	)
+}
+
+func dnsFailuresRow(d dnsfailure.Result, showNetNames bool) []string {
+	// client results cover every domain the client looked up, while domain
+	// results cover every client which looked the domain up
+	client, clientNetwork, domain := d.Client.IP, d.Client.NetworkName, d.Domain
+	failedDomains, clients := i(d.FailedDomainCount), "*"
+	if d.Type == dnsfailure.Domain {
+		client, clientNetwork = "*", "*"
+		failedDomains, clients = "*", i(d.ClientCount)
+	} else {
+		domain = "*"
+	}
+
+	row := []string{f(d.Score), d.Type}
+	if showNetNames {
+		row = append(row, clientNetwork, client)
+	} else {
+		row = append(row, client)
+	}
+	return append(row,
+		domain, i(d.QueryCount), i(d.NXDomainCount), i(d.ServFailCount), f(d.FailureRatio), failedDomains, clients,
+	)
+}
+
+func showDNSFailuresHuman(data []dnsfailure.Result, showNetNames bool) error {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(dnsFailuresHeaders(showNetNames))
+
+	for _, d := range data {
+		table.Append(dnsFailuresRow(d, showNetNames))
+	}
+	table.Render()
+	return nil
+}
+
+func showDNSFailuresDelim(data []dnsfailure.Result, delim string, showNetNames bool) error {
+	// Print the headers and analytic values, separated by a delimiter
+	fmt.Println(strings.Join(dnsFailuresHeaders(showNetNames), delim))
+	for _, d := range data {
+		fmt.Println(strings.Join(dnsFailuresRow(d, showNetNames), delim))
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/activecm/rita/pkg/dnstunnel"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:      "show-dns-tunnels",
+		Usage:     "Print clients and domains which show signs of DNS tunneling",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			limitFlag,
+			noLimitFlag,
+			delimFlag,
+			netNamesFlag,
+		},
+		Action: showDNSTunnels,
+	}
+
+	bootstrapCommands(command)
+}
+
+func showDNSTunnels(c *cli.Context) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+	res.DB.SelectDB(db)
+
+	data, err := dnstunnel.Results(res, c.Int("limit"), c.Bool("no-limit"))
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Src: data[i].SrcIP, FQDN: data[i].Domain}
+	})
+
+	if !(len(data) > 0) {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	showNetNames := c.Bool("network-names")
+
+	if c.Bool("human-readable") {
+		err := showDNSTunnelsHuman(data, showNetNames)
+		if err != nil {
+			return cli.NewExitError(err.Error(), -1)
+		}
+		return nil
+	}
+
+	err = showDNSTunnelsDelim(data, c.String("delimiter"), showNetNames)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	return nil
+}
+
+func dnsTunnelHeaders(showNetNames bool) []string {
+	headerFields := []string{"Score"}
+	if showNetNames {
+		headerFields = append(headerFields, "Source Network")
+	}
+	return append(headerFields,
+		"Source IP", "Domain", "Queries", "Unique Subdomains",
+		"Avg Label Length", "Max Label Length", "Avg Entropy",
+		"TXT/NULL/CNAME Ratio", "Example Queries",
+	)
+}
+
+func dnsTunnelRow(d dnstunnel.Result, showNetNames bool, exampleSep string) []string {
+	row := []string{f(d.Score)}
+	if showNetNames {
+		row = append(row, d.SrcNetworkName)
+	}
+	return append(row,
+		d.SrcIP, d.Domain, i(d.QueryCount), i(d.SubdomainCount),
+		f(d.AvgLabelLength), i(d.MaxLabelLength), f(d.AvgEntropy),
+		f(d.TunnelQueryTypeRatio), strings.Join(d.Examples, exampleSep),
+	)
+}
+
+func showDNSTunnelsHuman(data []dnstunnel.Result, showNetNames bool) error {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	table.SetHeader(dnsTunnelHeaders(showNetNames))
+
+	for _, d := range data {
+		table.Append(dnsTunnelRow(d, showNetNames, "\n"))
+	}
+	table.Render()
+	return nil
+}
+
+func showDNSTunnelsDelim(data []dnstunnel.Result, delim string, showNetNames bool) error {
+	// Print the headers and analytic values, separated by a delimiter
+	fmt.Println(strings.Join(dnsTunnelHeaders(showNetNames), delim))
+	for _, d := range data {
+		fmt.Println(strings.Join(dnsTunnelRow(d, showNetNames, " "), delim))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/activecm/rita/pkg/exfil"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:      "show-exfil",
+		Usage:     "Print internal hosts and connections ranked by outbound data volume",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			limitFlag,
+			noLimitFlag,
+			delimFlag,
+			netNamesFlag,
+		},
+		Action: showExfil,
+	}
+
+	bootstrapCommands(command)
+}
+
+func showExfil(c *cli.Context) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+	res.DB.SelectDB(db)
+
+	data, err := exfil.Results(res, c.Int("limit"), c.Bool("no-limit"))
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Src: data[i].Local.IP, Dst: data[i].Remote.IP}
+	})
+
+	if !(len(data) > 0) {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	showNetNames := c.Bool("network-names")
+
+	if c.Bool("human-readable") {
+		err := showExfilHuman(data, showNetNames)
+		if err != nil {
+			return cli.NewExitError(err.Error(), -1)
+		}
+		return nil
+	}
+
+	err = showExfilDelim(data, c.String("delimiter"), showNetNames)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	return nil
+}
+
+func exfilHeaders(showNetNames bool) []string {
+	headerFields := []string{"Score", "Type"}
+	if showNetNames {
+		headerFields = append(headerFields, "Internal Network", "Internal IP", "External Network", "External IP")
+	} else {
+		headerFields = append(headerFields, "Internal IP", "External IP")
+	}
+	return append(headerFields,
+		"Bytes Sent", "Bytes Received", "Upload Ratio", "Chunk Deviation", "Connections",
+	)
+}
+
+func exfilRow(d exfil.Result, showNetNames bool) []string {
+	// host results cover every external peer of the internal host
+	remote := d.Remote.IP
+	remoteNetwork := d.Remote.NetworkName
+	if d.Type == exfil.Host {
+		remote = "*"
+		remoteNetwork = "*"
+	}
+
+	row := []string{f(d.Score), d.Type}
+	if showNetNames {
+		row = append(row, d.Local.NetworkName, d.Local.IP, remoteNetwork, remote)
+	} else {
+		row = append(row, d.Local.IP, remote)
+	}
+	return append(row,
+		i(d.BytesSent), i(d.BytesReceived), f(d.UploadRatio), f(d.Deviation), i(d.ConnectionCount),
+	)
+}
+
+func showExfilHuman(data []exfil.Result, showNetNames bool) error {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(exfilHeaders(showNetNames))
+
+	for _, d := range data {
+		table.Append(exfilRow(d, showNetNames))
+	}
+	table.Render()
+	return nil
+}
+
+func showExfilDelim(data []exfil.Result, delim string, showNetNames bool) error {
+	// Print the headers and analytic values, separated by a delimiter
+	fmt.Println(strings.Join(exfilHeaders(showNetNames), delim))
+	for _, d := range data {
+		fmt.Println(strings.Join(exfilRow(d, showNetNames), delim))
+	}
+	return nil
+}
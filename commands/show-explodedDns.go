@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/activecm/rita/pkg/explodeddns"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/resources"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli"
@@ -20,6 +21,7 @@ func init() {
 		ArgsUsage: "<database>",
 		Flags: []cli.Flag{
 			ConfigFlag,
+			showSuppressedFlag,
 			humanFlag,
 			limitFlag,
 			noLimitFlag,
@@ -41,6 +43,10 @@ func init() {
 				return cli.NewExitError(err, -1)
 			}
 
+			filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+				return suppression.Finding{FQDN: data[i].Domain}
+			})
+
 			if len(data) == 0 {
 				return cli.NewExitError("No results were found for "+db, -1)
 			}
@@ -83,14 +89,14 @@ func splitSubN(s string, n int) []string {
 }
 
 func showDNSResults(dnsResults []explodeddns.Result, delim string) error {
-	headers := []string{"Domain", "Unique Subdomains", "Times Looked Up"}
+	headers := []string{"Domain", "Unique Subdomains", "Times Looked Up", "NXDOMAIN", "SERVFAIL"}
 
 	// Print the headers and analytic values, separated by a delimiter
 	fmt.Println(strings.Join(headers, delim))
 	for _, result := range dnsResults {
 		fmt.Println(
 			strings.Join(
-				[]string{result.Domain, i(result.SubdomainCount), i(result.Visited)},
+				[]string{result.Domain, i(result.SubdomainCount), i(result.Visited), i(result.NXDomainCount), i(result.ServFailCount)},
 				delim,
 			),
 		)
@@ -104,7 +110,7 @@ func showDNSResultsHuman(dnsResults []explodeddns.Result) error {
 	table.SetAutoWrapText(true)
 	table.SetRowSeparator("-")
 	table.SetRowLine(true)
-	table.SetHeader([]string{"Domain", "Unique Subdomains", "Times Looked Up"})
+	table.SetHeader([]string{"Domain", "Unique Subdomains", "Times Looked Up", "NXDOMAIN", "SERVFAIL"})
 	for _, result := range dnsResults {
 		domain := result.Domain
 		if len(domain) > DOMAINRECLEN {
@@ -113,7 +119,7 @@ func showDNSResultsHuman(dnsResults []explodeddns.Result) error {
 			domain = strings.Join(subs, "\n")
 		}
 		table.Append([]string{
-			domain, i(result.SubdomainCount), i(result.Visited),
+			domain, i(result.SubdomainCount), i(result.Visited), i(result.NXDomainCount), i(result.ServFailCount),
 		})
 	}
 	table.Render()
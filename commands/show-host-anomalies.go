@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/activecm/rita/pkg/baseline"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:      "show-host-anomalies",
+		Usage:     "Print internal hosts which deviated from their usual behaviour in the latest chunk",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			limitFlag,
+			noLimitFlag,
+			delimFlag,
+			netNamesFlag,
+		},
+		Action: showHostAnomalies,
+	}
+
+	bootstrapCommands(command)
+}
+
+func showHostAnomalies(c *cli.Context) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+
+	info, err := res.MetaDB.GetDBMetaInfo(db)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	res.DB.SelectDB(db)
+
+	// hosts are compared against their baselines as each chunk is imported,
+	// so only the comparisons made for the latest chunk are current
+	data, err := baseline.Results(res, info.CurrentChunk, c.Int("limit"), c.Bool("no-limit"))
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Src: data[i].IP}
+	})
+
+	if !(len(data) > 0) {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	showNetNames := c.Bool("network-names")
+
+	if c.Bool("human-readable") {
+		err := showHostAnomaliesHuman(data, showNetNames)
+		if err != nil {
+			return cli.NewExitError(err.Error(), -1)
+		}
+		return nil
+	}
+
+	err = showHostAnomaliesDelim(data, c.String("delimiter"), showNetNames)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	return nil
+}
+
+//hostAnomalyMetricHeaders names the column for each baseline metric
+var hostAnomalyMetricHeaders = map[string]string{
+	baseline.Destinations: "External Hosts",
+	baseline.Bytes:        "Bytes",
+	baseline.DNSQueries:   "DNS Queries",
+	baseline.Hours:        "New Hours",
+	baseline.Ports:        "New Ports",
+}
+
+func hostAnomalyHeaders(showNetNames bool) []string {
+	headerFields := []string{"Score"}
+	if showNetNames {
+		headerFields = append(headerFields, "Network", "IP")
+	} else {
+		headerFields = append(headerFields, "IP")
+	}
+	headerFields = append(headerFields, "Baseline Chunks")
+	for _, metric := range baseline.Metrics {
+		headerFields = append(headerFields, hostAnomalyMetricHeaders[metric])
+	}
+	return append(headerFields, "New Active Hours (UTC)", "New Port:Protocols")
+}
+
+func hostAnomalyRow(d baseline.Result, showNetNames bool) []string {
+	row := []string{f(d.Baseline.Score)}
+	if showNetNames {
+		row = append(row, d.NetworkName, d.IP)
+	} else {
+		row = append(row, d.IP)
+	}
+	row = append(row, strconv.Itoa(d.Baseline.Chunks))
+
+	// each metric shows the current value, the baseline mean, and the z-score
+	deviations := make(map[string]baseline.Deviation)
+	for _, dev := range d.Baseline.Deviations {
+		deviations[dev.Metric] = dev
+	}
+	for _, metric := range baseline.Metrics {
+		dev := deviations[metric]
+		row = append(row, fmt.Sprintf("%s (mean %s, z %s)", f(dev.Value), f(dev.Mean), f(dev.Score)))
+	}
+
+	hours := make([]string, 0, len(d.Baseline.NewHours))
+	for _, hour := range d.Baseline.NewHours {
+		hours = append(hours, fmt.Sprintf("%02d", hour))
+	}
+	return append(row, strings.Join(hours, " "), strings.Join(d.Baseline.NewPorts, " "))
+}
+
+func showHostAnomaliesHuman(data []baseline.Result, showNetNames bool) error {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(hostAnomalyHeaders(showNetNames))
+
+	for _, d := range data {
+		table.Append(hostAnomalyRow(d, showNetNames))
+	}
+	table.Render()
+	return nil
+}
+
+func showHostAnomaliesDelim(data []baseline.Result, delim string, showNetNames bool) error {
+	// Print the headers and analytic values, separated by a delimiter
+	fmt.Println(strings.Join(hostAnomalyHeaders(showNetNames), delim))
+	for _, d := range data {
+		fmt.Println(strings.Join(hostAnomalyRow(d, showNetNames), delim))
+	}
+	return nil
+}
@@ -6,6 +6,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/activecm/rita/pkg/geoip"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/pkg/uconn"
 	"github.com/activecm/rita/resources"
 	"github.com/olekukonko/tablewriter"
@@ -20,11 +23,16 @@ func init() {
 		ArgsUsage: "<database>",
 		Flags: []cli.Flag{
 			ConfigFlag,
+			showSuppressedFlag,
 			humanFlag,
 			limitFlag,
 			noLimitFlag,
 			delimFlag,
 			netNamesFlag,
+			geoFlag,
+			asnFlag,
+			countryFlag,
+			hostnamesFlag,
 		},
 		Action: func(c *cli.Context) error {
 			db := c.Args().Get(0)
@@ -43,18 +51,50 @@ func init() {
 				return cli.NewExitError(err, -1)
 			}
 
+			filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+				return suppression.Finding{Src: data[i].SrcIP, Dst: data[i].DstIP}
+			})
+
+			var ips []string
+			for _, d := range data {
+				ips = append(ips, d.DstIP)
+			}
+			geo, err := loadGeo(c, res, ips)
+			if err != nil {
+				res.Log.Error(err)
+				return cli.NewExitError(err, -1)
+			}
+			filter := geoFilter(c)
+			n := 0
+			for _, d := range data {
+				if filter.Matches(geo[d.DstIP]) {
+					data[n] = d
+					n++
+				}
+			}
+			data = data[:n]
+			if !c.Bool("geo") {
+				geo = nil
+			}
+
+			resolved, err := loadResolutions(c, res, ips)
+			if err != nil {
+				res.Log.Error(err)
+				return cli.NewExitError(err, -1)
+			}
+
 			if !(len(data) > 0) {
 				return cli.NewExitError("No results were found for "+db, -1)
 			}
 
 			if c.Bool("human-readable") {
-				err := showConnsHuman(data, c.Bool("network-names"))
+				err := showConnsHuman(data, c.Bool("network-names"), geo, resolved)
 				if err != nil {
 					return cli.NewExitError(err.Error(), -1)
 				}
 				return nil
 			}
-			err = showConns(data, c.String("delimiter"), c.Bool("network-names"))
+			err = showConns(data, c.String("delimiter"), c.Bool("network-names"), geo, resolved)
 			if err != nil {
 				return cli.NewExitError(err.Error(), -1)
 			}
@@ -90,7 +130,7 @@ func duration(d time.Duration) string {
 	return b.String()
 }
 
-func showConns(connResults []uconn.LongConnResult, delim string, showNetNames bool) error {
+func showConns(connResults []uconn.LongConnResult, delim string, showNetNames bool, geo map[string]geoip.Info, resolved *hostname.Resolutions) error {
 
 	var headerFields []string
 	if showNetNames {
@@ -99,6 +139,12 @@ func showConns(connResults []uconn.LongConnResult, delim string, showNetNames bo
 		headerFields = []string{"Source IP", "Destination IP", "Port:Protocol:Service", "Duration"}
 	}
 
+	if geo != nil {
+		headerFields = append(headerFields, geoHeaders...)
+	}
+	if resolved != nil {
+		headerFields = append(headerFields, hostnamesHeader)
+	}
 	// Print the headers and analytic values, separated by a delimiter
 	fmt.Println(strings.Join(headerFields, delim))
 	for _, result := range connResults {
@@ -121,12 +167,18 @@ func showConns(connResults []uconn.LongConnResult, delim string, showNetNames bo
 			}
 		}
 
+		if geo != nil {
+			row = append(row, geoColumns(geo[result.DstIP])...)
+		}
+		if resolved != nil {
+			row = append(row, hostnamesColumn(resolved, result.UniqueIPPair))
+		}
 		fmt.Println(strings.Join(row, delim))
 	}
 	return nil
 }
 
-func showConnsHuman(connResults []uconn.LongConnResult, showNetNames bool) error {
+func showConnsHuman(connResults []uconn.LongConnResult, showNetNames bool, geo map[string]geoip.Info, resolved *hostname.Resolutions) error {
 	table := tablewriter.NewWriter(os.Stdout)
 
 	var headerFields []string
@@ -136,6 +188,12 @@ func showConnsHuman(connResults []uconn.LongConnResult, showNetNames bool) error
 		headerFields = []string{"Source IP", "Destination IP", "Port:Protocol:Service", "Duration"}
 	}
 
+	if geo != nil {
+		headerFields = append(headerFields, geoHeaders...)
+	}
+	if resolved != nil {
+		headerFields = append(headerFields, hostnamesHeader)
+	}
 	table.SetHeader(headerFields)
 	for _, result := range connResults {
 		var row []string
@@ -157,6 +215,12 @@ func showConnsHuman(connResults []uconn.LongConnResult, showNetNames bool) error
 			}
 		}
 
+		if geo != nil {
+			row = append(row, geoColumns(geo[result.DstIP])...)
+		}
+		if resolved != nil {
+			row = append(row, hostnamesColumn(resolved, result.UniqueIPPair))
+		}
 		table.Append(row)
 	}
 	table.Render()
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+//init adds the show command of every analysis module which describes one
+func init() {
+	for _, module := range analysis.Registered() {
+		if table := module.Table(); table != nil {
+			bootstrapCommands(showTableCommand(table))
+		}
+	}
+}
+
+//showTableCommand creates the show command printing a module's results
+func showTableCommand(table *analysis.Table) cli.Command {
+	return cli.Command{
+		Name:      table.Command,
+		Usage:     table.Usage,
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			limitFlag,
+			noLimitFlag,
+			delimFlag,
+			netNamesFlag,
+		},
+		Action: func(c *cli.Context) error {
+			return showTable(c, table)
+		},
+	}
+}
+
+func showTable(c *cli.Context, table *analysis.Table) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+	res.DB.SelectDB(db)
+
+	showNetNames := c.Bool("network-names")
+
+	rows, err := table.Rows(res, c.Int("limit"), c.Bool("no-limit"), showNetNames)
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &rows, func(i int) suppression.Finding {
+		return rows[i].Match
+	})
+
+	if !(len(rows) > 0) {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	if c.Bool("human-readable") {
+		out := tablewriter.NewWriter(os.Stdout)
+		out.SetHeader(table.Header(showNetNames))
+		for _, row := range rows {
+			out.Append(row.Cells)
+		}
+		out.Render()
+		return nil
+	}
+
+	// Print the headers and analytic values, separated by a delimiter
+	delim := c.String("delimiter")
+	fmt.Println(strings.Join(table.Header(showNetNames), delim))
+	for _, row := range rows {
+		fmt.Println(strings.Join(row.Cells, delim))
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/activecm/rita/pkg/prevalence"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/activecm/rita/util"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:      "show-rare-destinations",
+		Usage:     "Print external IPs and FQDNs first contacted in the current chunk by few internal hosts",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			limitFlag,
+			noLimitFlag,
+			delimFlag,
+			netNamesFlag,
+		},
+		Action: showRareDestinations,
+	}
+
+	bootstrapCommands(command)
+}
+
+func showRareDestinations(c *cli.Context) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+	res.DB.SelectDB(db)
+
+	data, err := prevalence.RareResults(res, c.Int("limit"), c.Bool("no-limit"))
+
+	if err == prevalence.ErrNoPreviousChunk {
+		return cli.NewExitError("No results were found for "+db+": "+err.Error(), -1)
+	}
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Dst: data[i].IP, FQDN: data[i].FQDN}
+	})
+
+	if !(len(data) > 0) {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	showNetNames := c.Bool("network-names")
+
+	if c.Bool("human-readable") {
+		err := showRareDestinationsHuman(data, showNetNames)
+		if err != nil {
+			return cli.NewExitError(err.Error(), -1)
+		}
+		return nil
+	}
+
+	err = showRareDestinationsDelim(data, c.String("delimiter"), showNetNames)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	return nil
+}
+
+func rareDestinationHeaders(showNetNames bool) []string {
+	headerFields := []string{"First Seen", "Last Seen", "Type"}
+	if showNetNames {
+		headerFields = append(headerFields, "Destination Network")
+	}
+	return append(headerFields, "Destination", "Internal Hosts", "Internal Host IPs")
+}
+
+func rareDestinationRow(d prevalence.Result, showNetNames bool, clientSep string) []string {
+	destination := d.FQDN
+	if d.Type == prevalence.IP {
+		destination = d.IP
+	}
+
+	var clientIPs []string
+	for _, client := range d.Clients {
+		clientIPs = append(clientIPs, client.IP)
+	}
+
+	row := []string{
+		time.Unix(d.FirstSeen, 0).UTC().Format(util.TimeFormat),
+		time.Unix(d.LastSeen, 0).UTC().Format(util.TimeFormat),
+		d.Type,
+	}
+	if showNetNames {
+		row = append(row, d.NetworkName)
+	}
+	return append(row, destination, i(d.ClientCount), strings.Join(clientIPs, clientSep))
+}
+
+func showRareDestinationsHuman(data []prevalence.Result, showNetNames bool) error {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader(rareDestinationHeaders(showNetNames))
+
+	for _, d := range data {
+		table.Append(rareDestinationRow(d, showNetNames, "\n"))
+	}
+	table.Render()
+	return nil
+}
+
+func showRareDestinationsDelim(data []prevalence.Result, delim string, showNetNames bool) error {
+	// Print the headers and analytic values, separated by a delimiter
+	fmt.Println(strings.Join(rareDestinationHeaders(showNetNames), delim))
+	for _, d := range data {
+		fmt.Println(strings.Join(rareDestinationRow(d, showNetNames, " "), delim))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/activecm/rita/pkg/resolverbypass"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:      "show-resolver-bypass",
+		Usage:     "Print internal hosts sending DNS to resolvers other than the approved resolvers",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			limitFlag,
+			noLimitFlag,
+			delimFlag,
+			netNamesFlag,
+		},
+		Action: showResolverBypass,
+	}
+
+	bootstrapCommands(command)
+}
+
+func showResolverBypass(c *cli.Context) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+	res.DB.SelectDB(db)
+
+	data, err := resolverbypass.Results(res, c.Int("limit"), c.Bool("no-limit"))
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Src: data[i].SrcIP, Dst: data[i].DstIP}
+	})
+
+	if !(len(data) > 0) {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	showNetNames := c.Bool("network-names")
+
+	if c.Bool("human-readable") {
+		err := showResolverBypassHuman(data, showNetNames)
+		if err != nil {
+			return cli.NewExitError(err.Error(), -1)
+		}
+		return nil
+	}
+
+	err = showResolverBypassDelim(data, c.String("delimiter"), showNetNames)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	return nil
+}
+
+func resolverBypassHeaders(showNetNames bool) []string {
+	var headerFields []string
+	if showNetNames {
+		headerFields = []string{"Source Network", "Destination Network", "Source IP", "Destination IP"}
+	} else {
+		headerFields = []string{"Source IP", "Destination IP"}
+	}
+	return append(headerFields, "Method", "Server Names", "Connections", "Total Bytes")
+}
+
+func resolverBypassRow(d resolverbypass.Result, showNetNames bool) []string {
+	var row []string
+	if showNetNames {
+		row = []string{d.SrcNetworkName, d.DstNetworkName, d.SrcIP, d.DstIP}
+	} else {
+		row = []string{d.SrcIP, d.DstIP}
+	}
+	return append(row, d.Method, strings.Join(d.ServerNames, " "), i(d.ConnectionCount), i(d.TotalBytes))
+}
+
+func showResolverBypassHuman(data []resolverbypass.Result, showNetNames bool) error {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(resolverBypassHeaders(showNetNames))
+
+	for _, d := range data {
+		table.Append(resolverBypassRow(d, showNetNames))
+	}
+	table.Render()
+	return nil
+}
+
+func showResolverBypassDelim(data []resolverbypass.Result, delim string, showNetNames bool) error {
+	// Print the headers and analytic values, separated by a delimiter
+	fmt.Println(strings.Join(resolverBypassHeaders(showNetNames), delim))
+	for _, d := range data {
+		fmt.Println(strings.Join(resolverBypassRow(d, showNetNames), delim))
+	}
+	return nil
+}
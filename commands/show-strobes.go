@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/activecm/rita/pkg/beacon"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/resources"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli"
@@ -19,6 +21,7 @@ func init() {
 		ArgsUsage: "<database>",
 		Flags: []cli.Flag{
 			ConfigFlag,
+			showSuppressedFlag,
 			humanFlag,
 			cli.BoolFlag{
 				Name:  "connection-count, l",
@@ -28,6 +31,7 @@ func init() {
 			noLimitFlag,
 			delimFlag,
 			netNamesFlag,
+			hostnamesFlag,
 		},
 		Action: func(c *cli.Context) error {
 			db := c.Args().Get(0)
@@ -50,18 +54,32 @@ func init() {
 				return cli.NewExitError(err, -1)
 			}
 
+			filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+				return suppression.Finding{Src: data[i].SrcIP, Dst: data[i].DstIP}
+			})
+
+			var ips []string
+			for _, d := range data {
+				ips = append(ips, d.DstIP)
+			}
+			resolved, err := loadResolutions(c, res, ips)
+			if err != nil {
+				res.Log.Error(err)
+				return cli.NewExitError(err, -1)
+			}
+
 			if len(data) == 0 {
 				return cli.NewExitError("No results were found for "+db, -1)
 			}
 
 			if c.Bool("human-readable") {
-				err := showStrobesHuman(data, c.Bool("network-names"))
+				err := showStrobesHuman(data, c.Bool("network-names"), resolved)
 				if err != nil {
 					return cli.NewExitError(err.Error(), -1)
 				}
 				return nil
 			}
-			err = showStrobes(data, c.String("delimiter"), c.Bool("network-names"))
+			err = showStrobes(data, c.String("delimiter"), c.Bool("network-names"), resolved)
 			if err != nil {
 				return cli.NewExitError(err.Error(), -1)
 			}
@@ -71,13 +89,16 @@ func init() {
 	bootstrapCommands(command)
 }
 
-func showStrobes(strobes []beacon.StrobeResult, delim string, showNetNames bool) error {
+func showStrobes(strobes []beacon.StrobeResult, delim string, showNetNames bool, resolved *hostname.Resolutions) error {
 	var headerFields []string
 	if showNetNames {
 		headerFields = []string{"Source Network", "Destination Network", "Source", "Destination", "Connection Count"}
 	} else {
 		headerFields = []string{"Source", "Destination", "Connection Count"}
 	}
+	if resolved != nil {
+		headerFields = append(headerFields, hostnamesHeader)
+	}
 
 	// Print the headers and analytic values, separated by a delimiter
 	fmt.Println(strings.Join(headerFields, delim))
@@ -98,12 +119,15 @@ func showStrobes(strobes []beacon.StrobeResult, delim string, showNetNames bool)
 				i(strobe.ConnectionCount),
 			}
 		}
+		if resolved != nil {
+			row = append(row, hostnamesColumn(resolved, strobe.UniqueIPPair))
+		}
 		fmt.Println(strings.Join(row, delim))
 	}
 	return nil
 }
 
-func showStrobesHuman(strobes []beacon.StrobeResult, showNetNames bool) error {
+func showStrobesHuman(strobes []beacon.StrobeResult, showNetNames bool, resolved *hostname.Resolutions) error {
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetColWidth(100)
 
@@ -113,6 +137,9 @@ func showStrobesHuman(strobes []beacon.StrobeResult, showNetNames bool) error {
 	} else {
 		headerFields = []string{"Source", "Destination", "Connection Count"}
 	}
+	if resolved != nil {
+		headerFields = append(headerFields, hostnamesHeader)
+	}
 	table.SetHeader(headerFields)
 
 	for _, strobe := range strobes {
@@ -132,6 +159,9 @@ func showStrobesHuman(strobes []beacon.StrobeResult, showNetNames bool) error {
 				i(strobe.ConnectionCount),
 			}
 		}
+		if resolved != nil {
+			row = append(row, hostnamesColumn(resolved, strobe.UniqueIPPair))
+		}
 		table.Append(row)
 	}
 	table.Render()
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/pkg/threat"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:      "show-threats",
+		Usage:     "Print internal hosts ranked by a composite threat score across all analysis modules",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			limitFlag,
+			noLimitFlag,
+			delimFlag,
+			netNamesFlag,
+		},
+		Action: showThreats,
+	}
+
+	bootstrapCommands(command)
+}
+
+func showThreats(c *cli.Context) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+	res.DB.SelectDB(db)
+
+	data, err := threat.Results(res, c.Int("limit"), c.Bool("no-limit"))
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Src: data[i].IP}
+	})
+
+	if !(len(data) > 0) {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	showNetNames := c.Bool("network-names")
+
+	if c.Bool("human-readable") {
+		err := showThreatsHuman(data, showNetNames)
+		if err != nil {
+			return cli.NewExitError(err.Error(), -1)
+		}
+		return nil
+	}
+
+	err = showThreatsDelim(data, c.String("delimiter"), showNetNames)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	return nil
+}
+
+func threatsHeaders(showNetNames bool) []string {
+	headerFields := []string{"Score"}
+	if showNetNames {
+		headerFields = append(headerFields, "Network", "IP")
+	} else {
+		headerFields = append(headerFields, "IP")
+	}
+	return append(headerFields, "Findings")
+}
+
+//threatFinding formats a finding as "module (score): detail", noting how
+//many other findings the module reported for the host
+func threatFinding(finding threat.Finding) string {
+	formatted := fmt.Sprintf("%s (%s): %s", finding.Module, f(finding.Score), finding.Detail)
+	if finding.Count > 1 {
+		formatted += fmt.Sprintf(" [+%d more]", finding.Count-1)
+	}
+	return formatted
+}
+
+func threatsRow(d threat.Result, showNetNames bool, findingSep string) []string {
+	var findings []string
+	for _, finding := range d.Findings {
+		findings = append(findings, threatFinding(finding))
+	}
+
+	row := []string{f(d.Score)}
+	if showNetNames {
+		row = append(row, d.NetworkName, d.IP)
+	} else {
+		row = append(row, d.IP)
+	}
+	return append(row, strings.Join(findings, findingSep))
+}
+
+func showThreatsHuman(data []threat.Result, showNetNames bool) error {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(threatsHeaders(showNetNames))
+	table.SetAutoWrapText(false)
+
+	for _, d := range data {
+		table.Append(threatsRow(d, showNetNames, "\n"))
+	}
+	table.Render()
+	return nil
+}
+
+func showThreatsDelim(data []threat.Result, delim string, showNetNames bool) error {
+	// Print the headers and analytic values, separated by a delimiter
+	fmt.Println(strings.Join(threatsHeaders(showNetNames), delim))
+	for _, d := range data {
+		fmt.Println(strings.Join(threatsRow(d, showNetNames, " | "), delim))
+	}
+	return nil
+}
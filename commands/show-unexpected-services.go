@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/pkg/uconn"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:      "show-unexpected-services",
+		Usage:     "Print connections using services on unexpected ports and protocols",
+		ArgsUsage: "<database>",
+		Flags: []cli.Flag{
+			ConfigFlag,
+			showSuppressedFlag,
+			humanFlag,
+			limitFlag,
+			noLimitFlag,
+			delimFlag,
+			netNamesFlag,
+		},
+		Action: showUnexpectedServices,
+	}
+
+	bootstrapCommands(command)
+}
+
+func showUnexpectedServices(c *cli.Context) error {
+	db := c.Args().Get(0)
+	if db == "" {
+		return cli.NewExitError("Specify a database", -1)
+	}
+	res := resources.InitResources(getConfigFilePath(c))
+	res.DB.SelectDB(db)
+
+	data, err := uconn.UnexpectedServiceResults(res, c.Int("limit"), c.Bool("no-limit"))
+
+	if err != nil {
+		res.Log.Error(err)
+		return cli.NewExitError(err, -1)
+	}
+
+	filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+		return suppression.Finding{Src: data[i].SrcIP, Dst: data[i].DstIP}
+	})
+
+	if !(len(data) > 0) {
+		return cli.NewExitError("No results were found for "+db, -1)
+	}
+
+	showNetNames := c.Bool("network-names")
+
+	if c.Bool("human-readable") {
+		err := showUnexpectedServicesHuman(data, showNetNames)
+		if err != nil {
+			return cli.NewExitError(err.Error(), -1)
+		}
+		return nil
+	}
+
+	err = showUnexpectedServicesDelim(data, c.String("delimiter"), showNetNames)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+	return nil
+}
+
+func unexpectedServicesHeaders(showNetNames bool) []string {
+	var headerFields []string
+	if showNetNames {
+		headerFields = []string{"Source Network", "Destination Network", "Source IP", "Destination IP"}
+	} else {
+		headerFields = []string{"Source IP", "Destination IP"}
+	}
+	return append(headerFields, "Unexpected Port:Protocol:Service", "Port:Protocol:Service", "Connections")
+}
+
+func unexpectedServicesRow(d uconn.UnexpectedServiceResult, showNetNames bool) []string {
+	var row []string
+	if showNetNames {
+		row = []string{d.SrcNetworkName, d.DstNetworkName, d.SrcIP, d.DstIP}
+	} else {
+		row = []string{d.SrcIP, d.DstIP}
+	}
+	return append(row,
+		strings.Join(d.UnexpectedTuples, " "), strings.Join(d.Tuples, " "), i(d.ConnectionCount),
+	)
+}
+
+func showUnexpectedServicesHuman(data []uconn.UnexpectedServiceResult, showNetNames bool) error {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(unexpectedServicesHeaders(showNetNames))
+
+	for _, d := range data {
+		table.Append(unexpectedServicesRow(d, showNetNames))
+	}
+	table.Render()
+	return nil
+}
+
+func showUnexpectedServicesDelim(data []uconn.UnexpectedServiceResult, delim string, showNetNames bool) error {
+	// Print the headers and analytic values, separated by a delimiter
+	fmt.Println(strings.Join(unexpectedServicesHeaders(showNetNames), delim))
+	for _, d := range data {
+		fmt.Println(strings.Join(unexpectedServicesRow(d, showNetNames), delim))
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/pkg/useragent"
 	"github.com/activecm/rita/resources"
 	"github.com/olekukonko/tablewriter"
@@ -19,6 +20,7 @@ func init() {
 		ArgsUsage: "<database>",
 		Flags: []cli.Flag{
 			ConfigFlag,
+			showSuppressedFlag,
 			humanFlag,
 			cli.BoolFlag{
 				Name:  "least-used, l",
@@ -49,6 +51,10 @@ func init() {
 				return cli.NewExitError(err, -1)
 			}
 
+			filterSuppressed(c, res, &data, func(i int) suppression.Finding {
+				return suppression.UserAgentFinding(data[i].UserAgent, data[i].JA3)
+			})
+
 			if len(data) == 0 {
 				return cli.NewExitError("No results were found for "+db, -1)
 			}
@@ -0,0 +1,220 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	command := cli.Command{
+		Name:  "suppress",
+		Usage: "Manage suppressions which hide known benign results from the show commands and html reports",
+		Subcommands: []cli.Command{
+			{
+				Name:  "add",
+				Usage: "Suppress results matching every given criteria until the suppression expires",
+				Flags: []cli.Flag{
+					ConfigFlag,
+					cli.StringFlag{
+						Name:  "src",
+						Usage: "Match source IPs within `CIDR`",
+					},
+					cli.StringFlag{
+						Name:  "dst",
+						Usage: "Match destination IPs within `CIDR`",
+					},
+					cli.StringFlag{
+						Name:  "fqdn",
+						Usage: "Match `FQDN` and its subdomains",
+					},
+					cli.StringFlag{
+						Name:  "useragent",
+						Usage: "Match the exact `USER_AGENT`",
+					},
+					cli.StringFlag{
+						Name:  "ja3",
+						Usage: "Match the JA3 `HASH`",
+					},
+					cli.StringFlag{
+						Name:  "note",
+						Usage: "Record why the results are benign",
+					},
+					cli.StringFlag{
+						Name:  "expires",
+						Usage: "Expire the suppression on a date (2006-01-02) or after a duration (e.g. 30d, 12h)",
+					},
+				},
+				Before: SetConfigFilePath,
+				Action: addSuppression,
+			},
+			{
+				Name:   "list",
+				Usage:  "Print the suppressions, including expired suppressions",
+				Flags:  []cli.Flag{ConfigFlag},
+				Before: SetConfigFilePath,
+				Action: listSuppressions,
+			},
+			{
+				Name:      "remove",
+				Usage:     "Remove a suppression",
+				ArgsUsage: "<suppression id>",
+				Flags:     []cli.Flag{ConfigFlag},
+				Before:    SetConfigFilePath,
+				Action:    removeSuppression,
+			},
+		},
+	}
+
+	allCommands = append(allCommands, command)
+}
+
+func addSuppression(c *cli.Context) error {
+	now := time.Now()
+	entry := database.Suppression{
+		SrcCIDR:   c.String("src"),
+		DstCIDR:   c.String("dst"),
+		FQDN:      c.String("fqdn"),
+		UserAgent: c.String("useragent"),
+		JA3:       c.String("ja3"),
+		Note:      c.String("note"),
+		Created:   now,
+	}
+
+	if entry.SrcCIDR == "" && entry.DstCIDR == "" && entry.FQDN == "" && entry.UserAgent == "" && entry.JA3 == "" {
+		return cli.NewExitError("\n\t[!] Specify at least one of --src, --dst, --fqdn, --useragent, or --ja3", -1)
+	}
+	for _, cidr := range []string{entry.SrcCIDR, entry.DstCIDR} {
+		if cidr == "" {
+			continue
+		}
+		if _, err := suppression.ParseCIDR(cidr); err != nil {
+			return cli.NewExitError(fmt.Errorf("\n\t[!] %v", err), -1)
+		}
+	}
+	if entry.Note == "" {
+		return cli.NewExitError("\n\t[!] Specify a --note explaining why the results are benign", -1)
+	}
+	if c.String("expires") == "" {
+		return cli.NewExitError("\n\t[!] Specify when the suppression --expires", -1)
+	}
+	expires, err := suppression.ParseExpiry(c.String("expires"), now)
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("\n\t[!] %v", err), -1)
+	}
+	if !expires.After(now) {
+		return cli.NewExitError("\n\t[!] The expiry must be in the future", -1)
+	}
+	entry.Expires = expires
+
+	res := resources.InitResources(getConfigFilePath(c))
+	id, err := res.MetaDB.AddSuppression(entry)
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+
+	fmt.Printf("\t[+] Added suppression %s, expiring %s\n", id.Hex(), expires.Format(time.RFC1123))
+	fmt.Println(threatRescoreHint)
+	return nil
+}
+
+func listSuppressions(c *cli.Context) error {
+	res := resources.InitResources(getConfigFilePath(c))
+	entries, err := res.MetaDB.GetSuppressions()
+	if err != nil {
+		return cli.NewExitError(err.Error(), -1)
+	}
+
+	if len(entries) == 0 {
+		return cli.NewExitError("No suppressions were found", -1)
+	}
+
+	now := time.Now()
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Source", "Destination", "FQDN", "User Agent", "JA3", "Expires", "Status", "Note"})
+	for _, entry := range entries {
+		status := "active"
+		if !entry.Expires.After(now) {
+			status = "expired"
+		}
+		table.Append([]string{
+			entry.ID.Hex(), entry.SrcCIDR, entry.DstCIDR, entry.FQDN, entry.UserAgent, entry.JA3,
+			entry.Expires.Format("2006-01-02 15:04"), status, entry.Note,
+		})
+	}
+	table.Render()
+	return nil
+}
+
+func removeSuppression(c *cli.Context) error {
+	id := c.Args().Get(0)
+	if id == "" {
+		return cli.NewExitError("Specify a suppression id", -1)
+	}
+
+	res := resources.InitResources(getConfigFilePath(c))
+	if err := res.MetaDB.RemoveSuppression(id); err != nil {
+		return cli.NewExitError(fmt.Errorf("\n\t[!] Could not remove suppression %s: %v", id, err), -1)
+	}
+
+	fmt.Printf("\t[+] Removed suppression %s\n", id)
+	fmt.Println(threatRescoreHint)
+	return nil
+}
+
+//threatRescoreHint reminds the user that threat scores only reflect the
+//suppressions which were active when the threat module last ran
+const threatRescoreHint = "\t[-] Run `rita analyze --modules threat <database>` to update threat scores"
+
+//suppressionMatcher loads the active suppressions unless the user asked to
+//see suppressed results
+func suppressionMatcher(c *cli.Context, res *resources.Resources) *suppression.Matcher {
+	if c.Bool("show-suppressed") {
+		return nil
+	}
+	entries, err := res.MetaDB.GetSuppressions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "\t[!] Could not load suppressions, showing all results")
+		return nil
+	}
+	return suppression.NewMatcher(entries, time.Now())
+}
+
+//filterSuppressed removes the results matching an active suppression from the
+//slice results points to, and notes how many were hidden. finding describes
+//the i-th result to the suppressions.
+func filterSuppressed(c *cli.Context, res *resources.Resources, results interface{}, finding func(i int) suppression.Finding) {
+	sup := suppressionMatcher(c, res)
+	removeSuppressed(sup, results, finding)
+	printSuppressedCount(sup)
+}
+
+//removeSuppressed removes the results matching a suppression from the slice
+//results points to, keeping the order of the remaining results
+func removeSuppressed(sup *suppression.Matcher, results interface{}, finding func(i int) suppression.Finding) {
+	slice := reflect.ValueOf(results).Elem()
+	swap := reflect.Swapper(slice.Interface())
+	n := 0
+	for i := 0; i < slice.Len(); i++ {
+		if !sup.Suppressed(finding(i)) {
+			swap(n, i)
+			n++
+		}
+	}
+	slice.SetLen(n)
+}
+
+//printSuppressedCount notes how many results were hidden. The note is
+//written to stderr to keep delimited output intact.
+func printSuppressedCount(sup *suppression.Matcher) {
+	if sup.Hidden() > 0 {
+		fmt.Fprintf(os.Stderr, "\t[-] %d suppressed results hidden, use --show-suppressed to include them\n", sup.Hidden())
+	}
+}
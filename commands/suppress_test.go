@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveSuppressed(t *testing.T) {
+	type result struct {
+		src  string
+		fqdn string
+	}
+	results := []result{
+		{"10.0.0.1", "a.example.com"},
+		{"10.0.0.2", "b.example.com"},
+		{"10.0.0.3", "cdn.example.net"},
+		{"10.0.0.4", "d.example.com"},
+	}
+	finding := func(i int) suppression.Finding {
+		return suppression.Finding{Src: results[i].src, FQDN: results[i].fqdn}
+	}
+
+	now := time.Now()
+	sup := suppression.NewMatcher([]database.Suppression{
+		{SrcCIDR: "10.0.0.2/32", Expires: now.Add(time.Hour)},
+		{FQDN: "example.net", Expires: now.Add(time.Hour)},
+	}, now)
+
+	removeSuppressed(sup, &results, finding)
+	require.Equal(t, []result{
+		{"10.0.0.1", "a.example.com"},
+		{"10.0.0.4", "d.example.com"},
+	}, results)
+	require.Equal(t, 2, sup.Hidden())
+
+	// without suppressions every result is kept
+	removeSuppressed(nil, &results, finding)
+	require.Len(t, results, 2)
+}
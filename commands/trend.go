@@ -0,0 +1,27 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/activecm/rita/pkg/data"
+)
+
+//trendHeaders are the columns added to the output by --trend
+var trendHeaders = []string{"First Seen Chunk", "Last Score", "Delta", "Trend"}
+
+//trendColumns summarizes how a result's score changed across the chunks
+//it was scored in for output
+func trendColumns(history data.ScoreHistory) []string {
+	trend := history.Trend()
+	if trend.FirstSeen < 0 {
+		// scored before score histories were kept
+		return []string{"-", "-", "-", ""}
+	}
+	return []string{
+		strconv.Itoa(trend.FirstSeen),
+		f(trend.Last),
+		fmt.Sprintf("%+.3f", trend.Delta),
+		trend.Sparkline(),
+	}
+}
@@ -13,21 +13,41 @@ import (
 type (
 	//StaticCfg is the container for other static config sections
 	StaticCfg struct {
-		UserConfig   UserCfgStaticCfg     `yaml:"UserConfig"`
-		MongoDB      MongoDBStaticCfg     `yaml:"MongoDB"`
-		Rolling      RollingStaticCfg     `yaml:"Rolling"`
-		Log          LogStaticCfg         `yaml:"LogConfig"`
-		Blacklisted  BlacklistedStaticCfg `yaml:"BlackListed"`
-		Beacon       BeaconStaticCfg      `yaml:"Beacon"`
-		BeaconFQDN   BeaconFQDNStaticCfg  `yaml:"BeaconFQDN"`
-		BeaconProxy  BeaconProxyStaticCfg `yaml:"BeaconProxy"`
-		DNS          DNSStaticCfg         `yaml:"DNS"`
-		UserAgent    UserAgentStaticCfg   `yaml:"UserAgent"`
-		Bro          BroStaticCfg         `yaml:"Bro"` // kept in for MetaDB backwards compatibility
-		Filtering    FilteringStaticCfg   `yaml:"Filtering"`
-		Strobe       StrobeStaticCfg      `yaml:"Strobe"`
-		Version      string
-		ExactVersion string
+		UserConfig     UserCfgStaticCfg        `yaml:"UserConfig"`
+		Storage        StorageStaticCfg        `yaml:"Storage"`
+		MongoDB        MongoDBStaticCfg        `yaml:"MongoDB"`
+		Rolling        RollingStaticCfg        `yaml:"Rolling"`
+		Log            LogStaticCfg            `yaml:"LogConfig"`
+		Blacklisted    BlacklistedStaticCfg    `yaml:"BlackListed"`
+		Beacon         BeaconStaticCfg         `yaml:"Beacon"`
+		BeaconFQDN     BeaconFQDNStaticCfg     `yaml:"BeaconFQDN"`
+		BeaconProxy    BeaconProxyStaticCfg    `yaml:"BeaconProxy"`
+		BeaconSNI      BeaconSNIStaticCfg      `yaml:"BeaconSNI"`
+		DNS            DNSStaticCfg            `yaml:"DNS"`
+		DNSTunnel      DNSTunnelStaticCfg      `yaml:"DNSTunnel"`
+		DNSFailure     DNSFailureStaticCfg     `yaml:"DNSFailure"`
+		DGA            DGAStaticCfg            `yaml:"DGA"`
+		Scan           ScanStaticCfg           `yaml:"Scan"`
+		Exfil          ExfilStaticCfg          `yaml:"Exfil"`
+		Prevalence     PrevalenceStaticCfg     `yaml:"Prevalence"`
+		Baseline       BaselineStaticCfg       `yaml:"Baseline"`
+		DomainFronting DomainFrontingStaticCfg `yaml:"DomainFronting"`
+		GeoIP          GeoIPStaticCfg          `yaml:"GeoIP"`
+		Threat         ThreatStaticCfg         `yaml:"Threat"`
+		Services       ServicesStaticCfg       `yaml:"UnexpectedServices"`
+		UserAgent      UserAgentStaticCfg      `yaml:"UserAgent"`
+		Bro            BroStaticCfg            `yaml:"Bro"` // kept in for MetaDB backwards compatibility
+		Filtering      FilteringStaticCfg      `yaml:"Filtering"`
+		Strobe         StrobeStaticCfg         `yaml:"Strobe"`
+		Version        string
+		ExactVersion   string
+	}
+
+	//StorageStaticCfg selects where RITA stores its datasets
+	StorageStaticCfg struct {
+		Backend         string `yaml:"Backend" default:"mongodb"`
+		Path            string `yaml:"Path" default:"/var/lib/rita/rita.db"`
+		MaxCollectionMB int    `yaml:"MaxCollectionMB" default:"2048"`
 	}
 
 	//MongoDBStaticCfg contains the means for connecting to MongoDB
@@ -80,6 +100,10 @@ type (
 		BlacklistDatabase  string   `yaml:"BlacklistDatabase" default:"rita-bl"`
 		IPBlacklists       []string `yaml:"CustomIPBlacklists" default:"[]"`
 		HostnameBlacklists []string `yaml:"CustomHostnameBlacklists" default:"[]"`
+		STIXBundles        []string `yaml:"CustomSTIXBundles" default:"[]"`
+		MISPEvents         []string `yaml:"CustomMISPEvents" default:"[]"`
+		Offline            bool     `yaml:"Offline" default:"false"`
+		SnapshotDirectory  string   `yaml:"SnapshotDirectory" default:"/var/lib/rita/blacklist-snapshots"`
 	}
 
 	//BeaconStaticCfg is used to control the beaconing analysis module
@@ -100,9 +124,93 @@ type (
 		DefaultConnectionThresh int  `yaml:"DefaultConnectionThresh" default:"20"`
 	}
 
+	//BeaconSNIStaticCfg is used to control the TLS server name / JA3 beaconing analysis module
+	BeaconSNIStaticCfg struct {
+		Enabled                 bool `yaml:"Enabled" default:"true"`
+		DefaultConnectionThresh int  `yaml:"DefaultConnectionThresh" default:"20"`
+	}
+
 	//DNSStaticCfg is used to control the DNS analysis module
 	DNSStaticCfg struct {
-		Enabled bool `yaml:"Enabled" default:"true"`
+		Enabled           bool     `yaml:"Enabled" default:"true"`
+		ApprovedResolvers []string `yaml:"ApprovedResolvers" default:"[]"`
+		DoHServers        []string `yaml:"DoHServers" default:"[\"dns.google\", \"dns.google.com\", \"cloudflare-dns.com\", \"*.cloudflare-dns.com\", \"dns.quad9.net\", \"*.quad9.net\", \"doh.opendns.com\", \"dns.adguard.com\", \"*.nextdns.io\", \"doh.cleanbrowsing.org\"]"`
+	}
+
+	//DNSTunnelStaticCfg is used to control the DNS tunneling analysis module
+	DNSTunnelStaticCfg struct {
+		Enabled           bool `yaml:"Enabled" default:"true"`
+		MinimumQueryCount int  `yaml:"MinimumQueryCount" default:"10"`
+	}
+
+	//DNSFailureStaticCfg is used to control the failed DNS lookup analysis module
+	DNSFailureStaticCfg struct {
+		Enabled            bool `yaml:"Enabled" default:"true"`
+		MinimumQueryCount  int  `yaml:"MinimumQueryCount" default:"10"`
+		FailedDomainThresh int  `yaml:"FailedDomainThresh" default:"20"`
+	}
+
+	//DGAStaticCfg is used to control the domain generation algorithm scoring module
+	DGAStaticCfg struct {
+		Enabled        bool    `yaml:"Enabled" default:"true"`
+		ScoreThreshold float64 `yaml:"ScoreThreshold" default:"0.6"`
+	}
+
+	//ScanStaticCfg is used to control the port scan and host sweep analysis module
+	ScanStaticCfg struct {
+		Enabled              bool     `yaml:"Enabled" default:"true"`
+		IncludeInternal      bool     `yaml:"IncludeInternal" default:"false"`
+		VerticalPortThresh   int      `yaml:"VerticalPortThresh" default:"100"`
+		HorizontalHostThresh int      `yaml:"HorizontalHostThresh" default:"100"`
+		FailedConnStates     []string `yaml:"FailedConnStates" default:"[\"S0\", \"REJ\", \"RSTO\", \"RSTOS0\"]"`
+	}
+
+	//ExfilStaticCfg is used to control the data exfiltration analysis module
+	ExfilStaticCfg struct {
+		Enabled      bool  `yaml:"Enabled" default:"true"`
+		MinimumBytes int64 `yaml:"MinimumBytes" default:"1048576"`
+	}
+
+	//PrevalenceStaticCfg is used to control the destination prevalence analysis module
+	PrevalenceStaticCfg struct {
+		Enabled          bool `yaml:"Enabled" default:"true"`
+		RareClientThresh int  `yaml:"RareClientThresh" default:"2"`
+	}
+
+	//BaselineStaticCfg is used to control the per-host behavioural baseline module
+	BaselineStaticCfg struct {
+		Enabled       bool    `yaml:"Enabled" default:"true"`
+		MinimumChunks int     `yaml:"MinimumChunks" default:"3"`
+		ZScoreThresh  float64 `yaml:"ZScoreThresh" default:"3.0"`
+	}
+
+	//DomainFrontingStaticCfg is used to control the domain fronting analysis module
+	DomainFrontingStaticCfg struct {
+		Enabled           bool `yaml:"Enabled" default:"true"`
+		CorrelationWindow int  `yaml:"CorrelationWindow" default:"5"`
+		MinimumServedSNIs int  `yaml:"MinimumServedSNIs" default:"10"`
+	}
+
+	//GeoIPStaticCfg is used to control the ASN and country enrichment of external IPs
+	GeoIPStaticCfg struct {
+		Enabled         bool   `yaml:"Enabled" default:"false"`
+		ASNDatabase     string `yaml:"ASNDatabase" default:"/etc/rita/GeoLite2-ASN.mmdb"`
+		CountryDatabase string `yaml:"CountryDatabase" default:"/etc/rita/GeoLite2-Country.mmdb"`
+	}
+
+	//ThreatStaticCfg is used to control the composite threat scoring module.
+	//Weights sets how much each kind of finding contributes to the composite
+	//threat score from 0 (ignored) to 1. Kinds of findings left out are
+	//weighted by the module which reports them.
+	ThreatStaticCfg struct {
+		Enabled bool               `yaml:"Enabled" default:"true"`
+		Weights map[string]float64 `yaml:"Weights"`
+	}
+
+	//ServicesStaticCfg lists the services expected on each port and protocol.
+	//Entries are in the form port:proto:service.
+	ServicesStaticCfg struct {
+		ExpectedServices []string `yaml:"ExpectedServices" default:"[\"21:tcp:ftp\", \"22:tcp:ssh\", \"25:tcp:smtp\", \"53:udp:dns\", \"53:tcp:dns\", \"80:tcp:http\", \"123:udp:ntp\", \"443:tcp:ssl\", \"465:tcp:ssl\", \"993:tcp:ssl\", \"995:tcp:ssl\", \"3389:tcp:rdp\"]"`
 	}
 
 	//UserAgentStaticCfg is used to control the User Agent analysis module
@@ -167,6 +275,9 @@ func parseStaticConfig(cfgFile []byte, config *StaticCfg) error {
 
 	// clean all filepaths
 	config.Log.RitaLogPath = filepath.Clean(config.Log.RitaLogPath)
+	if config.Storage.Path != "" {
+		config.Storage.Path = filepath.Clean(config.Storage.Path)
+	}
 
 	// grab the version constants set by the build process
 	config.Version = Version
@@ -3,15 +3,21 @@ package config
 type (
 	//TableCfg is the container for other table config sections
 	TableCfg struct {
-		Log         LogTableCfg
-		DNS         DNSTableCfg
-		Structure   StructureTableCfg
-		Beacon      BeaconTableCfg
-		BeaconFQDN  BeaconFQDNTableCfg
-		BeaconProxy BeaconProxyTableCfg
-		UserAgent   UserAgentTableCfg
-		Cert        CertificateTableCfg
-		Meta        MetaTableCfg
+		Log            LogTableCfg
+		DNS            DNSTableCfg
+		Structure      StructureTableCfg
+		Beacon         BeaconTableCfg
+		BeaconFQDN     BeaconFQDNTableCfg
+		BeaconProxy    BeaconProxyTableCfg
+		BeaconSNI      BeaconSNITableCfg
+		Scan           ScanTableCfg
+		Exfil          ExfilTableCfg
+		Prevalence     PrevalenceTableCfg
+		DomainFronting DomainFrontingTableCfg
+		Threat         ThreatTableCfg
+		UserAgent      UserAgentTableCfg
+		Cert           CertificateTableCfg
+		Meta           MetaTableCfg
 	}
 
 	//LogTableCfg contains the configuration for logging
@@ -31,8 +37,11 @@ type (
 
 	//DNSTableCfg is used to control the dns analysis module
 	DNSTableCfg struct {
-		ExplodedDNSTable string `default:"explodedDns"`
-		HostnamesTable   string `default:"hostnames"`
+		ExplodedDNSTable    string `default:"explodedDns"`
+		HostnamesTable      string `default:"hostnames"`
+		DNSTunnelTable      string `default:"dnsTunnel"`
+		DNSFailureTable     string `default:"dnsFailure"`
+		ResolverBypassTable string `default:"resolverBypass"`
 	}
 
 	//BeaconTableCfg is used to control the beaconing analysis module
@@ -50,6 +59,36 @@ type (
 		BeaconProxyTable string `default:"beaconProxy"`
 	}
 
+	//BeaconSNITableCfg is used to control the beaconing analysis module
+	BeaconSNITableCfg struct {
+		BeaconSNITable string `default:"beaconSNI"`
+	}
+
+	//ScanTableCfg is used to control the scan analysis module
+	ScanTableCfg struct {
+		ScanTable string `default:"scan"`
+	}
+
+	//ExfilTableCfg is used to control the exfil analysis module
+	ExfilTableCfg struct {
+		ExfilTable string `default:"exfil"`
+	}
+
+	//PrevalenceTableCfg is used to control the prevalence analysis module
+	PrevalenceTableCfg struct {
+		PrevalenceTable string `default:"prevalence"`
+	}
+
+	//DomainFrontingTableCfg is used to control the domain fronting analysis module
+	DomainFrontingTableCfg struct {
+		DomainFrontingTable string `default:"domainFronting"`
+	}
+
+	//ThreatTableCfg is used to control the threat scoring module
+	ThreatTableCfg struct {
+		ThreatTable string `default:"threat"`
+	}
+
 	//UserAgentTableCfg is used to control the useragent analysis module
 	UserAgentTableCfg struct {
 		UserAgentTable string `default:"useragent"`
@@ -62,7 +101,8 @@ type (
 
 	//MetaTableCfg contains the meta db collection names
 	MetaTableCfg struct {
-		FilesTable     string `default:"files"`
-		DatabasesTable string `default:"databases"`
+		FilesTable        string `default:"files"`
+		DatabasesTable    string `default:"databases"`
+		SuppressionsTable string `default:"suppressions"`
 	}
 )
@@ -29,21 +29,34 @@ var MaxMongoDBVersion = semver.Version{
 
 // DB is the workhorse container for messing with the database
 type DB struct {
-	Session  *mgo.Session
+	Session  Session
 	log      *log.Logger
 	selected string
 }
 
-//NewDB constructs a new DB struct
+//NewDB constructs a new DB struct backed by the storage
+//backend selected in the configuration
 func NewDB(conf *config.Config, log *log.Logger) (*DB, error) {
-	// Jump into the requested database
-	session, err := connectToMongoDB(conf, log)
-	if err != nil {
-		return nil, err
+	var session Session
+	switch conf.S.Storage.Backend {
+	case "", MongoDBBackend:
+		mgoSession, err := connectToMongoDB(conf, log)
+		if err != nil {
+			return nil, err
+		}
+		mgoSession.SetSocketTimeout(conf.S.MongoDB.SocketTimeout)
+		mgoSession.SetSyncTimeout(conf.S.MongoDB.SocketTimeout)
+		mgoSession.SetCursorTimeout(0)
+		session = NewMongoSession(mgoSession)
+	case EmbeddedBackend:
+		var err error
+		session, err = NewEmbeddedSession(conf.S.Storage.Path, conf.S.Storage.MaxCollectionMB)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported storage backend %s", conf.S.Storage.Backend)
 	}
-	session.SetSocketTimeout(conf.S.MongoDB.SocketTimeout)
-	session.SetSyncTimeout(conf.S.MongoDB.SocketTimeout)
-	session.SetCursorTimeout(0)
 
 	return &DB{
 		Session:  session,
@@ -127,7 +140,7 @@ func (d *DB) CollectionExists(table string) bool {
 
 //CreateCollection creates a new collection in the currently selected
 //database with the required indexes
-func (d *DB) CreateCollection(name string, indexes []mgo.Index) error {
+func (d *DB) CreateCollection(name string, indexes []Index) error {
 	// Make a copy of the current session
 	session := d.Session.Copy()
 	defer session.Close()
@@ -136,7 +149,7 @@ func (d *DB) CreateCollection(name string, indexes []mgo.Index) error {
 
 	// Create new collection by referencing to it, no need to call Create
 	err := session.DB(d.selected).C(name).Create(
-		&mgo.CollectionInfo{},
+		&CollectionInfo{},
 	)
 
 	// Make sure it actually got created
@@ -157,7 +170,7 @@ func (d *DB) CreateCollection(name string, indexes []mgo.Index) error {
 
 //AggregateCollection builds a collection via a MongoDB pipeline
 func (d *DB) AggregateCollection(sourceCollection string,
-	session *mgo.Session, pipeline []bson.D) *mgo.Iter {
+	session Session, pipeline []bson.D) Iter {
 
 	// Identify the source collection we will aggregate information from into the new collection
 	if !d.CollectionExists(sourceCollection) {
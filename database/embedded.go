@@ -0,0 +1,1303 @@
+package database
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	bolt "go.etcd.io/bbolt"
+)
+
+type (
+	//embeddedEngine stores every database in a single bolt file. Collections
+	//are loaded into memory when first used and changed documents are
+	//written back to the file when sessions are closed. Since a whole
+	//collection is held in memory, collections larger than maxSize are
+	//refused rather than risk running out of memory.
+	embeddedEngine struct {
+		lock        sync.Mutex
+		path        string
+		file        *bolt.DB
+		maxSize     int64 // stored bytes allowed per collection, 0 for no limit
+		refs        int
+		dirty       int
+		collections map[string]*embeddedCollection
+	}
+
+	//embeddedDocument is a stored document. seq is its key in the bolt file
+	//and gives the natural order of the collection.
+	embeddedDocument struct {
+		seq     uint64
+		doc     bson.D
+		size    int // stored size in bytes as of the last flush
+		removed bool
+	}
+
+	//embeddedCollection is the in memory copy of a collection
+	embeddedCollection struct {
+		engine  *embeddedEngine
+		db      string
+		name    string
+		exists  bool
+		nextSeq uint64
+		size    int64 // stored size of the documents in bytes
+		docs    []*embeddedDocument
+		removed int
+		ids     map[string]*embeddedDocument
+		dirty   map[uint64]*embeddedDocument
+		defs    []Index
+		// indexes maps field paths to the documents holding each value
+		indexes map[string]map[string]map[uint64]*embeddedDocument
+	}
+
+	//embeddedSession implements Session for the embedded backend
+	embeddedSession struct {
+		engine *embeddedEngine
+		root   bool
+		closed bool
+	}
+
+	//embeddedDatabase implements Database for the embedded backend
+	embeddedDatabase struct {
+		engine *embeddedEngine
+		name   string
+	}
+
+	//embeddedCollectionHandle implements Collection for the embedded backend
+	embeddedCollectionHandle struct {
+		engine *embeddedEngine
+		db     string
+		name   string
+	}
+
+	//embeddedQuery implements Query for the embedded backend
+	embeddedQuery struct {
+		coll       *embeddedCollectionHandle
+		filter     interface{}
+		projection interface{}
+		sort       []string
+		skip       int
+		limit      int
+	}
+
+	//embeddedPipe implements Pipe for the embedded backend
+	embeddedPipe struct {
+		coll     *embeddedCollectionHandle
+		pipeline interface{}
+	}
+
+	//embeddedIter implements Iter over a set of results
+	embeddedIter struct {
+		docs []bson.D
+		pos  int
+		err  error
+	}
+
+	//embeddedBulk implements Bulk by running each queued write in turn
+	embeddedBulk struct {
+		coll *embeddedCollectionHandle
+		ops  []func() (matched int, modified int, err error)
+	}
+)
+
+//embeddedFlushThreshold is the number of changed documents held in memory
+//before they are written to the file without waiting for a session to close
+const embeddedFlushThreshold = 50000
+
+var (
+	docsBucket  = []byte("docs")
+	indexesKey  = []byte("indexes")
+	enginesLock sync.Mutex
+	engines     = make(map[string]*embeddedEngine)
+)
+
+//ErrCollectionTooLarge is returned by the embedded backend when a collection
+//grows past the size it may hold in memory
+var ErrCollectionTooLarge = errors.New("collection is too large for the embedded backend")
+
+//NewEmbeddedSession opens, or creates, the embedded database file at path.
+//Collections holding more than maxCollectionMB megabytes are refused with
+//ErrCollectionTooLarge, or any size is allowed if it is 0. Sessions opened
+//on the same path share the same underlying file and the first limit given.
+func NewEmbeddedSession(path string, maxCollectionMB int) (Session, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	enginesLock.Lock()
+	defer enginesLock.Unlock()
+
+	engine, ok := engines[path]
+	if !ok {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		file, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+		if err == bolt.ErrTimeout {
+			return nil, fmt.Errorf("database file %s is in use by another process", path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		engine = &embeddedEngine{
+			path:        path,
+			file:        file,
+			maxSize:     int64(maxCollectionMB) << 20,
+			collections: make(map[string]*embeddedCollection),
+		}
+		engines[path] = engine
+	}
+	engine.refs++
+	return &embeddedSession{engine: engine, root: true}, nil
+}
+
+//release closes the file once every root session using it is closed
+func (e *embeddedEngine) release() {
+	enginesLock.Lock()
+	defer enginesLock.Unlock()
+	e.refs--
+	if e.refs > 0 {
+		return
+	}
+	delete(engines, e.path)
+	e.file.Close()
+}
+
+//collection returns the in memory copy of a collection, loading it from
+//the file if needed. The engine lock must be held.
+func (e *embeddedEngine) collection(db, name string) (*embeddedCollection, error) {
+	key := db + "\x00" + name
+	if c, ok := e.collections[key]; ok {
+		return c, nil
+	}
+
+	c := &embeddedCollection{
+		engine:  e,
+		db:      db,
+		name:    name,
+		ids:     make(map[string]*embeddedDocument),
+		dirty:   make(map[uint64]*embeddedDocument),
+		indexes: make(map[string]map[string]map[uint64]*embeddedDocument),
+	}
+	err := e.file.View(func(tx *bolt.Tx) error {
+		bucket := collectionBucket(tx, db, name)
+		if bucket == nil {
+			return nil
+		}
+		c.exists = true
+
+		if raw := bucket.Get(indexesKey); raw != nil {
+			var stored struct {
+				Indexes []Index `bson:"indexes"`
+			}
+			if err := bson.Unmarshal(append([]byte(nil), raw...), &stored); err != nil {
+				return err
+			}
+			c.defs = stored.Indexes
+		}
+
+		docs := bucket.Bucket(docsBucket)
+		if docs == nil {
+			return nil
+		}
+		return docs.ForEach(func(k, v []byte) error {
+			// refuse the collection before it takes up too much memory
+			c.size += int64(len(v))
+			if c.tooLarge() {
+				return c.sizeError()
+			}
+
+			var doc bson.D
+			// bolt owns the value, and bson may keep slices of it
+			if err := bson.Unmarshal(append([]byte(nil), v...), &doc); err != nil {
+				return err
+			}
+			seq := binary.BigEndian.Uint64(k)
+			if seq > c.nextSeq {
+				c.nextSeq = seq
+			}
+			stored := &embeddedDocument{seq: seq, doc: doc, size: len(v)}
+			c.docs = append(c.docs, stored)
+			if id, ok := getField(doc, "_id"); ok {
+				c.ids[valueKey(id)] = stored
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.collections[key] = c
+	return c, nil
+}
+
+//tooLarge reports whether the collection has grown past the size the engine
+//may hold in memory
+func (c *embeddedCollection) tooLarge() bool {
+	return c.engine.maxSize > 0 && c.size > c.engine.maxSize
+}
+
+//sizeError describes a collection which is too large to hold in memory
+func (c *embeddedCollection) sizeError() error {
+	return fmt.Errorf("%w: %s.%s holds more than %d MB. Raise MaxCollectionMB in the Storage section "+
+		"of the config if there is memory to spare, or use the mongodb backend for datasets this large",
+		ErrCollectionTooLarge, c.db, c.name, c.engine.maxSize>>20)
+}
+
+//collectionBucket returns the bucket holding a collection or nil
+func collectionBucket(tx *bolt.Tx, db, name string) *bolt.Bucket {
+	dbBucket := tx.Bucket([]byte(db))
+	if dbBucket == nil {
+		return nil
+	}
+	return dbBucket.Bucket([]byte(name))
+}
+
+//create stores an empty collection in the file. The engine lock must be held.
+func (c *embeddedCollection) create() error {
+	err := c.engine.file.Update(func(tx *bolt.Tx) error {
+		dbBucket, err := tx.CreateBucketIfNotExists([]byte(c.db))
+		if err != nil {
+			return err
+		}
+		bucket, err := dbBucket.CreateBucketIfNotExists([]byte(c.name))
+		if err != nil {
+			return err
+		}
+		_, err = bucket.CreateBucketIfNotExists(docsBucket)
+		return err
+	})
+	if err == nil {
+		c.exists = true
+	}
+	return err
+}
+
+//flush writes every changed document to the file. The engine lock must be
+//held. The changes are written even if a collection grows too large, in
+//which case ErrCollectionTooLarge is returned.
+func (e *embeddedEngine) flush() error {
+	if e.dirty == 0 {
+		return nil
+	}
+	sizes := make(map[*embeddedDocument]int)
+	err := e.file.Update(func(tx *bolt.Tx) error {
+		for _, c := range e.collections {
+			if len(c.dirty) == 0 {
+				continue
+			}
+			dbBucket, err := tx.CreateBucketIfNotExists([]byte(c.db))
+			if err != nil {
+				return err
+			}
+			bucket, err := dbBucket.CreateBucketIfNotExists([]byte(c.name))
+			if err != nil {
+				return err
+			}
+			docs, err := bucket.CreateBucketIfNotExists(docsBucket)
+			if err != nil {
+				return err
+			}
+			for seq, stored := range c.dirty {
+				key := make([]byte, 8)
+				binary.BigEndian.PutUint64(key, seq)
+				if stored.removed {
+					if err := docs.Delete(key); err != nil {
+						return err
+					}
+					sizes[stored] = 0
+					continue
+				}
+				raw, err := bson.Marshal(stored.doc)
+				if err != nil {
+					return err
+				}
+				if err := docs.Put(key, raw); err != nil {
+					return err
+				}
+				sizes[stored] = len(raw)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var sizeErr error
+	for _, c := range e.collections {
+		for _, stored := range c.dirty {
+			c.size += int64(sizes[stored] - stored.size)
+			stored.size = sizes[stored]
+		}
+		c.dirty = make(map[uint64]*embeddedDocument)
+		if sizeErr == nil && c.tooLarge() {
+			sizeErr = c.sizeError()
+		}
+	}
+	e.dirty = 0
+	return sizeErr
+}
+
+//markDirty records a changed document so it is written to the file
+func (c *embeddedCollection) markDirty(stored *embeddedDocument) error {
+	if !c.exists {
+		if err := c.create(); err != nil {
+			return err
+		}
+	}
+	if _, ok := c.dirty[stored.seq]; !ok {
+		c.engine.dirty++
+	}
+	c.dirty[stored.seq] = stored
+	if c.engine.dirty >= embeddedFlushThreshold {
+		return c.engine.flush()
+	}
+	return nil
+}
+
+//live returns the documents which haven't been removed in natural order
+func (c *embeddedCollection) live() []*embeddedDocument {
+	if c.removed > 0 && c.removed > len(c.docs)/2 {
+		kept := make([]*embeddedDocument, 0, len(c.docs)-c.removed)
+		for _, stored := range c.docs {
+			if !stored.removed {
+				kept = append(kept, stored)
+			}
+		}
+		c.docs = kept
+		c.removed = 0
+	}
+	if c.removed == 0 {
+		return c.docs
+	}
+	docs := make([]*embeddedDocument, 0, len(c.docs)-c.removed)
+	for _, stored := range c.docs {
+		if !stored.removed {
+			docs = append(docs, stored)
+		}
+	}
+	return docs
+}
+
+//indexKeys returns the keys a document is indexed under for a field path.
+//Documents missing the field are indexed under null as they match null.
+func indexKeys(doc bson.D, path string) []string {
+	values := expandArrays(lookupPath(doc, splitPath(path)))
+	if len(values) == 0 {
+		return []string{valueKey(nil)}
+	}
+	keys := make([]string, 0, len(values))
+	for _, v := range values {
+		keys = append(keys, valueKey(v))
+	}
+	return keys
+}
+
+//index returns the hash index for a field path, building it if needed
+func (c *embeddedCollection) index(path string) map[string]map[uint64]*embeddedDocument {
+	if idx, ok := c.indexes[path]; ok {
+		return idx
+	}
+	idx := make(map[string]map[uint64]*embeddedDocument)
+	for _, stored := range c.live() {
+		addToIndex(idx, indexKeys(stored.doc, path), stored)
+	}
+	c.indexes[path] = idx
+	return idx
+}
+
+func addToIndex(idx map[string]map[uint64]*embeddedDocument, keys []string, stored *embeddedDocument) {
+	for _, key := range keys {
+		set, ok := idx[key]
+		if !ok {
+			set = make(map[uint64]*embeddedDocument)
+			idx[key] = set
+		}
+		set[stored.seq] = stored
+	}
+}
+
+func removeFromIndex(idx map[string]map[uint64]*embeddedDocument, keys []string, stored *embeddedDocument) {
+	for _, key := range keys {
+		if set, ok := idx[key]; ok {
+			delete(set, stored.seq)
+			if len(set) == 0 {
+				delete(idx, key)
+			}
+		}
+	}
+}
+
+//insert stores a new document
+func (c *embeddedCollection) insert(doc bson.D) error {
+	id, ok := getField(doc, "_id")
+	if !ok {
+		id = bson.NewObjectId()
+		doc = append(bson.D{{Name: "_id", Value: id}}, doc...)
+	}
+	idKey := valueKey(id)
+	if _, ok := c.ids[idKey]; ok {
+		return fmt.Errorf("E11000 duplicate key error collection: %s.%s _id: %v", c.db, c.name, id)
+	}
+	if err := c.checkUnique(doc, nil); err != nil {
+		return err
+	}
+
+	c.nextSeq++
+	stored := &embeddedDocument{seq: c.nextSeq, doc: doc}
+	c.docs = append(c.docs, stored)
+	c.ids[idKey] = stored
+	for path, idx := range c.indexes {
+		addToIndex(idx, indexKeys(doc, path), stored)
+	}
+	return c.markDirty(stored)
+}
+
+//replace swaps the contents of a stored document
+func (c *embeddedCollection) replace(stored *embeddedDocument, doc bson.D) error {
+	if err := c.checkUnique(doc, stored); err != nil {
+		return err
+	}
+	for path, idx := range c.indexes {
+		removeFromIndex(idx, indexKeys(stored.doc, path), stored)
+		addToIndex(idx, indexKeys(doc, path), stored)
+	}
+	stored.doc = doc
+	return c.markDirty(stored)
+}
+
+//checkUnique ensures a document does not share the keys of a unique index
+//with any stored document other than the one it replaces
+func (c *embeddedCollection) checkUnique(doc bson.D, except *embeddedDocument) error {
+	for _, def := range c.defs {
+		if !def.Unique || len(def.Key) == 0 {
+			continue
+		}
+		key := uniqueKey(doc, def.Key)
+		first := strings.TrimPrefix(def.Key[0], "-")
+		idx := c.index(first)
+		for _, indexKey := range indexKeys(doc, first) {
+			for _, stored := range idx[indexKey] {
+				if stored != except && uniqueKey(stored.doc, def.Key) == key {
+					return fmt.Errorf("E11000 duplicate key error collection: %s.%s index: %s", c.db, c.name, def.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+//uniqueValue returns the value a document holds for an index field
+func uniqueValue(doc bson.D, field string) interface{} {
+	value, _ := getPath(doc, splitPath(strings.TrimPrefix(field, "-")))
+	return value
+}
+
+//uniqueKey joins the values a document holds for each index field
+func uniqueKey(doc bson.D, fields []string) string {
+	keys := make([]string, 0, len(fields))
+	for _, field := range fields {
+		keys = append(keys, valueKey(uniqueValue(doc, field)))
+	}
+	return strings.Join(keys, "\x00")
+}
+
+//remove deletes a stored document
+func (c *embeddedCollection) remove(stored *embeddedDocument) error {
+	for path, idx := range c.indexes {
+		removeFromIndex(idx, indexKeys(stored.doc, path), stored)
+	}
+	if id, ok := getField(stored.doc, "_id"); ok {
+		delete(c.ids, valueKey(id))
+	}
+	stored.removed = true
+	c.removed++
+	return c.markDirty(stored)
+}
+
+//candidates narrows the documents which may match a filter using the
+//_id or the hash index on the most selective field the filter compares
+//for equality. It returns false if every document must be checked.
+func (c *embeddedCollection) candidates(filter bson.D, vars map[string]interface{}) ([]*embeddedDocument, bool) {
+	conds := indexableConditions(filter, vars)
+	if len(conds) == 0 {
+		return nil, false
+	}
+
+	if values, ok := conds["_id"]; ok {
+		var docs []*embeddedDocument
+		for _, v := range values {
+			if stored, ok := c.ids[valueKey(v)]; ok {
+				docs = append(docs, stored)
+			}
+		}
+		return sortBySeq(docs), true
+	}
+
+	// only the smallest set of matches is gathered
+	var bestKeys []string
+	var bestIndex map[string]map[uint64]*embeddedDocument
+	bestSize := -1
+	for path, values := range conds {
+		idx := c.index(path)
+		keys := make([]string, 0, len(values))
+		size := 0
+		for _, v := range values {
+			key := valueKey(v)
+			keys = append(keys, key)
+			size += len(idx[key])
+		}
+		if bestSize < 0 || size < bestSize {
+			bestKeys, bestIndex, bestSize = keys, idx, size
+		}
+		if size == 0 {
+			break
+		}
+	}
+
+	docs := make([]*embeddedDocument, 0, bestSize)
+	seen := make(map[uint64]bool, bestSize)
+	for _, key := range bestKeys {
+		for seq, stored := range bestIndex[key] {
+			if !seen[seq] {
+				seen[seq] = true
+				docs = append(docs, stored)
+			}
+		}
+	}
+	return sortBySeq(docs), true
+}
+
+//sortBySeq puts documents in natural order
+func sortBySeq(docs []*embeddedDocument) []*embeddedDocument {
+	sort.Slice(docs, func(i, j int) bool { return docs[i].seq < docs[j].seq })
+	return docs
+}
+
+//indexableConditions gathers the values a filter requires each field to
+//equal. Conditions on a field inside an array of arrays or holding
+//documents of operators are skipped.
+func indexableConditions(filter bson.D, vars map[string]interface{}) map[string][]interface{} {
+	conds := make(map[string][]interface{})
+	var gather func(filter bson.D)
+	gather = func(filter bson.D) {
+		for _, elem := range filter {
+			switch {
+			case elem.Name == "$and":
+				clauses, _ := elem.Value.([]interface{})
+				for _, clause := range clauses {
+					if sub, ok := clause.(bson.D); ok {
+						gather(sub)
+					}
+				}
+			case elem.Name == "$expr":
+				gatherExprConditions(elem.Value, vars, conds)
+			case strings.HasPrefix(elem.Name, "$"), isRegex(elem.Value):
+				continue
+			case isOperatorDoc(elem.Value):
+				for _, op := range elem.Value.(bson.D) {
+					switch op.Name {
+					case "$eq":
+						conds[elem.Name] = []interface{}{op.Value}
+					case "$in":
+						if values, ok := op.Value.([]interface{}); ok {
+							conds[elem.Name] = values
+						}
+					}
+				}
+			default:
+				conds[elem.Name] = []interface{}{elem.Value}
+			}
+		}
+	}
+	gather(filter)
+	return conds
+}
+
+//gatherExprConditions finds {$eq: ["$field", "$$var"]} comparisons in an
+//$expr, such as those written by $lookup pipelines
+func gatherExprConditions(expr interface{}, vars map[string]interface{}, conds map[string][]interface{}) {
+	doc, ok := expr.(bson.D)
+	if !ok || len(doc) != 1 {
+		return
+	}
+	args, _ := doc[0].Value.([]interface{})
+	switch doc[0].Name {
+	case "$and":
+		for _, arg := range args {
+			gatherExprConditions(arg, vars, conds)
+		}
+	case "$eq":
+		if len(args) != 2 {
+			return
+		}
+		field, value := args[0], args[1]
+		if !isFieldPath(field) {
+			field, value = value, field
+		}
+		if !isFieldPath(field) || isFieldPath(value) {
+			return
+		}
+		resolved, err := evalExpr(value, &exprContext{vars: vars})
+		if err != nil {
+			return
+		}
+		if _, isArray := resolved.([]interface{}); isArray {
+			// the index treats arrays as sets of elements
+			return
+		}
+		conds[field.(string)[1:]] = []interface{}{orNil(resolved)}
+	}
+}
+
+//isFieldPath reports whether an expression refers to a document field
+func isFieldPath(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, "$") && !strings.HasPrefix(s, "$$")
+}
+
+//find returns the documents matching a filter in natural order
+func (c *embeddedCollection) findStored(filter bson.D, vars map[string]interface{}) ([]*embeddedDocument, error) {
+	docs, ok := c.candidates(filter, vars)
+	if !ok {
+		docs = c.live()
+	}
+	var matched []*embeddedDocument
+	for _, stored := range docs {
+		ok, err := matchDoc(stored.doc, filter, vars)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, stored)
+		}
+	}
+	return matched, nil
+}
+
+//find returns the contents of the documents matching a filter
+func (c *embeddedCollection) find(filter bson.D, vars map[string]interface{}) ([]bson.D, error) {
+	stored, err := c.findStored(filter, vars)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]bson.D, 0, len(stored))
+	for _, s := range stored {
+		docs = append(docs, s.doc)
+	}
+	return docs, nil
+}
+
+//aggregate runs a pipeline over the collection. A leading $match uses the
+//indexes to avoid reading every document.
+func (c *embeddedCollection) aggregate(stages []interface{}, vars map[string]interface{}) ([]bson.D, error) {
+	var docs []bson.D
+	if len(stages) > 0 {
+		if stage, ok := stages[0].(bson.D); ok && len(stage) == 1 && stage[0].Name == "$match" {
+			filter, ok := stage[0].Value.(bson.D)
+			if !ok {
+				return nil, fmt.Errorf("$match requires a document")
+			}
+			var err error
+			docs, err = c.find(filter, vars)
+			if err != nil {
+				return nil, err
+			}
+			return c.engine.runPipeline(c.db, docs, stages[1:], vars)
+		}
+	}
+	docs, err := c.find(bson.D{}, vars)
+	if err != nil {
+		return nil, err
+	}
+	return c.engine.runPipeline(c.db, docs, stages, vars)
+}
+
+//update applies an update to the first, or every, matching document,
+//inserting a document if upsert is set and none match
+func (c *embeddedCollection) update(selector, update interface{}, multi, upsert bool) (*ChangeInfo, error) {
+	filter, err := toFilter(selector)
+	if err != nil {
+		return nil, err
+	}
+	updateDoc, err := toDoc(update)
+	if err != nil {
+		return nil, err
+	}
+
+	matched, err := c.findStored(filter, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !multi && len(matched) > 1 {
+		matched = matched[:1]
+	}
+
+	info := &ChangeInfo{}
+	if len(matched) == 0 {
+		if !upsert {
+			return info, nil
+		}
+		doc, err := upsertDoc(filter, updateDoc)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.insert(doc); err != nil {
+			return nil, err
+		}
+		info.UpsertedId, _ = getField(doc, "_id")
+		return info, nil
+	}
+
+	for _, stored := range matched {
+		resolved, err := resolvePositional(stored.doc, filter, updateDoc)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := applyUpdate(stored.doc, resolved, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.replace(stored, doc); err != nil {
+			return nil, err
+		}
+	}
+	info.Matched = len(matched)
+	info.Updated = len(matched)
+	return info, nil
+}
+
+//removeMatching removes the first, or every, matching document
+func (c *embeddedCollection) removeMatching(selector interface{}, multi bool) (*ChangeInfo, error) {
+	filter, err := toFilter(selector)
+	if err != nil {
+		return nil, err
+	}
+	matched, err := c.findStored(filter, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !multi && len(matched) > 1 {
+		matched = matched[:1]
+	}
+	for _, stored := range matched {
+		if err := c.remove(stored); err != nil {
+			return nil, err
+		}
+	}
+	return &ChangeInfo{Removed: len(matched), Matched: len(matched)}, nil
+}
+
+//drop removes the collection from memory and the file
+func (c *embeddedCollection) drop() error {
+	err := c.engine.file.Update(func(tx *bolt.Tx) error {
+		dbBucket := tx.Bucket([]byte(c.db))
+		if dbBucket == nil || dbBucket.Bucket([]byte(c.name)) == nil {
+			return nil
+		}
+		return dbBucket.DeleteBucket([]byte(c.name))
+	})
+	if err != nil {
+		return err
+	}
+	c.engine.dirty -= len(c.dirty)
+	delete(c.engine.collections, c.db+"\x00"+c.name)
+	return nil
+}
+
+func (s *embeddedSession) Copy() Session {
+	return &embeddedSession{engine: s.engine}
+}
+
+//Close writes any changes to the file. Closing the session returned by
+//NewEmbeddedSession also closes the file once no other session uses it.
+func (s *embeddedSession) Close() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.engine.lock.Lock()
+	// errors are reported by the next write as the file is left unchanged
+	_ = s.engine.flush()
+	s.engine.lock.Unlock()
+	if s.root {
+		s.engine.release()
+	}
+}
+
+func (s *embeddedSession) DB(name string) Database {
+	return &embeddedDatabase{engine: s.engine, name: name}
+}
+
+func (s *embeddedSession) DatabaseNames() ([]string, error) {
+	s.engine.lock.Lock()
+	defer s.engine.lock.Unlock()
+	names := make(map[string]bool)
+	err := s.engine.file.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names[string(name)] = true
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range s.engine.collections {
+		if c.exists {
+			names[c.db] = true
+		}
+	}
+	return sortedNames(names), nil
+}
+
+func (d *embeddedDatabase) C(name string) Collection {
+	return &embeddedCollectionHandle{engine: d.engine, db: d.name, name: name}
+}
+
+func (d *embeddedDatabase) CollectionNames() ([]string, error) {
+	d.engine.lock.Lock()
+	defer d.engine.lock.Unlock()
+	names := make(map[string]bool)
+	err := d.engine.file.View(func(tx *bolt.Tx) error {
+		dbBucket := tx.Bucket([]byte(d.name))
+		if dbBucket == nil {
+			return nil
+		}
+		return dbBucket.ForEach(func(name []byte, v []byte) error {
+			// nested buckets have no value
+			if v == nil {
+				names[string(name)] = true
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sortedNames(names), nil
+}
+
+func (d *embeddedDatabase) DropDatabase() error {
+	d.engine.lock.Lock()
+	defer d.engine.lock.Unlock()
+	for key, c := range d.engine.collections {
+		if c.db == d.name {
+			d.engine.dirty -= len(c.dirty)
+			delete(d.engine.collections, key)
+		}
+	}
+	return d.engine.file.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(d.name)) == nil {
+			return nil
+		}
+		return tx.DeleteBucket([]byte(d.name))
+	})
+}
+
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//with runs fn on the collection while holding the engine lock
+func (c *embeddedCollectionHandle) with(fn func(*embeddedCollection) error) error {
+	c.engine.lock.Lock()
+	defer c.engine.lock.Unlock()
+	coll, err := c.engine.collection(c.db, c.name)
+	if err != nil {
+		return err
+	}
+	return fn(coll)
+}
+
+func (c *embeddedCollectionHandle) Create(info *CollectionInfo) error {
+	return c.with(func(coll *embeddedCollection) error {
+		if coll.exists {
+			return fmt.Errorf("collection already exists")
+		}
+		return coll.create()
+	})
+}
+
+func (c *embeddedCollectionHandle) DropCollection() error {
+	c.engine.lock.Lock()
+	defer c.engine.lock.Unlock()
+	coll, err := c.engine.collection(c.db, c.name)
+	if errors.Is(err, ErrCollectionTooLarge) {
+		// collections too large to load may still be dropped
+		coll = &embeddedCollection{engine: c.engine, db: c.db, name: c.name, exists: true}
+	} else if err != nil {
+		return err
+	}
+	if !coll.exists {
+		return fmt.Errorf("ns not found")
+	}
+	return coll.drop()
+}
+
+//EnsureIndex records the index definition. Lookups are served by hash
+//indexes which are built on demand for the fields queried.
+func (c *embeddedCollectionHandle) EnsureIndex(index Index) error {
+	return c.with(func(coll *embeddedCollection) error {
+		for _, def := range coll.defs {
+			if strings.Join(def.Key, ",") == strings.Join(index.Key, ",") {
+				return nil
+			}
+		}
+		if index.Name == "" {
+			index.Name = strings.Join(index.Key, "_")
+		}
+		if !coll.exists {
+			if err := coll.create(); err != nil {
+				return err
+			}
+		}
+		defs := append(append([]Index(nil), coll.defs...), index)
+		raw, err := bson.Marshal(bson.M{"indexes": defs})
+		if err != nil {
+			return err
+		}
+		err = coll.engine.file.Update(func(tx *bolt.Tx) error {
+			return collectionBucket(tx, coll.db, coll.name).Put(indexesKey, raw)
+		})
+		if err == nil {
+			coll.defs = defs
+		}
+		return err
+	})
+}
+
+func (c *embeddedCollectionHandle) Indexes() ([]Index, error) {
+	var indexes []Index
+	err := c.with(func(coll *embeddedCollection) error {
+		if !coll.exists {
+			return fmt.Errorf("ns does not exist")
+		}
+		indexes = append([]Index{{Key: []string{"_id"}, Name: "_id_"}}, coll.defs...)
+		return nil
+	})
+	return indexes, err
+}
+
+func (c *embeddedCollectionHandle) Count() (int, error) {
+	var count int
+	err := c.with(func(coll *embeddedCollection) error {
+		count = len(coll.docs) - coll.removed
+		return nil
+	})
+	return count, err
+}
+
+func (c *embeddedCollectionHandle) Find(query interface{}) Query {
+	return &embeddedQuery{coll: c, filter: query}
+}
+
+func (c *embeddedCollectionHandle) Pipe(pipeline interface{}) Pipe {
+	return &embeddedPipe{coll: c, pipeline: pipeline}
+}
+
+func (c *embeddedCollectionHandle) Insert(docs ...interface{}) error {
+	return c.with(func(coll *embeddedCollection) error {
+		for _, d := range docs {
+			doc, err := toDoc(d)
+			if err != nil {
+				return err
+			}
+			if err := coll.insert(copyValue(doc).(bson.D)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (c *embeddedCollectionHandle) Update(selector interface{}, update interface{}) error {
+	return c.with(func(coll *embeddedCollection) error {
+		info, err := coll.update(selector, update, false, false)
+		if err == nil && info.Matched == 0 {
+			return ErrNotFound
+		}
+		return err
+	})
+}
+
+func (c *embeddedCollectionHandle) UpdateAll(selector interface{}, update interface{}) (*ChangeInfo, error) {
+	var info *ChangeInfo
+	err := c.with(func(coll *embeddedCollection) error {
+		var err error
+		info, err = coll.update(selector, update, true, false)
+		return err
+	})
+	return info, err
+}
+
+func (c *embeddedCollectionHandle) Upsert(selector interface{}, update interface{}) (*ChangeInfo, error) {
+	var info *ChangeInfo
+	err := c.with(func(coll *embeddedCollection) error {
+		var err error
+		info, err = coll.update(selector, update, false, true)
+		return err
+	})
+	return info, err
+}
+
+func (c *embeddedCollectionHandle) Remove(selector interface{}) error {
+	return c.with(func(coll *embeddedCollection) error {
+		info, err := coll.removeMatching(selector, false)
+		if err == nil && info.Removed == 0 {
+			return ErrNotFound
+		}
+		return err
+	})
+}
+
+func (c *embeddedCollectionHandle) RemoveId(id interface{}) error {
+	return c.Remove(bson.D{{Name: "_id", Value: id}})
+}
+
+func (c *embeddedCollectionHandle) RemoveAll(selector interface{}) (*ChangeInfo, error) {
+	var info *ChangeInfo
+	err := c.with(func(coll *embeddedCollection) error {
+		var err error
+		info, err = coll.removeMatching(selector, true)
+		return err
+	})
+	return info, err
+}
+
+func (c *embeddedCollectionHandle) Bulk() Bulk {
+	return &embeddedBulk{coll: c}
+}
+
+func (q *embeddedQuery) Select(selector interface{}) Query {
+	copied := *q
+	copied.projection = selector
+	return &copied
+}
+
+func (q *embeddedQuery) Sort(fields ...string) Query {
+	copied := *q
+	copied.sort = fields
+	return &copied
+}
+
+func (q *embeddedQuery) Skip(n int) Query {
+	copied := *q
+	copied.skip = n
+	return &copied
+}
+
+func (q *embeddedQuery) Limit(n int) Query {
+	copied := *q
+	copied.limit = n
+	return &copied
+}
+
+//run executes the query and returns the resulting documents
+func (q *embeddedQuery) run() ([]bson.D, error) {
+	filter, err := toFilter(q.filter)
+	if err != nil {
+		return nil, err
+	}
+	var projection bson.D
+	if q.projection != nil {
+		if projection, err = toDoc(q.projection); err != nil {
+			return nil, err
+		}
+	}
+
+	var docs []bson.D
+	err = q.coll.with(func(coll *embeddedCollection) error {
+		var err error
+		docs, err = coll.find(filter, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(q.sort) > 0 {
+		sortDocs(docs, q.sort)
+	}
+	if q.skip > 0 {
+		if q.skip >= len(docs) {
+			docs = nil
+		} else {
+			docs = docs[q.skip:]
+		}
+	}
+	if q.limit > 0 && q.limit < len(docs) {
+		docs = docs[:q.limit]
+	}
+	if projection != nil {
+		for i := range docs {
+			if docs[i], err = project(docs[i], projection, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return docs, nil
+}
+
+func (q *embeddedQuery) Count() (int, error) {
+	docs, err := q.run()
+	return len(docs), err
+}
+
+func (q *embeddedQuery) One(result interface{}) error {
+	docs, err := q.Limit(1).(*embeddedQuery).run()
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return ErrNotFound
+	}
+	return decodeDoc(docs[0], result)
+}
+
+func (q *embeddedQuery) All(result interface{}) error {
+	docs, err := q.run()
+	if err != nil {
+		return err
+	}
+	return decodeDocs(docs, result)
+}
+
+func (q *embeddedQuery) Iter() Iter {
+	docs, err := q.run()
+	return &embeddedIter{docs: docs, err: err}
+}
+
+func (p *embeddedPipe) AllowDiskUse() Pipe { return p }
+
+//run executes the pipeline and returns the resulting documents
+func (p *embeddedPipe) run() ([]bson.D, error) {
+	stages, err := toArray(p.pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var docs []bson.D
+	err = p.coll.with(func(coll *embeddedCollection) error {
+		var err error
+		docs, err = coll.aggregate(stages, map[string]interface{}{})
+		return err
+	})
+	return docs, err
+}
+
+func (p *embeddedPipe) One(result interface{}) error {
+	docs, err := p.run()
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return ErrNotFound
+	}
+	return decodeDoc(docs[0], result)
+}
+
+func (p *embeddedPipe) All(result interface{}) error {
+	docs, err := p.run()
+	if err != nil {
+		return err
+	}
+	return decodeDocs(docs, result)
+}
+
+func (p *embeddedPipe) Iter() Iter {
+	docs, err := p.run()
+	return &embeddedIter{docs: docs, err: err}
+}
+
+func (i *embeddedIter) Next(result interface{}) bool {
+	if i.err != nil || i.pos >= len(i.docs) {
+		return false
+	}
+	i.err = decodeDoc(i.docs[i.pos], result)
+	i.pos++
+	return i.err == nil
+}
+
+func (i *embeddedIter) All(result interface{}) error {
+	if i.err != nil {
+		return i.err
+	}
+	i.err = decodeDocs(i.docs[i.pos:], result)
+	i.pos = len(i.docs)
+	return i.err
+}
+
+func (i *embeddedIter) Err() error { return i.err }
+
+func (i *embeddedIter) Close() error { return i.err }
+
+//Unordered has no effect as queued writes always run in turn
+func (b *embeddedBulk) Unordered() {}
+
+func (b *embeddedBulk) Insert(docs ...interface{}) {
+	for _, doc := range docs {
+		doc := doc
+		b.ops = append(b.ops, func() (int, int, error) {
+			return 0, 0, b.coll.Insert(doc)
+		})
+	}
+}
+
+func (b *embeddedBulk) Update(pairs ...interface{}) {
+	b.queuePairs(pairs, false)
+}
+
+func (b *embeddedBulk) Upsert(pairs ...interface{}) {
+	b.queuePairs(pairs, true)
+}
+
+//queuePairs queues an update for each selector and update pair
+func (b *embeddedBulk) queuePairs(pairs []interface{}, upsert bool) {
+	if len(pairs)%2 != 0 {
+		panic("Bulk.Update requires an even number of parameters")
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		selector, update := pairs[i], pairs[i+1]
+		b.ops = append(b.ops, func() (int, int, error) {
+			var info *ChangeInfo
+			err := b.coll.with(func(coll *embeddedCollection) error {
+				var err error
+				info, err = coll.update(selector, update, false, upsert)
+				return err
+			})
+			if err != nil {
+				return 0, 0, err
+			}
+			return info.Matched, info.Updated, nil
+		})
+	}
+}
+
+func (b *embeddedBulk) Run() (*BulkResult, error) {
+	result := &BulkResult{}
+	ops := b.ops
+	b.ops = nil
+	var firstErr error
+	for _, op := range ops {
+		matched, modified, err := op()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		result.Matched += matched
+		result.Modified += modified
+	}
+	return result, firstErr
+}
@@ -0,0 +1,286 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+//matchDoc reports whether a document matches a query filter. vars holds
+//the variables available to $expr conditions.
+func matchDoc(doc bson.D, filter bson.D, vars map[string]interface{}) (bool, error) {
+	for _, elem := range filter {
+		var ok bool
+		var err error
+		switch elem.Name {
+		case "$and", "$or", "$nor":
+			ok, err = matchLogical(doc, elem.Name, elem.Value, vars)
+		case "$expr":
+			var result interface{}
+			result, err = evalExpr(elem.Value, &exprContext{root: doc, vars: vars})
+			ok = isTruthy(result)
+		default:
+			ok, err = matchField(doc, elem.Name, elem.Value)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+//matchLogical evaluates $and, $or, and $nor
+func matchLogical(doc bson.D, op string, value interface{}, vars map[string]interface{}) (bool, error) {
+	clauses, ok := value.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("%s requires an array", op)
+	}
+	for _, clause := range clauses {
+		filter, ok := clause.(bson.D)
+		if !ok {
+			return false, fmt.Errorf("%s requires an array of documents", op)
+		}
+		matched, err := matchDoc(doc, filter, vars)
+		if err != nil {
+			return false, err
+		}
+		if op == "$and" && !matched {
+			return false, nil
+		}
+		if op == "$or" && matched {
+			return true, nil
+		}
+		if op == "$nor" && matched {
+			return false, nil
+		}
+	}
+	return op != "$or", nil
+}
+
+//matchField checks a condition on a dotted path
+func matchField(doc bson.D, path string, cond interface{}) (bool, error) {
+	values := lookupPath(doc, splitPath(path))
+	if isOperatorDoc(cond) {
+		return matchOperators(values, cond.(bson.D))
+	}
+	if isRegex(cond) {
+		return matchOperator(values, "$regex", cond)
+	}
+	return matchEquals(values, cond), nil
+}
+
+//matchEquals reports whether any value, or any element of an array value,
+//equals the target. A nil target also matches missing values.
+func matchEquals(values []interface{}, target interface{}) bool {
+	if target == nil && len(values) == 0 {
+		return true
+	}
+	for _, v := range expandArrays(values) {
+		if valuesEqual(v, target) {
+			return true
+		}
+	}
+	return false
+}
+
+//matchOperators checks every operator in a condition such as {"$gt": 5, "$lt": 10}
+func matchOperators(values []interface{}, ops bson.D) (bool, error) {
+	for _, op := range ops {
+		arg := op.Value
+		// $options modifies the $regex beside it
+		if pattern, isString := arg.(string); isString && op.Name == "$regex" {
+			options, _ := getField(ops, "$options")
+			flags, _ := options.(string)
+			arg = bson.RegEx{Pattern: pattern, Options: flags}
+		}
+		ok, err := matchOperator(values, op.Name, arg)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchOperator(values []interface{}, op string, arg interface{}) (bool, error) {
+	switch op {
+	case "$eq":
+		return matchEquals(values, arg), nil
+	case "$ne":
+		return !matchEquals(values, arg), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		for _, v := range expandArrays(values) {
+			if typeOrder(v) != typeOrder(arg) {
+				continue
+			}
+			c := compareValues(v, arg)
+			if (op == "$gt" && c > 0) || (op == "$gte" && c >= 0) ||
+				(op == "$lt" && c < 0) || (op == "$lte" && c <= 0) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$in", "$nin":
+		targets, ok := arg.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s requires an array", op)
+		}
+		found := false
+		for _, target := range targets {
+			if matchEquals(values, target) {
+				found = true
+				break
+			}
+		}
+		return found == (op == "$in"), nil
+	case "$exists":
+		return (len(values) > 0) == isTruthy(arg), nil
+	case "$size":
+		size, ok := toFloat(arg)
+		if !ok {
+			return false, fmt.Errorf("$size requires a number")
+		}
+		for _, v := range values {
+			if array, ok := v.([]interface{}); ok && float64(len(array)) == size {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$elemMatch":
+		cond, ok := arg.(bson.D)
+		if !ok {
+			return false, fmt.Errorf("$elemMatch requires a document")
+		}
+		for _, v := range values {
+			array, ok := v.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, elem := range array {
+				matched, err := matchElement(elem, cond)
+				if err != nil {
+					return false, err
+				}
+				if matched {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	case "$not":
+		cond, ok := arg.(bson.D)
+		if !ok {
+			return false, fmt.Errorf("$not requires a document")
+		}
+		matched, err := matchOperators(values, cond)
+		return !matched, err
+	case "$all":
+		targets, ok := arg.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("$all requires an array")
+		}
+		for _, target := range targets {
+			if !matchEquals(values, target) {
+				return false, nil
+			}
+		}
+		return len(targets) > 0, nil
+	case "$regex":
+		var re *regexp.Regexp
+		var err error
+		switch pattern := arg.(type) {
+		case string:
+			re, err = regexp.Compile(pattern)
+		case bson.RegEx:
+			re, err = regexp.Compile(regexFlags(pattern.Options) + pattern.Pattern)
+		default:
+			return false, fmt.Errorf("$regex requires a string")
+		}
+		if err != nil {
+			return false, err
+		}
+		for _, v := range expandArrays(values) {
+			if s, ok := v.(string); ok && re.MatchString(s) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$options":
+		return true, nil
+	}
+	return false, fmt.Errorf("unsupported query operator %s", op)
+}
+
+//isRegex reports whether a query condition is a regular expression, which
+//matches strings rather than comparing for equality
+func isRegex(cond interface{}) bool {
+	_, ok := cond.(bson.RegEx)
+	return ok
+}
+
+//regexFlags converts the i, m, and s regular expression options into the
+//flags prefixed to a Go regular expression. Other options are ignored.
+func regexFlags(options string) string {
+	var flags strings.Builder
+	for _, option := range options {
+		if strings.ContainsRune("ims", option) {
+			flags.WriteRune(option)
+		}
+	}
+	if flags.Len() == 0 {
+		return ""
+	}
+	return "(?" + flags.String() + ")"
+}
+
+//matchElement checks an $elemMatch or $pull condition against a single array
+//element. Conditions made of operators apply to the element itself while
+//other conditions apply to the fields of a document element.
+func matchElement(elem interface{}, cond bson.D) (bool, error) {
+	if isOperatorDoc(cond) && cond[0].Name != "$and" && cond[0].Name != "$or" && cond[0].Name != "$nor" {
+		return matchOperators([]interface{}{elem}, cond)
+	}
+	doc, ok := elem.(bson.D)
+	if !ok {
+		return false, nil
+	}
+	return matchDoc(doc, cond, nil)
+}
+
+//equalityConditions gathers the fields a filter requires to equal a single
+//value. These seed the documents created by upserts and are used to find
+//candidate documents with an index.
+func equalityConditions(filter bson.D) bson.D {
+	var conds bson.D
+	for _, elem := range filter {
+		switch {
+		case elem.Name == "$and":
+			clauses, _ := elem.Value.([]interface{})
+			for _, clause := range clauses {
+				if sub, ok := clause.(bson.D); ok {
+					conds = append(conds, equalityConditions(sub)...)
+				}
+			}
+		case len(elem.Name) > 0 && elem.Name[0] == '$', isRegex(elem.Value):
+			continue
+		case isOperatorDoc(elem.Value):
+			for _, op := range elem.Value.(bson.D) {
+				if op.Name == "$eq" {
+					conds = append(conds, bson.DocElem{Name: elem.Name, Value: op.Value})
+				}
+			}
+		default:
+			conds = append(conds, elem)
+		}
+	}
+	return conds
+}
+
+//toFilter converts a selector into a filter document
+func toFilter(selector interface{}) (bson.D, error) {
+	if id, ok := selector.(bson.ObjectId); ok {
+		return bson.D{{Name: "_id", Value: id}}, nil
+	}
+	return toDoc(selector)
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedQueryOperators(t *testing.T) {
+	session, _ := newTestSession(t)
+	defer session.Close()
+	coll := session.DB("test").C("hosts")
+
+	require.NoError(t, coll.Insert(
+		bson.M{"_id": 1, "n": 5, "s": "alpha", "tags": []string{"a", "b"},
+			"dat": []bson.M{{"cid": 0, "c": 1}, {"cid": 1, "c": 3}}},
+		bson.M{"_id": 2, "n": 10, "s": "beta", "tags": []string{"b"},
+			"dat": []bson.M{{"cid": 1, "c": 7}}, "empty": nil},
+		bson.M{"_id": 3, "s": "Gamma", "tags": []string{}, "lo": 1, "hi": 2},
+	))
+
+	tests := []struct {
+		name   string
+		filter bson.M
+		want   []int
+	}{
+		{"equality", bson.M{"s": "alpha"}, []int{1}},
+		{"array element equality", bson.M{"tags": "b"}, []int{1, 2}},
+		{"nested array path", bson.M{"dat.cid": 0}, []int{1}},
+		{"null matches missing", bson.M{"n": nil}, []int{3}},
+		{"$eq", bson.M{"n": bson.M{"$eq": 10}}, []int{2}},
+		{"$ne", bson.M{"n": bson.M{"$ne": 5}}, []int{2, 3}},
+		{"$gt", bson.M{"n": bson.M{"$gt": 5}}, []int{2}},
+		{"$gte", bson.M{"n": bson.M{"$gte": 5}}, []int{1, 2}},
+		{"$lt", bson.M{"n": bson.M{"$lt": 10}}, []int{1}},
+		{"$lte", bson.M{"n": bson.M{"$lte": 10}}, []int{1, 2}},
+		{"range", bson.M{"n": bson.M{"$gt": 5, "$lt": 20}}, []int{2}},
+		{"comparisons only match the same type", bson.M{"s": bson.M{"$gt": 1}}, nil},
+		{"array element comparison", bson.M{"dat.c": bson.M{"$gt": 5}}, []int{2}},
+		{"$in", bson.M{"s": bson.M{"$in": []string{"alpha", "beta"}}}, []int{1, 2}},
+		{"$in array field", bson.M{"tags": bson.M{"$in": []string{"a"}}}, []int{1}},
+		{"$nin", bson.M{"s": bson.M{"$nin": []string{"alpha"}}}, []int{2, 3}},
+		{"$exists", bson.M{"n": bson.M{"$exists": true}}, []int{1, 2}},
+		{"$exists null field", bson.M{"empty": bson.M{"$exists": true}}, []int{2}},
+		{"not $exists", bson.M{"n": bson.M{"$exists": false}}, []int{3}},
+		{"$size", bson.M{"tags": bson.M{"$size": 0}}, []int{3}},
+		{"$elemMatch", bson.M{"dat": bson.M{"$elemMatch": bson.M{"cid": 1, "c": bson.M{"$gt": 5}}}}, []int{2}},
+		{"$elemMatch on values", bson.M{"tags": bson.M{"$elemMatch": bson.M{"$eq": "a"}}}, []int{1}},
+		{"$not", bson.M{"n": bson.M{"$not": bson.M{"$gt": 5}}}, []int{1, 3}},
+		{"$all", bson.M{"tags": bson.M{"$all": []string{"a", "b"}}}, []int{1}},
+		{"$regex", bson.M{"s": bson.M{"$regex": "^a"}}, []int{1}},
+		{"$regex with $options", bson.M{"s": bson.M{"$regex": "^g", "$options": "i"}}, []int{3}},
+		{"bson regex", bson.M{"s": bson.RegEx{Pattern: "A$", Options: "i"}}, []int{1, 2, 3}},
+		{"$and", bson.M{"$and": []bson.M{{"tags": "b"}, {"n": bson.M{"$gt": 5}}}}, []int{2}},
+		{"$or", bson.M{"$or": []bson.M{{"s": "alpha"}, {"n": nil}}}, []int{1, 3}},
+		{"$nor", bson.M{"$nor": []bson.M{{"s": "alpha"}, {"n": nil}}}, []int{2}},
+		{"$expr", bson.M{"$expr": bson.M{"$gt": []string{"$hi", "$lo"}}}, []int{3}},
+		{"_id", bson.M{"_id": bson.M{"$in": []int{2, 3, 4}}}, []int{2, 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var docs []struct {
+				ID int `bson:"_id"`
+			}
+			require.NoError(t, coll.Find(test.filter).Sort("_id").All(&docs))
+
+			var ids []int
+			for _, doc := range docs {
+				ids = append(ids, doc.ID)
+			}
+			require.Equal(t, test.want, ids)
+		})
+	}
+
+	_, err := coll.Find(bson.M{"n": bson.M{"$near": 1}}).Count()
+	require.Error(t, err)
+}
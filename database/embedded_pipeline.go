@@ -0,0 +1,1263 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+	//exprContext holds the document and variables an aggregation
+	//expression is evaluated against
+	exprContext struct {
+		root bson.D
+		vars map[string]interface{}
+	}
+
+	//missingValue marks a field path which doesn't exist so it can be
+	//left out of projected documents and accumulators
+	missingValue struct{}
+)
+
+var missing = missingValue{}
+
+//orNil converts a missing value into null
+func orNil(v interface{}) interface{} {
+	if _, ok := v.(missingValue); ok {
+		return nil
+	}
+	return v
+}
+
+//evalExpr evaluates an aggregation expression
+func evalExpr(expr interface{}, ctx *exprContext) (interface{}, error) {
+	switch value := expr.(type) {
+	case string:
+		if strings.HasPrefix(value, "$$") {
+			parts := splitPath(value[2:])
+			var base interface{}
+			switch parts[0] {
+			case "ROOT", "CURRENT":
+				base = ctx.root
+			default:
+				v, ok := ctx.vars[parts[0]]
+				if !ok {
+					return nil, fmt.Errorf("undefined variable %s", parts[0])
+				}
+				base = v
+			}
+			return evalFieldPath(base, parts[1:]), nil
+		}
+		if strings.HasPrefix(value, "$") {
+			return evalFieldPath(ctx.root, splitPath(value[1:])), nil
+		}
+		return value, nil
+	case bson.D:
+		if isOperatorDoc(value) {
+			if len(value) != 1 {
+				return nil, fmt.Errorf("an expression may only hold one operator")
+			}
+			return evalOperator(value[0].Name, value[0].Value, ctx)
+		}
+		doc := make(bson.D, 0, len(value))
+		for _, elem := range value {
+			v, err := evalExpr(elem.Value, ctx)
+			if err != nil {
+				return nil, err
+			}
+			if v == missing {
+				continue
+			}
+			doc = append(doc, bson.DocElem{Name: elem.Name, Value: v})
+		}
+		return doc, nil
+	case []interface{}:
+		array := make([]interface{}, 0, len(value))
+		for _, elem := range value {
+			v, err := evalExpr(elem, ctx)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, orNil(v))
+		}
+		return array, nil
+	}
+	return expr, nil
+}
+
+//evalFieldPath resolves a field path within an expression. Unlike query
+//paths, traversing an array of documents produces an array of the values.
+func evalFieldPath(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return v
+	}
+	switch value := v.(type) {
+	case bson.D:
+		field, ok := getField(value, path[0])
+		if !ok {
+			return missing
+		}
+		return evalFieldPath(field, path[1:])
+	case []interface{}:
+		array := make([]interface{}, 0, len(value))
+		for _, elem := range value {
+			if _, ok := elem.(bson.D); !ok {
+				continue
+			}
+			if result := evalFieldPath(elem, path); result != missing {
+				array = append(array, result)
+			}
+		}
+		return array
+	}
+	return missing
+}
+
+//evalArgs evaluates the arguments of an operator, which may be given
+//as a single expression or an array of expressions
+func evalArgs(arg interface{}, ctx *exprContext) ([]interface{}, error) {
+	list, ok := arg.([]interface{})
+	if !ok {
+		list = []interface{}{arg}
+	}
+	args := make([]interface{}, 0, len(list))
+	for _, elem := range list {
+		v, err := evalExpr(elem, ctx)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, orNil(v))
+	}
+	return args, nil
+}
+
+//evalOperator evaluates an expression operator
+func evalOperator(op string, arg interface{}, ctx *exprContext) (interface{}, error) {
+	switch op {
+	case "$literal":
+		return arg, nil
+	case "$cond":
+		return evalCond(arg, ctx)
+	case "$reduce":
+		return evalReduce(arg, ctx)
+	case "$filter", "$map":
+		return evalArrayMap(op, arg, ctx)
+	case "$ifNull":
+		args, err := evalArgs(arg, ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range args {
+			if v != nil {
+				return v, nil
+			}
+		}
+		return nil, nil
+	}
+
+	args, err := evalArgs(arg, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "$sum", "$avg", "$max", "$min":
+		// a single array argument is reduced over its elements
+		values := args
+		if len(args) == 1 {
+			if array, ok := args[0].([]interface{}); ok {
+				values = array
+			}
+		}
+		return reduceValues(op, values), nil
+
+	case "$add", "$subtract", "$multiply", "$divide", "$mod":
+		return evalArithmetic(op, args)
+
+	case "$abs", "$floor", "$ceil", "$log10", "$sqrt":
+		if len(args) != 1 || args[0] == nil {
+			return nil, nil
+		}
+		f, ok := toFloat(args[0])
+		if !ok {
+			return nil, fmt.Errorf("%s requires a number", op)
+		}
+		switch op {
+		case "$abs":
+			if isInteger(args[0]) {
+				n := toInt64(args[0])
+				if n < 0 {
+					n = -n
+				}
+				return n, nil
+			}
+			return math.Abs(f), nil
+		case "$floor":
+			return math.Floor(f), nil
+		case "$ceil":
+			return math.Ceil(f), nil
+		case "$log10":
+			return math.Log10(f), nil
+		}
+		return math.Sqrt(f), nil
+
+	case "$eq", "$ne", "$gt", "$gte", "$lt", "$lte", "$cmp":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s requires two arguments", op)
+		}
+		c := compareValues(args[0], args[1])
+		switch op {
+		case "$eq":
+			return c == 0, nil
+		case "$ne":
+			return c != 0, nil
+		case "$gt":
+			return c > 0, nil
+		case "$gte":
+			return c >= 0, nil
+		case "$lt":
+			return c < 0, nil
+		case "$lte":
+			return c <= 0, nil
+		}
+		return c, nil
+
+	case "$and":
+		for _, v := range args {
+			if !isTruthy(v) {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "$or":
+		for _, v := range args {
+			if isTruthy(v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$not":
+		return len(args) == 0 || !isTruthy(args[0]), nil
+
+	case "$in":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("$in requires two arguments")
+		}
+		array, ok := args[1].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("$in requires an array")
+		}
+		return containsValue(array, args[0]), nil
+
+	case "$size":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("$size requires one argument")
+		}
+		array, ok := args[0].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("$size requires an array, got %T", args[0])
+		}
+		return len(array), nil
+
+	case "$arrayElemAt":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("$arrayElemAt requires two arguments")
+		}
+		array, _ := args[0].([]interface{})
+		f, _ := toFloat(args[1])
+		idx := int(f)
+		if idx < 0 {
+			idx += len(array)
+		}
+		if idx < 0 || idx >= len(array) {
+			return missing, nil
+		}
+		return array[idx], nil
+
+	case "$first", "$last":
+		array, _ := args[0].([]interface{})
+		if len(array) == 0 {
+			return missing, nil
+		}
+		if op == "$first" {
+			return array[0], nil
+		}
+		return array[len(array)-1], nil
+
+	case "$slice":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("$slice requires an array and a count")
+		}
+		array, ok := args[0].([]interface{})
+		if !ok {
+			return nil, nil
+		}
+		start, count := 0, 0
+		if len(args) == 2 {
+			n, _ := toFloat(args[1])
+			count = int(n)
+			if count < 0 {
+				start, count = len(array)+count, -count
+			}
+		} else {
+			s, _ := toFloat(args[1])
+			n, _ := toFloat(args[2])
+			start, count = int(s), int(n)
+			if start < 0 {
+				start += len(array)
+			}
+		}
+		if start < 0 {
+			start = 0
+		}
+		if start > len(array) {
+			start = len(array)
+		}
+		end := start + count
+		if end > len(array) {
+			end = len(array)
+		}
+		return append([]interface{}{}, array[start:end]...), nil
+
+	case "$concatArrays", "$setUnion", "$setIntersection", "$setDifference":
+		return evalArrayOperator(op, args)
+
+	case "$anyElementTrue", "$allElementsTrue":
+		array, ok := args[0].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s requires an array", op)
+		}
+		for _, v := range array {
+			if isTruthy(v) == (op == "$anyElementTrue") {
+				return op == "$anyElementTrue", nil
+			}
+		}
+		return op != "$anyElementTrue", nil
+
+	case "$concat":
+		var sb strings.Builder
+		for _, v := range args {
+			s, ok := v.(string)
+			if !ok {
+				return nil, nil
+			}
+			sb.WriteString(s)
+		}
+		return sb.String(), nil
+
+	case "$toLower", "$toUpper":
+		s, _ := args[0].(string)
+		if op == "$toLower" {
+			return strings.ToLower(s), nil
+		}
+		return strings.ToUpper(s), nil
+	}
+
+	return nil, fmt.Errorf("unsupported expression operator %s", op)
+}
+
+//evalCond evaluates $cond in either its array or document form
+func evalCond(arg interface{}, ctx *exprContext) (interface{}, error) {
+	var cond, then, otherwise interface{}
+	switch value := arg.(type) {
+	case []interface{}:
+		if len(value) != 3 {
+			return nil, fmt.Errorf("$cond requires three arguments")
+		}
+		cond, then, otherwise = value[0], value[1], value[2]
+	case bson.D:
+		cond, _ = getField(value, "if")
+		then, _ = getField(value, "then")
+		otherwise, _ = getField(value, "else")
+	default:
+		return nil, fmt.Errorf("$cond requires an array or document")
+	}
+	result, err := evalExpr(cond, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if isTruthy(orNil(result)) {
+		return evalExpr(then, ctx)
+	}
+	return evalExpr(otherwise, ctx)
+}
+
+//evalReduce evaluates $reduce, binding $$value and $$this
+func evalReduce(arg interface{}, ctx *exprContext) (interface{}, error) {
+	spec, ok := arg.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$reduce requires a document")
+	}
+	inputExpr, _ := getField(spec, "input")
+	initialExpr, _ := getField(spec, "initialValue")
+	inExpr, _ := getField(spec, "in")
+
+	input, err := evalExpr(inputExpr, ctx)
+	if err != nil {
+		return nil, err
+	}
+	value, err := evalExpr(initialExpr, ctx)
+	if err != nil {
+		return nil, err
+	}
+	array, ok := input.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	inner := &exprContext{root: ctx.root, vars: copyVars(ctx.vars)}
+	for _, elem := range array {
+		inner.vars["value"] = orNil(value)
+		inner.vars["this"] = elem
+		value, err = evalExpr(inExpr, inner)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return orNil(value), nil
+}
+
+//evalArrayMap evaluates $filter and $map
+func evalArrayMap(op string, arg interface{}, ctx *exprContext) (interface{}, error) {
+	spec, ok := arg.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("%s requires a document", op)
+	}
+	inputExpr, _ := getField(spec, "input")
+	name := "this"
+	if as, ok := getField(spec, "as"); ok {
+		name, _ = as.(string)
+	}
+	field := "in"
+	if op == "$filter" {
+		field = "cond"
+	}
+	bodyExpr, _ := getField(spec, field)
+
+	input, err := evalExpr(inputExpr, ctx)
+	if err != nil {
+		return nil, err
+	}
+	array, ok := input.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	inner := &exprContext{root: ctx.root, vars: copyVars(ctx.vars)}
+	result := make([]interface{}, 0, len(array))
+	for _, elem := range array {
+		inner.vars[name] = elem
+		v, err := evalExpr(bodyExpr, inner)
+		if err != nil {
+			return nil, err
+		}
+		if op == "$map" {
+			result = append(result, orNil(v))
+		} else if isTruthy(orNil(v)) {
+			result = append(result, elem)
+		}
+	}
+	return result, nil
+}
+
+//evalArithmetic evaluates the arithmetic operators
+func evalArithmetic(op string, args []interface{}) (interface{}, error) {
+	for _, v := range args {
+		if v == nil {
+			return nil, nil
+		}
+		if _, ok := toFloat(v); !ok {
+			return nil, fmt.Errorf("%s requires numbers, got %T", op, v)
+		}
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s requires arguments", op)
+	}
+	switch op {
+	case "$add":
+		var total interface{} = int64(0)
+		for _, v := range args {
+			total = addNumbers(total, v)
+		}
+		return total, nil
+	case "$multiply":
+		if allIntegers(args) {
+			total := int64(1)
+			for _, v := range args {
+				total *= toInt64(v)
+			}
+			return total, nil
+		}
+		total := 1.0
+		for _, v := range args {
+			f, _ := toFloat(v)
+			total *= f
+		}
+		return total, nil
+	}
+
+	if len(args) != 2 {
+		return nil, fmt.Errorf("%s requires two arguments", op)
+	}
+	a, _ := toFloat(args[0])
+	b, _ := toFloat(args[1])
+	switch op {
+	case "$subtract":
+		if allIntegers(args) {
+			return toInt64(args[0]) - toInt64(args[1]), nil
+		}
+		return a - b, nil
+	case "$divide":
+		if b == 0 {
+			return nil, fmt.Errorf("can't $divide by zero")
+		}
+		return a / b, nil
+	}
+	if b == 0 {
+		return nil, fmt.Errorf("can't $mod by zero")
+	}
+	if allIntegers(args) {
+		return toInt64(args[0]) % toInt64(args[1]), nil
+	}
+	return math.Mod(a, b), nil
+}
+
+func allIntegers(args []interface{}) bool {
+	for _, v := range args {
+		if !isInteger(v) {
+			return false
+		}
+	}
+	return true
+}
+
+//evalArrayOperator evaluates the array concatenation and set operators
+func evalArrayOperator(op string, args []interface{}) (interface{}, error) {
+	arrays := make([][]interface{}, 0, len(args))
+	for _, v := range args {
+		if v == nil {
+			return nil, nil
+		}
+		array, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s requires arrays, got %T", op, v)
+		}
+		arrays = append(arrays, array)
+	}
+
+	result := []interface{}{}
+	switch op {
+	case "$concatArrays":
+		for _, array := range arrays {
+			result = append(result, array...)
+		}
+	case "$setUnion":
+		seen := make(map[string]bool)
+		for _, array := range arrays {
+			for _, v := range array {
+				key := valueKey(v)
+				if !seen[key] {
+					seen[key] = true
+					result = append(result, v)
+				}
+			}
+		}
+	case "$setIntersection", "$setDifference":
+		if len(arrays) == 0 {
+			return result, nil
+		}
+		others := make([]map[string]bool, 0, len(arrays)-1)
+		for _, array := range arrays[1:] {
+			set := make(map[string]bool)
+			for _, v := range array {
+				set[valueKey(v)] = true
+			}
+			others = append(others, set)
+		}
+		seen := make(map[string]bool)
+		for _, v := range arrays[0] {
+			key := valueKey(v)
+			if seen[key] {
+				continue
+			}
+			inAll := true
+			inAny := false
+			for _, set := range others {
+				if set[key] {
+					inAny = true
+				} else {
+					inAll = false
+				}
+			}
+			if (op == "$setIntersection" && inAll) || (op == "$setDifference" && !inAny) {
+				seen[key] = true
+				result = append(result, v)
+			}
+		}
+	}
+	return result, nil
+}
+
+//reduceValues computes $sum, $avg, $max, or $min over a list of values.
+//Values which aren't numbers are ignored by $sum and $avg, and nulls are
+//ignored by $max and $min.
+func reduceValues(op string, values []interface{}) interface{} {
+	switch op {
+	case "$sum", "$avg":
+		var total interface{} = 0
+		count := 0
+		for _, v := range values {
+			if _, ok := toFloat(v); ok {
+				total = addNumbers(total, v)
+				count++
+			}
+		}
+		if op == "$sum" {
+			return total
+		}
+		if count == 0 {
+			return nil
+		}
+		f, _ := toFloat(total)
+		return f / float64(count)
+	}
+
+	var best interface{}
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if best == nil {
+			best = v
+			continue
+		}
+		c := compareValues(v, best)
+		if (op == "$max" && c > 0) || (op == "$min" && c < 0) {
+			best = v
+		}
+	}
+	return best
+}
+
+func copyVars(vars map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(vars)+2)
+	for k, v := range vars {
+		copied[k] = v
+	}
+	return copied
+}
+
+//project applies a projection such as {"ip": 1, "dat": 0} or a $project
+//stage, which may also compute fields from expressions
+func project(doc bson.D, spec bson.D, vars map[string]interface{}) (bson.D, error) {
+	// a projection either only excludes fields or only includes fields,
+	// although _id may always be excluded
+	exclusion := true
+	excludeID := false
+	for _, elem := range spec {
+		if isExclusion(elem.Value) {
+			if elem.Name == "_id" {
+				excludeID = true
+			}
+			continue
+		}
+		exclusion = false
+	}
+
+	if len(spec) == 0 {
+		return doc, nil
+	}
+	if exclusion {
+		result := doc
+		for _, elem := range spec {
+			result = unsetPath(result, splitPath(elem.Name))
+		}
+		return result, nil
+	}
+
+	ctx := &exprContext{root: doc, vars: vars}
+	result := bson.D{}
+	if !excludeID {
+		if id, ok := getField(doc, "_id"); ok {
+			result = append(result, bson.DocElem{Name: "_id", Value: id})
+		}
+	}
+	for _, elem := range spec {
+		if isExclusion(elem.Value) {
+			continue
+		}
+		path := splitPath(elem.Name)
+		if isInclusion(elem.Value) {
+			included := includePath(doc, result, path)
+			if includedDoc, ok := included.(bson.D); ok {
+				result = includedDoc
+			}
+			continue
+		}
+		if nested, ok := elem.Value.(bson.D); ok && !isOperatorDoc(nested) && isNestedProjection(nested) {
+			field, _ := getPath(doc, path)
+			projected, err := projectNested(field, nested, vars)
+			if err != nil {
+				return nil, err
+			}
+			if projected != nil {
+				result, err = setPath(result, path, projected)
+				if err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		v, err := evalExpr(elem.Value, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if v == missing {
+			continue
+		}
+		result, err = setPath(result, path, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+//projectNested applies a nested projection such as {"src": {"ip": 1}}
+func projectNested(field interface{}, spec bson.D, vars map[string]interface{}) (interface{}, error) {
+	switch value := field.(type) {
+	case bson.D:
+		return project(value, spec, vars)
+	case []interface{}:
+		array := make([]interface{}, 0, len(value))
+		for _, elem := range value {
+			projected, err := projectNested(elem, spec, vars)
+			if err != nil {
+				return nil, err
+			}
+			if projected != nil {
+				array = append(array, projected)
+			}
+		}
+		return array, nil
+	}
+	return nil, nil
+}
+
+//isNestedProjection reports whether a document in a projection holds
+//inclusions or exclusions rather than expressions
+func isNestedProjection(spec bson.D) bool {
+	for _, elem := range spec {
+		if !isInclusion(elem.Value) && !isExclusion(elem.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+//isExclusion reports whether a projection value excludes a field
+func isExclusion(v interface{}) bool {
+	if b, ok := v.(bool); ok {
+		return !b
+	}
+	if f, ok := toFloat(v); ok {
+		return f == 0
+	}
+	return false
+}
+
+//isInclusion reports whether a projection value includes a field
+func isInclusion(v interface{}) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+	return false
+}
+
+//includePath copies the value at a dotted path from src into dst. Arrays
+//along the path have the path included from each of their documents.
+func includePath(src interface{}, dst interface{}, path []string) interface{} {
+	switch value := src.(type) {
+	case bson.D:
+		field, ok := getField(value, path[0])
+		if !ok {
+			return dst
+		}
+		doc, _ := dst.(bson.D)
+		if doc == nil {
+			doc = bson.D{}
+		}
+		doc = append(bson.D(nil), doc...)
+		if len(path) == 1 {
+			return setField(doc, path[0], field)
+		}
+		existing, _ := getField(doc, path[0])
+		if child := includePath(field, existing, path[1:]); child != nil {
+			doc = setField(doc, path[0], child)
+		}
+		return doc
+	case []interface{}:
+		existing, _ := dst.([]interface{})
+		array := make([]interface{}, 0, len(value))
+		for i, elem := range value {
+			if _, ok := elem.(bson.D); !ok {
+				continue
+			}
+			var prev interface{}
+			if i < len(existing) {
+				prev = existing[i]
+			}
+			child := includePath(elem, prev, path)
+			if child == nil {
+				child = bson.D{}
+			}
+			array = append(array, child)
+		}
+		return array
+	}
+	return dst
+}
+
+//runPipeline runs the stages of an aggregation pipeline over a set of
+//documents. Stages which need other collections, such as $lookup, read
+//them from the engine.
+func (e *embeddedEngine) runPipeline(db string, docs []bson.D, stages []interface{}, vars map[string]interface{}) ([]bson.D, error) {
+	for _, stageValue := range stages {
+		stage, ok := stageValue.(bson.D)
+		if !ok || len(stage) != 1 {
+			return nil, fmt.Errorf("each pipeline stage must be a document with a single field")
+		}
+		name, arg := stage[0].Name, stage[0].Value
+
+		var err error
+		switch name {
+		case "$match":
+			docs, err = filterDocs(docs, arg, vars)
+		case "$project":
+			docs, err = mapDocs(docs, arg, func(doc bson.D, spec bson.D) (bson.D, error) {
+				return project(doc, spec, vars)
+			})
+		case "$addFields", "$set":
+			docs, err = mapDocs(docs, arg, func(doc bson.D, spec bson.D) (bson.D, error) {
+				return addFields(doc, spec, vars)
+			})
+		case "$unset":
+			docs, err = unsetStage(docs, arg)
+		case "$replaceRoot":
+			docs, err = mapDocs(docs, arg, func(doc bson.D, spec bson.D) (bson.D, error) {
+				rootExpr, _ := getField(spec, "newRoot")
+				root, err := evalExpr(rootExpr, &exprContext{root: doc, vars: vars})
+				if err != nil {
+					return nil, err
+				}
+				newRoot, ok := root.(bson.D)
+				if !ok {
+					return nil, fmt.Errorf("$replaceRoot requires a document, got %T", root)
+				}
+				return newRoot, nil
+			})
+		case "$unwind":
+			docs, err = unwindDocs(docs, arg)
+		case "$group":
+			docs, err = groupDocs(docs, arg, vars)
+		case "$sort":
+			spec, ok := arg.(bson.D)
+			if !ok {
+				return nil, fmt.Errorf("$sort requires a document")
+			}
+			fields := make([]string, 0, len(spec))
+			for _, elem := range spec {
+				if f, _ := toFloat(elem.Value); f < 0 {
+					fields = append(fields, "-"+elem.Name)
+				} else {
+					fields = append(fields, elem.Name)
+				}
+			}
+			sorted := append([]bson.D(nil), docs...)
+			sortDocs(sorted, fields)
+			docs = sorted
+		case "$limit":
+			n, ok := toFloat(arg)
+			if !ok {
+				return nil, fmt.Errorf("$limit requires a number")
+			}
+			if int(n) < len(docs) {
+				docs = docs[:int(n)]
+			}
+		case "$skip":
+			n, ok := toFloat(arg)
+			if !ok {
+				return nil, fmt.Errorf("$skip requires a number")
+			}
+			if int(n) < len(docs) {
+				docs = docs[int(n):]
+			} else {
+				docs = nil
+			}
+		case "$count":
+			field, ok := arg.(string)
+			if !ok {
+				return nil, fmt.Errorf("$count requires a field name")
+			}
+			if len(docs) == 0 {
+				docs = nil
+			} else {
+				docs = []bson.D{{{Name: field, Value: len(docs)}}}
+			}
+		case "$lookup":
+			docs, err = e.lookupDocs(db, docs, arg, vars)
+		default:
+			err = fmt.Errorf("unsupported pipeline stage %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+//filterDocs implements $match
+func filterDocs(docs []bson.D, arg interface{}, vars map[string]interface{}) ([]bson.D, error) {
+	filter, ok := arg.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$match requires a document")
+	}
+	var matched []bson.D
+	for _, doc := range docs {
+		ok, err := matchDoc(doc, filter, vars)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, doc)
+		}
+	}
+	return matched, nil
+}
+
+//mapDocs applies a function taking a document argument to every document
+func mapDocs(docs []bson.D, arg interface{}, fn func(bson.D, bson.D) (bson.D, error)) ([]bson.D, error) {
+	spec, ok := arg.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("pipeline stage requires a document")
+	}
+	mapped := make([]bson.D, 0, len(docs))
+	for _, doc := range docs {
+		result, err := fn(doc, spec)
+		if err != nil {
+			return nil, err
+		}
+		mapped = append(mapped, result)
+	}
+	return mapped, nil
+}
+
+//addFields implements $addFields
+func addFields(doc bson.D, spec bson.D, vars map[string]interface{}) (bson.D, error) {
+	ctx := &exprContext{root: doc, vars: vars}
+	result := doc
+	for _, elem := range spec {
+		v, err := evalExpr(elem.Value, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if v == missing {
+			continue
+		}
+		result, err = setPath(result, splitPath(elem.Name), v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+//unsetStage implements the $unset stage
+func unsetStage(docs []bson.D, arg interface{}) ([]bson.D, error) {
+	var fields []interface{}
+	switch value := arg.(type) {
+	case string:
+		fields = []interface{}{value}
+	case []interface{}:
+		fields = value
+	default:
+		return nil, fmt.Errorf("$unset requires a field name or an array of field names")
+	}
+	result := make([]bson.D, 0, len(docs))
+	for _, doc := range docs {
+		for _, field := range fields {
+			name, _ := field.(string)
+			doc = unsetPath(doc, splitPath(name))
+		}
+		result = append(result, doc)
+	}
+	return result, nil
+}
+
+//unwindDocs implements $unwind
+func unwindDocs(docs []bson.D, arg interface{}) ([]bson.D, error) {
+	var pathExpr string
+	preserve := false
+	switch value := arg.(type) {
+	case string:
+		pathExpr = value
+	case bson.D:
+		p, _ := getField(value, "path")
+		pathExpr, _ = p.(string)
+		if v, ok := getField(value, "preserveNullAndEmptyArrays"); ok {
+			preserve = isTruthy(v)
+		}
+	}
+	if !strings.HasPrefix(pathExpr, "$") {
+		return nil, fmt.Errorf("$unwind requires a field path starting with $")
+	}
+	path := splitPath(pathExpr[1:])
+
+	var unwound []bson.D
+	for _, doc := range docs {
+		value, ok := getPath(doc, path)
+		array, isArray := value.([]interface{})
+		switch {
+		case !ok || value == nil || (isArray && len(array) == 0):
+			if preserve {
+				unwound = append(unwound, doc)
+			}
+		case !isArray:
+			unwound = append(unwound, doc)
+		default:
+			for _, elem := range array {
+				result, err := setPath(doc, path, elem)
+				if err != nil {
+					return nil, err
+				}
+				unwound = append(unwound, result)
+			}
+		}
+	}
+	return unwound, nil
+}
+
+//groupState accumulates the documents in a single $group group
+type groupState struct {
+	id     interface{}
+	values []interface{}
+	counts []int
+	seen   []map[string]bool
+	first  []bool
+}
+
+//groupDocs implements $group
+func groupDocs(docs []bson.D, arg interface{}, vars map[string]interface{}) ([]bson.D, error) {
+	spec, ok := arg.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$group requires a document")
+	}
+	idExpr, ok := getField(spec, "_id")
+	if !ok {
+		return nil, fmt.Errorf("$group requires an _id")
+	}
+
+	type accumulator struct {
+		field string
+		op    string
+		expr  interface{}
+	}
+	var accumulators []accumulator
+	for _, elem := range spec {
+		if elem.Name == "_id" {
+			continue
+		}
+		opDoc, ok := elem.Value.(bson.D)
+		if !ok || len(opDoc) != 1 {
+			return nil, fmt.Errorf("the %s field of $group requires an accumulator", elem.Name)
+		}
+		accumulators = append(accumulators, accumulator{field: elem.Name, op: opDoc[0].Name, expr: opDoc[0].Value})
+	}
+
+	var order []string
+	groups := make(map[string]*groupState)
+	for _, doc := range docs {
+		ctx := &exprContext{root: doc, vars: vars}
+		id, err := evalExpr(idExpr, ctx)
+		if err != nil {
+			return nil, err
+		}
+		id = orNil(id)
+		key := valueKey(id)
+		group, ok := groups[key]
+		if !ok {
+			group = &groupState{
+				id:     id,
+				values: make([]interface{}, len(accumulators)),
+				counts: make([]int, len(accumulators)),
+				seen:   make([]map[string]bool, len(accumulators)),
+				first:  make([]bool, len(accumulators)),
+			}
+			groups[key] = group
+			order = append(order, key)
+		}
+
+		for i, acc := range accumulators {
+			v, err := evalExpr(acc.expr, ctx)
+			if err != nil {
+				return nil, err
+			}
+			switch acc.op {
+			case "$sum", "$avg":
+				if _, ok := toFloat(v); ok {
+					if group.values[i] == nil {
+						group.values[i] = 0
+					}
+					group.values[i] = addNumbers(group.values[i], v)
+					group.counts[i]++
+				}
+			case "$first":
+				if !group.first[i] {
+					group.first[i] = true
+					group.values[i] = orNil(v)
+				}
+			case "$last":
+				group.values[i] = orNil(v)
+			case "$push":
+				array, _ := group.values[i].([]interface{})
+				if v != missing {
+					array = append(array, v)
+				}
+				if array == nil {
+					array = []interface{}{}
+				}
+				group.values[i] = array
+			case "$addToSet":
+				if group.seen[i] == nil {
+					group.seen[i] = make(map[string]bool)
+				}
+				array, _ := group.values[i].([]interface{})
+				if array == nil {
+					array = []interface{}{}
+				}
+				if v != missing {
+					key := valueKey(v)
+					if !group.seen[i][key] {
+						group.seen[i][key] = true
+						array = append(array, v)
+					}
+				}
+				group.values[i] = array
+			case "$max", "$min":
+				v = orNil(v)
+				if v == nil {
+					continue
+				}
+				if group.values[i] == nil {
+					group.values[i] = v
+					continue
+				}
+				c := compareValues(v, group.values[i])
+				if (acc.op == "$max" && c > 0) || (acc.op == "$min" && c < 0) {
+					group.values[i] = v
+				}
+			default:
+				return nil, fmt.Errorf("unsupported accumulator %s", acc.op)
+			}
+		}
+	}
+
+	grouped := make([]bson.D, 0, len(order))
+	for _, key := range order {
+		group := groups[key]
+		doc := bson.D{{Name: "_id", Value: group.id}}
+		for i, acc := range accumulators {
+			value := group.values[i]
+			switch acc.op {
+			case "$sum":
+				if value == nil {
+					value = 0
+				}
+			case "$avg":
+				if value != nil {
+					f, _ := toFloat(value)
+					value = f / float64(group.counts[i])
+				}
+			}
+			doc = append(doc, bson.DocElem{Name: acc.field, Value: value})
+		}
+		grouped = append(grouped, doc)
+	}
+	return grouped, nil
+}
+
+//lookupDocs implements $lookup with either localField and foreignField
+//or let and pipeline
+func (e *embeddedEngine) lookupDocs(db string, docs []bson.D, arg interface{}, vars map[string]interface{}) ([]bson.D, error) {
+	spec, ok := arg.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("$lookup requires a document")
+	}
+	fromValue, _ := getField(spec, "from")
+	asValue, _ := getField(spec, "as")
+	from, _ := fromValue.(string)
+	as, _ := asValue.(string)
+	if from == "" || as == "" {
+		return nil, fmt.Errorf("$lookup requires from and as")
+	}
+
+	foreign, err := e.collection(db, from)
+	if err != nil {
+		return nil, err
+	}
+
+	localValue, hasLocal := getField(spec, "localField")
+	foreignValue, _ := getField(spec, "foreignField")
+	letValue, _ := getField(spec, "let")
+	pipelineValue, _ := getField(spec, "pipeline")
+	pipeline, _ := pipelineValue.([]interface{})
+	let, _ := letValue.(bson.D)
+
+	result := make([]bson.D, 0, len(docs))
+	for _, doc := range docs {
+		var joined []bson.D
+		if hasLocal {
+			localField, _ := localValue.(string)
+			foreignField, _ := foreignValue.(string)
+			values := expandArrays(lookupPath(doc, splitPath(localField)))
+			if len(values) == 0 {
+				values = []interface{}{nil}
+			}
+			filter := bson.D{{Name: foreignField, Value: bson.D{{Name: "$in", Value: values}}}}
+			joined, err = foreign.find(filter, nil)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			innerVars := copyVars(vars)
+			ctx := &exprContext{root: doc, vars: vars}
+			for _, elem := range let {
+				v, err := evalExpr(elem.Value, ctx)
+				if err != nil {
+					return nil, err
+				}
+				innerVars[elem.Name] = orNil(v)
+			}
+			joined, err = foreign.aggregate(pipeline, innerVars)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		array := make([]interface{}, 0, len(joined))
+		for _, j := range joined {
+			array = append(array, j)
+		}
+		updated, err := setPath(doc, splitPath(as), array)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, updated)
+	}
+	return result, nil
+}
@@ -0,0 +1,243 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedExpressionOperators(t *testing.T) {
+	session, _ := newTestSession(t)
+	defer session.Close()
+	coll := session.DB("test").C("values")
+
+	require.NoError(t, coll.Insert(bson.M{
+		"_id":   1,
+		"a":     3,
+		"b":     4,
+		"f":     2.5,
+		"neg":   -2,
+		"zero":  0,
+		"yes":   true,
+		"null":  nil,
+		"s":     "MiXed",
+		"arr":   []int{1, 2, 3},
+		"words": []string{"x", "y"},
+		"other": []string{"y", "z"},
+		"dat":   []bson.M{{"cid": 0, "c": 1}, {"cid": 1, "c": 2}},
+	}))
+
+	tests := []struct {
+		name string
+		expr interface{}
+		want string
+	}{
+		{"field path", "$a", `3`},
+		{"array field path", "$dat.c", `[1, 2]`},
+		{"$$ROOT", "$$ROOT.b", `4`},
+		{"$literal", bson.M{"$literal": "$a"}, `"$a"`},
+		{"$cond", bson.M{"$cond": []interface{}{bson.M{"$gt": []string{"$a", "$zero"}}, "big", "small"}}, `"big"`},
+		{"$cond document", bson.M{"$cond": bson.M{"if": "$zero", "then": "yes", "else": "no"}}, `"no"`},
+		{"$reduce", bson.M{"$reduce": bson.M{
+			"input":        "$arr",
+			"initialValue": 0,
+			"in":           bson.M{"$add": []string{"$$value", "$$this"}},
+		}}, `6`},
+		{"$filter", bson.M{"$filter": bson.M{"input": "$arr", "as": "x", "cond": bson.M{"$gte": []interface{}{"$$x", 2}}}}, `[2, 3]`},
+		{"$map", bson.M{"$map": bson.M{"input": "$arr", "as": "x", "in": bson.M{"$multiply": []interface{}{"$$x", 10}}}}, `[10, 20, 30]`},
+		{"$ifNull", bson.M{"$ifNull": []string{"$missing", "$null", "fallback"}}, `"fallback"`},
+		{"$sum array", bson.M{"$sum": "$arr"}, `6`},
+		{"$sum arguments", bson.M{"$sum": []string{"$a", "$b", "$s"}}, `7`},
+		{"$avg", bson.M{"$avg": "$arr"}, `2`},
+		{"$max", bson.M{"$max": "$arr"}, `3`},
+		{"$min", bson.M{"$min": []string{"$a", "$b", "$null"}}, `3`},
+		{"$add", bson.M{"$add": []interface{}{"$a", "$b", 1}}, `8`},
+		{"$add null", bson.M{"$add": []string{"$a", "$null"}}, `null`},
+		{"$subtract", bson.M{"$subtract": []string{"$b", "$a"}}, `1`},
+		{"$multiply", bson.M{"$multiply": []string{"$a", "$f"}}, `7.5`},
+		{"$divide", bson.M{"$divide": []interface{}{"$b", 8}}, `0.5`},
+		{"$mod", bson.M{"$mod": []string{"$b", "$a"}}, `1`},
+		{"$abs", bson.M{"$abs": "$neg"}, `2`},
+		{"$floor", bson.M{"$floor": "$f"}, `2`},
+		{"$ceil", bson.M{"$ceil": "$f"}, `3`},
+		{"$log10", bson.M{"$log10": 1000}, `3`},
+		{"$sqrt", bson.M{"$sqrt": 16}, `4`},
+		{"$eq", bson.M{"$eq": []interface{}{"$a", 3}}, `true`},
+		{"$ne", bson.M{"$ne": []interface{}{"$a", 3}}, `false`},
+		{"$gt", bson.M{"$gt": []string{"$b", "$a"}}, `true`},
+		{"$gte", bson.M{"$gte": []interface{}{"$a", 3}}, `true`},
+		{"$lt", bson.M{"$lt": []string{"$b", "$a"}}, `false`},
+		{"$lte", bson.M{"$lte": []string{"$a", "$b"}}, `true`},
+		{"$cmp", bson.M{"$cmp": []string{"$a", "$b"}}, `-1`},
+		{"$and", bson.M{"$and": []string{"$yes", "$zero"}}, `false`},
+		{"$or", bson.M{"$or": []string{"$zero", "$yes"}}, `true`},
+		{"$not", bson.M{"$not": []string{"$yes"}}, `false`},
+		{"$in", bson.M{"$in": []string{"$a", "$arr"}}, `true`},
+		{"$size", bson.M{"$size": "$arr"}, `3`},
+		{"$arrayElemAt", bson.M{"$arrayElemAt": []interface{}{"$arr", -1}}, `3`},
+		{"$first", bson.M{"$first": "$arr"}, `1`},
+		{"$last", bson.M{"$last": "$arr"}, `3`},
+		{"$slice", bson.M{"$slice": []interface{}{"$arr", 2}}, `[1, 2]`},
+		{"$slice from end", bson.M{"$slice": []interface{}{"$arr", -1}}, `[3]`},
+		{"$slice position", bson.M{"$slice": []interface{}{"$arr", 1, 1}}, `[2]`},
+		{"$concatArrays", bson.M{"$concatArrays": []string{"$words", "$other"}}, `["x", "y", "y", "z"]`},
+		{"$setUnion", bson.M{"$setUnion": []string{"$words", "$other"}}, `["x", "y", "z"]`},
+		{"$setIntersection", bson.M{"$setIntersection": []string{"$words", "$other"}}, `["y"]`},
+		{"$setDifference", bson.M{"$setDifference": []string{"$words", "$other"}}, `["x"]`},
+		{"$anyElementTrue", bson.M{"$anyElementTrue": []interface{}{[]interface{}{0, "$yes"}}}, `true`},
+		{"$allElementsTrue", bson.M{"$allElementsTrue": []interface{}{[]interface{}{1, "$zero"}}}, `false`},
+		{"$concat", bson.M{"$concat": []string{"a-", "$s"}}, `"a-MiXed"`},
+		{"$toLower", bson.M{"$toLower": "$s"}, `"mixed"`},
+		{"$toUpper", bson.M{"$toUpper": "$s"}, `"MIXED"`},
+		{"document", bson.M{"sum": bson.M{"$add": []string{"$a", "$b"}}, "s": "$s"}, `{"sum": 7, "s": "MiXed"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var res struct {
+				V interface{} `bson:"v"`
+			}
+			err := coll.Pipe([]bson.M{{"$project": bson.M{"_id": 0, "v": test.expr}}}).One(&res)
+			require.NoError(t, err)
+			requireJSON(t, test.want, res.V)
+		})
+	}
+
+	// missing fields are left out of the result
+	var res bson.M
+	require.NoError(t, coll.Pipe([]bson.M{{"$project": bson.M{"_id": 0, "v": "$missing"}}}).One(&res))
+	require.Empty(t, res)
+
+	err := coll.Pipe([]bson.M{{"$project": bson.M{"v": bson.M{"$toDate": "$a"}}}}).One(&res)
+	require.Error(t, err)
+}
+
+func TestEmbeddedPipelineStages(t *testing.T) {
+	session, _ := newTestSession(t)
+	defer session.Close()
+	db := session.DB("test")
+
+	require.NoError(t, db.C("conns").Insert(
+		bson.M{"_id": 1, "src": "a", "bytes": 10, "tags": []string{"x", "y"}},
+		bson.M{"_id": 2, "src": "a", "bytes": 20, "tags": []string{}},
+		bson.M{"_id": 3, "src": "b", "bytes": 5},
+	))
+	require.NoError(t, db.C("hosts").Insert(
+		bson.M{"_id": "a", "name": "alpha"},
+		bson.M{"_id": "b", "name": "beta"},
+	))
+
+	tests := []struct {
+		name     string
+		pipeline []bson.M
+		want     string
+	}{
+		{"$match", []bson.M{
+			{"$match": bson.M{"src": "a", "bytes": bson.M{"$gt": 10}}},
+			{"$project": bson.M{"bytes": 1}},
+		}, `[{"_id": 2, "bytes": 20}]`},
+		{"$project inclusion", []bson.M{
+			{"$project": bson.M{"_id": 0, "src": 1}},
+		}, `[{"src": "a"}, {"src": "a"}, {"src": "b"}]`},
+		{"$project exclusion", []bson.M{
+			{"$match": bson.M{"_id": 1}},
+			{"$project": bson.M{"tags": 0, "bytes": 0}},
+		}, `[{"_id": 1, "src": "a"}]`},
+		{"$addFields", []bson.M{
+			{"$addFields": bson.M{"kb": bson.M{"$divide": []interface{}{"$bytes", 10}}}},
+			{"$project": bson.M{"kb": 1}},
+		}, `[{"_id": 1, "kb": 1}, {"_id": 2, "kb": 2}, {"_id": 3, "kb": 0.5}]`},
+		{"$set", []bson.M{
+			{"$match": bson.M{"_id": 3}},
+			{"$set": bson.M{"host.ip": "$src"}},
+			{"$project": bson.M{"host": 1}},
+		}, `[{"_id": 3, "host": {"ip": "b"}}]`},
+		{"$unset", []bson.M{
+			{"$match": bson.M{"_id": 1}},
+			{"$unset": []string{"tags", "bytes"}},
+		}, `[{"_id": 1, "src": "a"}]`},
+		{"$replaceRoot", []bson.M{
+			{"$match": bson.M{"_id": 1}},
+			{"$replaceRoot": bson.M{"newRoot": bson.M{"ip": "$src"}}},
+		}, `[{"ip": "a"}]`},
+		{"$unwind", []bson.M{
+			{"$unwind": "$tags"},
+			{"$project": bson.M{"tags": 1}},
+		}, `[{"_id": 1, "tags": "x"}, {"_id": 1, "tags": "y"}]`},
+		{"$unwind preserving empty arrays", []bson.M{
+			{"$unwind": bson.M{"path": "$tags", "preserveNullAndEmptyArrays": true}},
+			{"$project": bson.M{"tags": 1}},
+		}, `[{"_id": 1, "tags": "x"}, {"_id": 1, "tags": "y"}, {"_id": 2, "tags": []}, {"_id": 3}]`},
+		{"$group", []bson.M{
+			{"$group": bson.M{
+				"_id":   "$src",
+				"count": bson.M{"$sum": 1},
+				"total": bson.M{"$sum": "$bytes"},
+				"avg":   bson.M{"$avg": "$bytes"},
+				"first": bson.M{"$first": "$_id"},
+				"last":  bson.M{"$last": "$_id"},
+				"ids":   bson.M{"$push": "$_id"},
+				"tags":  bson.M{"$addToSet": "$tags"},
+				"max":   bson.M{"$max": "$bytes"},
+				"min":   bson.M{"$min": "$bytes"},
+			}},
+		}, `[
+			{"_id": "a", "count": 2, "total": 30, "avg": 15, "first": 1, "last": 2, "ids": [1, 2],
+			 "tags": [["x", "y"], []], "max": 20, "min": 10},
+			{"_id": "b", "count": 1, "total": 5, "avg": 5, "first": 3, "last": 3, "ids": [3],
+			 "tags": [], "max": 5, "min": 5}
+		]`},
+		{"$group null _id", []bson.M{
+			{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$bytes"}}},
+		}, `[{"_id": null, "total": 35}]`},
+		{"$sort", []bson.M{
+			{"$sort": bson.M{"bytes": -1}},
+			{"$project": bson.M{"_id": 1}},
+		}, `[{"_id": 2}, {"_id": 1}, {"_id": 3}]`},
+		{"$skip and $limit", []bson.M{
+			{"$sort": bson.M{"bytes": 1}},
+			{"$skip": 1},
+			{"$limit": 1},
+			{"$project": bson.M{"_id": 1}},
+		}, `[{"_id": 1}]`},
+		{"$count", []bson.M{
+			{"$match": bson.M{"src": "a"}},
+			{"$count": "n"},
+		}, `[{"n": 2}]`},
+		{"$count nothing", []bson.M{
+			{"$match": bson.M{"src": "c"}},
+			{"$count": "n"},
+		}, `[]`},
+		{"$lookup", []bson.M{
+			{"$match": bson.M{"_id": 3}},
+			{"$lookup": bson.M{"from": "hosts", "localField": "src", "foreignField": "_id", "as": "host"}},
+			{"$project": bson.M{"host.name": 1}},
+		}, `[{"_id": 3, "host": [{"name": "beta"}]}]`},
+		{"$lookup pipeline", []bson.M{
+			{"$match": bson.M{"_id": 1}},
+			{"$lookup": bson.M{
+				"from": "hosts",
+				"let":  bson.M{"ip": "$src"},
+				"pipeline": []bson.M{
+					{"$match": bson.M{"$expr": bson.M{"$eq": []string{"$_id", "$$ip"}}}},
+					{"$project": bson.M{"_id": 0, "name": 1}},
+				},
+				"as": "host",
+			}},
+			{"$project": bson.M{"host": 1}},
+		}, `[{"_id": 1, "host": [{"name": "alpha"}]}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := []bson.M{}
+			require.NoError(t, db.C("conns").Pipe(test.pipeline).All(&res))
+			requireJSON(t, test.want, res)
+		})
+	}
+
+	var res []bson.M
+	require.Error(t, db.C("conns").Pipe([]bson.M{{"$facet": bson.M{}}}).All(&res))
+	require.Error(t, db.C("conns").Pipe([]bson.M{{"$group": bson.M{"_id": "$src", "n": bson.M{"$stdDevPop": "$bytes"}}}}).All(&res))
+}
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSession(t *testing.T) (Session, string) {
+	dir, err := ioutil.TempDir("", "rita-embedded")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "rita.db")
+	session, err := NewEmbeddedSession(path, 0)
+	require.NoError(t, err)
+	return session, path
+}
+
+//requireJSON compares a result with the JSON it should encode to, which
+//ignores the integer and float types numbers were decoded into
+func requireJSON(t *testing.T, want string, got interface{}) {
+	encoded, err := json.Marshal(got)
+	require.NoError(t, err)
+	require.JSONEq(t, want, string(encoded))
+}
+
+func TestEmbeddedFindAndUpdate(t *testing.T) {
+	session, _ := newTestSession(t)
+	defer session.Close()
+	coll := session.DB("test").C("hosts")
+
+	require.NoError(t, coll.EnsureIndex(Index{Key: []string{"ip"}, Unique: true}))
+	require.NoError(t, coll.Insert(
+		bson.M{"ip": "10.0.0.1", "count": 1, "tags": []string{"a"}},
+		bson.M{"ip": "10.0.0.2", "count": 5},
+		bson.M{"ip": "10.0.0.3", "count": 3},
+	))
+
+	var res []struct {
+		IP    string `bson:"ip"`
+		Count int    `bson:"count"`
+	}
+	err := coll.Find(bson.M{"count": bson.M{"$gte": 3}}).Sort("-count").All(&res)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, "10.0.0.2", res[0].IP)
+	require.Equal(t, "10.0.0.3", res[1].IP)
+
+	n, err := coll.Find(bson.M{"ip": bson.M{"$in": []string{"10.0.0.1", "10.0.0.9"}}}).Count()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	err = coll.Update(
+		bson.M{"ip": "10.0.0.1"},
+		bson.M{"$inc": bson.M{"count": 2}, "$addToSet": bson.M{"tags": "b"}},
+	)
+	require.NoError(t, err)
+
+	var host struct {
+		Count int      `bson:"count"`
+		Tags  []string `bson:"tags"`
+	}
+	require.NoError(t, coll.Find(bson.M{"ip": "10.0.0.1"}).One(&host))
+	require.Equal(t, 3, host.Count)
+	require.Equal(t, []string{"a", "b"}, host.Tags)
+
+	// the unique index rejects a second host with the same address
+	require.Error(t, coll.Insert(bson.M{"ip": "10.0.0.1"}))
+
+	require.Equal(t, ErrNotFound, coll.Update(bson.M{"ip": "10.0.0.9"}, bson.M{"$set": bson.M{"count": 1}}))
+}
+
+func TestEmbeddedUpsertPositional(t *testing.T) {
+	session, _ := newTestSession(t)
+	defer session.Close()
+	coll := session.DB("test").C("uconn")
+
+	selector := bson.M{"src": "10.0.0.1", "dst": "10.0.0.2"}
+	_, err := coll.Upsert(selector, bson.M{
+		"$push": bson.M{"dat": bson.M{"count": 1, "cid": 0}},
+	})
+	require.NoError(t, err)
+
+	// a later upsert in the same chunk updates the matching array entry
+	_, err = coll.Upsert(
+		bson.M{"src": "10.0.0.1", "dst": "10.0.0.2", "dat.cid": 0},
+		bson.M{"$inc": bson.M{"dat.$.count": 4}},
+	)
+	require.NoError(t, err)
+
+	var conn struct {
+		Src string `bson:"src"`
+		Dat []struct {
+			Count int `bson:"count"`
+			CID   int `bson:"cid"`
+		} `bson:"dat"`
+	}
+	require.NoError(t, coll.Find(selector).One(&conn))
+	require.Equal(t, "10.0.0.1", conn.Src)
+	require.Len(t, conn.Dat, 1)
+	require.Equal(t, 5, conn.Dat[0].Count)
+}
+
+func TestEmbeddedPipeline(t *testing.T) {
+	session, _ := newTestSession(t)
+	defer session.Close()
+	db := session.DB("test")
+
+	require.NoError(t, db.C("conns").Insert(
+		bson.M{"src": "10.0.0.1", "bytes": []int{10, 20}},
+		bson.M{"src": "10.0.0.1", "bytes": []int{5}},
+		bson.M{"src": "10.0.0.2", "bytes": []int{1}},
+	))
+	require.NoError(t, db.C("hosts").Insert(
+		bson.M{"ip": "10.0.0.1", "name": "alpha"},
+		bson.M{"ip": "10.0.0.2", "name": "beta"},
+	))
+
+	var res []struct {
+		Src   string `bson:"_id"`
+		Total int    `bson:"total"`
+		Host  []struct {
+			Name string `bson:"name"`
+		} `bson:"host"`
+	}
+	err := db.C("conns").Pipe([]bson.M{
+		{"$unwind": "$bytes"},
+		{"$group": bson.M{"_id": "$src", "total": bson.M{"$sum": "$bytes"}}},
+		{"$lookup": bson.M{
+			"from":         "hosts",
+			"localField":   "_id",
+			"foreignField": "ip",
+			"as":           "host",
+		}},
+		{"$sort": bson.M{"total": -1}},
+	}).All(&res)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, "10.0.0.1", res[0].Src)
+	require.Equal(t, 35, res[0].Total)
+	require.Equal(t, "alpha", res[0].Host[0].Name)
+	require.Equal(t, 1, res[1].Total)
+}
+
+func TestEmbeddedPersistence(t *testing.T) {
+	session, path := newTestSession(t)
+	require.NoError(t, session.DB("test").C("hosts").Insert(bson.M{"ip": "10.0.0.1"}))
+	session.Close()
+
+	session, err := NewEmbeddedSession(path, 0)
+	require.NoError(t, err)
+	defer session.Close()
+
+	names, err := session.DatabaseNames()
+	require.NoError(t, err)
+	require.Equal(t, []string{"test"}, names)
+
+	n, err := session.DB("test").C("hosts").Find(bson.M{"ip": "10.0.0.1"}).Count()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestEmbeddedCollectionLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rita-embedded")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rita.db")
+
+	session, err := NewEmbeddedSession(path, 1)
+	require.NoError(t, err)
+	db := session.DB("test")
+
+	// 300 KB documents, so the fourth pushes the collection past 1 MB
+	padding := strings.Repeat("x", 300<<10)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, db.C("uconn").Insert(bson.M{"i": i, "padding": padding}))
+	}
+	require.NoError(t, db.C("host").Insert(bson.M{"ip": "10.0.0.1"}))
+
+	// changes are written out, but the collection is reported as too large
+	engine := session.(*embeddedSession).engine
+	engine.lock.Lock()
+	err = engine.flush()
+	engine.lock.Unlock()
+	require.True(t, errors.Is(err, ErrCollectionTooLarge))
+	session.Close()
+
+	session, err = NewEmbeddedSession(path, 1)
+	require.NoError(t, err)
+	defer session.Close()
+	db = session.DB("test")
+
+	// the collection is refused rather than loaded into memory
+	_, err = db.C("uconn").Find(nil).Count()
+	require.True(t, errors.Is(err, ErrCollectionTooLarge))
+	require.Contains(t, err.Error(), "test.uconn")
+
+	n, err := db.C("host").Find(nil).Count()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	// but it may still be dropped
+	require.NoError(t, db.C("uconn").DropCollection())
+	names, err := db.CollectionNames()
+	require.NoError(t, err)
+	require.Equal(t, []string{"host"}, names)
+}
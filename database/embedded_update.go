@@ -0,0 +1,321 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+//isReplacement reports whether an update replaces the whole document
+//rather than applying update operators
+func isReplacement(update bson.D) bool {
+	return len(update) == 0 || !strings.HasPrefix(update[0].Name, "$")
+}
+
+//applyUpdate returns a modified copy of a document. inserting is set when
+//the document is being created by an upsert so $setOnInsert is applied.
+func applyUpdate(doc bson.D, update bson.D, inserting bool) (bson.D, error) {
+	id, hasID := getField(doc, "_id")
+
+	if isReplacement(update) {
+		replaced := copyValue(removeField(copyValue(update).(bson.D), "_id")).(bson.D)
+		if hasID {
+			replaced = append(bson.D{{Name: "_id", Value: id}}, replaced...)
+		}
+		return replaced, nil
+	}
+
+	updated := copyValue(doc).(bson.D)
+	for _, op := range update {
+		fields, ok := op.Value.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("%s requires a document", op.Name)
+		}
+		if op.Name == "$setOnInsert" {
+			if !inserting {
+				continue
+			}
+			op.Name = "$set"
+		}
+		for _, field := range fields {
+			var err error
+			updated, err = applyOperator(updated, op.Name, splitPath(field.Name), copyValue(field.Value))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return updated, nil
+}
+
+//applyOperator applies a single update operator to a single field
+func applyOperator(doc bson.D, op string, path []string, arg interface{}) (bson.D, error) {
+	current, exists := getPath(doc, path)
+
+	switch op {
+	case "$set":
+		return setPath(doc, path, arg)
+
+	case "$unset":
+		return unsetPath(doc, path), nil
+
+	case "$inc":
+		if _, ok := toFloat(arg); !ok {
+			return nil, fmt.Errorf("$inc requires a number")
+		}
+		if !exists || current == nil {
+			return setPath(doc, path, arg)
+		}
+		if _, ok := toFloat(current); !ok {
+			return nil, fmt.Errorf("cannot $inc a %T", current)
+		}
+		return setPath(doc, path, addNumbers(current, arg))
+
+	case "$max", "$min":
+		if exists {
+			c := compareValues(arg, current)
+			if (op == "$max" && c <= 0) || (op == "$min" && c >= 0) {
+				return doc, nil
+			}
+		}
+		return setPath(doc, path, arg)
+
+	case "$push", "$addToSet":
+		var array []interface{}
+		if exists && current != nil {
+			var ok bool
+			array, ok = current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot %s to a %T", op, current)
+			}
+		}
+
+		items := []interface{}{arg}
+		slice, hasSlice := 0, false
+		if modifiers, ok := arg.(bson.D); ok && isOperatorDoc(modifiers) {
+			items = nil
+			for _, modifier := range modifiers {
+				switch modifier.Name {
+				case "$each":
+					each, ok := modifier.Value.([]interface{})
+					if !ok {
+						return nil, fmt.Errorf("$each requires an array")
+					}
+					items = each
+				case "$slice":
+					if n, ok := toFloat(modifier.Value); ok {
+						slice, hasSlice = int(n), true
+					}
+				default:
+					return nil, fmt.Errorf("unsupported %s modifier %s", op, modifier.Name)
+				}
+			}
+		}
+
+		for _, item := range items {
+			if op == "$addToSet" && containsValue(array, item) {
+				continue
+			}
+			array = append(array, item)
+		}
+
+		if hasSlice {
+			if slice >= 0 && slice < len(array) {
+				array = array[:slice]
+			} else if slice < 0 && -slice < len(array) {
+				array = array[len(array)+slice:]
+			}
+		}
+		if array == nil {
+			array = []interface{}{}
+		}
+		return setPath(doc, path, array)
+
+	case "$pull":
+		array, ok := current.([]interface{})
+		if !ok {
+			return doc, nil
+		}
+		kept := make([]interface{}, 0, len(array))
+		for _, elem := range array {
+			var matched bool
+			if cond, ok := arg.(bson.D); ok {
+				var err error
+				matched, err = matchElement(elem, cond)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				matched = valuesEqual(elem, arg)
+			}
+			if !matched {
+				kept = append(kept, elem)
+			}
+		}
+		return setPath(doc, path, kept)
+	}
+
+	return nil, fmt.Errorf("unsupported update operator %s", op)
+}
+
+//containsValue reports whether an array holds a value
+func containsValue(array []interface{}, v interface{}) bool {
+	for _, elem := range array {
+		if valuesEqual(elem, v) {
+			return true
+		}
+	}
+	return false
+}
+
+//upsertDoc builds the document inserted by an upsert which matched nothing
+func upsertDoc(selector bson.D, update bson.D) (bson.D, error) {
+	doc := bson.D{}
+	if !isReplacement(update) {
+		for _, cond := range equalityConditions(selector) {
+			var err error
+			doc, err = setPath(doc, splitPath(cond.Name), copyValue(cond.Value))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if isReplacement(update) {
+		// a replacement keeps its own _id or the _id the selector asks for
+		id, ok := getField(update, "_id")
+		if !ok {
+			id, ok = getField(equalityConditions(selector), "_id")
+		}
+		if ok {
+			doc = setField(doc, "_id", id)
+		}
+	}
+	if _, ok := getField(doc, "_id"); !ok {
+		doc = append(bson.D{{Name: "_id", Value: bson.NewObjectId()}}, doc...)
+	}
+	resolved, err := resolvePositional(doc, selector, update)
+	if err != nil {
+		return nil, err
+	}
+	return applyUpdate(doc, resolved, true)
+}
+
+//resolvePositional replaces the positional $ operator in the field paths of
+//an update with the index of the array element the filter matched
+func resolvePositional(doc bson.D, filter bson.D, update bson.D) (bson.D, error) {
+	if isReplacement(update) {
+		return update, nil
+	}
+	var resolved bson.D
+	for _, op := range update {
+		fields, ok := op.Value.(bson.D)
+		if !ok {
+			resolved = append(resolved, op)
+			continue
+		}
+		var resolvedFields bson.D
+		for _, field := range fields {
+			path := splitPath(field.Name)
+			for i, part := range path {
+				if part != "$" {
+					continue
+				}
+				arrayPath := strings.Join(path[:i], ".")
+				idx, ok, err := positionalIndex(doc, filter, arrayPath)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					return nil, fmt.Errorf("the positional operator did not find the match needed from the query")
+				}
+				path[i] = strconv.Itoa(idx)
+			}
+			resolvedFields = append(resolvedFields, bson.DocElem{Name: strings.Join(path, "."), Value: field.Value})
+		}
+		resolved = append(resolved, bson.DocElem{Name: op.Name, Value: resolvedFields})
+	}
+	return resolved, nil
+}
+
+//positionalIndex finds the first element of the array at arrayPath which
+//satisfies every condition the filter places on the array's elements
+func positionalIndex(doc bson.D, filter bson.D, arrayPath string) (int, bool, error) {
+	value, _ := getPath(doc, splitPath(arrayPath))
+	array, ok := value.([]interface{})
+	if !ok {
+		return 0, false, nil
+	}
+
+	var conds bson.D
+	var gather func(filter bson.D)
+	gather = func(filter bson.D) {
+		for _, elem := range filter {
+			if elem.Name == "$and" {
+				clauses, _ := elem.Value.([]interface{})
+				for _, clause := range clauses {
+					if sub, ok := clause.(bson.D); ok {
+						gather(sub)
+					}
+				}
+			} else if elem.Name == arrayPath || strings.HasPrefix(elem.Name, arrayPath+".") {
+				conds = append(conds, elem)
+			}
+		}
+	}
+	gather(filter)
+	if len(conds) == 0 {
+		return 0, false, nil
+	}
+
+	for i, elem := range array {
+		matched := true
+		for _, cond := range conds {
+			var ok bool
+			var err error
+			if cond.Name == arrayPath {
+				ok, err = matchArrayElement(elem, cond.Value)
+			} else if elemDoc, isDoc := elem.(bson.D); isDoc {
+				ok, err = matchField(elemDoc, cond.Name[len(arrayPath)+1:], cond.Value)
+			}
+			if err != nil {
+				return 0, false, err
+			}
+			if !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+//matchArrayElement checks a condition placed on an array field against one
+//of its elements
+func matchArrayElement(elem interface{}, cond interface{}) (bool, error) {
+	if !isOperatorDoc(cond) {
+		return valuesEqual(elem, cond), nil
+	}
+	ops := cond.(bson.D)
+	for _, op := range ops {
+		var ok bool
+		var err error
+		if op.Name == "$elemMatch" {
+			sub, isDoc := op.Value.(bson.D)
+			if !isDoc {
+				return false, fmt.Errorf("$elemMatch requires a document")
+			}
+			ok, err = matchElement(elem, sub)
+		} else {
+			ok, err = matchOperator([]interface{}{elem}, op.Name, op.Value)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
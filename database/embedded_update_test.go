@@ -0,0 +1,116 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedUpdateOperators(t *testing.T) {
+	session, _ := newTestSession(t)
+	defer session.Close()
+	coll := session.DB("test").C("uconn")
+
+	tests := []struct {
+		name     string
+		selector bson.M // narrows the selector for positional updates
+		update   bson.M
+		want     string
+	}{
+		{"$set", nil, bson.M{"$set": bson.M{"n": 7, "a.b": "x"}},
+			`{"n": 7, "a": {"b": "x"}, "tags": ["a"], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"$unset", nil, bson.M{"$unset": bson.M{"tags": "", "missing": ""}},
+			`{"n": 5, "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"$inc", nil, bson.M{"$inc": bson.M{"n": 2, "new": 1.5}},
+			`{"n": 7, "new": 1.5, "tags": ["a"], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"$max", nil, bson.M{"$max": bson.M{"n": 9, "new": 1}},
+			`{"n": 9, "new": 1, "tags": ["a"], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"$max keeps larger", nil, bson.M{"$max": bson.M{"n": 1}},
+			`{"n": 5, "tags": ["a"], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"$min", nil, bson.M{"$min": bson.M{"n": 1}},
+			`{"n": 1, "tags": ["a"], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"$push", nil, bson.M{"$push": bson.M{"tags": "a", "new": 1}},
+			`{"n": 5, "new": [1], "tags": ["a", "a"], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"$push $each $slice", nil, bson.M{"$push": bson.M{"tags": bson.M{"$each": []string{"b", "c"}, "$slice": -2}}},
+			`{"n": 5, "tags": ["b", "c"], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"$addToSet", nil, bson.M{"$addToSet": bson.M{"tags": "a"}},
+			`{"n": 5, "tags": ["a"], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"$addToSet $each", nil, bson.M{"$addToSet": bson.M{"tags": bson.M{"$each": []string{"a", "b", "b"}}}},
+			`{"n": 5, "tags": ["a", "b"], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"$pull value", nil, bson.M{"$pull": bson.M{"tags": "a"}},
+			`{"n": 5, "tags": [], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"$pull condition", nil, bson.M{"$pull": bson.M{"dat": bson.M{"cid": 0}}},
+			`{"n": 5, "tags": ["a"], "dat": [{"cid": 1, "c": 2}]}`},
+		{"$pull operator", nil, bson.M{"$pull": bson.M{"dat": bson.M{"c": bson.M{"$gte": 1}}}},
+			`{"n": 5, "tags": ["a"], "dat": []}`},
+		{"positional", bson.M{"dat.cid": 1}, bson.M{"$inc": bson.M{"dat.$.c": 5}, "$set": bson.M{"dat.$.seen": true}},
+			`{"n": 5, "tags": ["a"], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 7, "seen": true}]}`},
+		{"array index", nil, bson.M{"$set": bson.M{"dat.0.c": 4}},
+			`{"n": 5, "tags": ["a"], "dat": [{"cid": 0, "c": 4}, {"cid": 1, "c": 2}]}`},
+		{"$setOnInsert is skipped for updates", nil, bson.M{"$setOnInsert": bson.M{"n": 1}},
+			`{"n": 5, "tags": ["a"], "dat": [{"cid": 0, "c": 1}, {"cid": 1, "c": 2}]}`},
+		{"replacement", nil, bson.M{"n": 1},
+			`{"n": 1}`},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.NoError(t, coll.Insert(bson.M{
+				"_id":  i,
+				"n":    5,
+				"tags": []string{"a"},
+				"dat":  []bson.M{{"cid": 0, "c": 1}, {"cid": 1, "c": 2}},
+			}))
+
+			selector := bson.M{"_id": i}
+			for field, value := range test.selector {
+				selector[field] = value
+			}
+			require.NoError(t, coll.Update(selector, test.update))
+
+			var doc bson.M
+			require.NoError(t, coll.Find(bson.M{"_id": i}).Select(bson.M{"_id": 0}).One(&doc))
+			requireJSON(t, test.want, doc)
+		})
+	}
+
+	_, err := coll.UpdateAll(nil, bson.M{"$rename": bson.M{"n": "m"}})
+	require.Error(t, err)
+}
+
+func TestEmbeddedUpsertOperators(t *testing.T) {
+	session, _ := newTestSession(t)
+	defer session.Close()
+	coll := session.DB("test").C("host")
+
+	update := bson.M{
+		"$set":         bson.M{"seen": true},
+		"$setOnInsert": bson.M{"first": 1},
+		"$push":        bson.M{"dat": bson.M{"cid": 0}},
+	}
+
+	// a new document starts from the equality conditions of the selector
+	info, err := coll.Upsert(bson.M{"ip": "10.0.0.1", "uuid": bson.M{"$eq": "u"}, "n": bson.M{"$gt": 1}}, update)
+	require.NoError(t, err)
+	require.NotNil(t, info.UpsertedId)
+
+	var doc bson.M
+	require.NoError(t, coll.Find(bson.M{"ip": "10.0.0.1"}).Select(bson.M{"_id": 0}).One(&doc))
+	requireJSON(t, `{"ip": "10.0.0.1", "uuid": "u", "seen": true, "first": 1, "dat": [{"cid": 0}]}`, doc)
+
+	// $setOnInsert only applies when the document is created
+	update["$setOnInsert"] = bson.M{"first": 2}
+	info, err = coll.Upsert(bson.M{"ip": "10.0.0.1"}, update)
+	require.NoError(t, err)
+	require.Equal(t, 1, info.Updated)
+
+	require.NoError(t, coll.Find(bson.M{"ip": "10.0.0.1"}).Select(bson.M{"_id": 0}).One(&doc))
+	requireJSON(t, `{"ip": "10.0.0.1", "uuid": "u", "seen": true, "first": 1, "dat": [{"cid": 0}, {"cid": 0}]}`, doc)
+
+	// a replacement upsert keeps the selector's _id
+	_, err = coll.Upsert(bson.M{"_id": "h2"}, bson.M{"ip": "10.0.0.2"})
+	require.NoError(t, err)
+	require.NoError(t, coll.Find(bson.M{"_id": "h2"}).One(&doc))
+	requireJSON(t, `{"_id": "h2", "ip": "10.0.0.2"}`, doc)
+}
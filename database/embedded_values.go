@@ -0,0 +1,593 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+//toDoc converts a struct, map, or document into an ordered document
+//by passing it through BSON. Nested documents are also ordered documents.
+func toDoc(v interface{}) (bson.D, error) {
+	if v == nil {
+		return bson.D{}, nil
+	}
+	if doc, ok := v.(bson.D); ok && isNormalized(doc) {
+		return doc, nil
+	}
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	err = bson.Unmarshal(raw, &doc)
+	return doc, err
+}
+
+//toValue converts any value into the representation used by toDoc
+func toValue(v interface{}) (interface{}, error) {
+	doc, err := toDoc(bson.M{"v": v})
+	if err != nil {
+		return nil, err
+	}
+	return doc[0].Value, nil
+}
+
+//toArray converts a list such as a pipeline into a slice of values
+func toArray(v interface{}) ([]interface{}, error) {
+	value, err := toValue(v)
+	if err != nil {
+		return nil, err
+	}
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array, got %T", v)
+	}
+	return array, nil
+}
+
+//isNormalized reports whether a document only holds the types produced
+//by toDoc, in which case it doesn't need to be converted
+func isNormalized(doc bson.D) bool {
+	for _, elem := range doc {
+		if !isNormalizedValue(elem.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNormalizedValue(v interface{}) bool {
+	switch value := v.(type) {
+	case nil, string, int, int64, float64, bool, bson.ObjectId, time.Time, []byte:
+		return true
+	case bson.D:
+		return isNormalized(value)
+	case []interface{}:
+		for _, elem := range value {
+			if !isNormalizedValue(elem) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+//decodeDoc unmarshals a document into a result in the same way
+//mgo unmarshals documents received from MongoDB
+func decodeDoc(doc bson.D, result interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, result)
+}
+
+//decodeDocs unmarshals every document into a pointer to a slice
+func decodeDocs(docs []bson.D, result interface{}) error {
+	resultv := reflect.ValueOf(result)
+	if resultv.Kind() != reflect.Ptr || resultv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("result argument must be a slice address")
+	}
+	slicev := resultv.Elem()
+	slicev = slicev.Slice(0, 0)
+	elemt := slicev.Type().Elem()
+	for _, doc := range docs {
+		elemp := reflect.New(elemt)
+		if err := decodeDoc(doc, elemp.Interface()); err != nil {
+			return err
+		}
+		slicev = reflect.Append(slicev, elemp.Elem())
+	}
+	resultv.Elem().Set(slicev)
+	return nil
+}
+
+//getField returns the value of a top level field
+func getField(doc bson.D, key string) (interface{}, bool) {
+	for _, elem := range doc {
+		if elem.Name == key {
+			return elem.Value, true
+		}
+	}
+	return nil, false
+}
+
+//setField sets a top level field, appending it if it doesn't exist.
+//The document is modified in place.
+func setField(doc bson.D, key string, value interface{}) bson.D {
+	for i := range doc {
+		if doc[i].Name == key {
+			doc[i].Value = value
+			return doc
+		}
+	}
+	return append(doc, bson.DocElem{Name: key, Value: value})
+}
+
+//removeField removes a top level field
+func removeField(doc bson.D, key string) bson.D {
+	for i := range doc {
+		if doc[i].Name == key {
+			return append(doc[:i:i], doc[i+1:]...)
+		}
+	}
+	return doc
+}
+
+//lookupPath finds the values at a dotted path. Arrays along the path are
+//traversed, so a path may resolve to several values. Arrays at the end of
+//the path are returned as is.
+func lookupPath(v interface{}, path []string) []interface{} {
+	if len(path) == 0 {
+		return []interface{}{v}
+	}
+	switch value := v.(type) {
+	case bson.D:
+		field, ok := getField(value, path[0])
+		if !ok {
+			return nil
+		}
+		return lookupPath(field, path[1:])
+	case []interface{}:
+		// numeric path components index into arrays
+		if idx, err := strconv.Atoi(path[0]); err == nil {
+			if idx >= 0 && idx < len(value) {
+				return lookupPath(value[idx], path[1:])
+			}
+			return nil
+		}
+		var values []interface{}
+		for _, elem := range value {
+			if _, ok := elem.(bson.D); ok {
+				values = append(values, lookupPath(elem, path)...)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+//expandArrays returns the given values along with the elements of any arrays
+//among them, which are the values a query condition is compared against
+func expandArrays(values []interface{}) []interface{} {
+	var expanded []interface{}
+	for _, v := range values {
+		expanded = append(expanded, v)
+		if array, ok := v.([]interface{}); ok {
+			expanded = append(expanded, array...)
+		}
+	}
+	return expanded
+}
+
+//splitPath splits a dotted path into its components
+func splitPath(path string) []string {
+	return strings.Split(path, ".")
+}
+
+//setPath returns a copy of a document with the value at a dotted path set,
+//creating documents along the way. Only the documents and arrays along the
+//path are copied, so documents may be shared between results.
+func setPath(doc bson.D, path []string, value interface{}) (bson.D, error) {
+	doc = append(bson.D(nil), doc...)
+	if len(path) == 1 {
+		return setField(doc, path[0], value), nil
+	}
+	child, ok := getField(doc, path[0])
+	switch childv := child.(type) {
+	case bson.D:
+		updated, err := setPath(childv, path[1:], value)
+		if err != nil {
+			return doc, err
+		}
+		return setField(doc, path[0], updated), nil
+	case []interface{}:
+		idx, err := strconv.Atoi(path[1])
+		if err != nil || idx < 0 {
+			return doc, fmt.Errorf("cannot set %s in an array", strings.Join(path, "."))
+		}
+		childv = append([]interface{}(nil), childv...)
+		for len(childv) <= idx {
+			childv = append(childv, nil)
+		}
+		if len(path) == 2 {
+			childv[idx] = value
+		} else {
+			elem, _ := childv[idx].(bson.D)
+			updated, err := setPath(elem, path[2:], value)
+			if err != nil {
+				return doc, err
+			}
+			childv[idx] = updated
+		}
+		return setField(doc, path[0], childv), nil
+	default:
+		if ok && child != nil {
+			return doc, fmt.Errorf("cannot set %s in a %T", strings.Join(path, "."), child)
+		}
+		updated, err := setPath(bson.D{}, path[1:], value)
+		if err != nil {
+			return doc, err
+		}
+		return setField(doc, path[0], updated), nil
+	}
+}
+
+//unsetPath returns a copy of a document without the value at a dotted path.
+//Arrays along the path have the value removed from each of their documents.
+func unsetPath(doc bson.D, path []string) bson.D {
+	if len(path) == 1 {
+		return removeField(doc, path[0])
+	}
+	field, ok := getField(doc, path[0])
+	if !ok {
+		return doc
+	}
+	switch child := field.(type) {
+	case bson.D:
+		return setField(append(bson.D(nil), doc...), path[0], unsetPath(child, path[1:]))
+	case []interface{}:
+		array := make([]interface{}, len(child))
+		for i, elem := range child {
+			if elemDoc, ok := elem.(bson.D); ok {
+				array[i] = unsetPath(elemDoc, path[1:])
+			} else {
+				array[i] = elem
+			}
+		}
+		return setField(append(bson.D(nil), doc...), path[0], array)
+	}
+	return doc
+}
+
+//getPath returns the single value at a dotted path without traversing arrays
+func getPath(doc bson.D, path []string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, part := range path {
+		switch value := current.(type) {
+		case bson.D:
+			field, ok := getField(value, part)
+			if !ok {
+				return nil, false
+			}
+			current = field
+		case []interface{}:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(value) {
+				return nil, false
+			}
+			current = value[idx]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+//copyValue deep copies documents and arrays so they can be modified
+func copyValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case bson.D:
+		doc := make(bson.D, len(value))
+		for i, elem := range value {
+			doc[i] = bson.DocElem{Name: elem.Name, Value: copyValue(elem.Value)}
+		}
+		return doc
+	case []interface{}:
+		array := make([]interface{}, len(value))
+		for i, elem := range value {
+			array[i] = copyValue(elem)
+		}
+		return array
+	}
+	return v
+}
+
+//toFloat converts a number to a float64
+func toFloat(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case int:
+		return float64(value), true
+	case int32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	}
+	return 0, false
+}
+
+//isInteger reports whether a value is an integer type
+func isInteger(v interface{}) bool {
+	switch v.(type) {
+	case int, int32, int64:
+		return true
+	}
+	return false
+}
+
+//toInt64 converts an integer type to an int64
+func toInt64(v interface{}) int64 {
+	switch value := v.(type) {
+	case int:
+		return int64(value)
+	case int32:
+		return int64(value)
+	case int64:
+		return value
+	}
+	f, _ := toFloat(v)
+	return int64(f)
+}
+
+//addNumbers adds two numbers, keeping integers as integers
+func addNumbers(a, b interface{}) interface{} {
+	if isInteger(a) && isInteger(b) {
+		return toInt64(a) + toInt64(b)
+	}
+	fa, _ := toFloat(a)
+	fb, _ := toFloat(b)
+	return fa + fb
+}
+
+//typeOrder ranks values by type in the order MongoDB sorts them
+func typeOrder(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 1
+	case int, int32, int64, float32, float64:
+		return 2
+	case string:
+		return 3
+	case bson.D:
+		return 4
+	case []interface{}:
+		return 5
+	case []byte:
+		return 6
+	case bson.ObjectId:
+		return 7
+	case bool:
+		return 8
+	case time.Time:
+		return 9
+	}
+	return 10
+}
+
+//compareValues orders two values in the order MongoDB sorts them
+func compareValues(a, b interface{}) int {
+	ta, tb := typeOrder(a), typeOrder(b)
+	if ta != tb {
+		if ta < tb {
+			return -1
+		}
+		return 1
+	}
+	switch av := a.(type) {
+	case nil:
+		return 0
+	case string:
+		return strings.Compare(av, b.(string))
+	case bson.ObjectId:
+		return strings.Compare(string(av), string(b.(bson.ObjectId)))
+	case []byte:
+		return bytes.Compare(av, b.([]byte))
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		}
+		if !av {
+			return -1
+		}
+		return 1
+	case time.Time:
+		bv := b.(time.Time)
+		if av.Before(bv) {
+			return -1
+		}
+		if av.After(bv) {
+			return 1
+		}
+		return 0
+	case bson.D:
+		bv := b.(bson.D)
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			if c := strings.Compare(av[i].Name, bv[i].Name); c != 0 {
+				return c
+			}
+			if c := compareValues(av[i].Value, bv[i].Value); c != 0 {
+				return c
+			}
+		}
+		return compareInts(len(av), len(bv))
+	case []interface{}:
+		bv := b.([]interface{})
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			if c := compareValues(av[i], bv[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInts(len(av), len(bv))
+	}
+	if af, ok := toFloat(a); ok {
+		bf, _ := toFloat(b)
+		if af < bf {
+			return -1
+		}
+		if af > bf {
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+//valuesEqual reports whether two values are equal
+func valuesEqual(a, b interface{}) bool {
+	return typeOrder(a) == typeOrder(b) && compareValues(a, b) == 0
+}
+
+//valueKey builds a string which is identical for equal values so values
+//can be grouped and indexed
+func valueKey(v interface{}) string {
+	var sb strings.Builder
+	writeValueKey(&sb, v)
+	return sb.String()
+}
+
+func writeValueKey(sb *strings.Builder, v interface{}) {
+	switch value := v.(type) {
+	case nil:
+		sb.WriteString("n")
+	case string:
+		sb.WriteString("s")
+		sb.WriteString(strconv.Quote(value))
+	case bool:
+		if value {
+			sb.WriteString("bt")
+		} else {
+			sb.WriteString("bf")
+		}
+	case bson.ObjectId:
+		sb.WriteString("o")
+		sb.WriteString(value.Hex())
+	case time.Time:
+		sb.WriteString("t")
+		sb.WriteString(strconv.FormatInt(value.UnixNano(), 10))
+	case []byte:
+		sb.WriteString("x")
+		sb.WriteString(strconv.Quote(string(value)))
+	case bson.D:
+		sb.WriteString("{")
+		for _, elem := range value {
+			sb.WriteString(strconv.Quote(elem.Name))
+			sb.WriteString(":")
+			writeValueKey(sb, elem.Value)
+			sb.WriteString(",")
+		}
+		sb.WriteString("}")
+	case []interface{}:
+		sb.WriteString("[")
+		for _, elem := range value {
+			writeValueKey(sb, elem)
+			sb.WriteString(",")
+		}
+		sb.WriteString("]")
+	default:
+		if f, ok := toFloat(v); ok {
+			sb.WriteString("f")
+			if math.Trunc(f) == f && math.Abs(f) < 1e18 {
+				sb.WriteString(strconv.FormatInt(int64(f), 10))
+			} else {
+				sb.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+			}
+			return
+		}
+		sb.WriteString("?")
+		sb.WriteString(fmt.Sprint(v))
+	}
+}
+
+//isTruthy reports whether an aggregation expression result counts as true
+func isTruthy(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+	return true
+}
+
+//isOperatorDoc reports whether a value is a document of operators
+//such as {"$gt": 5}
+func isOperatorDoc(v interface{}) bool {
+	doc, ok := v.(bson.D)
+	return ok && len(doc) > 0 && strings.HasPrefix(doc[0].Name, "$")
+}
+
+//sortDocs sorts documents by the given fields. Fields starting with "-"
+//are sorted in descending order.
+func sortDocs(docs []bson.D, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	type sortKey struct {
+		path       []string
+		descending bool
+	}
+	keys := make([]sortKey, 0, len(fields))
+	for _, field := range fields {
+		key := sortKey{}
+		if strings.HasPrefix(field, "-") {
+			key.descending = true
+			field = field[1:]
+		} else if strings.HasPrefix(field, "+") {
+			field = field[1:]
+		}
+		key.path = splitPath(field)
+		keys = append(keys, key)
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range keys {
+			a, _ := getPath(docs[i], key.path)
+			b, _ := getPath(docs[j], key.path)
+			c := compareValues(a, b)
+			if c == 0 {
+				continue
+			}
+			if key.descending {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"errors"
 	"strconv"
 	"sync"
 	"time"
@@ -8,7 +9,6 @@ import (
 	"github.com/activecm/rita/config"
 	fpt "github.com/activecm/rita/parser/fileparsetypes"
 	"github.com/blang/semver"
-	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 	log "github.com/sirupsen/logrus"
 )
@@ -19,7 +19,7 @@ type (
 	MetaDB struct {
 		lock     *sync.Mutex    // Read and write lock
 		config   *config.Config // configuration info
-		dbHandle *mgo.Session   // Database handle
+		dbHandle Session        // Database handle
 		log      *log.Logger    // Logging object
 	}
 
@@ -48,10 +48,24 @@ type (
 		CurrentChunk   int           `bson:"current_chunk"`
 		TsRange        Range         `bson:"ts_range"`
 	}
+
+	// Suppression hides findings which match every one of its criteria
+	// until it expires
+	Suppression struct {
+		ID        bson.ObjectId `bson:"_id,omitempty"`
+		SrcCIDR   string        `bson:"src_cidr,omitempty"`  // Source IP range
+		DstCIDR   string        `bson:"dst_cidr,omitempty"`  // Destination IP range
+		FQDN      string        `bson:"fqdn,omitempty"`      // Domain, including its subdomains
+		UserAgent string        `bson:"useragent,omitempty"` // Exact user agent
+		JA3       string        `bson:"ja3,omitempty"`       // JA3 hash
+		Note      string        `bson:"note"`                // Analyst justification
+		Created   time.Time     `bson:"created"`
+		Expires   time.Time     `bson:"expires"`
+	}
 )
 
 // NewMetaDB instantiates a new handle for the RITA MetaDatabase
-func NewMetaDB(config *config.Config, dbHandle *mgo.Session,
+func NewMetaDB(config *config.Config, dbHandle Session,
 	log *log.Logger) *MetaDB {
 	metaDB := &MetaDB{
 		lock:     new(sync.Mutex),
@@ -67,11 +81,11 @@ func NewMetaDB(config *config.Config, dbHandle *mgo.Session,
 func (m *MetaDB) GetRollingSettings(db string) (exists bool, isRolling bool, currChunk int, totalChunks int, err error) {
 	// pull down dataset record from metadatabase
 	result, err := m.GetDBMetaInfo(db)
-	if err != nil && err != mgo.ErrNotFound {
+	if err != nil && err != ErrNotFound {
 		return
 	}
 
-	if err != mgo.ErrNotFound {
+	if err != ErrNotFound {
 		exists = true
 	}
 	err = nil
@@ -199,10 +213,10 @@ func (m *MetaDB) AddNewDB(name string, currentChunk, totalChunks int) error {
 //DBExists returns whether or not a metadatabase record has been created for a database
 func (m *MetaDB) DBExists(name string) (bool, error) {
 	_, err := m.GetDBMetaInfo(name)
-	if err != nil && err != mgo.ErrNotFound {
+	if err != nil && err != ErrNotFound {
 		return false, err
 	}
-	if err == mgo.ErrNotFound {
+	if err == ErrNotFound {
 		return false, nil
 	}
 	return true, nil
@@ -455,7 +469,7 @@ func (m *MetaDB) GetDBMetaInfo(name string) (DBMetaInfo, error) {
 		return DBMetaInfo{}, err
 	}
 	if len(results) == 0 {
-		return DBMetaInfo{}, mgo.ErrNotFound
+		return DBMetaInfo{}, ErrNotFound
 	}
 	return results[0], nil
 }
@@ -594,3 +608,60 @@ func (m *MetaDB) RemoveFilesByChunk(database string, cid int) error {
 	}
 	return nil
 }
+
+///////////////////////////////////////////////////////////////////////////////
+//                              Suppressions                                 //
+///////////////////////////////////////////////////////////////////////////////
+
+//AddSuppression stores a new suppression and returns its ID
+func (m *MetaDB) AddSuppression(suppression Suppression) (bson.ObjectId, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	ssn := m.dbHandle.Copy()
+	defer ssn.Close()
+
+	suppression.ID = bson.NewObjectId()
+	err := ssn.DB(m.config.S.MongoDB.MetaDB).C(m.config.T.Meta.SuppressionsTable).Insert(suppression)
+	if err != nil {
+		m.log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("could not insert suppression into meta database")
+		return "", err
+	}
+	return suppression.ID, nil
+}
+
+//GetSuppressions returns every suppression, including expired suppressions,
+//in the order they were created
+func (m *MetaDB) GetSuppressions() ([]Suppression, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	ssn := m.dbHandle.Copy()
+	defer ssn.Close()
+
+	var suppressions []Suppression
+	err := ssn.DB(m.config.S.MongoDB.MetaDB).C(m.config.T.Meta.SuppressionsTable).
+		Find(nil).Sort("created").All(&suppressions)
+	if err != nil {
+		m.log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("could not fetch suppressions from meta database")
+		return nil, err
+	}
+	return suppressions, nil
+}
+
+//RemoveSuppression deletes the suppression with the given ID
+func (m *MetaDB) RemoveSuppression(id string) error {
+	if !bson.IsObjectIdHex(id) {
+		return errors.New("invalid suppression id: " + id)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	ssn := m.dbHandle.Copy()
+	defer ssn.Close()
+
+	return ssn.DB(m.config.S.MongoDB.MetaDB).C(m.config.T.Meta.SuppressionsTable).
+		RemoveId(bson.ObjectIdHex(id))
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"github.com/globalsign/mgo"
+)
+
+type (
+	//mongoSession adapts an mgo session to the Session interface
+	mongoSession struct {
+		ssn *mgo.Session
+	}
+
+	//mongoDatabase adapts an mgo database to the Database interface
+	mongoDatabase struct {
+		db *mgo.Database
+	}
+
+	//mongoCollection adapts an mgo collection to the Collection interface
+	mongoCollection struct {
+		coll *mgo.Collection
+	}
+
+	//mongoQuery adapts an mgo query to the Query interface
+	mongoQuery struct {
+		query *mgo.Query
+	}
+
+	//mongoPipe adapts an mgo pipe to the Pipe interface
+	mongoPipe struct {
+		pipe *mgo.Pipe
+	}
+
+	//mongoBulk adapts an mgo bulk write to the Bulk interface
+	mongoBulk struct {
+		bulk *mgo.Bulk
+	}
+)
+
+//NewMongoSession wraps an existing mgo session
+func NewMongoSession(ssn *mgo.Session) Session {
+	return &mongoSession{ssn: ssn}
+}
+
+func (s *mongoSession) Copy() Session                    { return &mongoSession{ssn: s.ssn.Copy()} }
+func (s *mongoSession) Close()                           { s.ssn.Close() }
+func (s *mongoSession) DB(name string) Database          { return &mongoDatabase{db: s.ssn.DB(name)} }
+func (s *mongoSession) DatabaseNames() ([]string, error) { return s.ssn.DatabaseNames() }
+
+func (d *mongoDatabase) C(name string) Collection           { return &mongoCollection{coll: d.db.C(name)} }
+func (d *mongoDatabase) CollectionNames() ([]string, error) { return d.db.CollectionNames() }
+func (d *mongoDatabase) DropDatabase() error                { return d.db.DropDatabase() }
+
+func (c *mongoCollection) Create(info *CollectionInfo) error { return c.coll.Create(info) }
+func (c *mongoCollection) DropCollection() error             { return c.coll.DropCollection() }
+func (c *mongoCollection) EnsureIndex(index Index) error     { return c.coll.EnsureIndex(index) }
+func (c *mongoCollection) Indexes() ([]Index, error)         { return c.coll.Indexes() }
+func (c *mongoCollection) Count() (int, error)               { return c.coll.Count() }
+func (c *mongoCollection) Find(query interface{}) Query {
+	return &mongoQuery{query: c.coll.Find(query)}
+}
+func (c *mongoCollection) Pipe(pipeline interface{}) Pipe {
+	return &mongoPipe{pipe: c.coll.Pipe(pipeline)}
+}
+func (c *mongoCollection) Insert(docs ...interface{}) error  { return c.coll.Insert(docs...) }
+func (c *mongoCollection) Remove(selector interface{}) error { return c.coll.Remove(selector) }
+func (c *mongoCollection) RemoveId(id interface{}) error     { return c.coll.RemoveId(id) }
+func (c *mongoCollection) Bulk() Bulk                        { return &mongoBulk{bulk: c.coll.Bulk()} }
+
+func (c *mongoCollection) Update(selector interface{}, update interface{}) error {
+	return c.coll.Update(selector, update)
+}
+
+func (c *mongoCollection) UpdateAll(selector interface{}, update interface{}) (*ChangeInfo, error) {
+	return c.coll.UpdateAll(selector, update)
+}
+
+func (c *mongoCollection) Upsert(selector interface{}, update interface{}) (*ChangeInfo, error) {
+	return c.coll.Upsert(selector, update)
+}
+
+func (c *mongoCollection) RemoveAll(selector interface{}) (*ChangeInfo, error) {
+	return c.coll.RemoveAll(selector)
+}
+
+func (q *mongoQuery) Select(selector interface{}) Query {
+	return &mongoQuery{query: q.query.Select(selector)}
+}
+func (q *mongoQuery) Sort(fields ...string) Query  { return &mongoQuery{query: q.query.Sort(fields...)} }
+func (q *mongoQuery) Skip(n int) Query             { return &mongoQuery{query: q.query.Skip(n)} }
+func (q *mongoQuery) Limit(n int) Query            { return &mongoQuery{query: q.query.Limit(n)} }
+func (q *mongoQuery) Count() (int, error)          { return q.query.Count() }
+func (q *mongoQuery) One(result interface{}) error { return q.query.One(result) }
+func (q *mongoQuery) All(result interface{}) error { return q.query.All(result) }
+func (q *mongoQuery) Iter() Iter                   { return q.query.Iter() }
+
+func (p *mongoPipe) AllowDiskUse() Pipe           { return &mongoPipe{pipe: p.pipe.AllowDiskUse()} }
+func (p *mongoPipe) One(result interface{}) error { return p.pipe.One(result) }
+func (p *mongoPipe) All(result interface{}) error { return p.pipe.All(result) }
+func (p *mongoPipe) Iter() Iter                   { return p.pipe.Iter() }
+
+func (b *mongoBulk) Unordered()                  { b.bulk.Unordered() }
+func (b *mongoBulk) Insert(docs ...interface{})  { b.bulk.Insert(docs...) }
+func (b *mongoBulk) Update(pairs ...interface{}) { b.bulk.Update(pairs...) }
+func (b *mongoBulk) Upsert(pairs ...interface{}) { b.bulk.Upsert(pairs...) }
+func (b *mongoBulk) Run() (*BulkResult, error)   { return b.bulk.Run() }
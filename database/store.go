@@ -0,0 +1,102 @@
+package database
+
+import (
+	"github.com/globalsign/mgo"
+)
+
+const (
+	//MongoDBBackend stores datasets in a MongoDB server
+	MongoDBBackend = "mongodb"
+	//EmbeddedBackend stores datasets in a single local file
+	EmbeddedBackend = "embedded"
+)
+
+type (
+	//Index describes an index on a collection
+	Index = mgo.Index
+
+	//CollectionInfo holds the options used when creating a collection
+	CollectionInfo = mgo.CollectionInfo
+
+	//ChangeInfo reports the number of documents affected by a write
+	ChangeInfo = mgo.ChangeInfo
+
+	//BulkResult reports the outcome of a bulk write
+	BulkResult = mgo.BulkResult
+
+	//Session is a connection to the storage backend. Sessions are safe to
+	//share across goroutines, but each goroutine typically uses its own Copy.
+	Session interface {
+		Copy() Session
+		Close()
+		DB(name string) Database
+		DatabaseNames() ([]string, error)
+	}
+
+	//Database holds a set of named collections
+	Database interface {
+		C(name string) Collection
+		CollectionNames() ([]string, error)
+		DropDatabase() error
+	}
+
+	//Collection holds a set of documents. Selectors, updates, and pipelines
+	//are expressed in the MongoDB query language.
+	Collection interface {
+		Create(info *CollectionInfo) error
+		DropCollection() error
+		EnsureIndex(index Index) error
+		Indexes() ([]Index, error)
+		Count() (int, error)
+		Find(query interface{}) Query
+		Pipe(pipeline interface{}) Pipe
+		Insert(docs ...interface{}) error
+		Update(selector interface{}, update interface{}) error
+		UpdateAll(selector interface{}, update interface{}) (*ChangeInfo, error)
+		Upsert(selector interface{}, update interface{}) (*ChangeInfo, error)
+		Remove(selector interface{}) error
+		RemoveId(id interface{}) error
+		RemoveAll(selector interface{}) (*ChangeInfo, error)
+		Bulk() Bulk
+	}
+
+	//Query is a find operation on a collection
+	Query interface {
+		Select(selector interface{}) Query
+		Sort(fields ...string) Query
+		Skip(n int) Query
+		Limit(n int) Query
+		Count() (int, error)
+		One(result interface{}) error
+		All(result interface{}) error
+		Iter() Iter
+	}
+
+	//Pipe is an aggregation pipeline run against a collection
+	Pipe interface {
+		AllowDiskUse() Pipe
+		One(result interface{}) error
+		All(result interface{}) error
+		Iter() Iter
+	}
+
+	//Iter steps through the results of a query or pipeline
+	Iter interface {
+		Next(result interface{}) bool
+		All(result interface{}) error
+		Err() error
+		Close() error
+	}
+
+	//Bulk queues writes to a collection so they can be sent at once
+	Bulk interface {
+		Unordered()
+		Insert(docs ...interface{})
+		Update(pairs ...interface{})
+		Upsert(pairs ...interface{})
+		Run() (*BulkResult, error)
+	}
+)
+
+//ErrNotFound is returned when a query expecting a document finds none
+var ErrNotFound = mgo.ErrNotFound
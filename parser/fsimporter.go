@@ -7,86 +7,65 @@ import (
 	"os"
 	"sort"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
 	fpt "github.com/activecm/rita/parser/fileparsetypes"
-	"github.com/activecm/rita/parser/parsetypes"
-	"github.com/activecm/rita/pkg/beacon"
-	"github.com/activecm/rita/pkg/beaconfqdn"
-	"github.com/activecm/rita/pkg/beaconproxy"
+	"github.com/activecm/rita/pkg/analysis"
 	"github.com/activecm/rita/pkg/blacklist"
-	"github.com/activecm/rita/pkg/certificate"
 	"github.com/activecm/rita/pkg/data"
-	"github.com/activecm/rita/pkg/explodeddns"
 
 	"github.com/activecm/rita/pkg/host"
-	"github.com/activecm/rita/pkg/hostname"
+	// registers the analysis modules each batch is run through
+	_ "github.com/activecm/rita/pkg/modules"
 	"github.com/activecm/rita/pkg/remover"
 	"github.com/activecm/rita/pkg/uconn"
-	"github.com/activecm/rita/pkg/useragent"
 	"github.com/activecm/rita/resources"
 	"github.com/activecm/rita/util"
-	"github.com/globalsign/mgo/bson"
 	log "github.com/sirupsen/logrus"
 )
 
 type (
 	//FSImporter provides the ability to import bro files from the file system
 	FSImporter struct {
-		res                  *resources.Resources
-		importFiles          []string
-		rolling              bool
-		totalChunks          int
-		currentChunk         int
-		indexingThreads      int
-		parseThreads         int
-		batchSizeBytes       int64
-		internal             []*net.IPNet
-		httpProxyServers     []*net.IPNet
-		alwaysIncluded       []*net.IPNet
-		neverIncluded        []*net.IPNet
-		alwaysIncludedDomain []string
-		neverIncludedDomain  []string
-	}
-
-	trustedAppTiplet struct {
-		protocol string
-		port     int
-		service  string
+		res              *resources.Resources
+		importFiles      []string
+		rolling          bool
+		totalChunks      int
+		currentChunk     int
+		indexingThreads  int
+		parseThreads     int
+		batchSizeBytes   int64
+		filter           *analysis.Filter
+		expectedServices expectedServiceTable
 	}
 )
 
 //NewFSImporter creates a new file system importer
 func NewFSImporter(res *resources.Resources,
 	indexingThreads int, parseThreads int, importFiles []string) *FSImporter {
-	return &FSImporter{
-		res:                  res,
-		importFiles:          importFiles,
-		rolling:              res.Config.S.Rolling.Rolling,
-		totalChunks:          res.Config.S.Rolling.TotalChunks,
-		currentChunk:         res.Config.S.Rolling.CurrentChunk,
-		indexingThreads:      indexingThreads,
-		parseThreads:         parseThreads,
-		batchSizeBytes:       2 * (2 << 30), // 2 gigabytes (used to not run out of memory while importing)
-		internal:             util.ParseSubnets(res.Config.S.Filtering.InternalSubnets),
-		httpProxyServers:     util.ParseSubnets(res.Config.S.Filtering.HTTPProxyServers),
-		alwaysIncluded:       util.ParseSubnets(res.Config.S.Filtering.AlwaysInclude),
-		neverIncluded:        util.ParseSubnets(res.Config.S.Filtering.NeverInclude),
-		alwaysIncludedDomain: res.Config.S.Filtering.AlwaysIncludeDomain,
-		neverIncludedDomain:  res.Config.S.Filtering.NeverIncludeDomain,
+	expectedServices, invalid := newExpectedServiceTable(res.Config.S.Services.ExpectedServices)
+	for _, entry := range invalid {
+		res.Log.WithField("entry", entry).Warn("Ignoring malformed ExpectedServices entry. Entries must be in the form port:proto:service")
 	}
-}
 
-var trustedAppReferenceList = [...]trustedAppTiplet{
-	{"tcp", 80, "http"},
-	{"tcp", 443, "ssl"},
+	return &FSImporter{
+		res:              res,
+		importFiles:      importFiles,
+		rolling:          res.Config.S.Rolling.Rolling,
+		totalChunks:      res.Config.S.Rolling.TotalChunks,
+		currentChunk:     res.Config.S.Rolling.CurrentChunk,
+		indexingThreads:  indexingThreads,
+		parseThreads:     parseThreads,
+		batchSizeBytes:   2 * (2 << 30), // 2 gigabytes (used to not run out of memory while importing)
+		filter:           analysis.NewFilter(res.Config),
+		expectedServices: expectedServices,
+	}
 }
 
 //GetInternalSubnets returns the internal subnets from the config file
 func (fs *FSImporter) GetInternalSubnets() []*net.IPNet {
-	return fs.internal
+	return fs.filter.InternalSubnets()
 }
 
 //CollectFileDetails reads and hashes the files
@@ -175,45 +154,18 @@ func (fs *FSImporter) Run(indexedFiles []*fpt.IndexedFile) {
 	for i, indexedFileBatch := range batchedIndexedFiles {
 		fmt.Printf("\t[-] Processing batch %d of %d\n", i+1, len(batchedIndexedFiles))
 
+		// proxy servers listed by hostname must be known before parsing
+		fs.resolveProxyHostnames(indexedFileBatch)
+
 		// parse in those files!
-		uconnMap, hostMap, explodeddnsMap, hostnameMap, proxyHostnameMap, useragentMap, certMap := fs.parseFiles(indexedFileBatch, fs.parseThreads, fs.res.Log)
+		batch := fs.parseFiles(indexedFileBatch, fs.parseThreads, fs.res.Log)
 
 		// Set chunk before we continue so if process dies, we still verify with a delete if
 		// any data was written out.
 		fs.res.MetaDB.SetChunk(fs.currentChunk, fs.res.DB.GetSelectedDB(), true)
 
-		// build Hosts table.
-		fs.buildHosts(hostMap)
-
-		// build Uconns table. Must go before beacons.
-		fs.buildUconns(uconnMap)
-
-		// update ts range for dataset (needs to be run before beacons)
-		fs.updateTimestampRange()
-
-		// build or update the exploded DNS table. Must go before hostnames
-		fs.buildExplodedDNS(explodeddnsMap)
-
-		// build or update the exploded DNS table
-		fs.buildHostnames(hostnameMap)
-
-		// build or update Beacons table
-		fs.buildBeacons(uconnMap)
-
-		// build or update the FQDN Beacons Table
-		fs.buildFQDNBeacons(hostnameMap)
-
-		// build or update the Proxy Beacons Table
-		fs.buildProxyBeacons(proxyHostnameMap)
-
-		// build or update UserAgent table
-		fs.buildUserAgent(useragentMap)
-
-		// build or update Certificate table
-		fs.buildCertificates(certMap)
-
-		// update blacklisted peers in hosts collection
-		fs.markBlacklistedPeers(hostMap)
+		// run each enabled analysis module in dependency order
+		analysis.Run(fs.res, batch)
 
 		// record file+database name hash in metadabase to prevent duplicate content
 		fmt.Println("\t[-] Indexing log entries ... ")
@@ -307,27 +259,18 @@ func batchFilesBySize(indexedFiles []*fpt.IndexedFile, size int64) [][]*fpt.Inde
 //threads to use to parse the files, whether or not to sort data by date,
 //a MongoDB datastore object to store the bro data in, and a logger to report
 //errors and parses the bro files line by line into the database.
-func (fs *FSImporter) parseFiles(indexedFiles []*fpt.IndexedFile, parsingThreads int, logger *log.Logger) (
-	map[string]*uconn.Input, map[string]*host.Input, map[string]int, map[string]*hostname.Input, map[string]*beaconproxy.Input, map[string]*useragent.Input, map[string]*certificate.Input) {
+func (fs *FSImporter) parseFiles(indexedFiles []*fpt.IndexedFile, parsingThreads int, logger *log.Logger) *analysis.Batch {
 
 	fmt.Println("\t[-] Parsing logs to: " + fs.res.DB.GetSelectedDB() + " ... ")
 
-	// create log parsing maps
-	explodeddnsMap := make(map[string]int)
-
-	hostnameMap := make(map[string]*hostname.Input)
-
-	proxyHostnameMap := make(map[string]*beaconproxy.Input)
-
-	useragentMap := make(map[string]*useragent.Input)
-
-	certMap := make(map[string]*certificate.Input)
-
 	// Counts the number of uconns per source-destination pair
 	uconnMap := make(map[string]*uconn.Input)
 
 	hostMap := make(map[string]*host.Input)
 
+	// Aggregates the records for each enabled analysis module
+	parsers := analysis.NewParsers(fs.res, fs.filter)
+
 	//set up parallel parsing
 	n := len(indexedFiles)
 	parsingWG := new(sync.WaitGroup)
@@ -379,463 +322,21 @@ func (fs *FSImporter) parseFiles(indexedFiles []*fpt.IndexedFile, parsingThreads
 						logger,
 					)
 
-					if datum != nil {
-						//figure out which collection (dns, http, or conn) this line is heading for
-						targetCollection := indexedFiles[j].TargetCollection
-
-						switch targetCollection {
-
-						/// *************************************************************///
-						///                           CONNS                              ///
-						/// *************************************************************///
-						case fs.res.Config.T.Structure.ConnTable:
-
-							parseConn, ok := datum.(*parsetypes.Conn)
-							if !ok {
-								continue
-							}
-
-							// get source destination pair for connection record
-							src := parseConn.Source
-							dst := parseConn.Destination
-
-							// parse addresses into binary format
-							srcIP := net.ParseIP(src)
-							dstIP := net.ParseIP(dst)
-
-							// disambiguate addresses which are not publicly routable
-							srcUniqIP := data.NewUniqueIP(srcIP, parseConn.AgentUUID, parseConn.AgentHostname)
-							dstUniqIP := data.NewUniqueIP(dstIP, parseConn.AgentUUID, parseConn.AgentHostname)
-							srcDstPair := data.NewUniqueIPPair(srcUniqIP, dstUniqIP)
-
-							// get aggregation keys for ip addresses and connection pair
-							srcKey := srcUniqIP.MapKey()
-							dstKey := dstUniqIP.MapKey()
-							srcDstKey := srcDstPair.MapKey()
-
-							// Run conn pair through filter to filter out certain connections
-							ignore := fs.filterConnPair(srcIP, dstIP)
-
-							// If connection pair is not subject to filtering, process
-							if !ignore {
-								ts := parseConn.TimeStamp
-								origIPBytes := parseConn.OrigIPBytes
-								respIPBytes := parseConn.RespIPBytes
-								duration := parseConn.Duration
-								duration = math.Ceil((duration)*10000) / 10000
-								bytes := int64(origIPBytes + respIPBytes)
-								protocol := parseConn.Proto
-								service := parseConn.Service
-								dstPort := parseConn.DestinationPort
-								var tuple string
-								if service == "" {
-									tuple = strconv.Itoa(dstPort) + ":" + protocol + ":-"
-								} else {
-									tuple = strconv.Itoa(dstPort) + ":" + protocol + ":" + service
-								}
-
-								// Safely store the number of conns for this uconn
-								mutex.Lock()
-
-								// Check if the map value is set
-								if _, ok := hostMap[srcKey]; !ok {
-									// create new host record with src and dst
-									hostMap[srcKey] = &host.Input{
-										Host:    srcUniqIP,
-										IsLocal: util.ContainsIP(fs.GetInternalSubnets(), srcIP),
-										IP4:     util.IsIPv4(src),
-										IP4Bin:  util.IPv4ToBinary(srcIP),
-									}
-								}
-
-								// Check if the map value is set
-								if _, ok := hostMap[dstKey]; !ok {
-									// create new host record with src and dst
-									hostMap[dstKey] = &host.Input{
-										Host:    dstUniqIP,
-										IsLocal: util.ContainsIP(fs.GetInternalSubnets(), dstIP),
-										IP4:     util.IsIPv4(dst),
-										IP4Bin:  util.IPv4ToBinary(dstIP),
-									}
-								}
-
-								// Check if the map value is set
-								if _, ok := uconnMap[srcDstKey]; !ok {
-									// create new uconn record with src and dst
-									// Set IsLocalSrc and IsLocalDst fields based on InternalSubnets setting
-									// we only need to do this once if the uconn record does not exist
-									uconnMap[srcDstKey] = &uconn.Input{
-										Hosts:      srcDstPair,
-										IsLocalSrc: util.ContainsIP(fs.GetInternalSubnets(), srcIP),
-										IsLocalDst: util.ContainsIP(fs.GetInternalSubnets(), dstIP),
-									}
-
-									hostMap[srcKey].CountSrc++
-									hostMap[dstKey].CountDst++
-								}
-
-								// this is to keep track of how many times a host connected to
-								// an unexpected port - proto - service Tuple
-								// we only want to increment the count once per unique destination,
-								// not once per connection, hence the flag and the check
-								if !uconnMap[srcDstKey].UPPSFlag {
-									for _, entry := range trustedAppReferenceList {
-										if (protocol == entry.protocol) && (dstPort == entry.port) {
-											if service != entry.service {
-												hostMap[srcKey].UntrustedAppConnCount++
-												uconnMap[srcDstKey].UPPSFlag = true
-											}
-										}
-									}
-								}
-
-								// increment unique dst port: proto : service tuple list for host
-								if !stringInSlice(tuple, uconnMap[srcDstKey].Tuples) {
-									uconnMap[srcDstKey].Tuples = append(uconnMap[srcDstKey].Tuples, tuple)
-								}
-
-								// Check if invalid cert record was written before the uconns
-								// record, we'll need to update it with the tuples.
-								if _, ok := certMap[dstKey]; ok {
-									// add tuple to invlaid cert list
-									if !stringInSlice(tuple, certMap[dstKey].Tuples) {
-										certMap[dstKey].Tuples = append(certMap[dstKey].Tuples, tuple)
-									}
-								}
-
-								// Increment the connection count for the src-dst pair
-								uconnMap[srcDstKey].ConnectionCount++
-								hostMap[srcKey].ConnectionCount++
-								hostMap[dstKey].ConnectionCount++
-
-								// Only append unique timestamps to tslist
-								if !int64InSlice(ts, uconnMap[srcDstKey].TsList) {
-									uconnMap[srcDstKey].TsList = append(uconnMap[srcDstKey].TsList, ts)
-								}
-
-								// Append all origIPBytes to origBytesList
-								uconnMap[srcDstKey].OrigBytesList = append(uconnMap[srcDstKey].OrigBytesList, origIPBytes)
-
-								// Calculate and store the total number of bytes exchanged by the uconn pair
-								uconnMap[srcDstKey].TotalBytes += bytes
-								hostMap[srcKey].TotalBytes += bytes
-								hostMap[dstKey].TotalBytes += bytes
-
-								// Calculate and store the total duration
-								uconnMap[srcDstKey].TotalDuration += duration
-								hostMap[srcKey].TotalDuration += duration
-								hostMap[dstKey].TotalDuration += duration
-
-								// Replace existing duration if current duration is higher
-								if duration > uconnMap[srcDstKey].MaxDuration {
-									uconnMap[srcDstKey].MaxDuration = duration
-								}
-
-								if duration > hostMap[srcKey].MaxDuration {
-									hostMap[srcKey].MaxDuration = duration
-								}
-								if duration > hostMap[dstKey].MaxDuration {
-									hostMap[dstKey].MaxDuration = duration
-								}
-
-								mutex.Unlock()
-
-							}
-
-						/// *************************************************************///
-						///                             DNS                              ///
-						/// *************************************************************///
-						case fs.res.Config.T.Structure.DNSTable:
-							parseDNS, ok := datum.(*parsetypes.DNS)
-							if !ok {
-								continue
-							}
-
-							domain := parseDNS.Query
-							queryTypeName := parseDNS.QTypeName
-
-							// extract and store the dns client ip address
-							src := parseDNS.Source
-							srcIP := net.ParseIP(src)
-
-							// Run domain through filter to filter out certain domains
-							ignore := (fs.filterDomain(domain) || fs.filterSingleIP(srcIP))
-
-							// If domain is not subject to filtering, process
-							if !ignore {
-
-								// Safely store the number of conns for this uconn
-								mutex.Lock()
-
-								// increment domain map count for exploded dns
-								explodeddnsMap[domain]++
-
-								// initialize the hostname input objects for new hostnames
-								if _, ok := hostnameMap[domain]; !ok {
-									hostnameMap[domain] = &hostname.Input{
-										Host: domain,
-									}
-								}
-
-								srcUniqIP := data.NewUniqueIP(srcIP, parseDNS.AgentUUID, parseDNS.AgentHostname)
-								srcKey := srcUniqIP.MapKey()
-
-								hostnameMap[domain].ClientIPs.Insert(srcUniqIP)
-
-								if queryTypeName == "A" {
-									answers := parseDNS.Answers
-									for _, answer := range answers {
-										answerIP := net.ParseIP(answer)
-										// Check if answer is an IP address and store it if it is
-										if answerIP != nil {
-											answerUniqIP := data.NewUniqueIP(answerIP, parseDNS.AgentUUID, parseDNS.AgentHostname)
-											hostnameMap[domain].ResolvedIPs.Insert(answerUniqIP)
-										}
-									}
-								}
-
-								// We don't filter out the src ips like we do with the conn
-								// section since a c2 channel running over dns could have an
-								// internal ip to internal ip connection and not having that ip
-								// in the host table is limiting
-
-								// in some of these strings, the empty space will get counted as a domain,
-								// don't add host or increment dns query count if queried domain
-								// is blank or ends in 'in-addr.arpa'
-								if (domain != "") && (!strings.HasSuffix(domain, "in-addr.arpa")) {
-									// Check if host map value is set, because this record could
-									// come before a relevant conns record
-									if _, ok := hostMap[srcKey]; !ok {
-										// create new uconn record with src and dst
-										// Set IsLocalSrc and IsLocalDst fields based on InternalSubnets setting
-										// we only need to do this once if the uconn record does not exist
-										hostMap[srcKey] = &host.Input{
-											Host:    srcUniqIP,
-											IsLocal: util.ContainsIP(fs.GetInternalSubnets(), srcIP),
-											IP4:     util.IsIPv4(src),
-											IP4Bin:  util.IPv4ToBinary(srcIP),
-										}
-									}
-
-									// if there are no entries in the dnsquerycount map for this
-									// srcKey, initialize map
-									if hostMap[srcKey].DNSQueryCount == nil {
-										hostMap[srcKey].DNSQueryCount = make(map[string]int64)
-									}
-
-									// increment the dns query count for this domain
-									hostMap[srcKey].DNSQueryCount[domain]++
-								}
-
-								mutex.Unlock()
-							}
-
-						/// *************************************************************///
-						///                             HTTP                             ///
-						/// *************************************************************///
-						case fs.res.Config.T.Structure.HTTPTable:
-							parseHTTP, ok := datum.(*parsetypes.HTTP)
-							if !ok {
-								continue
-							}
-
-							// get source destination pair for connection record
-							src := parseHTTP.Source
-							dst := parseHTTP.Destination
-
-							// parse addresses into binary format
-							srcIP := net.ParseIP(src)
-							dstIP := net.ParseIP(dst)
-
-							// parse host
-							fqdn := parseHTTP.Host
-
-							if fs.filterDomain(fqdn) || fs.filterConnPair(srcIP, dstIP) {
-								continue
-							}
-
-							// disambiguate addresses which are not publicly routable
-							srcUniqIP := data.NewUniqueIP(srcIP, parseHTTP.AgentUUID, parseHTTP.AgentHostname)
-							dstUniqIP := data.NewUniqueIP(dstIP, parseHTTP.AgentUUID, parseHTTP.AgentHostname)
-							srcProxyFQDNTrio := beaconproxy.NewUniqueSrcProxyHostnameTrio(srcUniqIP, dstUniqIP, fqdn)
-
-							// get aggregation keys for ip addresses and connection pair
-							srcProxyFQDNKey := srcProxyFQDNTrio.MapKey()
-
-							// check if destination is a proxy server
-							dstIsProxy := fs.checkIfProxyServer(dstIP)
-
-							// parse method type
-							method := parseHTTP.Method
-
-							// check if internal IP is requesting a connection
-							// through a proxy
-							if method == "CONNECT" && dstIsProxy {
-
-								// Safely store the number of conns for this uconn
-								mutex.Lock()
-
-								// add client (src) IP to hostname map
-
-								// Check if the map value is set
-								if _, ok := proxyHostnameMap[srcProxyFQDNKey]; !ok {
-									// create new host record with src and dst
-									proxyHostnameMap[srcProxyFQDNKey] = &beaconproxy.Input{
-										Hosts: srcProxyFQDNTrio,
-									}
-								}
-
-								// increment connection count
-								proxyHostnameMap[srcProxyFQDNKey].ConnectionCount++
-
-								// parse timestamp
-								ts := parseHTTP.TimeStamp
-
-								// add timestamp to unique timestamp list
-								if !int64InSlice(ts, proxyHostnameMap[srcProxyFQDNKey].TsList) {
-									proxyHostnameMap[srcProxyFQDNKey].TsList = append(proxyHostnameMap[srcProxyFQDNKey].TsList, ts)
-								}
-
-								mutex.Unlock()
-
-							}
-
-							// parse out useragent info
-							userAgentName := parseHTTP.UserAgent
-							if userAgentName == "" {
-								userAgentName = "Empty user agent string"
-							}
-
-							// Safely store useragent information
-							mutex.Lock()
-
-							// create record if it doesn't exist
-							if _, ok := useragentMap[userAgentName]; !ok {
-								useragentMap[userAgentName] = &useragent.Input{
-									Name:     userAgentName,
-									Seen:     1,
-									Requests: []string{fqdn},
-								}
-								useragentMap[userAgentName].OrigIps.Insert(srcUniqIP)
-							} else {
-								// increment times seen count
-								useragentMap[userAgentName].Seen++
-
-								// add src of useragent request to unique array
-								useragentMap[userAgentName].OrigIps.Insert(srcUniqIP)
-
-								// add request string to unique array
-								if !stringInSlice(fqdn, useragentMap[userAgentName].Requests) {
-									useragentMap[userAgentName].Requests = append(useragentMap[userAgentName].Requests, fqdn)
-								}
-							}
-
-							mutex.Unlock()
-
-						/// *************************************************************///
-						///                             SSL                              ///
-						/// *************************************************************///
-						case fs.res.Config.T.Structure.SSLTable:
-							parseSSL, ok := datum.(*parsetypes.SSL)
-							if !ok {
-								continue
-							}
-							ja3Hash := parseSSL.JA3
-							src := parseSSL.Source
-							dst := parseSSL.Destination
-							host := parseSSL.ServerName
-							certStatus := parseSSL.ValidationStatus
-
-							srcIP := net.ParseIP(src)
-							dstIP := net.ParseIP(dst)
-
-							srcUniqIP := data.NewUniqueIP(srcIP, parseSSL.AgentUUID, parseSSL.AgentHostname)
-							dstUniqIP := data.NewUniqueIP(dstIP, parseSSL.AgentUUID, parseSSL.AgentHostname)
-							srcDstPair := data.NewUniqueIPPair(srcUniqIP, dstUniqIP)
-
-							srcDstKey := srcDstPair.MapKey()
-							dstKey := dstUniqIP.MapKey()
-
-							if ja3Hash == "" {
-								ja3Hash = "No JA3 hash generated"
-							}
-
-							// Safely store ja3 information
-							mutex.Lock()
-
-							// create useragent record if it doesn't exist
-							if _, ok := useragentMap[ja3Hash]; !ok {
-								useragentMap[ja3Hash] = &useragent.Input{
-									Name:     ja3Hash,
-									Seen:     1,
-									Requests: []string{host},
-									JA3:      true,
-								}
-								useragentMap[ja3Hash].OrigIps.Insert(srcUniqIP)
-							} else {
-								// increment times seen count
-								useragentMap[ja3Hash].Seen++
-
-								// add src of ssl request to unique array
-								useragentMap[ja3Hash].OrigIps.Insert(srcUniqIP)
-
-								// add request string to unique array
-								if !stringInSlice(host, useragentMap[ja3Hash].Requests) {
-									useragentMap[ja3Hash].Requests = append(useragentMap[ja3Hash].Requests, host)
-								}
-							}
-
-							// create uconn and cert records
-							// Run conn pair through filter to filter out certain connections
-							ignore := fs.filterConnPair(srcIP, dstIP)
-							if !ignore {
-
-								// Check if uconn map value is set, because this record could
-								// come before a relevant uconns record (or may be the only source
-								// for the uconns record)
-								if _, ok := uconnMap[srcDstKey]; !ok {
-									// create new uconn record if it does not exist
-									uconnMap[srcDstKey] = &uconn.Input{
-										Hosts:      srcDstPair,
-										IsLocalSrc: util.ContainsIP(fs.GetInternalSubnets(), srcIP),
-										IsLocalDst: util.ContainsIP(fs.GetInternalSubnets(), dstIP),
-									}
-								}
-
-								//if there's any problem in the certificate, mark it invalid
-								if certStatus != "ok" && certStatus != "-" && certStatus != "" && certStatus != " " {
-									// mark as having invalid cert
-									uconnMap[srcDstKey].InvalidCertFlag = true
-
-									// update relevant cert record
-									if _, ok := certMap[dstKey]; !ok {
-										// create new uconn record if it does not exist
-										certMap[dstKey] = &certificate.Input{
-											Host: dstUniqIP,
-											Seen: 1,
-										}
-									} else {
-										certMap[dstKey].Seen++
-									}
-
-									for _, tuple := range uconnMap[srcDstKey].Tuples {
-										// mark as having invalid cert
-										if !stringInSlice(tuple, certMap[dstKey].Tuples) {
-											certMap[dstKey].Tuples = append(certMap[dstKey].Tuples, tuple)
-										}
-									}
-									// mark as having invalid cert
-									if !stringInSlice(certStatus, certMap[dstKey].InvalidCerts) {
-										certMap[dstKey].InvalidCerts = append(certMap[dstKey].InvalidCerts, certStatus)
-									}
-									// add src of ssl request to unique array
-									certMap[dstKey].OrigIps.Insert(srcUniqIP)
-								}
-							}
-
-							mutex.Unlock()
-						}
+					if datum == nil {
+						continue
+					}
+
+					// resolve the hosts of the conn, dns, http, or ssl record
+					// and check them against the filters
+					record := analysis.NewRecord(fs.filter, datum)
+					if record == nil {
+						continue
 					}
+
+					mutex.Lock()
+					fs.aggregate(record, hostMap, uconnMap)
+					parsers.Parse(record)
+					mutex.Unlock()
 				}
 				indexedFiles[j].ParseTime = time.Now()
 				fileHandle.Close()
@@ -848,285 +349,217 @@ func (fs *FSImporter) parseFiles(indexedFiles []*fpt.IndexedFile, parsingThreads
 	}
 	parsingWG.Wait()
 
-	return uconnMap, hostMap, explodeddnsMap, hostnameMap, proxyHostnameMap, useragentMap, certMap
-}
-
-//buildExplodedDNS .....
-func (fs *FSImporter) buildExplodedDNS(domainMap map[string]int) {
-
-	if fs.res.Config.S.DNS.Enabled {
-		if len(domainMap) > 0 {
-			// Set up the database
-			explodedDNSRepo := explodeddns.NewMongoRepository(fs.res)
-			err := explodedDNSRepo.CreateIndexes()
-			if err != nil {
-				fs.res.Log.Error(err)
-			}
-			explodedDNSRepo.Upsert(domainMap)
-		} else {
-			fmt.Println("\t[!] No DNS data to analyze")
-		}
+	batch := &analysis.Batch{
+		Uconns: uconnMap,
+		Hosts:  hostMap,
 	}
+	parsers.Flush(batch)
+	return batch
 }
 
-//buildCertificates .....
-func (fs *FSImporter) buildCertificates(certMap map[string]*certificate.Input) {
-
-	if len(certMap) > 0 {
-		// Set up the database
-		certificateRepo := certificate.NewMongoRepository(fs.res)
-		err := certificateRepo.CreateIndexes()
-		if err != nil {
-			fs.res.Log.Error(err)
-		}
-		certificateRepo.Upsert(certMap)
-	} else {
-		fmt.Println("\t[!] No invalid certificate data to analyze")
+//aggregate adds a record to the hosts and uconns every analysis module
+//builds on
+func (fs *FSImporter) aggregate(record *analysis.Record, hostMap map[string]*host.Input, uconnMap map[string]*uconn.Input) {
+	if record.Filtered {
+		return
 	}
 
-}
-
-//removeAnalysisChunk .....
-func (fs *FSImporter) removeAnalysisChunk(cid int) error {
+	switch record.Log {
+	case analysis.ConnLog:
+		fs.aggregateConn(record, hostMap, uconnMap)
 
-	// Set up the remover
-	removerRepo := remover.NewMongoRemover(fs.res)
-	err := removerRepo.Remove(cid)
-	if err != nil {
-		fs.res.Log.Error(err)
-		return err
-	}
+	case analysis.DNSLog:
+		// We don't filter out the src ips like we do with the conn
+		// section since a c2 channel running over dns could have an
+		// internal ip to internal ip connection and not having that ip
+		// in the host table is limiting
 
-	fs.res.MetaDB.SetChunk(cid, fs.res.DB.GetSelectedDB(), false)
+		// in some of these strings, the empty space will get counted as a domain,
+		// don't add host or increment dns query count if queried domain
+		// is blank or ends in 'in-addr.arpa'
+		if !record.IsLookup() {
+			return
+		}
 
-	return nil
+		srcKey := record.Src.MapKey()
 
-}
+		// Check if host map value is set, because this record could
+		// come before a relevant conns record
+		if _, ok := hostMap[srcKey]; !ok {
+			hostMap[srcKey] = fs.newHost(record.Src, record.SrcIP)
+		}
 
-//buildHostnames .....
-func (fs *FSImporter) buildHostnames(hostnameMap map[string]*hostname.Input) {
-	// non-optional module
-	if len(hostnameMap) > 0 {
-		// Set up the database
-		hostnameRepo := hostname.NewMongoRepository(fs.res)
-		err := hostnameRepo.CreateIndexes()
-		if err != nil {
-			fs.res.Log.Error(err)
+		// if there are no entries in the dnsquerycount map for this
+		// srcKey, initialize map
+		if hostMap[srcKey].DNSQueryCount == nil {
+			hostMap[srcKey].DNSQueryCount = make(map[string]int64)
 		}
-		hostnameRepo.Upsert(hostnameMap)
-	} else {
-		fmt.Println("\t[!] No Hostname data to analyze")
-	}
 
-}
+		// increment the dns query count for this domain
+		hostMap[srcKey].DNSQueryCount[record.FQDN]++
 
-func (fs *FSImporter) buildUconns(uconnMap map[string]*uconn.Input) {
-	// non-optional module
-	if len(uconnMap) > 0 {
-		// Set up the database
-		uconnRepo := uconn.NewMongoRepository(fs.res)
+	case analysis.SSLLog:
+		srcDstKey := record.Hosts.MapKey()
 
-		err := uconnRepo.CreateIndexes()
-		if err != nil {
-			fs.res.Log.Error(err)
+		// Check if uconn map value is set, because this record could
+		// come before a relevant uconns record (or may be the only source
+		// for the uconns record)
+		if _, ok := uconnMap[srcDstKey]; !ok {
+			// create new uconn record if it does not exist
+			uconnMap[srcDstKey] = fs.newUconn(record)
 		}
 
-		// send uconns to uconn analysis
-		uconnRepo.Upsert(uconnMap)
-	} else {
-		fmt.Println("\t[!] No Uconn data to analyze")
-		fmt.Printf("\t\t[!!] No local network traffic found, please check ")
-		fmt.Println("InternalSubnets in your RITA config (/etc/rita/config.yaml)")
+		//if there's any problem in the certificate, mark it invalid
+		if record.SSL.InvalidCert() {
+			uconnMap[srcDstKey].InvalidCertFlag = true
+		}
 	}
 }
 
-func (fs *FSImporter) buildHosts(hostMap map[string]*host.Input) {
-	// non-optional module
-	if len(hostMap) > 0 {
-		hostRepo := host.NewMongoRepository(fs.res)
-
-		err := hostRepo.CreateIndexes()
-		if err != nil {
-			fs.res.Log.Error(err)
-		}
-
-		// send uconns to host analysis
-		hostRepo.Upsert(hostMap)
+//aggregateConn adds a connection to the hosts and uconns
+func (fs *FSImporter) aggregateConn(record *analysis.Record, hostMap map[string]*host.Input, uconnMap map[string]*uconn.Input) {
+	parseConn := record.Conn
+
+	// get aggregation keys for ip addresses and connection pair
+	srcKey := record.Src.MapKey()
+	dstKey := record.Dst.MapKey()
+	srcDstKey := record.Hosts.MapKey()
+
+	ts := parseConn.TimeStamp
+	origIPBytes := parseConn.OrigIPBytes
+	respIPBytes := parseConn.RespIPBytes
+	duration := parseConn.Duration
+	duration = math.Ceil((duration)*10000) / 10000
+	bytes := int64(origIPBytes + respIPBytes)
+	protocol := parseConn.Proto
+	service := parseConn.Service
+	dstPort := parseConn.DestinationPort
+	var tuple string
+	if service == "" {
+		tuple = strconv.Itoa(dstPort) + ":" + protocol + ":-"
 	} else {
-		fmt.Println("\t[!] No Host data to analyze")
-		fmt.Printf("\t\t[!!] No local network traffic found, please check ")
-		fmt.Println("InternalSubnets in your RITA config (/etc/rita/config.yaml)")
+		tuple = strconv.Itoa(dstPort) + ":" + protocol + ":" + service
 	}
-}
-
-func (fs *FSImporter) markBlacklistedPeers(hostMap map[string]*host.Input) {
-	// non-optional module
-	if len(hostMap) > 0 {
-		blacklistRepo := blacklist.NewMongoRepository(fs.res)
 
-		err := blacklistRepo.CreateIndexes()
-		if err != nil {
-			fs.res.Log.Error(err)
-		}
-
-		// send uconns to host analysis
-		blacklistRepo.Upsert()
+	// Check if the map value is set
+	if _, ok := hostMap[srcKey]; !ok {
+		// create new host record with src and dst
+		hostMap[srcKey] = fs.newHost(record.Src, record.SrcIP)
 	}
-}
 
-func (fs *FSImporter) buildBeacons(uconnMap map[string]*uconn.Input) {
-	if fs.res.Config.S.Beacon.Enabled {
-		if len(uconnMap) > 0 {
-			beaconRepo := beacon.NewMongoRepository(fs.res)
-
-			err := beaconRepo.CreateIndexes()
-			if err != nil {
-				fs.res.Log.Error(err)
-			}
-
-			// send uconns to beacon analysis
-			beaconRepo.Upsert(uconnMap)
-		} else {
-			fmt.Println("\t[!] No Beacon data to analyze")
-		}
+	// Check if the map value is set
+	if _, ok := hostMap[dstKey]; !ok {
+		// create new host record with src and dst
+		hostMap[dstKey] = fs.newHost(record.Dst, record.DstIP)
 	}
 
-}
-
-func (fs *FSImporter) buildFQDNBeacons(hostnameMap map[string]*hostname.Input) {
-	if fs.res.Config.S.BeaconFQDN.Enabled {
-		if len(hostnameMap) > 0 {
-			beaconFQDNRepo := beaconfqdn.NewMongoRepository(fs.res)
+	// Check if the map value is set
+	if _, ok := uconnMap[srcDstKey]; !ok {
+		// create new uconn record with src and dst
+		uconnMap[srcDstKey] = fs.newUconn(record)
 
-			err := beaconFQDNRepo.CreateIndexes()
-			if err != nil {
-				fs.res.Log.Error(err)
-			}
-
-			// send uconns to beacon analysis
-			beaconFQDNRepo.Upsert(hostnameMap)
-		} else {
-			fmt.Println("\t[!] No FQDN Beacon data to analyze")
-		}
+		hostMap[srcKey].CountSrc++
+		hostMap[dstKey].CountDst++
 	}
 
-}
-
-func (fs *FSImporter) buildProxyBeacons(proxyHostnameMap map[string]*beaconproxy.Input) {
-	if fs.res.Config.S.BeaconProxy.Enabled {
-		if len(proxyHostnameMap) > 0 {
-			beaconProxyRepo := beaconproxy.NewMongoRepository(fs.res)
-
-			err := beaconProxyRepo.CreateIndexes()
-			if err != nil {
-				fs.res.Log.Error(err)
-			}
-
-			// send uconns to beacon analysis
-			beaconProxyRepo.Upsert(proxyHostnameMap)
-		} else {
-			fmt.Println("\t[!] No Proxy Beacon data to analyze")
+	// this is to keep track of how many times a host connected to
+	// an unexpected port - proto - service Tuple
+	// we only want to increment the count once per unique destination,
+	// not once per connection, hence the flag and the check
+	if fs.expectedServices.isUnexpected(dstPort, protocol, service) {
+		if !uconnMap[srcDstKey].UPPSFlag {
+			hostMap[srcKey].UntrustedAppConnCount++
+			uconnMap[srcDstKey].UPPSFlag = true
+		}
+		if !stringInSlice(tuple, uconnMap[srcDstKey].UnexpectedTuples) {
+			uconnMap[srcDstKey].UnexpectedTuples = append(uconnMap[srcDstKey].UnexpectedTuples, tuple)
 		}
 	}
 
-}
+	// increment unique dst port: proto : service tuple list for host
+	if !stringInSlice(tuple, uconnMap[srcDstKey].Tuples) {
+		uconnMap[srcDstKey].Tuples = append(uconnMap[srcDstKey].Tuples, tuple)
+	}
 
-//buildUserAgent .....
-func (fs *FSImporter) buildUserAgent(useragentMap map[string]*useragent.Input) {
+	// Increment the connection count for the src-dst pair
+	uconnMap[srcDstKey].ConnectionCount++
+	hostMap[srcKey].ConnectionCount++
+	hostMap[dstKey].ConnectionCount++
 
-	if fs.res.Config.S.UserAgent.Enabled {
-		if len(useragentMap) > 0 {
-			// Set up the database
-			useragentRepo := useragent.NewMongoRepository(fs.res)
-			err := useragentRepo.CreateIndexes()
-			if err != nil {
-				fs.res.Log.Error(err)
-			}
-			useragentRepo.Upsert(useragentMap)
-		} else {
-			fmt.Println("\t[!] No UserAgent data to analyze")
-		}
+	// Only append unique timestamps to tslist
+	if !int64InSlice(ts, uconnMap[srcDstKey].TsList) {
+		uconnMap[srcDstKey].TsList = append(uconnMap[srcDstKey].TsList, ts)
 	}
-}
 
-func (fs *FSImporter) updateTimestampRange() {
-	session := fs.res.DB.Session.Copy()
-	defer session.Close()
+	// Append all origIPBytes to origBytesList
+	uconnMap[srcDstKey].OrigBytesList = append(uconnMap[srcDstKey].OrigBytesList, origIPBytes)
 
-	// set collection name
-	collectionName := fs.res.Config.T.Structure.UniqueConnTable
+	// Calculate and store the total number of bytes exchanged by the uconn pair
+	uconnMap[srcDstKey].TotalBytes += bytes
+	hostMap[srcKey].TotalBytes += bytes
+	hostMap[dstKey].TotalBytes += bytes
 
-	// check if collection already exists
-	names, _ := session.DB(fs.res.DB.GetSelectedDB()).CollectionNames()
+	// Store the bytes sent in each direction separately so uploads
+	// can be told apart from downloads
+	uconnMap[srcDstKey].OrigBytes += origIPBytes
+	uconnMap[srcDstKey].RespBytes += respIPBytes
+	hostMap[srcKey].BytesSent += origIPBytes
+	hostMap[srcKey].BytesReceived += respIPBytes
+	hostMap[dstKey].BytesSent += respIPBytes
+	hostMap[dstKey].BytesReceived += origIPBytes
 
-	exists := false
-	// make sure collection exists
-	for _, name := range names {
-		if name == collectionName {
-			exists = true
-			break
-		}
-	}
-
-	if !exists {
-		return
-	}
+	// Calculate and store the total duration
+	uconnMap[srcDstKey].TotalDuration += duration
+	hostMap[srcKey].TotalDuration += duration
+	hostMap[dstKey].TotalDuration += duration
 
-	// Build query for aggregation
-	timestampMinQuery := []bson.M{
-		{"$project": bson.M{"_id": 0, "ts": "$dat.ts"}},
-		{"$unwind": "$ts"},
-		{"$unwind": "$ts"}, // Not an error, must unwind it twice
-		{"$sort": bson.M{"ts": 1}},
-		{"$limit": 1},
+	// Replace existing duration if current duration is higher
+	if duration > uconnMap[srcDstKey].MaxDuration {
+		uconnMap[srcDstKey].MaxDuration = duration
 	}
 
-	var resultMin struct {
-		Timestamp int64 `bson:"ts"`
+	if duration > hostMap[srcKey].MaxDuration {
+		hostMap[srcKey].MaxDuration = duration
 	}
-
-	// get iminimum timestamp
-	// sort by the timestamp, limit it to 1 (only returns first result)
-	err := session.DB(fs.res.DB.GetSelectedDB()).C(collectionName).Pipe(timestampMinQuery).AllowDiskUse().One(&resultMin)
-
-	if err != nil {
-		fs.res.Log.WithFields(log.Fields{
-			"error": err.Error(),
-		}).Error("Could not retrieve minimum timestamp:", err)
-		return
+	if duration > hostMap[dstKey].MaxDuration {
+		hostMap[dstKey].MaxDuration = duration
 	}
+}
 
-	// Build query for aggregation
-	timestampMaxQuery := []bson.M{
-		{"$project": bson.M{"_id": 0, "ts": "$dat.ts"}},
-		{"$unwind": "$ts"},
-		{"$unwind": "$ts"}, // Not an error, must unwind it twice
-		{"$sort": bson.M{"ts": -1}},
-		{"$limit": 1},
+//newHost creates the host record for an address
+func (fs *FSImporter) newHost(uniqueIP data.UniqueIP, ip net.IP) *host.Input {
+	return &host.Input{
+		Host:    uniqueIP,
+		IsLocal: fs.filter.IsInternal(ip),
+		IP4:     util.IsIPv4(uniqueIP.IP),
+		IP4Bin:  util.IPv4ToBinary(ip),
 	}
+}
 
-	var resultMax struct {
-		Timestamp int64 `bson:"ts"`
+//newUconn creates the uconn record for the hosts of a record. IsLocalSrc and
+//IsLocalDst are set based on the InternalSubnets setting.
+func (fs *FSImporter) newUconn(record *analysis.Record) *uconn.Input {
+	return &uconn.Input{
+		Hosts:      record.Hosts,
+		IsLocalSrc: fs.filter.IsInternal(record.SrcIP),
+		IsLocalDst: fs.filter.IsInternal(record.DstIP),
 	}
+}
 
-	// get max timestamp
-	// sort by the timestamp, limit it to 1 (only returns first result)
-	err = session.DB(fs.res.DB.GetSelectedDB()).C(collectionName).Pipe(timestampMaxQuery).AllowDiskUse().One(&resultMax)
+//removeAnalysisChunk .....
+func (fs *FSImporter) removeAnalysisChunk(cid int) error {
 
+	// Set up the remover
+	removerRepo := remover.NewMongoRemover(fs.res)
+	err := removerRepo.Remove(cid)
 	if err != nil {
-		fs.res.Log.WithFields(log.Fields{
-			"error": err.Error(),
-		}).Error("Could not retrieve maximum timestamp:", err)
-		return
+		fs.res.Log.Error(err)
+		return err
 	}
 
-	// set range in metadatabase
-	err = fs.res.MetaDB.AddTSRange(fs.res.DB.GetSelectedDB(), resultMin.Timestamp, resultMax.Timestamp)
-	if err != nil {
-		fs.res.Log.WithFields(log.Fields{
-			"error": err.Error(),
-		}).Error("Could not set ts range in metadatabase: ", err)
-	}
+	fs.res.MetaDB.SetChunk(cid, fs.res.DB.GetSelectedDB(), false)
+
+	return nil
 
 }
 
@@ -103,3 +103,10 @@ func (line *SSL) TargetCollection(config *config.StructureTableCfg) string {
 func (line *SSL) ConvertFromJSON() {
 	line.TimeStamp = convertTimestamp(line.TimeStampGeneric)
 }
+
+//InvalidCert returns true if Zeek reported a problem validating the server's
+//certificate
+func (line *SSL) InvalidCert() bool {
+	status := line.ValidationStatus
+	return status != "ok" && status != "-" && status != "" && status != " "
+}
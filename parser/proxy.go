@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"net"
+	"os"
+	"strings"
+
+	fpt "github.com/activecm/rita/parser/fileparsetypes"
+	"github.com/activecm/rita/parser/parsetypes"
+	"github.com/activecm/rita/pkg/hostname"
+	log "github.com/sirupsen/logrus"
+)
+
+// resolveProxyHostnames gathers the addresses the proxy servers listed by
+// hostname resolved to earlier in the dataset and in the DNS logs of the
+// batch about to be parsed, so traffic sent to them is recognized while the
+// rest of the batch is parsed
+func (fs *FSImporter) resolveProxyHostnames(indexedFiles []*fpt.IndexedFile) {
+	if len(fs.filter.ProxyHostnames()) == 0 {
+		return
+	}
+
+	ips, err := hostname.ResolvedIPs(fs.res, fs.filter.ProxyHostnames())
+	if err != nil {
+		fs.res.Log.WithField("err", err).Error("Could not look up the addresses of the proxy servers listed by hostname")
+	}
+	for _, ip := range ips {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			fs.filter.AddProxyAddress(parsed)
+		}
+	}
+
+	for _, indexedFile := range indexedFiles {
+		if indexedFile.TargetCollection != fs.res.Config.T.Structure.DNSTable {
+			continue
+		}
+
+		fileHandle, err := os.Open(indexedFile.Path)
+		if err != nil {
+			fs.res.Log.WithFields(log.Fields{
+				"file":  indexedFile.Path,
+				"error": err.Error(),
+			}).Error("Could not open file to resolve proxy servers")
+			continue
+		}
+
+		fileScanner, err := getFileScanner(fileHandle)
+		if err != nil {
+			fileHandle.Close()
+			continue
+		}
+
+		for fileScanner.Scan() {
+			datum := parseLine(
+				fileScanner.Text(),
+				indexedFile.GetHeader(),
+				indexedFile.GetFieldMap(),
+				indexedFile.GetBroDataFactory(),
+				indexedFile.IsJSON(),
+				fs.res.Log,
+			)
+			if parseDNS, ok := datum.(*parsetypes.DNS); ok {
+				fs.recordProxyAnswers(parseDNS)
+			}
+		}
+		fileHandle.Close()
+	}
+}
+
+// recordProxyAnswers stores the addresses returned by a DNS lookup of one of
+// the proxy servers listed by hostname
+func (fs *FSImporter) recordProxyAnswers(parseDNS *parsetypes.DNS) {
+	query := strings.TrimSuffix(strings.ToLower(parseDNS.Query), ".")
+	if !stringInSlice(query, fs.filter.ProxyHostnames()) {
+		return
+	}
+	for _, answer := range parseDNS.Answers {
+		if ip := net.ParseIP(answer); ip != nil {
+			fs.filter.AddProxyAddress(ip)
+		}
+	}
+}
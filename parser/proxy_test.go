@@ -0,0 +1,26 @@
+package parser
+
+import (
+	"net"
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/parser/parsetypes"
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsProxyServer(t *testing.T) {
+	conf := &config.Config{}
+	conf.S.Filtering.HTTPProxyServers = []string{"192.168.0.1", "proxy.mydomain.com"}
+	fsTest := &FSImporter{filter: analysis.NewFilter(conf)}
+
+	// only lookups of the listed proxy hostnames are recorded
+	fsTest.recordProxyAnswers(&parsetypes.DNS{Query: "Proxy.MyDomain.com.", Answers: []string{"proxy-1.mydomain.com", "192.168.0.5"}})
+	fsTest.recordProxyAnswers(&parsetypes.DNS{Query: "www.mydomain.com", Answers: []string{"192.168.0.6"}})
+
+	assert.True(t, fsTest.filter.IsProxyServer(net.ParseIP("192.168.0.1")))
+	assert.True(t, fsTest.filter.IsProxyServer(net.ParseIP("192.168.0.5")))
+	assert.False(t, fsTest.filter.IsProxyServer(net.ParseIP("192.168.0.6")))
+	assert.False(t, fsTest.filter.IsProxyServer(net.ParseIP("192.168.0.2")))
+}
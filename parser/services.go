@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+//expectedServiceTable holds the services expected on each port:proto pair and
+//the port:proto pairs each service is expected on
+type expectedServiceTable struct {
+	byPortProto map[string]map[string]struct{}
+	byService   map[string]map[string]struct{}
+}
+
+//newExpectedServiceTable builds the expected service table from entries in the
+//form "port:proto:service". Malformed entries are skipped and returned.
+func newExpectedServiceTable(entries []string) (expectedServiceTable, []string) {
+	table := expectedServiceTable{
+		byPortProto: make(map[string]map[string]struct{}),
+		byService:   make(map[string]map[string]struct{}),
+	}
+	var invalid []string
+
+	for _, entry := range entries {
+		fields := strings.Split(strings.ToLower(strings.TrimSpace(entry)), ":")
+		if len(fields) != 3 || fields[1] == "" || fields[2] == "" {
+			invalid = append(invalid, entry)
+			continue
+		}
+		if _, err := strconv.ParseUint(fields[0], 10, 16); err != nil {
+			invalid = append(invalid, entry)
+			continue
+		}
+
+		portProto := fields[0] + ":" + fields[1]
+		service := fields[2]
+
+		if _, ok := table.byPortProto[portProto]; !ok {
+			table.byPortProto[portProto] = make(map[string]struct{})
+		}
+		table.byPortProto[portProto][service] = struct{}{}
+
+		if _, ok := table.byService[service]; !ok {
+			table.byService[service] = make(map[string]struct{})
+		}
+		table.byService[service][portProto] = struct{}{}
+	}
+
+	return table, invalid
+}
+
+//isUnexpected returns true if the service detected on a port:proto pair is not
+//one of the services expected there, or if a service in the table was detected
+//on a port:proto pair which is not in the table. service may hold several
+//comma separated services, as reported by Zeek. Connections Zeek couldn't
+//identify a service for are never flagged since most are short lived or
+//failed connections rather than a different service.
+func (t expectedServiceTable) isUnexpected(port int, proto string, service string) bool {
+	portProto := strconv.Itoa(port) + ":" + proto
+
+	var services []string
+	for _, s := range strings.Split(service, ",") {
+		if s != "" && s != "-" {
+			services = append(services, s)
+		}
+	}
+	if len(services) == 0 {
+		return false
+	}
+
+	if expected, ok := t.byPortProto[portProto]; ok {
+		match := false
+		for _, s := range services {
+			if _, ok := expected[s]; ok {
+				match = true
+			}
+		}
+		return !match
+	}
+
+	for _, s := range services {
+		if expected, ok := t.byService[s]; ok {
+			if _, ok := expected[portProto]; !ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCaseService struct {
+	port    int
+	proto   string
+	service string
+	out     bool
+	msg     string
+}
+
+func TestExpectedServiceTable(t *testing.T) {
+	table, invalid := newExpectedServiceTable([]string{
+		"80:tcp:http",
+		"443:tcp:ssl",
+		"8443:tcp:ssl",
+		"22:tcp:ssh",
+		"2222:tcp:ssh",
+		"53:udp:dns",
+		"tcp:80:http",
+		"99999:tcp:http",
+	})
+
+	assert.Equal(t, []string{"tcp:80:http", "99999:tcp:http"}, invalid, "malformed entries should be reported")
+
+	testCases := []testCaseService{
+		{80, "tcp", "http", false, "http on 80/tcp is expected"},
+		{8443, "tcp", "ssl", false, "ssl on a configured alternate port is expected"},
+		{2222, "tcp", "ssh", false, "ssh on a configured alternate port is expected"},
+		{443, "tcp", "ssh", true, "ssh on 443/tcp is unexpected"},
+		{443, "tcp", "", false, "an unidentified service on 443/tcp is not flagged"},
+		{22, "tcp", "-", false, "an unset service on 22/tcp is not flagged"},
+		{443, "tcp", "ssl,http", false, "an expected service in a list of services is expected"},
+		{5353, "udp", "dns", true, "dns on a port other than 53/udp is unexpected"},
+		{53, "tcp", "dns", true, "dns is only expected on the configured protocols"},
+		{8080, "tcp", "", false, "an unidentified service on a port not in the table is not flagged"},
+		{9999, "tcp", "irc", false, "a service not in the table is not flagged"},
+	}
+
+	for _, test := range testCases {
+		output := table.isUnexpected(test.port, test.proto, test.service)
+		assert.Equal(t, test.out, output, test.msg)
+	}
+}
@@ -0,0 +1,176 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/resources"
+)
+
+//Definition implements Module using the functions of an analysis package
+type Definition struct {
+	ModuleName string
+	LogInputs  []LogType
+	// names of the modules which must run first
+	Dependencies []string
+	// the kinds of findings the module reports to the threat module
+	Scored []FindingType
+	// gathers the module's findings, required if Scored is set
+	Collect func(res *resources.Resources) ([]Finding, error)
+	// runs after every registered module with Scored set
+	AfterFindings bool
+	// defaults to always enabled
+	IsEnabled func(conf *config.Config) bool
+	Tables    func(conf *config.Config) []string
+	Removal   ChunkRemoval
+	// arrays besides dat in the module's collections holding an entry for
+	// each chunk, the entry is pulled when the chunk is removed
+	ChunkArrays []string
+	// creates the parser which aggregates each batch of logs for the module
+	Parse   func(res *resources.Resources, filter *Filter) Parser
+	Indexes func(res *resources.Resources) error
+	// reports whether the batch holds anything for the module to analyze,
+	// defaults to checking the module's parsed results if it has a parser
+	// and always running the module otherwise
+	HasData func(batch *Batch) bool
+	// printed as "No <Label> data to analyze" when HasData returns false
+	Label string
+	// printed after the message above
+	Hint   string
+	Upsert func(res *resources.Resources, batch *Batch)
+	Report []Page
+	// the show command printing the module's results
+	Show *Table
+	// loads the module's stored results into a batch for re-analysis
+	Load func(res *resources.Resources, batch *Batch) error
+	// removes the module's results before re-analysis. Modules without one
+	// can only be re-analyzed if they are rebuilt on every run.
+	Reset func(res *resources.Resources) error
+}
+
+//Name uniquely identifies the module
+func (d *Definition) Name() string { return d.ModuleName }
+
+//Inputs lists the Zeek logs the module's parser is handed records from
+func (d *Definition) Inputs() []LogType { return d.LogInputs }
+
+//After lists the names of the modules which must analyze a Batch first
+func (d *Definition) After() []string {
+	if !d.AfterFindings {
+		return d.Dependencies
+	}
+	after := append([]string{}, d.Dependencies...)
+	for _, module := range registry {
+		if module.ReportsFindings() && module.Name() != d.ModuleName {
+			after = append(after, module.Name())
+		}
+	}
+	return after
+}
+
+//ReportsFindings returns true if the module's results are scored as findings
+func (d *Definition) ReportsFindings() bool { return len(d.Scored) > 0 }
+
+//FindingTypes lists the kinds of findings the module reports
+func (d *Definition) FindingTypes() []FindingType { return d.Scored }
+
+//Findings gathers the module's findings from the selected database
+func (d *Definition) Findings(res *resources.Resources) ([]Finding, error) {
+	if d.Collect == nil {
+		return nil, nil
+	}
+	return d.Collect(res)
+}
+
+//Enabled returns true if the module should run with the given config
+func (d *Definition) Enabled(conf *config.Config) bool {
+	return d.IsEnabled == nil || d.IsEnabled(conf)
+}
+
+//Collections lists the collections the module writes to
+func (d *Definition) Collections(conf *config.Config) []string {
+	if d.Tables == nil {
+		return nil
+	}
+	return d.Tables(conf)
+}
+
+//ChunkRemoval describes how the module's results are updated when a chunk
+//of a rolling dataset is replaced
+func (d *Definition) ChunkRemoval() ChunkRemoval { return d.Removal }
+
+//ChunkFields lists the arrays besides dat in the module's collections which
+//hold an entry for each chunk
+func (d *Definition) ChunkFields() []string { return d.ChunkArrays }
+
+//NewParser returns the parser which aggregates a batch of logs for the
+//module or nil if the module has none
+func (d *Definition) NewParser(res *resources.Resources, filter *Filter) Parser {
+	if d.Parse == nil {
+		return nil
+	}
+	return d.Parse(res, filter)
+}
+
+//CreateIndexes creates the module's collections and their indexes
+func (d *Definition) CreateIndexes(res *resources.Resources) error {
+	if d.Indexes == nil {
+		return nil
+	}
+	return d.Indexes(res)
+}
+
+//Analyze creates the module's indexes and upserts the results for a Batch
+func (d *Definition) Analyze(res *resources.Resources, batch *Batch) {
+	if !d.hasData(batch) {
+		if d.Label != "" {
+			fmt.Println("\t[!] No " + d.Label + " data to analyze")
+		}
+		if d.Hint != "" {
+			fmt.Println(d.Hint)
+		}
+		return
+	}
+
+	err := d.CreateIndexes(res)
+	if err != nil {
+		res.Log.Error(err)
+	}
+	d.Upsert(res, batch)
+}
+
+//hasData reports whether the batch holds anything for the module to analyze
+func (d *Definition) hasData(batch *Batch) bool {
+	if d.HasData != nil {
+		return d.HasData(batch)
+	}
+	return d.Parse == nil || batch.Len(d.ModuleName) > 0
+}
+
+//Pages lists the html report pages which render the module's results
+func (d *Definition) Pages() []Page { return d.Report }
+
+//Table describes the show command which prints the module's results
+func (d *Definition) Table() *Table { return d.Show }
+
+//Reload adds the module's stored results to a Batch
+func (d *Definition) Reload(res *resources.Resources, batch *Batch) error {
+	if d.Load == nil {
+		return nil
+	}
+	return d.Load(res, batch)
+}
+
+//Reanalyzable returns true if the module's results can be rebuilt from the
+//data stored in the dataset
+func (d *Definition) Reanalyzable() bool {
+	return d.Upsert != nil && (d.Reset != nil || d.Removal == Rebuild)
+}
+
+//Clear removes the module's results before they are re-analyzed
+func (d *Definition) Clear(res *resources.Resources) error {
+	if d.Reset == nil {
+		return nil
+	}
+	return d.Reset(res)
+}
@@ -0,0 +1,178 @@
+package analysis
+
+import (
+	"net"
+	"strings"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/util"
+)
+
+//Filter applies the Filtering section of the config to the records handed
+//to the module parsers
+type Filter struct {
+	internal             []*net.IPNet
+	alwaysIncluded       []*net.IPNet
+	neverIncluded        []*net.IPNet
+	alwaysIncludedDomain []string
+	neverIncludedDomain  []string
+	httpProxyServers     []*net.IPNet
+	httpProxyHostnames   []string
+	proxyAddresses       map[string]bool // addresses the proxy hostnames resolved to
+}
+
+//NewFilter creates a Filter from the config
+func NewFilter(conf *config.Config) *Filter {
+	proxySubnets, proxyHostnames := splitProxyServers(conf.S.Filtering.HTTPProxyServers)
+
+	return &Filter{
+		internal:             util.ParseSubnets(conf.S.Filtering.InternalSubnets),
+		alwaysIncluded:       util.ParseSubnets(conf.S.Filtering.AlwaysInclude),
+		neverIncluded:        util.ParseSubnets(conf.S.Filtering.NeverInclude),
+		alwaysIncludedDomain: conf.S.Filtering.AlwaysIncludeDomain,
+		neverIncludedDomain:  conf.S.Filtering.NeverIncludeDomain,
+		httpProxyServers:     util.ParseSubnets(proxySubnets),
+		httpProxyHostnames:   proxyHostnames,
+		proxyAddresses:       make(map[string]bool),
+	}
+}
+
+//InternalSubnets returns the internal subnets from the config
+func (f *Filter) InternalSubnets() []*net.IPNet {
+	return f.internal
+}
+
+//IsInternal returns true if an IP is in one of the internal subnets
+func (f *Filter) IsInternal(ip net.IP) bool {
+	return util.ContainsIP(f.internal, ip)
+}
+
+// FilterConnPair returns true if a connection pair is filtered/excluded.
+// This is determined by the following rules, in order:
+//   1. Not filtered if either IP is on the AlwaysInclude list
+//   2. Filtered if either IP is on the NeverInclude list
+//   3. Not filtered if InternalSubnets is empty
+//   4. Filtered if both IPs are internal or both are external
+//   5. Not filtered in all other cases
+func (f *Filter) FilterConnPair(srcIP net.IP, dstIP net.IP) bool {
+	// check if on always included list
+	isSrcIncluded := util.ContainsIP(f.alwaysIncluded, srcIP)
+	isDstIncluded := util.ContainsIP(f.alwaysIncluded, dstIP)
+
+	// check if on never included list
+	isSrcExcluded := util.ContainsIP(f.neverIncluded, srcIP)
+	isDstExcluded := util.ContainsIP(f.neverIncluded, dstIP)
+
+	// if either IP is on the AlwaysInclude list, filter does not apply
+	if isSrcIncluded || isDstIncluded {
+		return false
+	}
+
+	// if either IP is on the NeverInclude list, filter applies
+	if isSrcExcluded || isDstExcluded {
+		return true
+	}
+
+	// if no internal subnets are defined, filter does not apply
+	// this is was the default behavior before InternalSubnets was added
+	if len(f.internal) == 0 {
+		return false
+	}
+
+	// check if src and dst are internal
+	isSrcInternal := util.ContainsIP(f.internal, srcIP)
+	isDstInternal := util.ContainsIP(f.internal, dstIP)
+
+	// if both addresses are internal, filter applies
+	if isSrcInternal && isDstInternal {
+		return true
+	}
+
+	// if both addresses are external, filter applies
+	if (!isSrcInternal) && (!isDstInternal) {
+		return true
+	}
+
+	// default to not filter the connection pair
+	return false
+}
+
+// FilterSingleIP returns true if an IP is filtered/excluded.
+// This is determined by the following rules, in order:
+//   1. Not filtered IP is on the AlwaysInclude list
+//   2. Filtered IP is on the NeverInclude list
+//   3. Not filtered in all other cases
+func (f *Filter) FilterSingleIP(IP net.IP) bool {
+	// check if on always included list
+	if util.ContainsIP(f.alwaysIncluded, IP) {
+		return false
+	}
+
+	// check if on never included list
+	if util.ContainsIP(f.neverIncluded, IP) {
+		return true
+	}
+
+	// default to not filter the IP address
+	return false
+}
+
+// FilterDomain returns true if a domain is filtered/excluded.
+// This is determined by the following rules, in order:
+//   1. Not filtered if domain is on the AlwaysInclude list
+//   2. Filtered if domain is on the NeverInclude list
+//   5. Not filtered in all other cases
+func (f *Filter) FilterDomain(domain string) bool {
+	// check if on always included list
+	isDomainIncluded := util.ContainsDomain(f.alwaysIncludedDomain, domain)
+
+	// check if on never included list
+	isDomainExcluded := util.ContainsDomain(f.neverIncludedDomain, domain)
+
+	// if either IP is on the AlwaysInclude list, filter does not apply
+	if isDomainIncluded {
+		return false
+	}
+
+	// if either IP is on the NeverInclude list, filter applies
+	if isDomainExcluded {
+		return true
+	}
+
+	// default to not filter the connection pair
+	return false
+}
+
+func (f *Filter) checkIfProxyServer(host net.IP) bool {
+	return util.ContainsIP(f.httpProxyServers, host)
+}
+
+// IsProxyServer returns true if host is a listed proxy server or one of the
+// addresses the proxy servers listed by hostname resolved to
+func (f *Filter) IsProxyServer(host net.IP) bool {
+	return f.checkIfProxyServer(host) || f.proxyAddresses[host.String()]
+}
+
+// ProxyHostnames returns the proxy servers listed by hostname
+func (f *Filter) ProxyHostnames() []string {
+	return f.httpProxyHostnames
+}
+
+// AddProxyAddress records an address one of the proxy servers listed by
+// hostname resolved to
+func (f *Filter) AddProxyAddress(ip net.IP) {
+	f.proxyAddresses[ip.String()] = true
+}
+
+// splitProxyServers separates the HTTPProxyServers entries which are IP
+// addresses or CIDR ranges from those which are hostnames
+func splitProxyServers(entries []string) (subnets []string, hostnames []string) {
+	for _, entry := range entries {
+		if _, _, err := net.ParseCIDR(entry); err == nil || net.ParseIP(entry) != nil {
+			subnets = append(subnets, entry)
+			continue
+		}
+		hostnames = append(hostnames, strings.TrimSuffix(strings.ToLower(entry), "."))
+	}
+	return subnets, hostnames
+}
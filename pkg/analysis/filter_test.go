@@ -1,4 +1,4 @@
-package parser
+package analysis
 
 import (
 	"net"
@@ -35,10 +35,7 @@ type testCaseSingleIP struct {
 
 func TestCheckIfProxyServer(t *testing.T) {
 
-	fsTest := &FSImporter{
-		res:              nil,
-		indexingThreads:  1,
-		parseThreads:     1,
+	filter := &Filter{
 		httpProxyServers: util.ParseSubnets([]string{"1.1.1.1", "1.1.1.2/32", "1.2.0.0/16"}),
 	}
 
@@ -56,20 +53,17 @@ func TestCheckIfProxyServer(t *testing.T) {
 	}
 
 	for _, test := range testCases {
-		output := fsTest.checkIfProxyServer(net.ParseIP(test.ip))
+		output := filter.checkIfProxyServer(net.ParseIP(test.ip))
 		assert.Equal(t, test.out, output, test.msg)
 	}
 }
 
 func TestFilterConnPairWithInternalSubnets(t *testing.T) {
 
-	fsTest := &FSImporter{
-		res:             nil,
-		indexingThreads: 1,
-		parseThreads:    1,
-		internal:        util.ParseSubnets([]string{"10.0.0.0/8"}),
-		alwaysIncluded:  util.ParseSubnets([]string{"10.0.0.1/32", "10.0.0.3/32", "1.1.1.1/32", "1.1.1.3/32"}),
-		neverIncluded:   util.ParseSubnets([]string{"10.0.0.2/32", "10.0.0.3/32", "1.1.1.2/32", "1.1.1.3/32"}),
+	filter := &Filter{
+		internal:       util.ParseSubnets([]string{"10.0.0.0/8"}),
+		alwaysIncluded: util.ParseSubnets([]string{"10.0.0.1/32", "10.0.0.3/32", "1.1.1.1/32", "1.1.1.3/32"}),
+		neverIncluded:  util.ParseSubnets([]string{"10.0.0.2/32", "10.0.0.3/32", "1.1.1.2/32", "1.1.1.3/32"}),
 	}
 
 	// all permutations of being on internal, always, and never lists
@@ -115,17 +109,14 @@ func TestFilterConnPairWithInternalSubnets(t *testing.T) {
 	}
 
 	for _, test := range testCases {
-		output := fsTest.filterConnPair(net.ParseIP(test.src), net.ParseIP(test.dst))
+		output := filter.FilterConnPair(net.ParseIP(test.src), net.ParseIP(test.dst))
 		assert.Equal(t, test.out, output, test.msg)
 	}
 }
 
 func TestFilterConnPairWithoutInternalSubnets(t *testing.T) {
 
-	fsTest := &FSImporter{
-		res:             nil,
-		indexingThreads: 1,
-		parseThreads:    1,
+	filter := &Filter{
 		// purposely omitting internal subnet definition
 		alwaysIncluded: util.ParseSubnets([]string{"10.0.0.1/32", "10.0.0.3/32", "1.1.1.1/32", "1.1.1.3/32"}),
 		neverIncluded:  util.ParseSubnets([]string{"10.0.0.4/32", "10.0.0.3/32", "1.1.1.2/32", "1.1.1.3/32"}),
@@ -148,17 +139,14 @@ func TestFilterConnPairWithoutInternalSubnets(t *testing.T) {
 	}
 
 	for _, test := range testCases {
-		output := fsTest.filterConnPair(net.ParseIP(test.src), net.ParseIP(test.dst))
+		output := filter.FilterConnPair(net.ParseIP(test.src), net.ParseIP(test.dst))
 		assert.Equal(t, test.out, output, test.msg)
 	}
 }
 
 func TestFilterDomain(t *testing.T) {
 
-	fsTest := &FSImporter{
-		res:                  nil,
-		indexingThreads:      1,
-		parseThreads:         1,
+	filter := &Filter{
 		internal:             util.ParseSubnets([]string{"10.0.0.0/8"}),
 		alwaysIncluded:       util.ParseSubnets([]string{"10.0.0.1/32", "10.0.0.3/32", "1.1.1.1/32", "1.1.1.3/32"}),
 		neverIncluded:        util.ParseSubnets([]string{"10.0.0.2/32", "10.0.0.3/32", "1.1.1.2/32", "1.1.1.3/32"}),
@@ -182,17 +170,14 @@ func TestFilterDomain(t *testing.T) {
 	}
 
 	for _, test := range testCases {
-		output := fsTest.filterDomain(test.domain)
+		output := filter.FilterDomain(test.domain)
 		assert.Equal(t, test.out, output, test.msg)
 	}
 }
 
 func TestFilterSingleIP(t *testing.T) {
 
-	fsTest := &FSImporter{
-		res:             nil,
-		indexingThreads: 1,
-		parseThreads:    1,
+	filter := &Filter{
 		// purposely omitting internal subnet definition
 		alwaysIncluded: util.ParseSubnets([]string{"10.0.0.1/32", "10.0.0.3/32", "1.1.1.1/32", "1.1.1.3/32"}),
 		neverIncluded:  util.ParseSubnets([]string{"10.0.0.4/32", "10.0.0.3/32", "1.1.1.2/32", "1.1.1.3/32"}),
@@ -211,7 +196,14 @@ func TestFilterSingleIP(t *testing.T) {
 	}
 
 	for _, test := range testCases {
-		output := fsTest.filterSingleIP(net.ParseIP(test.ip))
+		output := filter.FilterSingleIP(net.ParseIP(test.ip))
 		assert.Equal(t, test.out, output, test.msg)
 	}
 }
+
+func TestSplitProxyServers(t *testing.T) {
+	subnets, hostnames := splitProxyServers([]string{"192.168.0.1", "10.10.0.0/16", "Proxy.MyDomain.com.", "2001:db8::1"})
+
+	assert.Equal(t, []string{"192.168.0.1", "10.10.0.0/16", "2001:db8::1"}, subnets)
+	assert.Equal(t, []string{"proxy.mydomain.com"}, hostnames)
+}
@@ -0,0 +1,47 @@
+package analysis
+
+import (
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/suppression"
+)
+
+type (
+	//FindingType is a kind of finding an analysis module reports to the
+	//threat module
+	FindingType struct {
+		Name string // stored with each finding, e.g. beacon
+		// key of the finding's weight under Threat.Weights in the config
+		WeightKey string
+		// weight used when the config doesn't set one, from 0 (ignored) to 1
+		Weight float64
+	}
+
+	//Finding is a result of an analysis module which implicates a host. The
+	//threat module only scores findings against internal hosts.
+	Finding struct {
+		Host   data.UniqueIP
+		Type   string  // Name of the finding's FindingType
+		Score  float64 // the module's own score scaled to 0-1
+		Detail string
+		// checked against the active suppressions
+		Match suppression.Finding
+	}
+)
+
+//Weights maps each kind of finding reported by the registered modules to how
+//much it contributes to the threat score. Weights set in the config take
+//precedence over the ones the modules declare.
+func Weights(conf *config.Config) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, module := range Registered() {
+		for _, kind := range module.FindingTypes() {
+			weight, ok := conf.S.Threat.Weights[kind.WeightKey]
+			if !ok {
+				weight = kind.Weight
+			}
+			weights[kind.Name] = weight
+		}
+	}
+	return weights
+}
@@ -0,0 +1,280 @@
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/pkg/geoip"
+	"github.com/activecm/rita/pkg/host"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/pkg/uconn"
+	"github.com/activecm/rita/resources"
+)
+
+type (
+	//Module is an analysis module which turns each batch of parsed logs into
+	//one or more collections in the dataset
+	Module interface {
+		//Name uniquely identifies the module
+		Name() string
+		//Inputs lists the Zeek logs the module's parser is handed records from
+		Inputs() []LogType
+		//After lists the names of the modules which must analyze a Batch
+		//before this module does
+		After() []string
+		//ReportsFindings returns true if the module's results are scored as
+		//findings against each host by the threat module
+		ReportsFindings() bool
+		//FindingTypes lists the kinds of findings the module reports
+		FindingTypes() []FindingType
+		//Findings gathers the module's findings from the selected database
+		Findings(res *resources.Resources) ([]Finding, error)
+		//Enabled returns true if the module should run with the given config
+		Enabled(conf *config.Config) bool
+		//Collections lists the collections the module writes to
+		Collections(conf *config.Config) []string
+		//ChunkRemoval describes how the module's results are updated when a
+		//chunk of a rolling dataset is replaced
+		ChunkRemoval() ChunkRemoval
+		//ChunkFields lists the arrays besides dat in the module's collections
+		//which hold an entry for each chunk
+		ChunkFields() []string
+		//NewParser returns the parser which aggregates a batch of logs for
+		//the module or nil if the module reads the other modules' results
+		NewParser(res *resources.Resources, filter *Filter) Parser
+		//CreateIndexes creates the module's collections and their indexes
+		CreateIndexes(res *resources.Resources) error
+		//Analyze analyzes a Batch and writes the results to the dataset
+		Analyze(res *resources.Resources, batch *Batch)
+		//Pages lists the html report pages which render the module's results
+		Pages() []Page
+		//Table describes the show command which prints the module's results
+		//or is nil if the module has no such command
+		Table() *Table
+		//Reload adds the module's stored results to a Batch so the modules
+		//which depend on it can be re-analyzed without re-importing logs
+		Reload(res *resources.Resources, batch *Batch) error
+		//Reanalyzable returns true if the module's results can be rebuilt
+		//from the data stored in the dataset
+		Reanalyzable() bool
+		//Clear removes the module's results before they are re-analyzed
+		Clear(res *resources.Resources) error
+	}
+
+	//LogType names a Zeek log an analysis module reads
+	LogType string
+
+	//ChunkRemoval describes how the results of an analysis module are
+	//updated when a chunk of a rolling dataset is replaced
+	ChunkRemoval int
+
+	//Batch holds the records parsed from a batch of log files. The importer
+	//builds the hosts and uconns every module relies on while the other
+	//results are stored under the name of the module which parsed them.
+	Batch struct {
+		Uconns  map[string]*uconn.Input
+		Hosts   map[string]*host.Input
+		results map[string]interface{}
+	}
+
+	//Page is an html report page rendering the results of an analysis module
+	Page struct {
+		Name   string                     // used in error messages
+		Render func(*ReportContext) error // writes the page to the working directory
+	}
+
+	//Table describes a show command which prints the results of an analysis
+	//module as a table
+	Table struct {
+		Command string // e.g. show-scans
+		Usage   string
+		// lists the column names
+		Header func(showNetNames bool) []string
+		// reads the module's results from the selected database
+		Rows func(res *resources.Resources, limit int, noLimit bool, showNetNames bool) ([]Row, error)
+	}
+
+	//Row is a single result printed by a show command
+	Row struct {
+		Cells []string
+		// checked against the active suppressions
+		Match suppression.Finding
+	}
+
+	//ReportContext holds the options an html report page is rendered with
+	ReportContext struct {
+		DB           string
+		ShowNetNames bool
+		Suppressions *suppression.Matcher
+		Filter       geoip.Filter
+		Res          *resources.Resources
+	}
+)
+
+const (
+	//ConnLog is Zeek's conn.log
+	ConnLog LogType = "conn"
+	//DNSLog is Zeek's dns.log
+	DNSLog LogType = "dns"
+	//HTTPLog is Zeek's http.log
+	HTTPLog LogType = "http"
+	//SSLLog is Zeek's ssl.log
+	SSLLog LogType = "ssl"
+)
+
+const (
+	//RemoveChunk removes the chunk's entries from the module's collections
+	RemoveChunk ChunkRemoval = iota
+	//Rebuild leaves the module's collections alone since they are rebuilt
+	//from the other collections on every import
+	Rebuild
+	//Derived leaves the module's collections alone since its results are
+	//stored in and removed with another module's collections
+	Derived
+)
+
+//Store keeps a module's results in the batch
+func (b *Batch) Store(module string, results interface{}) {
+	if b.results == nil {
+		b.results = make(map[string]interface{})
+	}
+	b.results[module] = results
+}
+
+//Result returns the results a module stored in the batch
+func (b *Batch) Result(module string) interface{} {
+	return b.results[module]
+}
+
+//Len returns the number of results a module stored in the batch
+func (b *Batch) Len(module string) int {
+	results := reflect.ValueOf(b.results[module])
+	switch results.Kind() {
+	case reflect.Map, reflect.Slice:
+		return results.Len()
+	}
+	return 0
+}
+
+var registry []Module
+
+//Register adds an analysis module to the registry. Modules registered
+//earlier run first unless their dependencies require otherwise.
+func Register(module Module) {
+	for _, registered := range registry {
+		if registered.Name() == module.Name() {
+			panic("analysis: module registered twice: " + module.Name())
+		}
+	}
+	registry = append(registry, module)
+}
+
+//Registered returns every registered module in the order they are run
+func Registered() []Module {
+	ordered, err := order(registry)
+	if err != nil {
+		panic("analysis: " + err.Error())
+	}
+	return ordered
+}
+
+//Modules returns the modules enabled by the config in the order they are run
+func Modules(conf *config.Config) []Module {
+	var enabled []Module
+	for _, module := range Registered() {
+		if module.Enabled(conf) {
+			enabled = append(enabled, module)
+		}
+	}
+	return enabled
+}
+
+//Lookup returns the registered module with the given name
+func Lookup(name string) (Module, bool) {
+	for _, module := range registry {
+		if module.Name() == name {
+			return module, true
+		}
+	}
+	return nil, false
+}
+
+//Run analyzes a Batch with each module enabled by the config
+func Run(res *resources.Resources, batch *Batch) {
+	for _, module := range Modules(res.Config) {
+		module.Analyze(res, batch)
+	}
+}
+
+//ChunkedCollections returns the collections of every registered module
+//which must have a chunk removed when it is replaced
+func ChunkedCollections(conf *config.Config) []string {
+	var collections []string
+	for _, module := range Registered() {
+		if module.ChunkRemoval() == RemoveChunk {
+			collections = append(collections, module.Collections(conf)...)
+		}
+	}
+	return collections
+}
+
+//ChunkFields returns the arrays besides dat which hold an entry for each
+//chunk, keyed by the collection holding them
+func ChunkFields(conf *config.Config) map[string][]string {
+	fields := make(map[string][]string)
+	for _, module := range Registered() {
+		for _, collection := range module.Collections(conf) {
+			fields[collection] = append(fields[collection], module.ChunkFields()...)
+		}
+	}
+	return fields
+}
+
+//order sorts the modules so each runs after the modules it depends on while
+//otherwise keeping them in registration order
+func order(modules []Module) ([]Module, error) {
+	byName := make(map[string]Module)
+	for _, module := range modules {
+		byName[module.Name()] = module
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var ordered []Module
+
+	var visit func(module Module) error
+	visit = func(module Module) error {
+		switch state[module.Name()] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("module %s depends on itself", module.Name())
+		}
+
+		state[module.Name()] = visiting
+		for _, name := range module.After() {
+			dependency, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("module %s depends on unknown module %s", module.Name(), name)
+			}
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		state[module.Name()] = visited
+		ordered = append(ordered, module)
+		return nil
+	}
+
+	for _, module := range modules {
+		if err := visit(module); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,111 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/stretchr/testify/require"
+)
+
+func names(modules []Module) []string {
+	var out []string
+	for _, module := range modules {
+		out = append(out, module.Name())
+	}
+	return out
+}
+
+func TestOrder(t *testing.T) {
+	modules := []Module{
+		&Definition{ModuleName: "beacon", Dependencies: []string{"uconn"}},
+		&Definition{ModuleName: "host"},
+		&Definition{ModuleName: "uconn", Dependencies: []string{"host"}},
+		&Definition{ModuleName: "threat", Dependencies: []string{"beacon", "scan"}},
+		&Definition{ModuleName: "scan"},
+	}
+
+	ordered, err := order(modules)
+	require.NoError(t, err)
+	// dependencies are moved forward, everything else keeps its place
+	require.Equal(t, []string{"host", "uconn", "beacon", "scan", "threat"}, names(ordered))
+
+	_, err = order([]Module{
+		&Definition{ModuleName: "a", Dependencies: []string{"b"}},
+		&Definition{ModuleName: "b", Dependencies: []string{"a"}},
+	})
+	require.Error(t, err)
+
+	_, err = order([]Module{&Definition{ModuleName: "a", Dependencies: []string{"missing"}}})
+	require.Error(t, err)
+}
+
+func TestChunkedCollections(t *testing.T) {
+	saved := registry
+	defer func() { registry = saved }()
+	registry = nil
+
+	table := func(name string) func(*config.Config) []string {
+		return func(*config.Config) []string { return []string{name} }
+	}
+	Register(&Definition{ModuleName: "uconn", Tables: table("uconn"), Removal: RemoveChunk})
+	Register(&Definition{ModuleName: "blacklist", Tables: table("host"), Removal: Derived})
+	Register(&Definition{ModuleName: "baseline", Tables: table("host"), Removal: Derived, ChunkArrays: []string{"baseline.dat"}})
+	Register(&Definition{ModuleName: "threat", Tables: table("threat"), Removal: Rebuild})
+	Register(&Definition{
+		ModuleName: "beacon",
+		Tables:     table("beacon"),
+		Removal:    RemoveChunk,
+		IsEnabled:  func(*config.Config) bool { return false },
+	})
+
+	// disabled modules may still hold results from earlier imports
+	require.Equal(t, []string{"uconn", "beacon"}, ChunkedCollections(&config.Config{}))
+	require.Equal(t, []string{"uconn", "blacklist", "baseline", "threat"}, names(Modules(&config.Config{})))
+
+	// chunk entries outside dat are only pulled from the declaring module's collections
+	fields := ChunkFields(&config.Config{})
+	require.Equal(t, []string{"baseline.dat"}, fields["host"])
+	require.Empty(t, fields["uconn"])
+
+	require.Panics(t, func() { Register(&Definition{ModuleName: "uconn"}) })
+}
+
+func TestAfterFindings(t *testing.T) {
+	saved := registry
+	defer func() { registry = saved }()
+	registry = nil
+
+	Register(&Definition{ModuleName: "host"})
+	Register(&Definition{ModuleName: "threat", Dependencies: []string{"host"}, AfterFindings: true})
+	Register(&Definition{ModuleName: "beacon", Scored: []FindingType{{Name: "beacon"}}})
+	Register(&Definition{ModuleName: "scan", Scored: []FindingType{{Name: "scan"}}})
+
+	threat, ok := Lookup("threat")
+	require.True(t, ok)
+	require.Equal(t, []string{"host", "beacon", "scan"}, threat.After())
+
+	// finding modules registered later still run first
+	require.Equal(t, []string{"host", "beacon", "scan", "threat"}, names(Registered()))
+}
+
+func TestWeights(t *testing.T) {
+	saved := registry
+	defer func() { registry = saved }()
+	registry = nil
+
+	Register(&Definition{ModuleName: "beacon", Scored: []FindingType{
+		{Name: "beacon", WeightKey: "Beacon", Weight: 1},
+		{Name: "strobe", WeightKey: "Strobe", Weight: 0.5},
+	}})
+	// disabled modules are weighted too since their findings may be stored
+	Register(&Definition{
+		ModuleName: "scan",
+		Scored:     []FindingType{{Name: "scan", WeightKey: "Scan", Weight: 0.7}},
+		IsEnabled:  func(*config.Config) bool { return false },
+	})
+
+	conf := &config.Config{}
+	conf.S.Threat.Weights = map[string]float64{"Strobe": 0.2, "Scan": 0}
+
+	require.Equal(t, map[string]float64{"beacon": 1, "strobe": 0.2, "scan": 0}, Weights(conf))
+}
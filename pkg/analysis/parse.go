@@ -0,0 +1,143 @@
+package analysis
+
+import (
+	"net"
+	"strings"
+
+	"github.com/activecm/rita/parser/parsetypes"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/resources"
+	"github.com/activecm/rita/util"
+)
+
+type (
+	//Parser aggregates the log records of a batch for an analysis module.
+	//The importer only hands a parser records from the logs listed in its
+	//module's Inputs and serializes the calls, so parsers don't need to lock.
+	Parser interface {
+		//Parse aggregates a single log record
+		Parse(record *Record)
+		//Flush returns the aggregated results once every file in the batch
+		//has been parsed. The importer stores them in the batch under the
+		//module's name. The batch's Hosts and Uconns are complete by then.
+		Flush(batch *Batch) interface{}
+	}
+
+	//Record is a log record along with the hosts it involves. Only the field
+	//matching Log is set.
+	Record struct {
+		Log  LogType
+		Conn *parsetypes.Conn
+		DNS  *parsetypes.DNS
+		HTTP *parsetypes.HTTP
+		SSL  *parsetypes.SSL
+
+		SrcIP net.IP
+		DstIP net.IP
+		Src   data.UniqueIP
+		Dst   data.UniqueIP
+		Hosts data.UniqueIPPair
+
+		// the query of a DNS record, the host an HTTP request was sent to,
+		// or the server name of an SSL record
+		FQDN string
+
+		// true if the record is excluded by the Filtering section of the
+		// config. Conn and SSL records are filtered by their hosts, DNS
+		// records by their query and client, and HTTP records by their hosts
+		// and the host they were sent to.
+		Filtered bool
+	}
+)
+
+//NewRecord fills in the hosts of a log record and checks it against the
+//filter
+func NewRecord(filter *Filter, datum interface{}) *Record {
+	var record *Record
+	var src, dst, agentUUID, agentHostname string
+
+	switch parsed := datum.(type) {
+	case *parsetypes.Conn:
+		record = &Record{Log: ConnLog, Conn: parsed}
+		src, dst, agentUUID, agentHostname = parsed.Source, parsed.Destination, parsed.AgentUUID, parsed.AgentHostname
+	case *parsetypes.DNS:
+		record = &Record{Log: DNSLog, DNS: parsed, FQDN: parsed.Query}
+		src, dst, agentUUID, agentHostname = parsed.Source, parsed.Destination, parsed.AgentUUID, parsed.AgentHostname
+	case *parsetypes.HTTP:
+		record = &Record{Log: HTTPLog, HTTP: parsed, FQDN: parsed.Host}
+		// CONNECT requests carry the host:port to tunnel to
+		if parsed.Method == "CONNECT" {
+			record.FQDN = util.ConnectTarget(parsed.Host)
+		}
+		src, dst, agentUUID, agentHostname = parsed.Source, parsed.Destination, parsed.AgentUUID, parsed.AgentHostname
+	case *parsetypes.SSL:
+		record = &Record{Log: SSLLog, SSL: parsed, FQDN: parsed.ServerName}
+		src, dst, agentUUID, agentHostname = parsed.Source, parsed.Destination, parsed.AgentUUID, parsed.AgentHostname
+	default:
+		return nil
+	}
+
+	// parse addresses into binary format
+	record.SrcIP = net.ParseIP(src)
+	record.DstIP = net.ParseIP(dst)
+
+	// disambiguate addresses which are not publicly routable
+	record.Src = data.NewUniqueIP(record.SrcIP, agentUUID, agentHostname)
+	record.Dst = data.NewUniqueIP(record.DstIP, agentUUID, agentHostname)
+	record.Hosts = data.NewUniqueIPPair(record.Src, record.Dst)
+
+	switch record.Log {
+	case DNSLog:
+		record.Filtered = filter.FilterDomain(record.FQDN) || filter.FilterSingleIP(record.SrcIP)
+	case HTTPLog:
+		record.Filtered = filter.FilterDomain(record.FQDN) || filter.FilterConnPair(record.SrcIP, record.DstIP)
+	default:
+		record.Filtered = filter.FilterConnPair(record.SrcIP, record.DstIP)
+	}
+	return record
+}
+
+//IsLookup returns true if the record is a DNS query for a domain. The
+//blank queries and reverse lookups Zeek logs are left out.
+func (r *Record) IsLookup() bool {
+	return r.Log == DNSLog && r.FQDN != "" && !strings.HasSuffix(r.FQDN, "in-addr.arpa")
+}
+
+//Parsers hands each record parsed from a batch of logs to the parsers of the
+//enabled modules which read its log
+type Parsers struct {
+	modules []Module
+	parsers []Parser
+	byLog   map[LogType][]Parser
+}
+
+//NewParsers creates the parsers of the modules enabled by the config
+func NewParsers(res *resources.Resources, filter *Filter) *Parsers {
+	p := &Parsers{byLog: make(map[LogType][]Parser)}
+	for _, module := range Modules(res.Config) {
+		parser := module.NewParser(res, filter)
+		if parser == nil {
+			continue
+		}
+		p.modules = append(p.modules, module)
+		p.parsers = append(p.parsers, parser)
+		for _, log := range module.Inputs() {
+			p.byLog[log] = append(p.byLog[log], parser)
+		}
+	}
+	return p
+}
+
+//Parse hands a record to the parsers which read its log
+func (p *Parsers) Parse(record *Record) {
+	for _, parser := range p.byLog[record.Log] {
+		parser.Parse(record)
+	}
+}
+
+//Flush stores the results of every parser in the batch
+func (p *Parsers) Flush(batch *Batch) {
+	for i, parser := range p.parsers {
+		batch.Store(p.modules[i].Name(), parser.Flush(batch))
+	}
+}
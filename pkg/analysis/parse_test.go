@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/parser/parsetypes"
+	"github.com/activecm/rita/resources"
+	"github.com/stretchr/testify/require"
+)
+
+//countParser counts the records handed to it
+type countParser struct {
+	logs []LogType
+}
+
+func (p *countParser) Parse(record *Record) { p.logs = append(p.logs, record.Log) }
+
+func (p *countParser) Flush(batch *Batch) interface{} { return p.logs }
+
+func TestParsers(t *testing.T) {
+	saved := registry
+	defer func() { registry = saved }()
+	registry = nil
+
+	counter := func(res *resources.Resources, filter *Filter) Parser { return &countParser{} }
+	Register(&Definition{ModuleName: "host"})
+	Register(&Definition{ModuleName: "dns", LogInputs: []LogType{DNSLog}, Parse: counter})
+	Register(&Definition{ModuleName: "tls", LogInputs: []LogType{ConnLog, SSLLog}, Parse: counter})
+	Register(&Definition{
+		ModuleName: "disabled",
+		LogInputs:  []LogType{ConnLog},
+		Parse:      counter,
+		IsEnabled:  func(*config.Config) bool { return false },
+	})
+
+	conf := &config.Config{}
+	conf.S.Filtering.InternalSubnets = []string{"10.0.0.0/8"}
+	filter := NewFilter(conf)
+	parsers := NewParsers(&resources.Resources{Config: conf}, filter)
+
+	parsers.Parse(NewRecord(filter, &parsetypes.Conn{Source: "10.0.0.1", Destination: "1.1.1.1"}))
+	parsers.Parse(NewRecord(filter, &parsetypes.DNS{Source: "10.0.0.1", Destination: "10.0.0.53", Query: "a.com"}))
+	parsers.Parse(NewRecord(filter, &parsetypes.SSL{Source: "10.0.0.1", Destination: "1.1.1.1", ServerName: "b.com"}))
+	parsers.Parse(NewRecord(filter, &parsetypes.HTTP{Source: "10.0.0.1", Destination: "1.1.1.1", Host: "c.com"}))
+
+	batch := &Batch{}
+	parsers.Flush(batch)
+
+	// each parser is only handed the logs its module reads
+	require.Equal(t, []LogType{DNSLog}, batch.Result("dns"))
+	require.Equal(t, []LogType{ConnLog, SSLLog}, batch.Result("tls"))
+	require.Nil(t, batch.Result("disabled"))
+	require.Nil(t, batch.Result("host"))
+
+	// modules with a parser only run when it found something
+	dns, _ := Lookup("dns")
+	require.True(t, dns.(*Definition).hasData(batch))
+	require.False(t, dns.(*Definition).hasData(&Batch{}))
+	host, _ := Lookup("host")
+	require.True(t, host.(*Definition).hasData(&Batch{}))
+}
+
+func TestNewRecord(t *testing.T) {
+	conf := &config.Config{}
+	conf.S.Filtering.InternalSubnets = []string{"10.0.0.0/8"}
+	conf.S.Filtering.NeverIncludeDomain = []string{"*.example.com"}
+	filter := NewFilter(conf)
+
+	// CONNECT requests name the host being tunneled to
+	record := NewRecord(filter, &parsetypes.HTTP{Source: "10.0.0.1", Destination: "10.0.0.2", Method: "CONNECT", Host: "c2.example.net:443"})
+	require.Equal(t, "c2.example.net", record.FQDN)
+	require.True(t, record.Filtered)
+
+	// DNS queries are filtered by their client and domain, not their resolver
+	record = NewRecord(filter, &parsetypes.DNS{Source: "10.0.0.1", Destination: "10.0.0.53", Query: "a.com"})
+	require.False(t, record.Filtered)
+	require.True(t, record.IsLookup())
+	record = NewRecord(filter, &parsetypes.DNS{Source: "10.0.0.1", Destination: "1.1.1.1", Query: "www.example.com"})
+	require.True(t, record.Filtered)
+	record = NewRecord(filter, &parsetypes.DNS{Source: "10.0.0.1", Destination: "10.0.0.53", Query: "1.0.0.10.in-addr.arpa"})
+	require.False(t, record.IsLookup())
+
+	require.Nil(t, NewRecord(filter, "not a record"))
+}
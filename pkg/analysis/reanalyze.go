@@ -0,0 +1,95 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/resources"
+)
+
+//ReanalysisModules returns the modules which are re-analyzed when the named
+//modules are. Every module which depends on one of them is included so its
+//results stay consistent. If no modules are named, every enabled module which
+//can be rebuilt from stored data is returned.
+func ReanalysisModules(conf *config.Config, names []string) ([]Module, error) {
+	return reanalysisModules(Registered(), conf, names)
+}
+
+//Reanalyze clears and rebuilds the results of the given modules from the
+//data stored in the selected dataset using the current config
+func Reanalyze(res *resources.Resources, modules []Module) error {
+	// load the stored results the modules are built from
+	batch := &Batch{}
+	loaded := make(map[string]bool)
+	for _, module := range modules {
+		// modules which read no logs are rebuilt from the other collections
+		// rather than the batch
+		if len(module.Inputs()) == 0 {
+			continue
+		}
+		// modules which keep their own data, such as the SNI beacons, are
+		// loaded along with the modules they depend on
+		for _, name := range append([]string{module.Name()}, module.After()...) {
+			dependency, ok := Lookup(name)
+			if !ok || loaded[name] {
+				continue
+			}
+			loaded[name] = true
+			if err := dependency.Reload(res, batch); err != nil {
+				return fmt.Errorf("could not load the stored %s results: %v", name, err)
+			}
+		}
+	}
+
+	for _, module := range modules {
+		if err := module.Clear(res); err != nil {
+			return fmt.Errorf("could not clear the %s results: %v", module.Name(), err)
+		}
+		module.Analyze(res, batch)
+	}
+	return nil
+}
+
+//reanalysisModules selects the modules to re-analyze from the ordered
+//modules
+func reanalysisModules(ordered []Module, conf *config.Config, names []string) ([]Module, error) {
+	byName := make(map[string]Module)
+	for _, module := range ordered {
+		byName[module.Name()] = module
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range names {
+		module, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown analysis module %s", name)
+		}
+		if !module.Reanalyzable() {
+			return nil, fmt.Errorf("module %s cannot be re-analyzed from stored data, re-import the logs to rebuild it", name)
+		}
+		if !module.Enabled(conf) {
+			return nil, fmt.Errorf("module %s is disabled in the config", name)
+		}
+		selected[name] = true
+	}
+
+	var modules []Module
+	for _, module := range ordered {
+		if !module.Reanalyzable() || !module.Enabled(conf) {
+			continue
+		}
+
+		include := len(names) == 0 || selected[module.Name()]
+		for _, dependency := range module.After() {
+			include = include || selected[dependency]
+		}
+
+		// the modules are in dependency order, so marking this one selected
+		// also pulls in the modules which depend on it
+		if include {
+			selected[module.Name()] = true
+			modules = append(modules, module)
+		}
+	}
+	return modules, nil
+}
@@ -0,0 +1,96 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/resources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReanalysisModules(t *testing.T) {
+	upsert := func(*resources.Resources, *Batch) {}
+	reset := func(*resources.Resources) error { return nil }
+	disabled := func(*config.Config) bool { return false }
+
+	ordered, err := order([]Module{
+		&Definition{ModuleName: "uconn", Upsert: upsert},
+		&Definition{ModuleName: "beacon", Dependencies: []string{"uconn"}, Upsert: upsert, Reset: reset},
+		&Definition{ModuleName: "beaconfqdn", Dependencies: []string{"uconn"}, Upsert: upsert, Reset: reset},
+		&Definition{ModuleName: "beaconsni", Dependencies: []string{"uconn"}, Upsert: upsert, Reset: reset, IsEnabled: disabled},
+		&Definition{ModuleName: "threat", Dependencies: []string{"beacon", "beaconfqdn"}, Upsert: upsert, Removal: Rebuild},
+	})
+	require.NoError(t, err)
+	conf := &config.Config{}
+
+	// everything which can be rebuilt
+	modules, err := reanalysisModules(ordered, conf, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"beacon", "beaconfqdn", "threat"}, names(modules))
+
+	// dependents are rebuilt with the named modules
+	modules, err = reanalysisModules(ordered, conf, []string{"beacon"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"beacon", "threat"}, names(modules))
+
+	_, err = reanalysisModules(ordered, conf, []string{"uconn"})
+	require.Error(t, err)
+
+	_, err = reanalysisModules(ordered, conf, []string{"beaconsni"})
+	require.Error(t, err)
+
+	_, err = reanalysisModules(ordered, conf, []string{"missing"})
+	require.Error(t, err)
+}
+
+func TestReanalyzeLoads(t *testing.T) {
+	saved := registry
+	defer func() { registry = saved }()
+	registry = nil
+
+	var loaded, analyzed []string
+	load := func(name string) func(*resources.Resources, *Batch) error {
+		return func(*resources.Resources, *Batch) error {
+			loaded = append(loaded, name)
+			return nil
+		}
+	}
+	upsert := func(name string) func(*resources.Resources, *Batch) {
+		return func(*resources.Resources, *Batch) { analyzed = append(analyzed, name) }
+	}
+
+	Register(&Definition{ModuleName: "uconn", Scored: []FindingType{{Name: "long_connection"}}, Load: load("uconn")})
+	Register(&Definition{
+		ModuleName:   "beacon",
+		LogInputs:    []LogType{ConnLog},
+		Dependencies: []string{"uconn"},
+		Scored:       []FindingType{{Name: "beacon"}},
+		Upsert:       upsert("beacon"),
+	})
+	Register(&Definition{
+		ModuleName:   "beaconsni",
+		LogInputs:    []LogType{SSLLog},
+		Dependencies: []string{"uconn"},
+		Scored:       []FindingType{{Name: "beacon_sni"}},
+		Upsert:       upsert("beaconsni"),
+		Load:         load("beaconsni"),
+	})
+	Register(&Definition{ModuleName: "threat", AfterFindings: true, Upsert: upsert("threat")})
+
+	beacon, _ := Lookup("beacon")
+	sni, _ := Lookup("beaconsni")
+	threat, _ := Lookup("threat")
+
+	// the threat module reads the stored results, not the batch
+	require.NoError(t, Reanalyze(nil, []Module{threat}))
+	require.Empty(t, loaded)
+	require.Equal(t, []string{"threat"}, analyzed)
+
+	require.NoError(t, Reanalyze(nil, []Module{beacon, threat}))
+	require.Equal(t, []string{"uconn"}, loaded)
+
+	// modules which keep their own data load it as well
+	loaded = nil
+	require.NoError(t, Reanalyze(nil, []Module{sni, threat}))
+	require.Equal(t, []string{"beaconsni", "uconn"}, loaded)
+}
@@ -0,0 +1,295 @@
+package baseline
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+	//analyzer : structure for baseline analysis
+	analyzer struct {
+		chunk            int            //current chunk (0 if not on rolling analysis)
+		db               *database.DB   // provides access to MongoDB
+		conf             *config.Config // contains details needed to access MongoDB
+		analyzedCallback func(*update)  // called on each analyzed result
+		closedCallback   func()         // called when .close() is called and no more calls to analyzedCallback will be made
+		analysisChannel  chan *Input    // holds unanalyzed data
+		analysisWg       sync.WaitGroup // wait for analysis to finish
+	}
+)
+
+//newAnalyzer creates a new collector for comparing hosts against their baselines
+func newAnalyzer(chunk int, db *database.DB, conf *config.Config, analyzedCallback func(*update), closedCallback func()) *analyzer {
+	return &analyzer{
+		chunk:            chunk,
+		db:               db,
+		conf:             conf,
+		analyzedCallback: analyzedCallback,
+		closedCallback:   closedCallback,
+		analysisChannel:  make(chan *Input),
+	}
+}
+
+//collect sends a host to be analyzed
+func (a *analyzer) collect(data *Input) {
+	a.analysisChannel <- data
+}
+
+//close waits for the collector to finish
+func (a *analyzer) close() {
+	close(a.analysisChannel)
+	a.analysisWg.Wait()
+	a.closedCallback()
+}
+
+//start kicks off a new analysis thread
+func (a *analyzer) start() {
+	a.analysisWg.Add(1)
+	go func() {
+		ssn := a.db.Session.Copy()
+		defer ssn.Close()
+
+		for entry := range a.analysisChannel {
+
+			// the behaviour in each chunk is stored in the baseline.dat array
+			// of the host record so the current chunk can be compared against
+			// the rest of the dataset
+			var stored struct {
+				Baseline struct {
+					Dat []chunk `bson:"dat"`
+				} `bson:"baseline"`
+			}
+
+			_ = ssn.DB(a.db.GetSelectedDB()).C(a.conf.T.Structure.HostTable).
+				Find(entry.Host.BSONKey()).Select(bson.M{DatField: 1}).One(&stored)
+
+			dat := addToChunk(stored.Baseline.Dat, entry, a.chunk)
+
+			eval := evaluate(dat, a.chunk, a.conf.S.Baseline.MinimumChunks)
+
+			a.analyzedCallback(&update{
+				selector: entry.Host.BSONKey(),
+				query: bson.M{
+					"$set": bson.M{
+						DatField:              dat,
+						"baseline.cid":        eval.CID,
+						"baseline.chunks":     eval.Chunks,
+						"baseline.score":      eval.Score,
+						"baseline.deviations": eval.Deviations,
+						"baseline.new_hours":  eval.NewHours,
+						"baseline.new_ports":  eval.NewPorts,
+					},
+				},
+			})
+		}
+		a.analysisWg.Done()
+	}()
+}
+
+//addToChunk adds the behaviour from the current batch to the entry for the
+//current chunk, creating the entry if this is the first batch in the chunk.
+//The destinations of every other chunk are dropped in favor of their count
+//so the host record doesn't grow with each chunk in the dataset.
+func addToChunk(dat []chunk, entry *Input, cid int) []chunk {
+	idx := -1
+	for i := range dat {
+		if dat[i].CID == cid {
+			idx = i
+			continue
+		}
+		if dat[i].Destinations != nil {
+			dat[i].DestinationCount = int64(len(dat[i].Destinations))
+			dat[i].Destinations = nil
+		}
+	}
+
+	if idx == -1 {
+		dat = append(dat, chunk{CID: cid})
+		idx = len(dat) - 1
+	}
+
+	current := &dat[idx]
+	current.Bytes += entry.Bytes
+	current.DNSQueries += entry.DNSQueries
+
+	destinations := toSet(current.Destinations)
+	for dst := range entry.Destinations {
+		destinations[dst] = true
+	}
+	current.Destinations = fromSet(destinations)
+	current.DestinationCount = int64(len(current.Destinations))
+
+	ports := toSet(current.Ports)
+	for port := range entry.Ports {
+		ports[port] = true
+	}
+	current.Ports = fromSet(ports)
+
+	hours := make(map[int]bool)
+	for _, hour := range current.Hours {
+		hours[hour] = true
+	}
+	for hour := range entry.Hours {
+		hours[hour] = true
+	}
+	current.Hours = make([]int, 0, len(hours))
+	for hour := range hours {
+		current.Hours = append(current.Hours, hour)
+	}
+	sort.Ints(current.Hours)
+
+	return dat
+}
+
+//evaluate compares the behaviour of a host in the given chunk against its
+//behaviour in every other chunk. Hosts seen in fewer than minChunks other
+//chunks are still learning their baseline and receive no score.
+func evaluate(dat []chunk, cid int, minChunks int) Evaluation {
+	eval := Evaluation{CID: cid, Deviations: []Deviation{}, NewHours: []int{}, NewPorts: []string{}}
+
+	var current chunk
+	var history []chunk
+	for _, c := range dat {
+		if c.CID == cid {
+			current = c
+		} else {
+			history = append(history, c)
+		}
+	}
+
+	eval.Chunks = len(history)
+	if len(history) == 0 || len(history) < minChunks {
+		return eval
+	}
+
+	var destinations, bytes, queries []float64
+	currentHours, currentPorts := hourSet(current.Hours), toSet(current.Ports)
+	var historyHours, historyPorts []map[string]bool
+	for _, c := range history {
+		destinations = append(destinations, float64(c.DestinationCount))
+		bytes = append(bytes, float64(c.Bytes))
+		queries = append(queries, float64(c.DNSQueries))
+		historyHours = append(historyHours, hourSet(c.Hours))
+		historyPorts = append(historyPorts, toSet(c.Ports))
+	}
+
+	newHours, hourNovelty := novelty(currentHours, historyHours)
+	newPorts, portNovelty := novelty(currentPorts, historyPorts)
+
+	for _, hour := range current.Hours {
+		if newHours[strconv.Itoa(hour)] {
+			eval.NewHours = append(eval.NewHours, hour)
+		}
+	}
+	eval.NewPorts = fromSet(newPorts)
+
+	eval.Deviations = []Deviation{
+		deviation(Destinations, float64(current.DestinationCount), destinations),
+		deviation(Bytes, float64(current.Bytes), bytes),
+		deviation(DNSQueries, float64(current.DNSQueries), queries),
+		deviation(Hours, float64(len(newHours)), hourNovelty),
+		deviation(Ports, float64(len(newPorts)), portNovelty),
+	}
+
+	// only behaviour above the baseline is scored since a host doing less
+	// than usual is rarely a sign of compromise
+	for _, dev := range eval.Deviations {
+		eval.Score = math.Max(eval.Score, dev.Score)
+	}
+
+	return eval
+}
+
+//deviation computes how many standard deviations the current value of a
+//metric is from its mean across the baseline
+func deviation(metric string, value float64, history []float64) Deviation {
+	mean := 0.0
+	for _, v := range history {
+		mean += v
+	}
+	mean /= float64(len(history))
+
+	variance := 0.0
+	for _, v := range history {
+		variance += (v - mean) * (v - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(history)))
+
+	// avoid flagging small changes against a perfectly flat baseline by
+	// allowing at least 10% of the mean (or 1) as normal variation
+	spread := math.Max(stdDev, math.Max(mean*0.1, 1))
+
+	return Deviation{
+		Metric: metric,
+		Value:  value,
+		Mean:   math.Ceil(mean*1000) / 1000,
+		StdDev: math.Ceil(stdDev*1000) / 1000,
+		Score:  math.Ceil(((value-mean)/spread)*1000) / 1000,
+	}
+}
+
+//novelty returns the members of the current set which were not seen in any
+//of the history sets, along with how many members each history set held
+//that none of the other history sets did
+func novelty(current map[string]bool, history []map[string]bool) (map[string]bool, []float64) {
+	seen := make(map[string]int)
+	for _, set := range history {
+		for member := range set {
+			seen[member]++
+		}
+	}
+
+	unseen := make(map[string]bool)
+	for member := range current {
+		if seen[member] == 0 {
+			unseen[member] = true
+		}
+	}
+
+	counts := make([]float64, 0, len(history))
+	for _, set := range history {
+		count := 0
+		for member := range set {
+			if seen[member] == 1 {
+				count++
+			}
+		}
+		counts = append(counts, float64(count))
+	}
+
+	return unseen, counts
+}
+
+//hourSet converts a list of hours into a set
+func hourSet(hours []int) map[string]bool {
+	set := make(map[string]bool)
+	for _, hour := range hours {
+		set[strconv.Itoa(hour)] = true
+	}
+	return set
+}
+
+//toSet converts a list into a set
+func toSet(list []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, member := range list {
+		set[member] = true
+	}
+	return set
+}
+
+//fromSet converts a set into a sorted list
+func fromSet(set map[string]bool) []string {
+	list := make([]string, 0, len(set))
+	for member := range set {
+		list = append(list, member)
+	}
+	sort.Strings(list)
+	return list
+}
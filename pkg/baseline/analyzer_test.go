@@ -0,0 +1,103 @@
+package baseline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddToChunk(t *testing.T) {
+	entry := &Input{
+		Destinations: map[string]bool{"1.1.1.1": true},
+		Bytes:        10,
+		DNSQueries:   2,
+		Hours:        map[int]bool{9: true},
+		Ports:        map[string]bool{"443:tcp": true},
+	}
+
+	dat := addToChunk(nil, entry, 0)
+	require.Equal(t, []chunk{{
+		Destinations:     []string{"1.1.1.1"},
+		DestinationCount: 1,
+		Bytes:            10,
+		DNSQueries:       2,
+		Hours:            []int{9},
+		Ports:            []string{"443:tcp"},
+		CID:              0,
+	}}, dat)
+
+	// a second batch in the same chunk merges with the existing entry
+	entry = &Input{
+		Destinations: map[string]bool{"1.1.1.1": true, "8.8.8.8": true},
+		Bytes:        5,
+		DNSQueries:   1,
+		Hours:        map[int]bool{8: true},
+		Ports:        map[string]bool{"53:udp": true},
+	}
+	dat = addToChunk(dat, entry, 0)
+	require.Equal(t, []chunk{{
+		Destinations:     []string{"1.1.1.1", "8.8.8.8"},
+		DestinationCount: 2,
+		Bytes:            15,
+		DNSQueries:       3,
+		Hours:            []int{8, 9},
+		Ports:            []string{"443:tcp", "53:udp"},
+		CID:              0,
+	}}, dat)
+
+	// a new chunk creates a new entry and the finished chunk keeps only
+	// its destination count
+	dat = addToChunk(dat, entry, 1)
+	require.Len(t, dat, 2)
+	require.Nil(t, dat[0].Destinations)
+	require.Equal(t, int64(2), dat[0].DestinationCount)
+	require.Equal(t, 1, dat[1].CID)
+	require.Equal(t, int64(5), dat[1].Bytes)
+	require.Equal(t, int64(2), dat[1].DestinationCount)
+}
+
+func TestEvaluate(t *testing.T) {
+	history := []chunk{
+		{DestinationCount: 2, Bytes: 1000, DNSQueries: 100, Hours: []int{9, 10}, Ports: []string{"443:tcp"}, CID: 0},
+		{DestinationCount: 2, Bytes: 1200, DNSQueries: 110, Hours: []int{9, 11}, Ports: []string{"443:tcp"}, CID: 1},
+		{DestinationCount: 2, Bytes: 800, DNSQueries: 90, Hours: []int{10, 11}, Ports: []string{"443:tcp", "80:tcp"}, CID: 2},
+	}
+
+	// a chunk in line with the baseline
+	normal := append(history, chunk{DestinationCount: 2, Bytes: 1000, DNSQueries: 100, Hours: []int{9}, Ports: []string{"443:tcp"}, CID: 3})
+	eval := evaluate(normal, 3, 3)
+	require.Equal(t, 3, eval.CID)
+	require.Equal(t, 3, eval.Chunks)
+	require.Len(t, eval.Deviations, len(Metrics))
+	require.True(t, eval.Score < 1)
+	require.Empty(t, eval.NewHours)
+	require.Empty(t, eval.NewPorts)
+
+	// a chunk with far more traffic at a new hour on new ports
+	unusual := append(history, chunk{
+		DestinationCount: 8,
+		Bytes:            50000,
+		DNSQueries:       100,
+		Hours:            []int{3, 9},
+		Ports:            []string{"22:tcp", "443:tcp", "4444:tcp"},
+		CID:              3,
+	})
+	eval = evaluate(unusual, 3, 3)
+	require.True(t, eval.Score >= 3)
+	require.Equal(t, []int{3}, eval.NewHours)
+	require.Equal(t, []string{"22:tcp", "4444:tcp"}, eval.NewPorts)
+	for _, dev := range eval.Deviations {
+		switch dev.Metric {
+		case Bytes, Destinations:
+			require.True(t, dev.Score >= 3, dev.Metric)
+		case DNSQueries:
+			require.InDelta(t, 0, dev.Score, 0.001)
+		}
+	}
+
+	// hosts still learning their baseline are not scored
+	eval = evaluate(unusual[1:], 3, 3)
+	require.Equal(t, 2, eval.Chunks)
+	require.Equal(t, 0.0, eval.Score)
+	require.Empty(t, eval.Deviations)
+}
@@ -0,0 +1,73 @@
+package baseline
+
+import (
+	"strings"
+	"time"
+
+	"github.com/activecm/rita/pkg/host"
+	"github.com/activecm/rita/pkg/uconn"
+)
+
+//FromBatch gathers the behaviour of every internal host from the given
+//hosts and uconns
+func FromBatch(hostMap map[string]*host.Input, uconnMap map[string]*uconn.Input) map[string]*Input {
+	baselineMap := make(map[string]*Input)
+
+	for key, entry := range hostMap {
+		if !entry.IsLocal {
+			continue
+		}
+
+		input := newInput(entry)
+		input.Bytes = entry.TotalBytes
+		for _, count := range entry.DNSQueryCount {
+			input.DNSQueries += count
+		}
+		baselineMap[key] = input
+	}
+
+	for _, entry := range uconnMap {
+		// behaviour is tracked from the point of view of the host
+		// opening the connections
+		if !entry.IsLocalSrc {
+			continue
+		}
+
+		src := entry.Hosts.UniqueSrcIP.Unpair()
+		input, ok := baselineMap[src.MapKey()]
+		if !ok {
+			continue
+		}
+
+		if !entry.IsLocalDst {
+			input.Destinations[entry.Hosts.UniqueDstIP.Unpair().IP] = true
+		}
+
+		// hours are taken in UTC so they don't depend on the analyst's timezone
+		for _, ts := range entry.TsList {
+			input.Hours[time.Unix(ts, 0).UTC().Hour()] = true
+		}
+
+		// tuples are stored as port:proto:service. The service is dropped
+		// since it is already covered by the unexpected services analysis.
+		for _, tuple := range entry.Tuples {
+			fields := strings.SplitN(tuple, ":", 3)
+			if len(fields) < 2 {
+				continue
+			}
+			input.Ports[fields[0]+":"+fields[1]] = true
+		}
+	}
+
+	return baselineMap
+}
+
+//newInput creates an empty input for the given host
+func newInput(entry *host.Input) *Input {
+	return &Input{
+		Host:         entry.Host,
+		Destinations: make(map[string]bool),
+		Hours:        make(map[int]bool),
+		Ports:        make(map[string]bool),
+	}
+}
@@ -0,0 +1,88 @@
+package baseline
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/resources"
+	"github.com/activecm/rita/util"
+	"github.com/vbauerster/mpb"
+	"github.com/vbauerster/mpb/decor"
+)
+
+type repo struct {
+	res *resources.Resources
+}
+
+//NewMongoRepository create new repository
+func NewMongoRepository(res *resources.Resources) Repository {
+	return &repo{
+		res: res,
+	}
+}
+
+//CreateIndexes sets up the indices needed to find hosts which deviated from their baselines
+func (r *repo) CreateIndexes() error {
+	session := r.res.DB.Session.Copy()
+	defer session.Close()
+
+	coll := session.DB(r.res.DB.GetSelectedDB()).C(r.res.Config.T.Structure.HostTable)
+
+	// Desired indexes
+	indexes := []database.Index{
+		{Key: []string{"baseline.cid", "-baseline.score"}},
+	}
+
+	for _, index := range indexes {
+		err := coll.EnsureIndex(index)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Upsert loops through every internal host and compares its behaviour
+//against its baseline
+func (r *repo) Upsert(baselineMap map[string]*Input) {
+
+	//Create the workers
+	writerWorker := newWriter(r.res.Config.T.Structure.HostTable, r.res.DB, r.res.Config, r.res.Log)
+
+	analyzerWorker := newAnalyzer(
+		r.res.Config.S.Rolling.CurrentChunk,
+		r.res.DB,
+		r.res.Config,
+		writerWorker.collect,
+		writerWorker.close,
+	)
+
+	//kick off the threaded goroutines
+	for i := 0; i < util.Max(1, runtime.NumCPU()/2); i++ {
+		analyzerWorker.start()
+		writerWorker.start()
+	}
+
+	// progress bar for troubleshooting
+	p := mpb.New(mpb.WithWidth(20))
+	bar := p.AddBar(int64(len(baselineMap)),
+		mpb.PrependDecorators(
+			decor.Name("\t[-] Baseline Analysis:", decor.WC{W: 30, C: decor.DidentRight}),
+			decor.CountersNoUnit(" %d / %d ", decor.WCSyncWidth),
+		),
+		mpb.AppendDecorators(decor.Percentage()),
+	)
+
+	// loop over map entries
+	for _, entry := range baselineMap {
+		start := time.Now()
+		analyzerWorker.collect(entry)
+		bar.IncrBy(1, time.Since(start))
+	}
+
+	p.Wait()
+
+	// start the closing cascade (this will also close the other channels)
+	analyzerWorker.close()
+}
@@ -0,0 +1,94 @@
+package baseline
+
+import (
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	//Destinations is the number of external hosts an internal host contacted
+	Destinations = "destinations"
+	//Bytes is the number of bytes an internal host transferred
+	Bytes = "bytes"
+	//DNSQueries is the number of DNS queries an internal host made
+	DNSQueries = "dns_queries"
+	//Hours is the number of hours of the day an internal host was active in for the first time
+	Hours = "hours"
+	//Ports is the number of port:protocol pairs an internal host connected to for the first time
+	Ports = "ports"
+
+	//DatField holds the observations for each chunk in the host collection
+	DatField = "baseline.dat"
+)
+
+type (
+
+	// Repository for the baselines stored in the host collection
+	Repository interface {
+		CreateIndexes() error
+		Upsert(baselineMap map[string]*Input)
+	}
+
+	//update ....
+	update struct {
+		selector bson.M
+		query    bson.M
+	}
+
+	//chunk holds the behaviour of an internal host in a single chunk. The
+	//destinations are only kept while the chunk is being imported so later
+	//batches aren't counted twice, finished chunks keep only the count.
+	chunk struct {
+		Destinations     []string `bson:"dests,omitempty"`
+		DestinationCount int64    `bson:"dest_count"`
+		Bytes            int64    `bson:"bytes"`
+		DNSQueries       int64    `bson:"dns"`
+		Hours            []int    `bson:"hours"`
+		Ports            []string `bson:"ports"`
+		CID              int      `bson:"cid"`
+	}
+
+	//Deviation describes how far an internal host strayed from its baseline
+	//for a single metric. The destination, byte, and DNS query metrics compare
+	//the totals for the current chunk. The hour and port metrics compare how
+	//many hours and ports were seen for the first time in the current chunk
+	//with how many new hours and ports each baseline chunk added.
+	Deviation struct {
+		Metric string  `bson:"metric"`
+		Value  float64 `bson:"value"`
+		Mean   float64 `bson:"mean"`
+		StdDev float64 `bson:"stddev"`
+		Score  float64 `bson:"score"`
+	}
+
+	//Evaluation holds how an internal host behaved in a chunk compared with
+	//its behaviour in the other chunks of the dataset
+	Evaluation struct {
+		CID        int         `bson:"cid"`
+		Chunks     int         `bson:"chunks"`
+		Score      float64     `bson:"score"`
+		Deviations []Deviation `bson:"deviations"`
+		NewHours   []int       `bson:"new_hours"`
+		NewPorts   []string    `bson:"new_ports"`
+	}
+
+	//Result represents an internal host which deviated from its baseline
+	//in the current chunk
+	Result struct {
+		data.UniqueIP `bson:",inline"`
+		Baseline      Evaluation `bson:"baseline"`
+	}
+
+	//Input holds the behaviour of an internal host in a single import
+	Input struct {
+		Host         data.UniqueIP
+		Destinations map[string]bool
+		Bytes        int64
+		DNSQueries   int64
+		Hours        map[int]bool
+		Ports        map[string]bool
+	}
+)
+
+//Metrics lists the metrics tracked for each internal host in display order
+var Metrics = []string{Destinations, Bytes, DNSQueries, Hours, Ports}
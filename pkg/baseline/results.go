@@ -0,0 +1,32 @@
+package baseline
+
+import (
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//Results returns internal hosts which deviated from their baselines in the
+//given chunk by at least the configured z-score threshold, sorted by score.
+//limit and noLimit control how many results are returned.
+func Results(res *resources.Resources, cid int, limit int, noLimit bool) ([]Result, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var baselineResults []Result
+
+	baselineQuery := bson.M{
+		"baseline.cid":   cid,
+		"baseline.score": bson.M{"$gt": 0, "$gte": res.Config.S.Baseline.ZScoreThresh},
+	}
+
+	query := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.Structure.HostTable).
+		Find(baselineQuery).Select(bson.M{DatField: 0, "dat": 0}).Sort("-baseline.score")
+
+	if !noLimit {
+		query = query.Limit(limit)
+	}
+
+	err := query.All(&baselineResults)
+
+	return baselineResults, err
+}
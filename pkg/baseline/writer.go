@@ -0,0 +1,65 @@
+package baseline
+
+import (
+	"sync"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	writer struct {
+		targetCollection string
+		db               *database.DB   // provides access to MongoDB
+		conf             *config.Config // contains details needed to access MongoDB
+		log              *log.Logger    // main logger for RITA
+		writeChannel     chan *update   // holds analyzed data
+		writeWg          sync.WaitGroup // wait for writing to finish
+	}
+)
+
+//newWriter creates a new writer object to write output data to the host collection
+func newWriter(targetCollection string, db *database.DB, conf *config.Config, log *log.Logger) *writer {
+	return &writer{
+		targetCollection: targetCollection,
+		db:               db,
+		conf:             conf,
+		log:              log,
+		writeChannel:     make(chan *update),
+	}
+}
+
+//collect sends a group of results to the writer for writing out to the database
+func (w *writer) collect(data *update) {
+	w.writeChannel <- data
+}
+
+//close waits for the write threads to finish
+func (w *writer) close() {
+	close(w.writeChannel)
+	w.writeWg.Wait()
+}
+
+//start kicks off a new write thread
+func (w *writer) start() {
+	w.writeWg.Add(1)
+	go func() {
+		ssn := w.db.Session.Copy()
+		defer ssn.Close()
+
+		for data := range w.writeChannel {
+
+			// the host record is created by the host module
+			err := ssn.DB(w.db.GetSelectedDB()).C(w.targetCollection).Update(data.selector, data.query)
+
+			if err != nil {
+				w.log.WithFields(log.Fields{
+					"Module": "baseline",
+					"Data":   data,
+				}).Error(err)
+			}
+		}
+		w.writeWg.Done()
+	}()
+}
@@ -4,10 +4,11 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/activecm/rita/database"
 	"github.com/activecm/rita/pkg/uconn"
 	"github.com/activecm/rita/resources"
 	"github.com/activecm/rita/util"
-	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
 	"github.com/vbauerster/mpb"
 	"github.com/vbauerster/mpb/decor"
 )
@@ -46,7 +47,7 @@ func (r *repo) CreateIndexes() error {
 	}
 
 	// set desired indexes
-	indexes := []mgo.Index{
+	indexes := []database.Index{
 		{Key: []string{"-score"}},
 		{Key: []string{"src", "dst", "src_network_uuid", "dst_network_uuid"}, Unique: true},
 		{Key: []string{"src", "src_network_uuid"}},
@@ -128,3 +129,23 @@ func (r *repo) Upsert(uconnMap map[string]*uconn.Input) {
 	// start the closing cascade (this will also close the other channels)
 	dissectorWorker.close()
 }
+
+//Reset removes the beacon results and the max beacon scores recorded for each
+//host so they can be re-analyzed
+func (r *repo) Reset() error {
+	session := r.res.DB.Session.Copy()
+	defer session.Close()
+
+	if r.res.DB.CollectionExists(r.res.Config.T.Beacon.BeaconTable) {
+		err := session.DB(r.res.DB.GetSelectedDB()).C(r.res.Config.T.Beacon.BeaconTable).DropCollection()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := session.DB(r.res.DB.GetSelectedDB()).C(r.res.Config.T.Structure.HostTable).UpdateAll(
+		bson.M{"dat.mbdst": bson.M{"$exists": true}},
+		bson.M{"$pull": bson.M{"dat": bson.M{"mbdst": bson.M{"$exists": true}}}},
+	)
+	return err
+}
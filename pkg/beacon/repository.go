@@ -10,6 +10,7 @@ import (
 type Repository interface {
 	CreateIndexes() error
 	Upsert(uconnMap map[string]*uconn.Input)
+	Reset() error
 }
 
 type updateInfo struct {
@@ -48,12 +49,13 @@ type DSData struct {
 //on connection delta times and the amount of data transferred
 type Result struct {
 	data.UniqueIPPair `bson:",inline"`
-	Connections       int64   `bson:"connection_count"`
-	AvgBytes          float64 `bson:"avg_bytes"`
-	TotalBytes        int64    `bson:"total_bytes"`
-	Ts                TSData  `bson:"ts"`
-	Ds                DSData  `bson:"ds"`
-	Score             float64 `bson:"score"`
+	Connections       int64             `bson:"connection_count"`
+	AvgBytes          float64           `bson:"avg_bytes"`
+	TotalBytes        int64             `bson:"total_bytes"`
+	Ts                TSData            `bson:"ts"`
+	Ds                DSData            `bson:"ds"`
+	Score             float64           `bson:"score"`
+	History           data.ScoreHistory `bson:"history"`
 }
 
 //StrobeResult represents a unique connection with a large amount
@@ -0,0 +1,218 @@
+package beacon
+
+import (
+	"math"
+	"sort"
+
+	"github.com/activecm/rita/util"
+)
+
+type (
+	//TSScore holds the statistics and score computed from the
+	//timestamps of a beacon's connections
+	TSScore struct {
+		Range           int64
+		Mode            int64
+		ModeCount       int64
+		Intervals       []int64
+		IntervalCounts  []int64
+		Dispersion      int64
+		Skew            float64
+		SkewScore       float64
+		DispersionScore float64
+		ConnsScore      float64
+		Score           float64
+	}
+
+	//DSScore holds the statistics and score computed from the
+	//data sizes of a beacon's connections
+	DSScore struct {
+		Range           int64
+		Mode            int64
+		ModeCount       int64
+		Sizes           []int64
+		Counts          []int64
+		Dispersion      int64
+		Skew            float64
+		SkewScore       float64
+		DispersionScore float64
+		SmallnessScore  float64
+		Score           float64
+	}
+)
+
+//ScoreTimestamps scores how regularly a set of connections occurred.
+//tsList must be sorted, free of duplicates, and hold at least two timestamps.
+//tsMin and tsMax bound the timestamps of the whole dataset.
+func ScoreTimestamps(tsList []int64, connCount int64, tsMin int64, tsMax int64) TSScore {
+	//store the diff slice length since we use it a lot
+	//for timestamps this is one less then the data slice length
+	//since we are calculating the times in between readings
+	tsLength := len(tsList) - 1
+
+	//find the delta times between the timestamps
+	diff := make([]int64, tsLength)
+	for i := 0; i < tsLength; i++ {
+		diff[i] = tsList[i+1] - tsList[i]
+	}
+
+	//perfect beacons should have symmetric delta time distributions
+	//Bowley's measure of skew is used to check symmetry
+	sort.Sort(util.SortableInt64(diff))
+	tsSkew, tsMid := bowleySkew(diff)
+
+	//perfect beacons should have very low dispersion around the
+	//median of their delta times
+	//Median Absolute Deviation About the Median
+	//is used to check dispersion
+	tsMadm := medianDeviation(diff, tsMid)
+
+	//get a list of the intervals found in the data,
+	//the number of times the interval was found,
+	//and the most occurring interval
+	intervals, intervalCounts, tsMode, tsModeCount := createCountMap(diff)
+
+	score := TSScore{
+		//Store the range for human analysis
+		Range:          diff[tsLength-1] - diff[0],
+		Mode:           tsMode,
+		ModeCount:      tsModeCount,
+		Intervals:      intervals,
+		IntervalCounts: intervalCounts,
+		Dispersion:     tsMadm,
+		Skew:           tsSkew,
+		//more skewed distributions receive a lower score
+		//less skewed distributions receive a higher score
+		SkewScore: 1.0 - math.Abs(tsSkew),
+		//lower dispersion is better, cutoff dispersion scores at 30 seconds
+		DispersionScore: math.Max(1.0-float64(tsMadm)/30.0, 0),
+	}
+
+	// connection count scoring
+	tsConnDiv := (float64(tsMax) - float64(tsMin)) / 10.0
+	score.ConnsScore = math.Min(float64(connCount)/tsConnDiv, 1.0)
+
+	score.Score = math.Ceil((score.sum()/3.0)*1000) / 1000
+	return score
+}
+
+//ScoreSizes scores how consistent the sizes of a set of connections were.
+//sizes must be sorted and hold at least one entry.
+func ScoreSizes(sizes []int64) DSScore {
+	dsLength := len(sizes)
+
+	//perfect beacons should have symmetric data size distributions
+	dsSkew, dsMid := bowleySkew(sizes)
+
+	//perfect beacons should have very low dispersion around the
+	//median of their data sizes
+	dsMadm := medianDeviation(sizes, dsMid)
+
+	dsSizes, dsCounts, dsMode, dsModeCount := createCountMap(sizes)
+
+	score := DSScore{
+		//Store the range for human analysis
+		Range:      sizes[dsLength-1] - sizes[0],
+		Mode:       dsMode,
+		ModeCount:  dsModeCount,
+		Sizes:      dsSizes,
+		Counts:     dsCounts,
+		Dispersion: dsMadm,
+		Skew:       dsSkew,
+		SkewScore:  1.0 - math.Abs(dsSkew),
+		//lower dispersion is better, cutoff dispersion scores at 32 bytes
+		DispersionScore: math.Max(1.0-float64(dsMadm)/32.0, 0),
+		//smaller data sizes receive a higher score
+		SmallnessScore: math.Max(1.0-float64(dsMode)/65535.0, 0),
+	}
+
+	score.Score = math.Ceil((score.sum()/3.0)*1000) / 1000
+	return score
+}
+
+//sum totals the timestamp subscores
+func (s TSScore) sum() float64 {
+	return s.SkewScore + s.DispersionScore + s.ConnsScore
+}
+
+//sum totals the data size subscores
+func (s DSScore) sum() float64 {
+	return s.SkewScore + s.DispersionScore + s.SmallnessScore
+}
+
+//combinedScore averages the timestamp and data size subscores
+func combinedScore(ts TSScore, ds DSScore) float64 {
+	return math.Ceil(((ts.sum()+ds.sum())/6.0)*1000) / 1000
+}
+
+//bowleySkew returns Bowley's measure of skew for sorted data along with
+//the median of the data
+func bowleySkew(sorted []int64) (float64, int64) {
+	//length -1 is used since the data is a zero based slice
+	last := float64(len(sorted) - 1)
+	low := sorted[util.Round(.25*last)]
+	mid := sorted[util.Round(.5*last)]
+	high := sorted[util.Round(.75*last)]
+	num := low + high - 2*mid
+	den := high - low
+
+	//skew should equal zero if the denominator equals zero
+	//bowley skew is unreliable if Q2 = Q1 or Q2 = Q3
+	if den != 0 && mid != low && mid != high {
+		return float64(num) / float64(den), mid
+	}
+	return 0, mid
+}
+
+//medianDeviation returns the median absolute deviation of data about its median
+func medianDeviation(data []int64, median int64) int64 {
+	devs := make([]int64, len(data))
+	for i := range data {
+		devs[i] = util.Abs(data[i] - median)
+	}
+	sort.Sort(util.SortableInt64(devs))
+	return devs[util.Round(.5*float64(len(devs)-1))]
+}
+
+// createCountMap returns a distinct data array, data count array, the mode,
+// and the number of times the mode occurred
+func createCountMap(sortedIn []int64) ([]int64, []int64, int64, int64) {
+	//Since the data is already sorted, we can call this without fear
+	distinct, countsMap := countAndRemoveConsecutiveDuplicates(sortedIn)
+	countsArr := make([]int64, len(distinct))
+	mode := distinct[0]
+	max := countsMap[mode]
+	for i, datum := range distinct {
+		count := countsMap[datum]
+		countsArr[i] = count
+		if count > max {
+			max = count
+			mode = datum
+		}
+	}
+	return distinct, countsArr, mode, max
+}
+
+//countAndRemoveConsecutiveDuplicates removes consecutive
+//duplicates in an array of integers and counts how many
+//instances of each number exist in the array.
+//Similar to `uniq -c`, but counts all duplicates, not just
+//consecutive duplicates.
+func countAndRemoveConsecutiveDuplicates(numberList []int64) ([]int64, map[int64]int64) {
+	//Avoid some reallocations
+	result := make([]int64, 0, len(numberList)/2)
+	counts := make(map[int64]int64)
+
+	last := numberList[0]
+	result = append(result, last)
+	counts[last]++
+
+	for idx := 1; idx < len(numberList); idx++ {
+		if last != numberList[idx] {
+			result = append(result, numberList[idx])
+		}
+		last = numberList[idx]
+		counts[last]++
+	}
+	return result, counts
+}
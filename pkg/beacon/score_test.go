@@ -0,0 +1,50 @@
+package beacon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreTimestamps(t *testing.T) {
+	// a connection every 60 seconds, frequent enough for a full connection score
+	var perfect []int64
+	for ts := int64(0); ts <= 600; ts += 60 {
+		perfect = append(perfect, ts)
+	}
+	score := ScoreTimestamps(perfect, int64(len(perfect)), 0, 100)
+	require.Equal(t, int64(60), score.Mode)
+	require.Equal(t, int64(10), score.ModeCount)
+	require.Equal(t, []int64{60}, score.Intervals)
+	require.Equal(t, []int64{10}, score.IntervalCounts)
+	require.Equal(t, int64(0), score.Range)
+	require.Equal(t, int64(0), score.Dispersion)
+	require.Equal(t, 0.0, score.Skew)
+	require.Equal(t, 1.0, score.ConnsScore)
+	require.Equal(t, 1.0, score.Score)
+
+	// irregular connections score lower
+	irregular := []int64{0, 5, 90, 100, 300, 310, 600}
+	jittery := ScoreTimestamps(irregular, int64(len(irregular)), 0, 100)
+	require.Equal(t, int64(285), jittery.Range)
+	require.True(t, jittery.Score < score.Score)
+	require.True(t, jittery.DispersionScore >= 0)
+}
+
+func TestScoreSizes(t *testing.T) {
+	score := ScoreSizes([]int64{100, 100, 100, 100})
+	require.Equal(t, int64(100), score.Mode)
+	require.Equal(t, int64(4), score.ModeCount)
+	require.Equal(t, int64(0), score.Dispersion)
+	require.Equal(t, 1.0, score.SkewScore)
+	require.Equal(t, 1.0, score.DispersionScore)
+	require.InDelta(t, 1.0-100.0/65535.0, score.SmallnessScore, 1e-9)
+
+	// widely varying sizes are penalized for their dispersion
+	varied := ScoreSizes([]int64{10, 200, 4000, 60000})
+	require.Equal(t, 0.0, varied.DispersionScore)
+	require.True(t, varied.Score < score.Score)
+
+	ts := ScoreTimestamps([]int64{0, 60, 120, 180}, 4, 0, 40)
+	require.Equal(t, 1.0, combinedScore(ts, DSScore{SkewScore: 1, DispersionScore: 1, SmallnessScore: 1}))
+}
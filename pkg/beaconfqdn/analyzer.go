@@ -244,6 +244,9 @@ func (a *analyzer) start() {
 					"cid":                a.chunk,
 					"src_network_name":   entry.Src.SrcNetworkName,
 					"resolved_ips":       entry.ResolvedIPs,
+
+					// keep the score from each chunk to show how it changes
+					data.HistoryField(a.chunk): data.HistoryEntry{Score: score, TS: a.tsMax},
 				}
 
 				// set query
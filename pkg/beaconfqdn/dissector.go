@@ -5,189 +5,260 @@ import (
 
 	"github.com/activecm/rita/config"
 	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/data"
 	"github.com/activecm/rita/pkg/hostname"
 	"github.com/globalsign/mgo/bson"
 )
 
+//dissectGroupSize is the approximate number of resolved IPs matched against
+//the uconn collection by each aggregation
+const dissectGroupSize = 5000
+
 type (
 	dissector struct {
 		connLimit         int64                     // limit for strobe classification
+		threads           int                       // number of aggregations to run at once
 		db                *database.DB              // provides access to MongoDB
 		conf              *config.Config            // contains details needed to access MongoDB
 		dissectedCallback func(*hostname.FqdnInput) // called on each analyzed result
 		closedCallback    func()                    // called when .close() is called and no more calls to analyzedCallback will be made
-		dissectChannel    chan *hostname.FqdnInput  // holds unanalyzed data
-		dissectWg         sync.WaitGroup            // wait for analysis to finish
+	}
+
+	//srcHostnameConns accumulates the connections from a source to every IP a
+	//hostname resolved to
+	srcHostnameConns struct {
+		input *hostname.FqdnInput
+		ts    map[int64]struct{} // unique timestamps
+	}
+
+	//hostnameGroup is a set of hostnames and the distinct IPs they resolved to
+	hostnameGroup struct {
+		hosts []*hostname.Input
+		ips   []string
+	}
+
+	//uconnSummary is a uconn record with its chunked data flattened
+	uconnSummary struct {
+		data.UniqueIPPair `bson:",inline"`
+		Count             int64   `bson:"count"`
+		Ts                []int64 `bson:"ts"`
+		Bytes             []int64 `bson:"bytes"`
+		TBytes            int64   `bson:"tbytes"`
 	}
 )
 
-//newdissector creates a new collector for gathering data
-func newDissector(connLimit int64, db *database.DB, conf *config.Config, dissectedCallback func(*hostname.FqdnInput), closedCallback func()) *dissector {
+//newDissector creates a new dissector for joining sources with the hostnames
+//they contacted
+func newDissector(connLimit int64, threads int, db *database.DB, conf *config.Config, dissectedCallback func(*hostname.FqdnInput), closedCallback func()) *dissector {
 	return &dissector{
 		connLimit:         connLimit,
+		threads:           threads,
 		db:                db,
 		conf:              conf,
 		dissectedCallback: dissectedCallback,
 		closedCallback:    closedCallback,
-		dissectChannel:    make(chan *hostname.FqdnInput),
 	}
 }
 
-//collect sends a chunk of data to be analyzed
-func (d *dissector) collect(entry *hostname.FqdnInput) {
-	d.dissectChannel <- entry
-}
+//join matches every uconn record whose destination one of the hostnames
+//resolved to with those hostnames, and totals the connections made by each
+//source to each hostname. Rather than querying the uconn collection for every
+//hostname and source, the hostnames are split into groups resolving to about
+//dissectGroupSize IPs which are matched with one aggregation each. Since a
+//group holds every IP its hostnames resolved to, the totals for a group are
+//complete once its aggregation finishes, so they are dissected right away
+//rather than held until every group is done. progress is called with the
+//number of hostnames in each finished group. join returns the number of
+//source and hostname pairs found.
+func (d *dissector) join(hostnameMap map[string]*hostname.Input, progress func(int)) (int, error) {
+	var mutex sync.Mutex
+	var firstErr error
+	pairCount := 0
 
-//close waits for the collector to finish
-func (d *dissector) close() {
-	close(d.dissectChannel)
-	d.dissectWg.Wait()
-	d.closedCallback()
-}
-
-//start kicks off a new analysis thread
-func (d *dissector) start() {
-	d.dissectWg.Add(1)
-	go func() {
-		ssn := d.db.Session.Copy()
-		defer ssn.Close()
-
-		for entry := range d.dissectChannel {
-
-			// This will work for both updating and inserting completely new Beacons
-			// for every new hostnames record we have, we will check every entry in the
-			// uconn table where the source IP from the hostnames record connected to one
-			// of the associated IPs for  FQDN. This
-			// will always return a result because even with a brand new database, we already
-			// created the uconns table. It will only continue and analyze if the connection
-			// meets the required specs, again working for both an update and a new src-fqdn
-			// pair. We would have to perform this check regardless if we want the rolling
-			// update option to remain, and this gets us the vetting for both situations, and
-			// Only works on the current entries - not a re-aggregation on the whole collection,
-			// and individual lookups like this are really fast. This also ensures a unique
-			// set of timestamps for analysis.
-			uconnFindQuery := []bson.M{
-				// beacons strobe ignores any already flagged strobes, but we don't want to do
-				// that here. Beacons relies on the uconn table for having the updated connection info
-				// we do not have that, so the calculation must happen. We don't necessarily need to store
-				// the tslist or byte list, but I don't think that leaving it in will significantly impact
-				// performance on a few strobes.
-				{"$match": entry.Src.BSONKey()},
-				{"$match": bson.M{"$or": entry.DstBSONList}},
-				{"$project": bson.M{
-					"src": 1,
-					"ts": bson.M{
-						"$reduce": bson.M{
-							"input":        "$dat.ts",
-							"initialValue": []interface{}{},
-							"in":           bson.M{"$concatArrays": []interface{}{"$$value", "$$this"}},
-						},
-					},
-					"bytes": bson.M{
-						"$reduce": bson.M{
-							"input":        "$dat.bytes",
-							"initialValue": []interface{}{},
-							"in":           bson.M{"$concatArrays": []interface{}{"$$value", "$$this"}},
-						},
-					},
-					"count":  bson.M{"$sum": "$dat.count"},
-					"tbytes": bson.M{"$sum": "$dat.tbytes"},
-				}},
-				{"$group": bson.M{
-					"_id":    "$src",
-					"ts":     bson.M{"$push": "$ts"},
-					"bytes":  bson.M{"$push": "$bytes"},
-					"count":  bson.M{"$sum": "$count"},
-					"tbytes": bson.M{"$sum": "$tbytes"},
-				}},
-				{"$match": bson.M{"count": bson.M{"$gt": d.conf.S.BeaconFQDN.DefaultConnectionThresh}}},
-				{"$unwind": bson.M{
-					"path": "$ts",
-					// by default, $unwind does not output a document if the field value is null,
-					// missing, or an empty array. Since uconns stops storing ts and byte array
-					// results if a result is going to be guaranteed to be a beacon, we need this
-					// to not discard the result so we can update the fqdn beacon accurately
-					"preserveNullAndEmptyArrays": true,
-				}},
-				{"$unwind": bson.M{
-					"path":                       "$ts",
-					"preserveNullAndEmptyArrays": true,
-				}},
-				{"$group": bson.M{
-					"_id": "$_id",
-					// need to unique-ify timestamps or else results
-					// will be skewed by "0 distant" data points
-					"ts":     bson.M{"$addToSet": "$ts"},
-					"bytes":  bson.M{"$first": "$bytes"},
-					"count":  bson.M{"$first": "$count"},
-					"tbytes": bson.M{"$first": "$tbytes"},
-				}},
-				{"$unwind": bson.M{
-					"path":                       "$bytes",
-					"preserveNullAndEmptyArrays": true,
-				}},
-				{"$unwind": bson.M{
-					"path":                       "$bytes",
-					"preserveNullAndEmptyArrays": true,
-				}},
-				{"$group": bson.M{
-					"_id":    "$_id",
-					"ts":     bson.M{"$first": "$ts"},
-					"bytes":  bson.M{"$push": "$bytes"},
-					"count":  bson.M{"$first": "$count"},
-					"tbytes": bson.M{"$first": "$tbytes"},
-				}},
-				{"$project": bson.M{
-					"_id":    0,
-					"ts":     1,
-					"bytes":  1,
-					"count":  1,
-					"tbytes": 1,
-				}},
-			}
+	groups := make(chan hostnameGroup)
+	var wg sync.WaitGroup
+	for i := 0; i < d.threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ssn := d.db.Session.Copy()
+			defer ssn.Close()
 
-			var res struct {
-				Count  int64   `bson:"count"`
-				Ts     []int64 `bson:"ts"`
-				Bytes  []int64 `bson:"bytes"`
-				TBytes int64   `bson:"tbytes"`
-			}
+			for group := range groups {
+				// index the group's hostnames by the IPs they resolved to
+				resolvedBy := make(map[string][]*hostname.Input)
+				for _, entry := range group.hosts {
+					for _, ip := range entry.ResolvedIPs {
+						key := ip.MapKey()
+						resolvedBy[key] = append(resolvedBy[key], entry)
+					}
+				}
 
-			_ = ssn.DB(d.db.GetSelectedDB()).C(d.conf.T.Structure.UniqueConnTable).Pipe(uconnFindQuery).AllowDiskUse().One(&res)
+				pairs := make(map[string]*srcHostnameConns)
+				iter := ssn.DB(d.db.GetSelectedDB()).C(d.conf.T.Structure.UniqueConnTable).
+					Pipe(uconnSummaryQuery(group.ips)).AllowDiskUse().Iter()
 
-			// Check for errors and parse results
-			// this is here because it will still return an empty document even if there are no results
-			if res.Count > 0 {
+				var uconn uconnSummary
+				for iter.Next(&uconn) {
+					for _, entry := range resolvedBy[uconn.UniqueDstIP.Unpair().MapKey()] {
+						addUconn(pairs, entry, &uconn)
+					}
+					uconn = uconnSummary{}
+				}
 
-				analysisInput := &hostname.FqdnInput{
-					FQDN:            entry.FQDN,
-					Src:             entry.Src,
-					ConnectionCount: res.Count,
-					TotalBytes:      res.TBytes,
-					ResolvedIPs:     entry.ResolvedIPs,
+				err := iter.Close()
+				mutex.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
 				}
+				pairCount += len(pairs)
+				mutex.Unlock()
 
-				// check if beacon has become a strobe
-				if analysisInput.ConnectionCount > d.connLimit {
+				for _, pair := range pairs {
+					d.dissect(pair)
+				}
+				progress(len(group.hosts))
+			}
+		}()
+	}
 
-					// set to sorter channel
-					d.dissectedCallback(analysisInput)
+	for _, group := range groupHostnames(hostnameMap, dissectGroupSize) {
+		groups <- group
+	}
+	close(groups)
+	wg.Wait()
 
-				} else { // otherwise, parse timestamps and orig ip bytes
+	return pairCount, firstErr
+}
 
-					analysisInput.TsList = res.Ts
-					analysisInput.OrigBytesList = res.Bytes
+//groupHostnames splits the hostnames into groups resolving to no more than
+//size distinct IPs, unless a single hostname resolved to more
+func groupHostnames(hostnameMap map[string]*hostname.Input, size int) []hostnameGroup {
+	var groups []hostnameGroup
+	var current hostnameGroup
+	seenIPs := make(map[string]struct{})
 
-					// send to sorter channel if we have over UNIQUE 3 timestamps (analysis needs this verification)
-					if len(analysisInput.TsList) > 3 {
-						d.dissectedCallback(analysisInput)
-					}
+	for _, entry := range hostnameMap {
+		if len(entry.ResolvedIPs) == 0 {
+			continue
+		}
 
-				}
+		// count the IPs the hostname would add to the current group
+		added := 0
+		for _, ip := range entry.ResolvedIPs {
+			if _, ok := seenIPs[ip.IP]; !ok {
+				added++
+			}
+		}
+		if len(current.hosts) > 0 && len(current.ips)+added > size {
+			groups = append(groups, current)
+			current = hostnameGroup{}
+			seenIPs = make(map[string]struct{})
+		}
 
+		current.hosts = append(current.hosts, entry)
+		for _, ip := range entry.ResolvedIPs {
+			if _, ok := seenIPs[ip.IP]; !ok {
+				seenIPs[ip.IP] = struct{}{}
+				current.ips = append(current.ips, ip.IP)
 			}
+		}
+	}
+
+	if len(current.hosts) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
 
+//uconnSummaryQuery returns the aggregation which flattens the timestamps and
+//bytes of every uconn record sent to one of the given IPs
+func uconnSummaryQuery(ips []string) []bson.M {
+	return []bson.M{
+		{"$match": bson.M{"dst": bson.M{"$in": ips}}},
+		{"$project": bson.M{
+			"_id":              0,
+			"src":              1,
+			"src_network_uuid": 1,
+			"src_network_name": 1,
+			"dst":              1,
+			"dst_network_uuid": 1,
+			"dst_network_name": 1,
+			// uconns stops storing the timestamps and bytes of strobes, so
+			// these may be empty
+			"ts": bson.M{
+				"$reduce": bson.M{
+					"input":        "$dat.ts",
+					"initialValue": []interface{}{},
+					"in":           bson.M{"$concatArrays": []interface{}{"$$value", "$$this"}},
+				},
+			},
+			"bytes": bson.M{
+				"$reduce": bson.M{
+					"input":        "$dat.bytes",
+					"initialValue": []interface{}{},
+					"in":           bson.M{"$concatArrays": []interface{}{"$$value", "$$this"}},
+				},
+			},
+			"count":  bson.M{"$sum": "$dat.count"},
+			"tbytes": bson.M{"$sum": "$dat.tbytes"},
+		}},
+	}
+}
+
+//addUconn adds the connections in a uconn record to the totals for its
+//source and a hostname which resolved to its destination
+func addUconn(pairs map[string]*srcHostnameConns, entry *hostname.Input, uconn *uconnSummary) {
+	key := uconn.UniqueSrcIP.Unpair().MapKey() + entry.Host
+	pair, ok := pairs[key]
+	if !ok {
+		pair = &srcHostnameConns{
+			input: &hostname.FqdnInput{
+				FQDN:        entry.Host,
+				Src:         uconn.UniqueSrcIP,
+				ResolvedIPs: entry.ResolvedIPs,
+			},
+			ts: make(map[int64]struct{}),
 		}
-		d.dissectWg.Done()
-	}()
+		pairs[key] = pair
+	}
+
+	pair.input.ConnectionCount += uconn.Count
+	pair.input.TotalBytes += uconn.TBytes
+	// need to unique-ify timestamps or else results
+	// will be skewed by "0 distant" data points
+	for _, ts := range uconn.Ts {
+		pair.ts[ts] = struct{}{}
+	}
+	pair.input.OrigBytesList = append(pair.input.OrigBytesList, uconn.Bytes...)
+}
+
+//dissect vets the connections from a source to a hostname and sends them on
+//for analysis if they may be a beacon
+func (d *dissector) dissect(pair *srcHostnameConns) {
+	analysisInput := pair.input
+	if analysisInput.ConnectionCount <= int64(d.conf.S.BeaconFQDN.DefaultConnectionThresh) {
+		return
+	}
+
+	// check if beacon has become a strobe
+	if analysisInput.ConnectionCount > d.connLimit {
+		analysisInput.OrigBytesList = nil
+		d.dissectedCallback(analysisInput)
+		return
+	}
+
+	for ts := range pair.ts {
+		analysisInput.TsList = append(analysisInput.TsList, ts)
+	}
+
+	// send to sorter channel if we have over UNIQUE 3 timestamps (analysis needs this verification)
+	if len(analysisInput.TsList) > 3 {
+		d.dissectedCallback(analysisInput)
+	}
+}
+
+//close waits for the collector to finish
+func (d *dissector) close() {
+	d.closedCallback()
 }
@@ -0,0 +1,115 @@
+package beaconfqdn
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/stretchr/testify/require"
+)
+
+func uconnTo(src, dst string, ts []int64) *uconnSummary {
+	bytes := make([]int64, len(ts))
+	for i := range bytes {
+		bytes[i] = 100
+	}
+	return &uconnSummary{
+		UniqueIPPair: data.NewUniqueIPPair(data.UniqueIP{IP: src}, data.UniqueIP{IP: dst}),
+		Count:        int64(len(ts)),
+		Ts:           ts,
+		Bytes:        bytes,
+		TBytes:       int64(100 * len(ts)),
+	}
+}
+
+func TestDissect(t *testing.T) {
+	conf := &config.Config{}
+	conf.S.BeaconFQDN.DefaultConnectionThresh = 4
+
+	var dissected []*hostname.FqdnInput
+	d := newDissector(20, 1, nil, conf, func(input *hostname.FqdnInput) {
+		dissected = append(dissected, input)
+	}, func() {})
+
+	cdn := &hostname.Input{
+		Host:        "cdn.example.com",
+		ResolvedIPs: data.UniqueIPSet{{IP: "93.184.216.34"}, {IP: "93.184.216.35"}},
+	}
+
+	pairs := make(map[string]*srcHostnameConns)
+	// connections to both resolved IPs are totaled, sharing a timestamp
+	addUconn(pairs, cdn, uconnTo("10.0.0.1", "93.184.216.34", []int64{10, 20, 30}))
+	addUconn(pairs, cdn, uconnTo("10.0.0.1", "93.184.216.35", []int64{30, 40, 50}))
+	// too few connections
+	addUconn(pairs, cdn, uconnTo("10.0.0.2", "93.184.216.34", []int64{10, 20, 30}))
+	require.Len(t, pairs, 2)
+
+	for _, pair := range pairs {
+		d.dissect(pair)
+	}
+
+	require.Len(t, dissected, 1)
+	require.Equal(t, "10.0.0.1", dissected[0].Src.SrcIP)
+	require.Equal(t, int64(6), dissected[0].ConnectionCount)
+	require.Equal(t, int64(600), dissected[0].TotalBytes)
+	require.ElementsMatch(t, []int64{10, 20, 30, 40, 50}, dissected[0].TsList)
+	require.Len(t, dissected[0].OrigBytesList, 6)
+
+	// strobes are sent on without their timestamps
+	dissected = nil
+	strobe := make([]int64, 25)
+	for i := range strobe {
+		strobe[i] = int64(i)
+	}
+	pairs = make(map[string]*srcHostnameConns)
+	addUconn(pairs, cdn, uconnTo("10.0.0.3", "93.184.216.34", strobe))
+	for _, pair := range pairs {
+		d.dissect(pair)
+	}
+	require.Len(t, dissected, 1)
+	require.Empty(t, dissected[0].TsList)
+	require.Empty(t, dissected[0].OrigBytesList)
+}
+
+func TestGroupHostnames(t *testing.T) {
+	resolving := func(host string, ips ...string) *hostname.Input {
+		entry := &hostname.Input{Host: host}
+		for _, ip := range ips {
+			entry.ResolvedIPs = append(entry.ResolvedIPs, data.UniqueIP{IP: ip})
+		}
+		return entry
+	}
+
+	hostnameMap := map[string]*hostname.Input{
+		"a.example.com":   resolving("a.example.com", "1.1.1.1", "1.1.1.2"),
+		"b.example.com":   resolving("b.example.com", "1.1.1.2", "1.1.1.3"),
+		"c.example.com":   resolving("c.example.com", "2.2.2.1", "2.2.2.2", "2.2.2.3", "2.2.2.4"),
+		"unresolved.test": resolving("unresolved.test"),
+	}
+
+	groups := groupHostnames(hostnameMap, 3)
+
+	// every resolved hostname is in exactly one group holding all of its IPs
+	seen := make(map[string]int)
+	for _, group := range groups {
+		ips := make(map[string]struct{})
+		for _, ip := range group.ips {
+			ips[ip] = struct{}{}
+		}
+		require.Len(t, ips, len(group.ips))
+
+		for _, entry := range group.hosts {
+			seen[entry.Host]++
+			for _, ip := range entry.ResolvedIPs {
+				require.Contains(t, ips, ip.IP)
+			}
+		}
+
+		// only a hostname resolving to more IPs than the limit exceeds it
+		if len(group.hosts) > 1 {
+			require.True(t, len(group.ips) <= 3)
+		}
+	}
+	require.Equal(t, map[string]int{"a.example.com": 1, "b.example.com": 1, "c.example.com": 1}, seen)
+}
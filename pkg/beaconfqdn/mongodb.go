@@ -4,10 +4,12 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/activecm/rita/database"
 	"github.com/activecm/rita/pkg/hostname"
 	"github.com/activecm/rita/resources"
 	"github.com/activecm/rita/util"
-	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	log "github.com/sirupsen/logrus"
 	"github.com/vbauerster/mpb"
 	"github.com/vbauerster/mpb/decor"
 )
@@ -46,7 +48,7 @@ func (r *repo) CreateIndexes() error {
 	}
 
 	// set desired indexes
-	indexes := []mgo.Index{
+	indexes := []database.Index{
 		{Key: []string{"-score"}},
 		{Key: []string{"src", "src_network_uuid"}},
 		{Key: []string{"fqdn"}},
@@ -70,7 +72,7 @@ func (r *repo) Upsert(hostnameMap map[string]*hostname.Input) {
 
 	// Create the workers
 
-	// stage 5 - write out results
+	// stage 4 - write out results
 	writerWorker := newWriter(
 		r.res.Config.T.BeaconFQDN.BeaconFQDNTable,
 		r.res.DB,
@@ -78,7 +80,7 @@ func (r *repo) Upsert(hostnameMap map[string]*hostname.Input) {
 		r.res.Log,
 	)
 
-	// stage 4 - perform the analysis
+	// stage 3 - perform the analysis
 	analyzerWorker := newAnalyzer(
 		r.min,
 		r.max,
@@ -89,7 +91,7 @@ func (r *repo) Upsert(hostnameMap map[string]*hostname.Input) {
 		writerWorker.close,
 	)
 
-	// stage 3 - sort data
+	// stage 2 - sort data
 	sorterWorker := newSorter(
 		r.res.DB,
 		r.res.Config,
@@ -97,36 +99,38 @@ func (r *repo) Upsert(hostnameMap map[string]*hostname.Input) {
 		analyzerWorker.close,
 	)
 
-	// stage 2 - get and vet beacon details
+	// stage 1 - join the sources with the hostnames which
+	// resolved to the IPs they connected to
+	threads := util.Max(1, runtime.NumCPU()/2)
 	dissectorWorker := newDissector(
 		int64(r.res.Config.S.Strobe.ConnectionLimit),
+		threads,
 		r.res.DB,
 		r.res.Config,
 		sorterWorker.collect,
 		sorterWorker.close,
 	)
 
-	// stage 1 - get all the sources that connected
-	// to a resolved FQDN
-	accumulatorWorker := newAccumulator(
-		r.res.DB,
-		r.res.Config,
-		dissectorWorker.collect,
-		dissectorWorker.close,
-	)
-
 	//kick off the threaded goroutines
-	for i := 0; i < util.Max(1, runtime.NumCPU()/2); i++ {
-		accumulatorWorker.start()
-		dissectorWorker.start()
+	for i := 0; i < threads; i++ {
 		sorterWorker.start()
 		analyzerWorker.start()
 		writerWorker.start()
 	}
 
+	start := time.Now()
+
+	// hostnames which didn't resolve to any IPs aren't joined
+	resolved := 0
+	for _, entry := range hostnameMap {
+		if len(entry.ResolvedIPs) > 0 {
+			resolved++
+		}
+	}
+
 	// progress bar for troubleshooting
 	p := mpb.New(mpb.WithWidth(20))
-	bar := p.AddBar(int64(len(hostnameMap)),
+	bar := p.AddBar(int64(resolved),
 		mpb.PrependDecorators(
 			decor.Name("\t[-] FQDN Beacon Analysis:", decor.WC{W: 30, C: decor.DidentRight}),
 			decor.CountersNoUnit(" %d / %d ", decor.WCSyncWidth),
@@ -134,22 +138,43 @@ func (r *repo) Upsert(hostnameMap map[string]*hostname.Input) {
 		mpb.AppendDecorators(decor.Percentage()),
 	)
 
-	// loop over map entries (each hostname)
-	for _, entry := range hostnameMap {
+	// join each source with the hostnames it connected to, dissecting
+	// the results as they are completed
+	pairCount, err := dissectorWorker.join(hostnameMap, func(hosts int) {
+		bar.IncrBy(hosts)
+	})
+	if err != nil {
+		r.res.Log.WithField("err", err).Error("Could not join the uconn collection with the resolved hostnames")
+	}
 
-		start := time.Now()
+	p.Wait()
 
-		// check to make sure hostname has resolved ips, skip otherwise
-		if len(entry.ResolvedIPs) > 0 {
-			accumulatorWorker.collect(entry)
-		}
+	// start the closing cascade (this will also close the other channels)
+	dissectorWorker.close()
+
+	r.res.Log.WithFields(log.Fields{
+		"hostnames":      len(hostnameMap),
+		"src_fqdn_pairs": pairCount,
+		"total_time":     time.Since(start).String(),
+	}).Info("Finished FQDN beacon analysis")
+}
 
-		// progress bar increment
-		bar.IncrBy(1, time.Since(start))
+//Reset removes the FQDN beacon results and the max FQDN beacon scores recorded for each
+//host so they can be re-analyzed
+func (r *repo) Reset() error {
+	session := r.res.DB.Session.Copy()
+	defer session.Close()
 
+	if r.res.DB.CollectionExists(r.res.Config.T.BeaconFQDN.BeaconFQDNTable) {
+		err := session.DB(r.res.DB.GetSelectedDB()).C(r.res.Config.T.BeaconFQDN.BeaconFQDNTable).DropCollection()
+		if err != nil {
+			return err
+		}
 	}
-	p.Wait()
 
-	// start the closing cascade (this will also close the other channels)
-	accumulatorWorker.close()
+	_, err := session.DB(r.res.DB.GetSelectedDB()).C(r.res.Config.T.Structure.HostTable).UpdateAll(
+		bson.M{"dat.mbfqdn": bson.M{"$exists": true}},
+		bson.M{"$pull": bson.M{"dat": bson.M{"mbfqdn": bson.M{"$exists": true}}}},
+	)
+	return err
 }
@@ -12,6 +12,7 @@ type (
 	Repository interface {
 		CreateIndexes() error
 		Upsert(hostnameMap map[string]*hostname.Input)
+		Reset() error
 	}
 
 	updateInfo struct {
@@ -48,16 +49,17 @@ type (
 	// an FQDN. An FQDN can be comprised of one or more destination IPs.
 	// Contains information on connection delta times and the amount of data transferred
 	Result struct {
-		FQDN           string          `bson:"fqdn"`
-		SrcIP          string          `bson:"src"`
-		SrcNetworkName string          `bson:"src_network_name"`
-		SrcNetworkUUID bson.Binary     `bson:"src_network_uuid"`
-		Connections    int64           `bson:"connection_count"`
-		AvgBytes       float64         `bson:"avg_bytes"`
-		Ts             TSData          `bson:"ts"`
-		Ds             DSData          `bson:"ds"`
-		Score          float64         `bson:"score"`
-		ResolvedIPs    []data.UniqueIP `bson:"resolved_ips"`
+		FQDN           string            `bson:"fqdn"`
+		SrcIP          string            `bson:"src"`
+		SrcNetworkName string            `bson:"src_network_name"`
+		SrcNetworkUUID bson.Binary       `bson:"src_network_uuid"`
+		Connections    int64             `bson:"connection_count"`
+		AvgBytes       float64           `bson:"avg_bytes"`
+		Ts             TSData            `bson:"ts"`
+		Ds             DSData            `bson:"ds"`
+		Score          float64           `bson:"score"`
+		ResolvedIPs    []data.UniqueIP   `bson:"resolved_ips"`
+		History        data.ScoreHistory `bson:"history"`
 	}
 
 	//StrobeResult represents a unique connection with a large amount
@@ -192,6 +192,9 @@ func (a *analyzer) start() {
 					"tslist":             entry.TsList,
 					"score":              score,
 					"cid":                a.chunk,
+
+					// keep the score from each chunk to show how it changes
+					data.HistoryField(a.chunk): data.HistoryEntry{Score: score, TS: a.tsMax},
 				}
 
 				// set query
@@ -0,0 +1,104 @@
+package beaconproxy
+
+import (
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/util"
+)
+
+type (
+	//Correlator joins the HTTP CONNECT requests and TLS sessions sent to a
+	//proxy while parsing so each tunnel is attributed to the server name the
+	//client asked for. Only traffic sent to a proxy server should be added.
+	//Correlator is not safe for concurrent use.
+	Correlator struct {
+		tunnels map[string]*tunnel // CONNECT requests and TLS sessions by Zeek connection UID
+		orphans []*tunnel          // records without a UID
+	}
+
+	tunnel struct {
+		hosts  data.UniqueIPPair
+		ts     int64
+		target string // CONNECT target without the port
+		sni    string // server name sent inside the tunnel
+	}
+)
+
+// NewCorrelator creates an empty Correlator
+func NewCorrelator() *Correlator {
+	return &Correlator{
+		tunnels: make(map[string]*tunnel),
+	}
+}
+
+// AddConnect records an HTTP CONNECT request and the host:port it asked the
+// proxy to tunnel to
+func (c *Correlator) AddConnect(uid string, hosts data.UniqueIPPair, ts int64, target string) {
+	c.get(uid, hosts, ts).target = util.ConnectTarget(target)
+}
+
+// AddTLS records a TLS session and the server name the client sent. Since
+// Zeek reports the TLS handshake carried by a CONNECT tunnel under the same
+// UID as the request, the two are joined on it. Sessions without a UID can't
+// be joined and are ignored.
+func (c *Correlator) AddTLS(uid string, hosts data.UniqueIPPair, ts int64, sni string) {
+	sni = util.ConnectTarget(sni)
+	if sni == "" || uid == "" {
+		return
+	}
+	c.get(uid, hosts, ts).sni = sni
+}
+
+// get returns the tunnel recorded for a UID, creating it if necessary
+func (c *Correlator) get(uid string, hosts data.UniqueIPPair, ts int64) *tunnel {
+	if uid == "" {
+		t := &tunnel{hosts: hosts, ts: ts}
+		c.orphans = append(c.orphans, t)
+		return t
+	}
+	t, ok := c.tunnels[uid]
+	if !ok {
+		t = &tunnel{hosts: hosts, ts: ts}
+		c.tunnels[uid] = t
+	}
+	return t
+}
+
+// Correlate groups the tunnels by source, proxy, and destination hostname.
+// The server name sent inside a tunnel takes precedence over its CONNECT
+// target. TLS sessions without a logged CONNECT request weren't tunneled
+// through the proxy, so they are left out.
+func (c *Correlator) Correlate() map[string]*Input {
+	proxyHostnameMap := make(map[string]*Input)
+
+	add := func(t *tunnel) {
+		if t.target == "" {
+			return
+		}
+		fqdn := t.sni
+		if fqdn == "" {
+			fqdn = t.target
+		}
+
+		trio := NewUniqueSrcProxyHostnameTrio(t.hosts.UniqueSrcIP.Unpair(), t.hosts.UniqueDstIP.Unpair(), fqdn)
+		key := trio.MapKey()
+		if _, ok := proxyHostnameMap[key]; !ok {
+			proxyHostnameMap[key] = &Input{Hosts: trio}
+		}
+		proxyHostnameMap[key].ConnectionCount++
+
+		for _, ts := range proxyHostnameMap[key].TsList {
+			if ts == t.ts {
+				return
+			}
+		}
+		proxyHostnameMap[key].TsList = append(proxyHostnameMap[key].TsList, t.ts)
+	}
+
+	for _, t := range c.tunnels {
+		add(t)
+	}
+	for _, t := range c.orphans {
+		add(t)
+	}
+	return proxyHostnameMap
+}
@@ -0,0 +1,51 @@
+package beaconproxy
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func pair(src, dst string) data.UniqueIPPair {
+	return data.NewUniqueIPPair(data.UniqueIP{IP: src}, data.UniqueIP{IP: dst})
+}
+
+func TestCorrelate(t *testing.T) {
+	c := NewCorrelator()
+	proxied := pair("10.0.0.1", "10.0.0.254")
+
+	// CONNECT request and the TLS handshake it carried
+	c.AddConnect("C1", proxied, 100, "cdn.example.com:443")
+	c.AddTLS("C1", proxied, 100, "c2.attacker.net")
+
+	// handshake logged before its CONNECT request
+	c.AddTLS("C2", proxied, 200, "c2.attacker.net")
+	c.AddConnect("C2", proxied, 200, "cdn.example.com:443")
+
+	// CONNECT request without a server name
+	c.AddConnect("C3", proxied, 300, "Update.Example.com:443")
+
+	// TLS sessions sent to the proxy without a logged CONNECT request
+	c.AddTLS("", proxied, 400, "c2.attacker.net")
+	c.AddTLS("C4", proxied, 500, "c2.attacker.net")
+
+	// CONNECT request without a UID
+	c.AddConnect("", proxied, 600, "c2.attacker.net:443")
+
+	findings := c.Correlate()
+	require.Len(t, findings, 2)
+
+	c2 := findings[NewUniqueSrcProxyHostnameTrio(
+		data.UniqueIP{IP: "10.0.0.1"}, data.UniqueIP{IP: "10.0.0.254"}, "c2.attacker.net",
+	).MapKey()]
+	require.NotNil(t, c2)
+	require.Equal(t, int64(3), c2.ConnectionCount)
+	require.ElementsMatch(t, []int64{100, 200, 600}, c2.TsList)
+
+	update := findings[NewUniqueSrcProxyHostnameTrio(
+		data.UniqueIP{IP: "10.0.0.1"}, data.UniqueIP{IP: "10.0.0.254"}, "update.example.com",
+	).MapKey()]
+	require.NotNil(t, update)
+	require.Equal(t, int64(1), update.ConnectionCount)
+}
@@ -4,9 +4,10 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/activecm/rita/database"
 	"github.com/activecm/rita/resources"
 	"github.com/activecm/rita/util"
-	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
 	"github.com/vbauerster/mpb"
 	"github.com/vbauerster/mpb/decor"
 )
@@ -45,7 +46,7 @@ func (r *repo) CreateIndexes() error {
 	}
 
 	// set desired indexes
-	indexes := []mgo.Index{
+	indexes := []database.Index{
 		{Key: []string{"-score"}},
 		{Key: []string{"dst", "dst_network_uuid"}},
 		{Key: []string{"src", "src_network_uuid"}},
@@ -141,3 +142,27 @@ func (r *repo) Upsert(proxyHostnameMap map[string]*Input) {
 	// start the closing cascade (this will also close the other channels)
 	dissectorWorker.close()
 }
+
+//Reset removes the proxy beacon scores and the max proxy beacon scores recorded
+//for each host so they can be re-analyzed. The timestamps stored for each
+//proxy beacon are kept since the scores are rebuilt from them.
+func (r *repo) Reset() error {
+	session := r.res.DB.Session.Copy()
+	defer session.Close()
+
+	if r.res.DB.CollectionExists(r.res.Config.T.BeaconProxy.BeaconProxyTable) {
+		_, err := session.DB(r.res.DB.GetSelectedDB()).C(r.res.Config.T.BeaconProxy.BeaconProxyTable).UpdateAll(
+			bson.M{},
+			bson.M{"$unset": bson.M{"strobeFQDN": 1, "ts": 1, "score": 1}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := session.DB(r.res.DB.GetSelectedDB()).C(r.res.Config.T.Structure.HostTable).UpdateAll(
+		bson.M{"dat.mbproxy": bson.M{"$exists": true}},
+		bson.M{"$pull": bson.M{"dat": bson.M{"mbproxy": bson.M{"$exists": true}}}},
+	)
+	return err
+}
@@ -0,0 +1,47 @@
+package beaconproxy
+
+import (
+	"github.com/activecm/rita/pkg/analysis"
+)
+
+//parser joins the HTTP CONNECT requests and TLS sessions sent to the proxy
+//servers in a batch of http and ssl records
+type parser struct {
+	filter     *analysis.Filter
+	correlator *Correlator
+}
+
+//NewParser creates a parser which gathers the tunnels clients opened through
+//the proxy servers
+func NewParser(filter *analysis.Filter) analysis.Parser {
+	return &parser{
+		filter:     filter,
+		correlator: NewCorrelator(),
+	}
+}
+
+//Parse records the CONNECT requests and TLS sessions sent to a proxy server
+func (p *parser) Parse(record *analysis.Record) {
+	if record.Filtered || !p.filter.IsProxyServer(record.DstIP) {
+		return
+	}
+
+	switch record.Log {
+	case analysis.HTTPLog:
+		if record.HTTP.Method == "CONNECT" {
+			p.correlator.AddConnect(record.HTTP.UID, record.Hosts, record.HTTP.TimeStamp, record.FQDN)
+		}
+
+	case analysis.SSLLog:
+		// record sessions sent to a proxy so tunnels can be attributed to
+		// the server name the client sent
+		if !p.filter.FilterDomain(record.FQDN) {
+			p.correlator.AddTLS(record.SSL.UID, record.Hosts, record.SSL.TimeStamp, record.FQDN)
+		}
+	}
+}
+
+//Flush returns the proxied hostnames gathered from the batch
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	return p.correlator.Correlate()
+}
@@ -13,6 +13,7 @@ type (
 	Repository interface {
 		CreateIndexes() error
 		Upsert(proxyHostnameMap map[string]*Input)
+		Reset() error
 	}
 
 	updateInfo struct {
@@ -38,16 +39,17 @@ type (
 	//Result represents a beacon proxy between a source IP and
 	// an proxy.
 	Result struct {
-		FQDN           string      `bson:"fqdn"`
-		SrcIP          string      `bson:"src"`
-		SrcNetworkName string      `bson:"src_network_name"`
-		SrcNetworkUUID bson.Binary `bson:"src_network_uuid"`
-		DstIP          string      `bson:"dst"`
-		DstNetworkName string      `bson:"dst_network_name"`
-		DstNetworkUUID bson.Binary `bson:"dst_network_uuid"`
-		Connections    int64       `bson:"connection_count"`
-		Ts             TSData      `bson:"ts"`
-		Score          float64     `bson:"score"`
+		FQDN           string            `bson:"fqdn"`
+		SrcIP          string            `bson:"src"`
+		SrcNetworkName string            `bson:"src_network_name"`
+		SrcNetworkUUID bson.Binary       `bson:"src_network_uuid"`
+		DstIP          string            `bson:"dst"`
+		DstNetworkName string            `bson:"dst_network_name"`
+		DstNetworkUUID bson.Binary       `bson:"dst_network_uuid"`
+		Connections    int64             `bson:"connection_count"`
+		Ts             TSData            `bson:"ts"`
+		Score          float64           `bson:"score"`
+		History        data.ScoreHistory `bson:"history"`
 	}
 
 	//StrobeResult represents a unique connection with a large amount
@@ -18,3 +18,33 @@ func Results(res *resources.Resources, cutoffScore float64) ([]Result, error) {
 
 	return beaconsProxy, err
 }
+
+//StoredInputs returns the proxy beacons stored in the dataset so they can be
+//re-analyzed. Strobes don't keep their timestamps, so they are returned with
+//only their connection count.
+func StoredInputs(res *resources.Resources) (map[string]*Input, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var stored struct {
+		Hosts           UniqueSrcProxyHostnameTrio `bson:",inline"`
+		ConnectionCount int64                      `bson:"connection_count"`
+		TsList          []int64                    `bson:"tslist"`
+		Strobe          bool                       `bson:"strobeFQDN"`
+	}
+
+	proxyHostnameMap := make(map[string]*Input)
+	iter := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.BeaconProxy.BeaconProxyTable).Find(nil).Iter()
+	for iter.Next(&stored) {
+		entry := &Input{
+			Hosts:           stored.Hosts,
+			ConnectionCount: stored.ConnectionCount,
+		}
+		if !stored.Strobe {
+			entry.TsList = stored.TsList
+		}
+		proxyHostnameMap[stored.Hosts.MapKey()] = entry
+	}
+
+	return proxyHostnameMap, iter.Close()
+}
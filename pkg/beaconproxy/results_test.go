@@ -0,0 +1,97 @@
+package beaconproxy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/resources"
+	"github.com/activecm/rita/util"
+	"github.com/globalsign/mgo/bson"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReanalyze(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rita-beaconproxy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &config.Config{}
+	conf.S.Storage.Backend = database.EmbeddedBackend
+	conf.S.Storage.Path = filepath.Join(dir, "rita.db")
+	db, err := database.NewDB(conf, logrus.New())
+	require.NoError(t, err)
+	defer db.Session.Close()
+	db.SelectDB("dataset")
+
+	conf.T.BeaconProxy.BeaconProxyTable = "beaconProxy"
+	conf.T.Structure.HostTable = "host"
+	conf.S.BeaconFQDN.DefaultConnectionThresh = 3
+	conf.S.Strobe.ConnectionLimit = 1000
+	res := &resources.Resources{Config: conf, Log: logrus.New(), DB: db}
+	r := &repo{res: res, min: 0, max: 100}
+	require.NoError(t, r.CreateIndexes())
+
+	src := data.UniqueIP{
+		IP:          "10.0.0.1",
+		NetworkUUID: util.UnknownPrivateNetworkUUID,
+		NetworkName: util.UnknownPrivateNetworkName,
+	}
+	proxy := data.UniqueIP{
+		IP:          "203.0.113.100",
+		NetworkUUID: util.PublicNetworkUUID,
+		NetworkName: util.PublicNetworkName,
+	}
+	trio := NewUniqueSrcProxyHostnameTrio(src, proxy, "c2.example.com")
+	batch := &Input{Hosts: trio, ConnectionCount: 11}
+	for ts := int64(0); ts <= 600; ts += 60 {
+		batch.TsList = append(batch.TsList, ts)
+	}
+	r.Upsert(map[string]*Input{trio.MapKey(): batch})
+
+	// re-analyze the stored proxy beacons the way `rita analyze --modules beaconproxy` does
+	reanalyze := func() {
+		stored, err := StoredInputs(res)
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		require.Equal(t, trio, stored[trio.MapKey()].Hosts)
+		require.Equal(t, batch.TsList, stored[trio.MapKey()].TsList)
+		require.NoError(t, r.Reset())
+		r.Upsert(stored)
+	}
+
+	maxScores := func() int {
+		count, err := db.Session.DB("dataset").C("host").Find(bson.M{"dat.mbproxy": "c2.example.com"}).Count()
+		require.NoError(t, err)
+		return count
+	}
+
+	results, err := Results(res, 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, 1.0, results[0].Score)
+	require.Equal(t, 1, maxScores())
+
+	// raising the threshold drops the beacon and the source's max score
+	conf.S.BeaconFQDN.DefaultConnectionThresh = 20
+	reanalyze()
+	results, err = Results(res, 0)
+	require.NoError(t, err)
+	require.Empty(t, results)
+	require.Equal(t, 0, maxScores())
+
+	// and lowering it scores the beacon again from the stored timestamps
+	conf.S.BeaconFQDN.DefaultConnectionThresh = 3
+	reanalyze()
+	results, err = Results(res, 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, 1.0, results[0].Score)
+	require.Equal(t, int64(11), results[0].Connections)
+	require.Equal(t, 1, maxScores())
+}
@@ -0,0 +1,162 @@
+package beaconsni
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/beacon"
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+	analyzer struct {
+		tsMin            int64           // min timestamp for the whole dataset
+		tsMax            int64           // max timestamp for the whole dataset
+		chunk            int             //current chunk (0 if not on rolling analysis)
+		chunkStr         string          //current chunk (0 if not on rolling analysis)
+		db               *database.DB    // provides access to MongoDB
+		conf             *config.Config  // contains details needed to access MongoDB
+		analyzedCallback func(*update)   // called on each analyzed result
+		closedCallback   func()          // called when .close() is called and no more calls to analyzedCallback will be made
+		analysisChannel  chan *dissected // holds unanalyzed data
+		analysisWg       sync.WaitGroup  // wait for analysis to finish
+	}
+)
+
+//newAnalyzer creates a new collector for gathering data
+func newAnalyzer(min int64, max int64, chunk int, db *database.DB, conf *config.Config, analyzedCallback func(*update), closedCallback func()) *analyzer {
+	return &analyzer{
+		tsMin:            min,
+		tsMax:            max,
+		chunk:            chunk,
+		chunkStr:         strconv.Itoa(chunk),
+		db:               db,
+		conf:             conf,
+		analyzedCallback: analyzedCallback,
+		closedCallback:   closedCallback,
+		analysisChannel:  make(chan *dissected),
+	}
+}
+
+//collect sends a chunk of data to be analyzed
+func (a *analyzer) collect(data *dissected) {
+	a.analysisChannel <- data
+}
+
+//close waits for the collector to finish
+func (a *analyzer) close() {
+	close(a.analysisChannel)
+	a.analysisWg.Wait()
+	a.closedCallback()
+}
+
+//start kicks off a new analysis thread
+func (a *analyzer) start() {
+	a.analysisWg.Add(1)
+	go func() {
+
+		for entry := range a.analysisChannel {
+
+			// set up beacon writer output
+			output := &update{}
+
+			merged := entry.merged
+
+			// create query
+			query := bson.M{}
+
+			// if the trio has turned into a strobe, we will not have any timestamps here.
+			// Don't store the timestamps for this batch either, mirroring how uconn
+			// handles strobes.
+			batchTs := entry.batch.TsList
+			if merged.TsList == nil {
+				batchTs = []int64{}
+			}
+
+			// store the current batch so future imports can merge it in. Trios
+			// loaded for re-analysis have no new data to store.
+			if entry.batch.ConnectionCount > 0 {
+				query["$push"] = bson.M{
+					"dat": bson.M{
+						"count": entry.batch.ConnectionCount,
+						"ts":    batchTs,
+						"dsts":  entry.batch.DstIPs,
+						"cid":   a.chunk,
+					},
+				}
+			}
+
+			if merged.TsList == nil {
+
+				// set strobe info
+				query["$set"] = bson.M{
+					"strobe":           true,
+					"connection_count": merged.ConnectionCount,
+					"dst_count":        len(merged.DstIPs),
+					"src_network_name": merged.Hosts.SrcNetworkName,
+					"cid":              a.chunk,
+				}
+
+				// unset any beacon calculations since this
+				// is now a strobe and those would be inaccurate
+				// (this will only apply to chunked imports)
+				query["$unset"] = bson.M{
+					"ts":    1,
+					"score": 1,
+				}
+
+			} else if merged.ConnectionCount <= int64(a.conf.S.BeaconSNI.DefaultConnectionThresh) ||
+				len(merged.TsList) <= 3 {
+
+				// not enough data to analyze yet (analysis needs over 3 unique timestamps)
+				query["$set"] = bson.M{
+					"connection_count": merged.ConnectionCount,
+					"dst_count":        len(merged.DstIPs),
+					"src_network_name": merged.Hosts.SrcNetworkName,
+					"cid":              a.chunk,
+				}
+
+				// clear out any stale scores in case chunks were removed
+				// from a rolling dataset
+				query["$unset"] = bson.M{
+					"ts":    1,
+					"score": 1,
+				}
+
+			} else {
+				ts := beacon.ScoreTimestamps(merged.TsList, merged.ConnectionCount, a.tsMin, a.tsMax)
+
+				// update beacon query
+				query["$set"] = bson.M{
+					"strobe":             false,
+					"connection_count":   merged.ConnectionCount,
+					"dst_count":          len(merged.DstIPs),
+					"src_network_name":   merged.Hosts.SrcNetworkName,
+					"ts.range":           ts.Range,
+					"ts.mode":            ts.Mode,
+					"ts.mode_count":      ts.ModeCount,
+					"ts.intervals":       ts.Intervals,
+					"ts.interval_counts": ts.IntervalCounts,
+					"ts.dispersion":      ts.Dispersion,
+					"ts.skew":            ts.Skew,
+					"ts.conns_score":     ts.ConnsScore,
+					"ts.score":           ts.Score,
+					"score":              ts.Score,
+					"cid":                a.chunk,
+				}
+			}
+
+			// set query
+			output.beacon.query = query
+
+			// create selector for output
+			output.beacon.selector = merged.Hosts.BSONKey()
+
+			// set to writer channel
+			a.analyzedCallback(output)
+		}
+		a.analysisWg.Done()
+	}()
+}
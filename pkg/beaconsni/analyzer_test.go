@@ -0,0 +1,53 @@
+package beaconsni
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/require"
+)
+
+func analyze(t *testing.T, entry *dissected) bson.M {
+	conf := &config.Config{}
+	conf.S.BeaconSNI.DefaultConnectionThresh = 3
+
+	var output *update
+	a := newAnalyzer(0, 100, 0, nil, conf, func(u *update) { output = u }, func() {})
+	a.start()
+	a.collect(entry)
+	a.close()
+
+	require.NotNil(t, output)
+	require.Equal(t, entry.merged.Hosts.BSONKey(), output.beacon.selector)
+	return output.beacon.query
+}
+
+func TestAnalyze(t *testing.T) {
+	trio := NewUniqueSrcSNIJA3Trio(data.UniqueIP{IP: "10.0.0.1"}, "c2.example.com", "abc")
+	var tsList []int64
+	for ts := int64(0); ts <= 600; ts += 60 {
+		tsList = append(tsList, ts)
+	}
+	batch := &Input{Hosts: trio, TsList: tsList, ConnectionCount: int64(len(tsList))}
+
+	// a regular beacon receives the same timestamp score as an ip beacon
+	query := analyze(t, &dissected{batch: batch, merged: mergeChunks(batch, nil, 100)})
+	set := query["$set"].(bson.M)
+	require.Equal(t, 1.0, set["score"])
+	require.Equal(t, int64(60), set["ts.mode"])
+	require.Equal(t, false, set["strobe"])
+	require.Equal(t, tsList, query["$push"].(bson.M)["dat"].(bson.M)["ts"])
+
+	// too few connections to score
+	few := &Input{Hosts: trio, TsList: []int64{0, 60}, ConnectionCount: 2}
+	query = analyze(t, &dissected{batch: few, merged: mergeChunks(few, nil, 100)})
+	require.NotContains(t, query["$set"], "score")
+	require.Contains(t, query["$unset"], "score")
+
+	// strobes don't store their timestamps
+	query = analyze(t, &dissected{batch: batch, merged: mergeChunks(batch, nil, 5)})
+	require.Equal(t, true, query["$set"].(bson.M)["strobe"])
+	require.Equal(t, []int64{}, query["$push"].(bson.M)["dat"].(bson.M)["ts"])
+}
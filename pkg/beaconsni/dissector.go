@@ -0,0 +1,118 @@
+package beaconsni
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/util"
+)
+
+type (
+	dissector struct {
+		connLimit         int64            // limit for strobe classification
+		db                *database.DB     // provides access to MongoDB
+		conf              *config.Config   // contains details needed to access MongoDB
+		dissectedCallback func(*dissected) // called on each analyzed result
+		closedCallback    func()           // called when .close() is called and no more calls to analyzedCallback will be made
+		dissectChannel    chan *Input      // holds unanalyzed data
+		dissectWg         sync.WaitGroup   // wait for analysis to finish
+	}
+)
+
+//newdissector creates a new collector for gathering data
+func newDissector(connLimit int64, db *database.DB, conf *config.Config, dissectedCallback func(*dissected), closedCallback func()) *dissector {
+	return &dissector{
+		connLimit:         connLimit,
+		db:                db,
+		conf:              conf,
+		dissectedCallback: dissectedCallback,
+		closedCallback:    closedCallback,
+		dissectChannel:    make(chan *Input),
+	}
+}
+
+//collect sends a chunk of data to be analyzed
+func (d *dissector) collect(entry *Input) {
+	d.dissectChannel <- entry
+}
+
+//close waits for the collector to finish
+func (d *dissector) close() {
+	close(d.dissectChannel)
+	d.dissectWg.Wait()
+	d.closedCallback()
+}
+
+//start kicks off a new analysis thread
+func (d *dissector) start() {
+	d.dissectWg.Add(1)
+	go func() {
+		ssn := d.db.Session.Copy()
+		defer ssn.Close()
+
+		for entry := range d.dissectChannel {
+
+			// unlike the ip beacons, there is no uconn style collection holding the
+			// per chunk data for a src/sni/ja3 trio. Instead, each chunk is stored in
+			// the dat array of the beaconSNI record itself, and we merge those chunks
+			// with the current batch here so rolling datasets are analyzed as a whole.
+			var stored struct {
+				Dat []storedChunk `bson:"dat"`
+			}
+
+			_ = ssn.DB(d.db.GetSelectedDB()).C(d.conf.T.BeaconSNI.BeaconSNITable).Find(entry.Hosts.BSONKey()).One(&stored)
+
+			merged := mergeChunks(entry, stored.Dat, d.connLimit)
+
+			// the batch data is always passed along so the writer can store it, even
+			// if the trio doesn't meet the requirements for analysis yet
+			d.dissectedCallback(&dissected{
+				batch:  entry,
+				merged: merged,
+			})
+		}
+		d.dissectWg.Done()
+	}()
+}
+
+//mergeChunks combines the trio data parsed in the current batch with the data
+//stored for previous chunks and batches. The merged timestamps are sorted and
+//left nil if the trio has turned into a strobe.
+func mergeChunks(entry *Input, chunks []storedChunk, connLimit int64) *Input {
+	merged := &Input{
+		Hosts:           entry.Hosts,
+		ConnectionCount: entry.ConnectionCount,
+	}
+
+	tsSet := make(map[int64]struct{})
+	for _, ts := range entry.TsList {
+		tsSet[ts] = struct{}{}
+	}
+	for _, dst := range entry.DstIPs {
+		merged.DstIPs.Insert(dst)
+	}
+
+	for _, chunk := range chunks {
+		merged.ConnectionCount += chunk.Count
+		for _, ts := range chunk.Ts {
+			tsSet[ts] = struct{}{}
+		}
+		for _, dst := range chunk.Dsts {
+			merged.DstIPs.Insert(dst)
+		}
+	}
+
+	// check if strobe. The analyzer will check if the TsList is nil and,
+	// if so, will process it as a strobe
+	if merged.ConnectionCount <= connLimit {
+		merged.TsList = make([]int64, 0, len(tsSet))
+		for ts := range tsSet {
+			merged.TsList = append(merged.TsList, ts)
+		}
+		//sort the timestamps to compute quantiles in the analyzer
+		sort.Sort(util.SortableInt64(merged.TsList))
+	}
+	return merged
+}
@@ -0,0 +1,36 @@
+package beaconsni
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeChunks(t *testing.T) {
+	trio := NewUniqueSrcSNIJA3Trio(data.UniqueIP{IP: "10.0.0.1"}, "c2.example.com", "abc")
+	batch := &Input{
+		Hosts:           trio,
+		TsList:          []int64{300, 100},
+		DstIPs:          data.UniqueIPSet{{IP: "93.184.216.34"}},
+		ConnectionCount: 2,
+	}
+	chunks := []storedChunk{
+		{Count: 2, Ts: []int64{100, 200}, Dsts: data.UniqueIPSet{{IP: "93.184.216.34"}}},
+		{Count: 1, Ts: []int64{400}, Dsts: data.UniqueIPSet{{IP: "93.184.216.35"}}},
+	}
+
+	merged := mergeChunks(batch, chunks, 10)
+	require.Equal(t, trio, merged.Hosts)
+	require.Equal(t, int64(5), merged.ConnectionCount)
+	require.Equal(t, []int64{100, 200, 300, 400}, merged.TsList)
+	require.Len(t, merged.DstIPs, 2)
+
+	// the batch is left untouched so it can be stored as is
+	require.Equal(t, []int64{300, 100}, batch.TsList)
+
+	// strobes are sent on without their timestamps
+	strobe := mergeChunks(batch, chunks, 4)
+	require.Equal(t, int64(5), strobe.ConnectionCount)
+	require.Nil(t, strobe.TsList)
+}
@@ -0,0 +1,150 @@
+package beaconsni
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/resources"
+	"github.com/activecm/rita/util"
+	"github.com/globalsign/mgo/bson"
+	"github.com/vbauerster/mpb"
+	"github.com/vbauerster/mpb/decor"
+)
+
+type repo struct {
+	res *resources.Resources
+	min int64
+	max int64
+}
+
+//NewMongoRepository create new repository
+func NewMongoRepository(res *resources.Resources) Repository {
+	min, max, _ := res.MetaDB.GetTSRange(res.DB.GetSelectedDB())
+	return &repo{
+		res: res,
+		min: min,
+		max: max,
+	}
+}
+
+func (r *repo) CreateIndexes() error {
+	session := r.res.DB.Session.Copy()
+	defer session.Close()
+
+	// set collection name
+	collectionName := r.res.Config.T.BeaconSNI.BeaconSNITable
+
+	// check if collection already exists
+	names, _ := session.DB(r.res.DB.GetSelectedDB()).CollectionNames()
+
+	// if collection exists, we don't need to do anything else
+	for _, name := range names {
+		if name == collectionName {
+			return nil
+		}
+	}
+
+	// set desired indexes
+	indexes := []database.Index{
+		{Key: []string{"-score"}},
+		{Key: []string{"src", "src_network_uuid", "sni", "ja3"}, Unique: true},
+		{Key: []string{"src", "src_network_uuid"}},
+		{Key: []string{"sni"}},
+		{Key: []string{"ja3"}},
+		{Key: []string{"-connection_count"}},
+	}
+
+	// create collection
+	err := r.res.DB.CreateCollection(collectionName, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//Upsert loops through every new src/sni/ja3 trio ....
+func (r *repo) Upsert(sniMap map[string]*Input) {
+
+	// Create the workers
+
+	// stage 3 - write out results
+	writerWorker := newWriter(
+		r.res.Config.T.BeaconSNI.BeaconSNITable,
+		r.res.DB,
+		r.res.Config,
+		r.res.Log,
+	)
+
+	// stage 2 - perform the analysis
+	analyzerWorker := newAnalyzer(
+		r.min,
+		r.max,
+		r.res.Config.S.Rolling.CurrentChunk,
+		r.res.DB,
+		r.res.Config,
+		writerWorker.collect,
+		writerWorker.close,
+	)
+
+	// stage 1 - merge with previous chunks, sort, and vet beacon details
+	dissectorWorker := newDissector(
+		int64(r.res.Config.S.Strobe.ConnectionLimit),
+		r.res.DB,
+		r.res.Config,
+		analyzerWorker.collect,
+		analyzerWorker.close,
+	)
+
+	//kick off the threaded goroutines
+	for i := 0; i < util.Max(1, runtime.NumCPU()/2); i++ {
+		dissectorWorker.start()
+		analyzerWorker.start()
+		writerWorker.start()
+	}
+
+	// progress bar for troubleshooting
+	p := mpb.New(mpb.WithWidth(20))
+	bar := p.AddBar(int64(len(sniMap)),
+		mpb.PrependDecorators(
+			decor.Name("\t[-] SNI Beacon Analysis:", decor.WC{W: 30, C: decor.DidentRight}),
+			decor.CountersNoUnit(" %d / %d ", decor.WCSyncWidth),
+		),
+		mpb.AppendDecorators(decor.Percentage()),
+	)
+
+	// loop over map entries (each src/sni/ja3 trio)
+	for _, entry := range sniMap {
+
+		start := time.Now()
+
+		// pass entry to dissector
+		dissectorWorker.collect(entry)
+
+		// progress bar increment
+		bar.IncrBy(1, time.Since(start))
+
+	}
+	p.Wait()
+
+	// start the closing cascade (this will also close the other channels)
+	dissectorWorker.close()
+}
+
+//Reset removes the SNI beacon scores so they can be re-analyzed. The data
+//stored for each chunk is kept since the scores are rebuilt from it.
+func (r *repo) Reset() error {
+	session := r.res.DB.Session.Copy()
+	defer session.Close()
+
+	if !r.res.DB.CollectionExists(r.res.Config.T.BeaconSNI.BeaconSNITable) {
+		return nil
+	}
+
+	_, err := session.DB(r.res.DB.GetSelectedDB()).C(r.res.Config.T.BeaconSNI.BeaconSNITable).UpdateAll(
+		bson.M{},
+		bson.M{"$unset": bson.M{"strobe": 1, "ts": 1, "score": 1}},
+	)
+	return err
+}
@@ -0,0 +1,63 @@
+package beaconsni
+
+import (
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/util"
+)
+
+//parser groups the TLS sessions in a batch of ssl records by source, server
+//name, and client fingerprint so beacons which hop between destination IPs
+//(e.g. behind a CDN) can still be analyzed
+type parser struct {
+	filter *analysis.Filter
+	sniMap map[string]*Input
+}
+
+//NewParser creates a parser which groups TLS sessions by source, server
+//name, and client fingerprint
+func NewParser(filter *analysis.Filter) analysis.Parser {
+	return &parser{
+		filter: filter,
+		sniMap: make(map[string]*Input),
+	}
+}
+
+//Parse adds a TLS session to its group
+func (p *parser) Parse(record *analysis.Record) {
+	host := record.FQDN
+	if record.Filtered || host == "" || p.filter.FilterDomain(host) {
+		return
+	}
+
+	ja3Hash := record.SSL.JA3
+	if ja3Hash == "" {
+		ja3Hash = "No JA3 hash generated"
+	}
+
+	srcSNIJA3Trio := NewUniqueSrcSNIJA3Trio(record.Src, host, ja3Hash)
+	srcSNIJA3Key := srcSNIJA3Trio.MapKey()
+
+	if _, ok := p.sniMap[srcSNIJA3Key]; !ok {
+		p.sniMap[srcSNIJA3Key] = &Input{
+			Hosts: srcSNIJA3Trio,
+		}
+	}
+	sni := p.sniMap[srcSNIJA3Key]
+
+	// increment connection count
+	sni.ConnectionCount++
+
+	// add timestamp to unique timestamp list
+	ts := record.SSL.TimeStamp
+	if !util.Int64InSlice(ts, sni.TsList) {
+		sni.TsList = append(sni.TsList, ts)
+	}
+
+	// add the destination serving the sni to the unique set
+	sni.DstIPs.Insert(record.Dst)
+}
+
+//Flush returns the TLS sessions gathered from the batch
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	return p.sniMap
+}
@@ -0,0 +1,125 @@
+package beaconsni
+
+import (
+	"strings"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+
+	// Repository for beaconSNI collection
+	Repository interface {
+		CreateIndexes() error
+		Upsert(sniMap map[string]*Input)
+		Reset() error
+	}
+
+	updateInfo struct {
+		selector bson.M
+		query    bson.M
+	}
+
+	//update ....
+	update struct {
+		beacon updateInfo
+	}
+
+	//dissected pairs the trio data parsed in the current import batch with
+	//the same trio's data merged across every stored chunk
+	dissected struct {
+		batch  *Input // data parsed in the current import batch
+		merged *Input // batch data combined with the data stored in previous chunks and batches
+	}
+
+	//storedChunk holds the trio data saved for a previous chunk or batch
+	storedChunk struct {
+		Count int64            `bson:"count"`
+		Ts    []int64          `bson:"ts"`
+		Dsts  data.UniqueIPSet `bson:"dsts"`
+	}
+
+	//TSData ...
+	TSData struct {
+		Range      int64   `bson:"range"`
+		Mode       int64   `bson:"mode"`
+		ModeCount  int64   `bson:"mode_count"`
+		Skew       float64 `bson:"skew"`
+		Dispersion int64   `bson:"dispersion"`
+	}
+
+	//Result represents a TLS beacon between a source IP and a
+	//server name / JA3 fingerprint pair, regardless of the destination
+	//IPs the server name resolved to.
+	Result struct {
+		data.UniqueSrcIP `bson:",inline"`
+		SNI              string  `bson:"sni"`
+		JA3              string  `bson:"ja3"`
+		Connections      int64   `bson:"connection_count"`
+		DstCount         int64   `bson:"dst_count"`
+		Ts               TSData  `bson:"ts"`
+		Score            float64 `bson:"score"`
+	}
+
+	//UniqueSrcSNIJA3Trio is used to make a tuple of
+	// Src IP/UUID/Name, the TLS server name indicator, and the JA3
+	// fingerprint of the client hello
+	UniqueSrcSNIJA3Trio struct {
+		data.UniqueSrcIP `bson:",inline"`
+		SNI              string `bson:"sni"`
+		JA3              string `bson:"ja3"`
+	}
+
+	//Input structure for sending data
+	//to the analyzer. Contains a tuple of
+	// Src IP/UUID/Name, SNI, and JA3 hash. Contains a list of unique
+	// time stamps for the TLS sessions, the set of destination IPs
+	// which served the SNI, and a count of the sessions.
+	Input struct {
+		Hosts           UniqueSrcSNIJA3Trio
+		TsList          []int64
+		DstIPs          data.UniqueIPSet
+		ConnectionCount int64
+	}
+)
+
+//NewUniqueSrcSNIJA3Trio binds a source UniqueIP to a server name and JA3 hash.
+func NewUniqueSrcSNIJA3Trio(source data.UniqueIP, sni string, ja3 string) UniqueSrcSNIJA3Trio {
+	return UniqueSrcSNIJA3Trio{
+		UniqueSrcIP: source.AsSrc(),
+		SNI:         sni,
+		JA3:         ja3,
+	}
+}
+
+//MapKey generates a string which may be used to index a src/sni/ja3 trio. Concatenates IP, UUID, SNI and JA3.
+func (p UniqueSrcSNIJA3Trio) MapKey() string {
+	var builder strings.Builder
+
+	srcUUIDLen := 1 + len(p.SrcNetworkUUID.Data)
+
+	builder.Grow(len(p.SrcIP) + srcUUIDLen + len(p.SNI) + 1 + len(p.JA3))
+	builder.WriteString(p.SrcIP)
+	builder.WriteByte(p.SrcNetworkUUID.Kind)
+	builder.Write(p.SrcNetworkUUID.Data)
+
+	builder.WriteString(p.SNI)
+	// separate the sni from the ja3 hash so the two can't run together
+	builder.WriteByte(0)
+	builder.WriteString(p.JA3)
+
+	return builder.String()
+}
+
+//BSONKey generates a BSON map which may be used to index a given src/sni/ja3 trio
+//Includes IP and Network UUID.
+func (p UniqueSrcSNIJA3Trio) BSONKey() bson.M {
+	key := bson.M{
+		"src":              p.SrcIP,
+		"src_network_uuid": p.SrcNetworkUUID,
+		"sni":              p.SNI,
+		"ja3":              p.JA3,
+	}
+	return key
+}
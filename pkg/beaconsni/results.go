@@ -0,0 +1,45 @@
+package beaconsni
+
+import (
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//Results finds SNI beacons in the database greater than a given cutoffScore
+func Results(res *resources.Resources, cutoffScore float64) ([]Result, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var beaconsSNI []Result
+
+	beaconSNIQuery := bson.M{"score": bson.M{"$gt": cutoffScore}}
+
+	err := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.BeaconSNI.BeaconSNITable).Find(beaconSNIQuery).Sort("-score").All(&beaconsSNI)
+
+	return beaconsSNI, err
+}
+
+//StoredInputs returns the src/sni/ja3 trios stored in the dataset so they can
+//be re-analyzed. The trios carry no data of their own since the dissector
+//merges in the data stored for each chunk.
+func StoredInputs(res *resources.Resources) (map[string]*Input, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var stored UniqueSrcSNIJA3Trio
+
+	sniMap := make(map[string]*Input)
+	iter := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.BeaconSNI.BeaconSNITable).Find(nil).Select(bson.M{
+		"_id":              0,
+		"src":              1,
+		"src_network_uuid": 1,
+		"src_network_name": 1,
+		"sni":              1,
+		"ja3":              1,
+	}).Iter()
+	for iter.Next(&stored) {
+		sniMap[stored.MapKey()] = &Input{Hosts: stored}
+	}
+
+	return sniMap, iter.Close()
+}
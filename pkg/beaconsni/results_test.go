@@ -0,0 +1,92 @@
+package beaconsni
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/resources"
+	"github.com/activecm/rita/util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReanalyze(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rita-beaconsni")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &config.Config{}
+	conf.S.Storage.Backend = database.EmbeddedBackend
+	conf.S.Storage.Path = filepath.Join(dir, "rita.db")
+	db, err := database.NewDB(conf, logrus.New())
+	require.NoError(t, err)
+	defer db.Session.Close()
+	db.SelectDB("dataset")
+
+	conf.T.BeaconSNI.BeaconSNITable = "beaconSNI"
+	conf.S.BeaconSNI.DefaultConnectionThresh = 3
+	conf.S.Strobe.ConnectionLimit = 1000
+	res := &resources.Resources{Config: conf, Log: logrus.New(), DB: db}
+	r := &repo{res: res, min: 0, max: 100}
+	require.NoError(t, r.CreateIndexes())
+
+	trio := NewUniqueSrcSNIJA3Trio(data.UniqueIP{
+		IP:          "10.0.0.1",
+		NetworkUUID: util.UnknownPrivateNetworkUUID,
+		NetworkName: util.UnknownPrivateNetworkName,
+	}, "c2.example.com", "abc")
+	batch := &Input{Hosts: trio, ConnectionCount: 11}
+	for ts := int64(0); ts <= 600; ts += 60 {
+		batch.TsList = append(batch.TsList, ts)
+	}
+	batch.DstIPs.Insert(data.UniqueIP{
+		IP:          "203.0.113.1",
+		NetworkUUID: util.PublicNetworkUUID,
+		NetworkName: util.PublicNetworkName,
+	})
+	r.Upsert(map[string]*Input{trio.MapKey(): batch})
+
+	// re-analyze the stored trios the way `rita analyze --modules beaconsni` does
+	reanalyze := func() {
+		stored, err := StoredInputs(res)
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		require.Equal(t, trio, stored[trio.MapKey()].Hosts)
+		require.NoError(t, r.Reset())
+		r.Upsert(stored)
+	}
+
+	results, err := Results(res, 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, 1.0, results[0].Score)
+
+	// raising the threshold drops the beacon
+	conf.S.BeaconSNI.DefaultConnectionThresh = 20
+	reanalyze()
+	results, err = Results(res, 0)
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	// and lowering it scores the beacon again from the stored timestamps
+	conf.S.BeaconSNI.DefaultConnectionThresh = 3
+	reanalyze()
+	results, err = Results(res, 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, 1.0, results[0].Score)
+	require.Equal(t, int64(11), results[0].Connections)
+	require.Equal(t, int64(1), results[0].DstCount)
+
+	// re-analysis doesn't store the chunk's data again
+	var stored struct {
+		Dat []storedChunk `bson:"dat"`
+	}
+	require.NoError(t, db.Session.DB("dataset").C("beaconSNI").Find(trio.BSONKey()).One(&stored))
+	require.Len(t, stored.Dat, 1)
+}
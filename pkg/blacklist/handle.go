@@ -0,0 +1,162 @@
+package blacklist
+
+import (
+	"sync"
+
+	ritaBLdb "github.com/activecm/rita-bl/database"
+	"github.com/activecm/rita-bl/list"
+	"github.com/activecm/rita/database"
+	"github.com/globalsign/mgo/bson"
+)
+
+//listsCollection holds the metadata of the lists registered with rita-bl
+const listsCollection = "lists"
+
+//storeHandle lets rita-bl keep its lists in RITA's own storage backend
+//so the blacklist works without a MongoDB server
+type storeHandle struct {
+	session  database.Session
+	database string
+}
+
+//newStoreHandle returns a rita-bl database handle which uses the given session
+func newStoreHandle(session database.Session, db string) ritaBLdb.Handle {
+	return &storeHandle{session: session, database: db}
+}
+
+//GetRegisteredLists retrieves all of the lists registered with the database
+func (s *storeHandle) GetRegisteredLists() ([]list.Metadata, error) {
+	var lists []list.Metadata
+	ssn := s.session.Copy()
+	defer ssn.Close()
+
+	err := ssn.DB(s.database).C(listsCollection).Find(nil).All(&lists)
+	return lists, err
+}
+
+//RegisterList registers a new blacklist source with the database
+func (s *storeHandle) RegisterList(l list.Metadata) error {
+	ssn := s.session.Copy()
+	defer ssn.Close()
+
+	collectionNames, err := ssn.DB(s.database).CollectionNames()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for _, name := range collectionNames {
+		existing[name] = true
+	}
+
+	if !existing[listsCollection] {
+		err = ssn.DB(s.database).C(listsCollection).Create(&database.CollectionInfo{})
+		if err != nil {
+			return err
+		}
+		err = ssn.DB(s.database).C(listsCollection).EnsureIndex(database.Index{
+			Key:    []string{"name"},
+			Unique: true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ssn.DB(s.database).C(listsCollection).Insert(l)
+	if err != nil {
+		return err
+	}
+
+	//create the collections for the types of entries this list produces
+	for _, entryType := range l.Types {
+		if existing[string(entryType)] {
+			continue
+		}
+		coll := ssn.DB(s.database).C(string(entryType))
+		err = coll.Create(&database.CollectionInfo{})
+		if err != nil {
+			return err
+		}
+		err = coll.EnsureIndex(database.Index{
+			Key:    []string{"index", "list"},
+			Unique: true,
+		})
+		if err != nil {
+			return err
+		}
+		existing[string(entryType)] = true
+	}
+	return nil
+}
+
+//RemoveList removes an existing blacklist source from the database
+func (s *storeHandle) RemoveList(l list.Metadata) error {
+	err := s.ClearCache(l)
+	if err != nil {
+		return err
+	}
+	ssn := s.session.Copy()
+	defer ssn.Close()
+	return ssn.DB(s.database).C(listsCollection).Remove(bson.M{"name": l.Name})
+}
+
+//UpdateListMetadata updates the metadata of an existing blacklist
+func (s *storeHandle) UpdateListMetadata(l list.Metadata) error {
+	ssn := s.session.Copy()
+	defer ssn.Close()
+	return ssn.DB(s.database).C(listsCollection).Update(bson.M{"name": l.Name}, l)
+}
+
+//ClearCache clears old entries for a given list
+func (s *storeHandle) ClearCache(l list.Metadata) error {
+	ssn := s.session.Copy()
+	defer ssn.Close()
+	for _, entryType := range l.Types {
+		_, err := ssn.DB(s.database).C(string(entryType)).RemoveAll(bson.M{"list": l.Name})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//InsertEntries inserts entries from a list into the database
+func (s *storeHandle) InsertEntries(entryType list.BlacklistedEntryType,
+	entries <-chan list.BlacklistedEntry, wg *sync.WaitGroup, errorsOut chan<- error) {
+	defer wg.Done()
+	ssn := s.session.Copy()
+	defer ssn.Close()
+
+	buffSize := 100000
+	i := 0
+	bulk := ssn.DB(s.database).C(string(entryType)).Bulk()
+	for entry := range entries {
+		bulk.Insert(ritaBLdb.BlacklistResult{
+			Index:     entry.Index,
+			List:      entry.List.GetMetadata().Name,
+			ExtraData: entry.ExtraData,
+		})
+		i++
+		if i == buffSize {
+			if _, err := bulk.Run(); err != nil {
+				errorsOut <- err
+			}
+			i = 0
+			bulk = ssn.DB(s.database).C(string(entryType)).Bulk()
+		}
+	}
+	if i != 0 {
+		if _, err := bulk.Run(); err != nil {
+			errorsOut <- err
+		}
+	}
+}
+
+//FindEntries finds entries of a given type and index
+func (s *storeHandle) FindEntries(dataType list.BlacklistedEntryType, index string) ([]ritaBLdb.BlacklistResult, error) {
+	ssn := s.session.Copy()
+	defer ssn.Close()
+	var entries []ritaBLdb.BlacklistResult
+	err := ssn.DB(s.database).C(string(dataType)).Find(bson.M{"index": index}).All(&entries)
+	return entries, err
+}
@@ -0,0 +1,417 @@
+package blacklist
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/activecm/rita-bl/list"
+)
+
+//Indicator types which may be read from threat intelligence files
+const (
+	IndicatorIP        = "ip"
+	IndicatorHostname  = "hostname"
+	IndicatorJA3       = "ja3"
+	IndicatorUserAgent = "useragent"
+)
+
+type (
+	//Reason describes why an entry was blacklisted. Entries from line separated
+	//lists only record the list they came from.
+	Reason struct {
+		List        string   `bson:"list"`
+		Source      string   `bson:"source,omitempty"`
+		Confidence  int      `bson:"confidence,omitempty"`
+		Tags        []string `bson:"tags,omitempty"`
+		Description string   `bson:"description,omitempty"`
+	}
+
+	//Indicator is a blacklisted value read from a threat intelligence file
+	Indicator struct {
+		Type   string `bson:"type"`
+		Value  string `bson:"value"`
+		Reason `bson:",inline"`
+	}
+
+	stixBundle struct {
+		Objects []stixObject `json:"objects"`
+	}
+
+	stixObject struct {
+		Type           string   `json:"type"`
+		ID             string   `json:"id"`
+		Name           string   `json:"name"`
+		Description    string   `json:"description"`
+		Pattern        string   `json:"pattern"`
+		PatternType    string   `json:"pattern_type"`
+		CreatedByRef   string   `json:"created_by_ref"`
+		Confidence     int      `json:"confidence"`
+		Labels         []string `json:"labels"`
+		IndicatorTypes []string `json:"indicator_types"`
+		Revoked        bool     `json:"revoked"`
+	}
+
+	mispTag struct {
+		Name string `json:"name"`
+	}
+
+	mispAttribute struct {
+		Type    string    `json:"type"`
+		Value   string    `json:"value"`
+		Comment string    `json:"comment"`
+		ToIDS   *bool     `json:"to_ids"`
+		Tags    []mispTag `json:"Tag"`
+	}
+
+	mispEvent struct {
+		Info          string      `json:"info"`
+		ThreatLevelID json.Number `json:"threat_level_id"`
+		Orgc          struct {
+			Name string `json:"name"`
+		} `json:"Orgc"`
+		Tags       []mispTag       `json:"Tag"`
+		Attributes []mispAttribute `json:"Attribute"`
+		Objects    []struct {
+			Attributes []mispAttribute `json:"Attribute"`
+		} `json:"Object"`
+	}
+
+	mispWrapper struct {
+		Event *mispEvent `json:"Event"`
+	}
+
+	//intelList is a blacklist read from a threat intelligence file on disk.
+	//IPs and hostnames are passed to rita-bl along with their metadata, while
+	//CIDR ranges, JA3 hashes, and user agents are held back to be stored by
+	//storeExtendedEntries.
+	intelList struct {
+		meta       list.Metadata
+		path       string
+		parse      func(io.Reader, string) ([]Indicator, error)
+		ranges     []RangeMatch
+		indicators []Indicator
+		loaded     bool // whether the file was parsed by the last FetchData
+	}
+)
+
+//newSTIXList creates a new blacklist from a STIX 2.1 bundle on disk
+func newSTIXList(path string) *intelList {
+	return newIntelList(path, parseSTIXBundle)
+}
+
+//newMISPList creates a new blacklist from a MISP event export on disk
+func newMISPList(path string) *intelList {
+	return newIntelList(path, parseMISPEvents)
+}
+
+func newIntelList(path string, parse func(io.Reader, string) ([]Indicator, error)) *intelList {
+	return &intelList{
+		meta: list.Metadata{
+			Types:     []list.BlacklistedEntryType{list.BlacklistedIPType, list.BlacklistedHostnameType},
+			Name:      path,
+			CacheTime: 0, // Always reload the data
+		},
+		path:  path,
+		parse: parse,
+	}
+}
+
+//GetMetadata returns the Metadata associated with this blacklist
+func (l *intelList) GetMetadata() list.Metadata {
+	return l.meta
+}
+
+//SetMetadata sets the Metadata associated with this blacklist
+func (l *intelList) SetMetadata(meta list.Metadata) {
+	l.meta = meta
+}
+
+//extendedEntries returns the ranges and indicators rita-bl can't index and
+//whether the file was parsed successfully
+func (l *intelList) extendedEntries() ([]RangeMatch, []Indicator, bool) {
+	return l.ranges, l.indicators, l.loaded
+}
+
+//FetchData reads the indicators from the file, sending IPs and hostnames
+//to rita-bl and keeping the rest for storeExtendedEntries
+func (l *intelList) FetchData(entryMap list.BlacklistedEntryMap, errorsOut chan<- error) {
+	defer close(entryMap[list.BlacklistedIPType])
+	defer close(entryMap[list.BlacklistedHostnameType])
+
+	l.ranges = nil
+	l.indicators = nil
+	l.loaded = false
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		errorsOut <- err
+		return
+	}
+	defer file.Close()
+
+	indicators, err := l.parse(file, l.meta.Name)
+	if err != nil {
+		errorsOut <- err
+		return
+	}
+
+	// rita-bl rejects duplicate entries within a list, so only the
+	// first indicator for each value is kept
+	seen := make(map[string]struct{})
+	for _, indicator := range indicators {
+		key := indicator.Type + "|" + indicator.Value
+		if _, ok := seen[key]; ok || indicator.Value == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		switch indicator.Type {
+		case IndicatorIP:
+			if strings.Contains(indicator.Value, "/") {
+				_, network, err := net.ParseCIDR(indicator.Value)
+				if err != nil {
+					errorsOut <- err
+					continue
+				}
+				ones, bits := network.Mask.Size()
+				if ones != bits {
+					l.ranges = append(l.ranges, RangeMatch{Range: network.String(), Reason: indicator.Reason})
+					continue
+				}
+				indicator.Value = network.IP.String()
+			}
+			entryMap[list.BlacklistedIPType] <- newReasonEntry(indicator, l)
+		case IndicatorHostname:
+			entryMap[list.BlacklistedHostnameType] <- newReasonEntry(indicator, l)
+		default:
+			l.indicators = append(l.indicators, indicator)
+		}
+	}
+	l.loaded = true
+}
+
+//newReasonEntry creates a rita-bl entry carrying the indicator's metadata
+func newReasonEntry(indicator Indicator, source list.List) list.BlacklistedEntry {
+	entry := list.NewBlacklistedEntry(indicator.Value, source)
+	if indicator.Source != "" {
+		entry.ExtraData["source"] = indicator.Source
+	}
+	if indicator.Confidence != 0 {
+		entry.ExtraData["confidence"] = indicator.Confidence
+	}
+	if len(indicator.Tags) > 0 {
+		entry.ExtraData["tags"] = indicator.Tags
+	}
+	if indicator.Description != "" {
+		entry.ExtraData["description"] = indicator.Description
+	}
+	return entry
+}
+
+//stixComparison matches a single comparison expression in a STIX pattern,
+//capturing the object type, the object path, and the quoted value
+var stixComparison = regexp.MustCompile(`([a-z0-9-]+):([A-Za-z0-9_.'\-]+)\s*=\s*'((?:[^'\\]|\\.)*)'`)
+
+//mispThreatLevels maps MISP threat level ids to their names
+var mispThreatLevels = map[string]string{
+	"1": "high",
+	"2": "medium",
+	"3": "low",
+}
+
+//parseSTIXBundle reads the indicators from a STIX 2.1 bundle. Only equality
+//comparisons in STIX patterns are supported. list names the file the bundle
+//was read from.
+func parseSTIXBundle(r io.Reader, list string) ([]Indicator, error) {
+	var bundle stixBundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, err
+	}
+
+	// map identity ids to their names so indicators can name their source
+	identities := make(map[string]string)
+	for _, obj := range bundle.Objects {
+		if obj.Type == "identity" {
+			identities[obj.ID] = obj.Name
+		}
+	}
+
+	var indicators []Indicator
+	for _, obj := range bundle.Objects {
+		if obj.Type != "indicator" || obj.Revoked {
+			continue
+		}
+		if obj.PatternType != "" && obj.PatternType != "stix" {
+			continue
+		}
+
+		reason := Reason{
+			List:        list,
+			Source:      identities[obj.CreatedByRef],
+			Confidence:  obj.Confidence,
+			Tags:        appendUnique(obj.Labels, obj.IndicatorTypes...),
+			Description: obj.Description,
+		}
+		if reason.Description == "" {
+			reason.Description = obj.Name
+		}
+
+		for _, match := range stixComparison.FindAllStringSubmatch(obj.Pattern, -1) {
+			indicatorType := stixIndicatorType(match[1], strings.ToLower(match[2]))
+			if indicatorType == "" {
+				continue
+			}
+			value := strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(match[3])
+			indicators = append(indicators, newIndicator(indicatorType, value, reason))
+		}
+	}
+	return indicators, nil
+}
+
+//stixIndicatorType returns the indicator type for a STIX object type and
+//path, or an empty string if the comparison can't be matched by RITA
+func stixIndicatorType(objType string, path string) string {
+	switch {
+	case strings.Contains(objType, "ja3") || strings.Contains(path, "ja3"):
+		return IndicatorJA3
+	case strings.Contains(objType, "user-agent") || strings.Contains(path, "user-agent"):
+		return IndicatorUserAgent
+	case (objType == "ipv4-addr" || objType == "ipv6-addr") && path == "value":
+		return IndicatorIP
+	case objType == "network-traffic" && (path == "src_ref.value" || path == "dst_ref.value"):
+		return IndicatorIP
+	case objType == "domain-name" && path == "value":
+		return IndicatorHostname
+	case objType == "url" && path == "value":
+		return IndicatorHostname
+	}
+	return ""
+}
+
+//parseMISPEvents reads the attributes from a MISP event export. Single
+//events, lists of events, and REST API responses are accepted.
+//list names the file the events were read from.
+func parseMISPEvents(r io.Reader, list string) ([]Indicator, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	var events []mispWrapper
+	var single mispWrapper
+	var response struct {
+		Response []mispWrapper `json:"response"`
+	}
+	if err := json.Unmarshal(raw, &events); err != nil {
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil, err
+		}
+		if single.Event != nil {
+			events = []mispWrapper{single}
+		} else if err := json.Unmarshal(raw, &response); err == nil {
+			events = response.Response
+		}
+	}
+
+	var indicators []Indicator
+	for _, wrapper := range events {
+		event := wrapper.Event
+		if event == nil {
+			continue
+		}
+
+		var eventTags []string
+		for _, tag := range event.Tags {
+			eventTags = appendUnique(eventTags, tag.Name)
+		}
+		// MISP events carry a threat level rather than a confidence
+		if level, ok := mispThreatLevels[event.ThreatLevelID.String()]; ok {
+			eventTags = appendUnique(eventTags, "threat-level:"+level)
+		}
+
+		attributes := event.Attributes
+		for _, obj := range event.Objects {
+			attributes = append(attributes, obj.Attributes...)
+		}
+
+		for _, attr := range attributes {
+			// attributes not meant for detection are skipped
+			if attr.ToIDS != nil && !*attr.ToIDS {
+				continue
+			}
+
+			reason := Reason{
+				List:        list,
+				Source:      event.Orgc.Name,
+				Tags:        append([]string(nil), eventTags...),
+				Description: attr.Comment,
+			}
+			for _, tag := range attr.Tags {
+				reason.Tags = appendUnique(reason.Tags, tag.Name)
+			}
+			if reason.Description == "" {
+				reason.Description = event.Info
+			}
+
+			// composite attributes hold two values separated by a pipe
+			values := strings.SplitN(attr.Value, "|", 2)
+			switch attr.Type {
+			case "ip-src", "ip-dst", "ip-src|port", "ip-dst|port":
+				indicators = append(indicators, newIndicator(IndicatorIP, values[0], reason))
+			case "domain", "hostname", "hostname|port", "url":
+				indicators = append(indicators, newIndicator(IndicatorHostname, values[0], reason))
+			case "domain|ip":
+				indicators = append(indicators, newIndicator(IndicatorHostname, values[0], reason))
+				if len(values) > 1 {
+					indicators = append(indicators, newIndicator(IndicatorIP, values[1], reason))
+				}
+			case "ja3-fingerprint-md5":
+				indicators = append(indicators, newIndicator(IndicatorJA3, values[0], reason))
+			case "user-agent":
+				indicators = append(indicators, newIndicator(IndicatorUserAgent, attr.Value, reason))
+			}
+		}
+	}
+	return indicators, nil
+}
+
+//newIndicator normalizes an indicator value for matching against the dataset
+func newIndicator(indicatorType string, value string, reason Reason) Indicator {
+	switch indicatorType {
+	case IndicatorHostname:
+		// urls are matched on their host
+		if u, err := url.Parse(value); err == nil && u.Host != "" {
+			value = u.Hostname()
+		}
+		value = strings.TrimSuffix(strings.ToLower(value), ".")
+	case IndicatorIP, IndicatorJA3:
+		value = strings.ToLower(value)
+	}
+	return Indicator{
+		Type:   indicatorType,
+		Value:  strings.TrimSpace(value),
+		Reason: reason,
+	}
+}
+
+//appendUnique appends the values not already in the slice
+func appendUnique(slice []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range slice {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			slice = append(slice, value)
+		}
+	}
+	return slice
+}
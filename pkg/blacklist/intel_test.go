@@ -0,0 +1,109 @@
+package blacklist
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSTIXBundle = `{
+  "type": "bundle",
+  "id": "bundle--1",
+  "objects": [
+    {"type": "identity", "id": "identity--1", "name": "Intel Team"},
+    {
+      "type": "indicator",
+      "id": "indicator--1",
+      "created_by_ref": "identity--1",
+      "name": "C2 infrastructure",
+      "confidence": 80,
+      "labels": ["c2"],
+      "indicator_types": ["malicious-activity"],
+      "pattern_type": "stix",
+      "pattern": "[ipv4-addr:value = '198.51.100.7'] OR [domain-name:value = 'Evil.Example.COM'] OR [ipv4-addr:value = '203.0.113.0/24']"
+    },
+    {
+      "type": "indicator",
+      "id": "indicator--2",
+      "description": "Implant TLS client",
+      "pattern_type": "stix",
+      "pattern": "[x-ja3:value = 'E7D705A3286E19EA42F587B344EE6865'] AND [network-traffic:extensions.'http-request-ext'.request_header.'User-Agent' = 'Mozilla\\'s Agent']"
+    },
+    {
+      "type": "indicator",
+      "id": "indicator--3",
+      "revoked": true,
+      "pattern_type": "stix",
+      "pattern": "[ipv4-addr:value = '192.0.2.1']"
+    },
+    {
+      "type": "indicator",
+      "id": "indicator--4",
+      "pattern_type": "snort",
+      "pattern": "alert tcp any any -> any any"
+    }
+  ]
+}`
+
+const testMISPEvent = `{
+  "Event": {
+    "info": "Phishing campaign",
+    "threat_level_id": "1",
+    "Orgc": {"name": "CIRCL"},
+    "Tag": [{"name": "tlp:amber"}],
+    "Attribute": [
+      {"type": "ip-dst|port", "value": "198.51.100.9|443", "to_ids": true},
+      {"type": "domain|ip", "value": "bad.example.net|198.51.100.10", "comment": "landing page"},
+      {"type": "ip-src", "value": "192.0.2.55", "to_ids": false},
+      {"type": "md5", "value": "d41d8cd98f00b204e9800998ecf8427e"}
+    ],
+    "Object": [
+      {"Attribute": [{"type": "user-agent", "value": "EvilBot/1.0", "Tag": [{"name": "bot"}]}]}
+    ]
+  }
+}`
+
+func TestParseSTIXBundle(t *testing.T) {
+	indicators, err := parseSTIXBundle(strings.NewReader(testSTIXBundle), "bundle.json")
+	require.NoError(t, err)
+
+	c2Reason := Reason{
+		List:        "bundle.json",
+		Source:      "Intel Team",
+		Confidence:  80,
+		Tags:        []string{"c2", "malicious-activity"},
+		Description: "C2 infrastructure",
+	}
+	implantReason := Reason{List: "bundle.json", Description: "Implant TLS client"}
+
+	require.Equal(t, []Indicator{
+		{Type: IndicatorIP, Value: "198.51.100.7", Reason: c2Reason},
+		{Type: IndicatorHostname, Value: "evil.example.com", Reason: c2Reason},
+		{Type: IndicatorIP, Value: "203.0.113.0/24", Reason: c2Reason},
+		{Type: IndicatorJA3, Value: "e7d705a3286e19ea42f587b344ee6865", Reason: implantReason},
+		{Type: IndicatorUserAgent, Value: "Mozilla's Agent", Reason: implantReason},
+	}, indicators)
+}
+
+func TestParseMISPEvents(t *testing.T) {
+	indicators, err := parseMISPEvents(strings.NewReader(testMISPEvent), "event.json")
+	require.NoError(t, err)
+
+	eventTags := []string{"tlp:amber", "threat-level:high"}
+	require.Equal(t, []Indicator{
+		{Type: IndicatorIP, Value: "198.51.100.9", Reason: Reason{List: "event.json", Source: "CIRCL", Tags: eventTags, Description: "Phishing campaign"}},
+		{Type: IndicatorHostname, Value: "bad.example.net", Reason: Reason{List: "event.json", Source: "CIRCL", Tags: eventTags, Description: "landing page"}},
+		{Type: IndicatorIP, Value: "198.51.100.10", Reason: Reason{List: "event.json", Source: "CIRCL", Tags: eventTags, Description: "landing page"}},
+		{Type: IndicatorUserAgent, Value: "EvilBot/1.0", Reason: Reason{List: "event.json", Source: "CIRCL", Tags: []string{"tlp:amber", "threat-level:high", "bot"}, Description: "Phishing campaign"}},
+	}, indicators)
+
+	// lists of events are accepted as well
+	listed, err := parseMISPEvents(strings.NewReader("["+testMISPEvent+"]"), "event.json")
+	require.NoError(t, err)
+	require.Equal(t, indicators, listed)
+
+	response, err := parseMISPEvents(strings.NewReader(`{"response": [`+testMISPEvent+`]}`), "event.json")
+	require.NoError(t, err)
+	require.Equal(t, indicators, response)
+}
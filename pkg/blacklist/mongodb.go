@@ -2,13 +2,15 @@ package blacklist
 
 import (
 	"fmt"
+	"net"
 	"runtime"
 
+	"github.com/activecm/rita/database"
 	"github.com/activecm/rita/pkg/data"
 	"github.com/activecm/rita/resources"
 	"github.com/activecm/rita/util"
-	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
+	log "github.com/sirupsen/logrus"
 )
 
 type repo struct {
@@ -31,7 +33,7 @@ func (r *repo) CreateIndexes() error {
 
 	// create hosts collection
 	// Desired indexes
-	indexes := []mgo.Index{
+	indexes := []database.Index{
 		{Key: []string{"dat.bl.ip", "dat.bl.network_uuid"}},
 	}
 
@@ -47,6 +49,10 @@ func (r *repo) CreateIndexes() error {
 //Upsert loops through every domain ....
 func (r *repo) Upsert() {
 
+	// flag hosts which fall within a blacklisted range before
+	// finding the peers of every blacklisted host
+	r.markBlacklistedRanges()
+
 	session := r.res.DB.Session.Copy()
 	defer session.Close()
 
@@ -82,3 +88,73 @@ func (r *repo) Upsert() {
 	analyzerWorker.close()
 
 }
+
+//markBlacklistedRanges flags the hosts seen in the current chunk which fall
+//within a blacklisted CIDR range, and records which ranges and lists they matched
+func (r *repo) markBlacklistedRanges() {
+	session := r.res.DB.Session.Copy()
+	defer session.Close()
+
+	var ranges []RangeMatch
+	err := session.DB(r.res.Config.S.Blacklisted.BlacklistDatabase).C(rangeCollection).Find(nil).All(&ranges)
+	if err != nil {
+		r.res.Log.Error(err)
+		return
+	}
+	index := newRangeIndex(ranges)
+
+	hostColl := session.DB(r.res.DB.GetSelectedDB()).C(r.res.Config.T.Structure.HostTable)
+	ipColl := session.DB(r.res.Config.S.Blacklisted.BlacklistDatabase).C("ip")
+
+	// without any ranges, only hosts flagged by a previous import need updating
+	hostQuery := bson.M{"cid": r.res.Config.S.Rolling.CurrentChunk}
+	if len(ranges) == 0 {
+		hostQuery["bl_ranges"] = bson.M{"$exists": true}
+	}
+
+	iter := hostColl.Find(hostQuery).Select(bson.M{"ip": 1, "network_uuid": 1, "bl_ranges": 1}).Iter()
+
+	var host struct {
+		data.UniqueIP `bson:",inline"`
+		Ranges        []RangeMatch `bson:"bl_ranges"`
+	}
+	for iter.Next(&host) {
+		flagged := len(host.Ranges) > 0
+		// Next does not clear fields missing from the next document
+		host.Ranges = nil
+
+		var query bson.M
+		matches := index.lookup(net.ParseIP(host.IP))
+		if len(matches) > 0 {
+			query = bson.M{"$set": bson.M{"blacklisted": true, "bl_ranges": matches}}
+		} else if flagged {
+			// the host may still be blacklisted by rita-bl's exact IP lists
+			listed, err := ipColl.Find(bson.M{"index": host.IP}).Count()
+			if err != nil {
+				r.res.Log.WithFields(log.Fields{
+					"Module": "bl updater",
+					"Data":   host.IP,
+				}).Error(err)
+				continue
+			}
+			query = bson.M{
+				"$set":   bson.M{"blacklisted": listed > 0},
+				"$unset": bson.M{"bl_ranges": ""},
+			}
+		} else {
+			continue
+		}
+
+		err := hostColl.Update(host.UniqueIP.BSONKey(), query)
+		if err != nil {
+			r.res.Log.WithFields(log.Fields{
+				"Module": "bl updater",
+				"Data":   host.IP,
+			}).Error(err)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		r.res.Log.Error(err)
+	}
+}
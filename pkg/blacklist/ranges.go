@@ -0,0 +1,170 @@
+package blacklist
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/activecm/rita-bl/list"
+)
+
+//rangeCollection holds the CIDR ranges read from the custom IP blacklists
+//and indicatorCollection holds the JA3 and user agent indicators read from
+//threat intelligence files. Both live in the blacklist database alongside
+//the rita-bl collections.
+const (
+	rangeCollection     = "ip_range"
+	indicatorCollection = "indicator"
+)
+
+type (
+	//RangeMatch records a blacklisted CIDR range and why it was blacklisted
+	RangeMatch struct {
+		Range  string `bson:"range"`
+		Reason `bson:",inline"`
+	}
+
+	//extendedList is a blacklist holding entries rita-bl can't index
+	extendedList interface {
+		list.List
+		extendedEntries() ([]RangeMatch, []Indicator, bool)
+	}
+
+	//rangeList is a line separated IP blacklist which may contain CIDR ranges.
+	//rita-bl only indexes single addresses, so single IPs are passed through
+	//to rita-bl while ranges are held back to be stored in rangeCollection.
+	rangeList struct {
+		meta       list.Metadata
+		dataSource func() (io.ReadCloser, error)
+		ranges     []RangeMatch
+		loaded     bool // whether the whole list was read by the last FetchData
+	}
+
+	//rangeIndex supports looking up every blacklisted range containing an IP.
+	//Ranges are grouped by prefix length so each lookup costs one map access
+	//per distinct prefix length rather than one comparison per range.
+	rangeIndex struct {
+		masks    []net.IPMask
+		networks map[string][]RangeMatch
+	}
+)
+
+//newRangeList creates a new IP blacklist which accepts CIDR ranges
+func newRangeList(name string, dataFactory func() (io.ReadCloser, error)) *rangeList {
+	return &rangeList{
+		meta: list.Metadata{
+			Types:     []list.BlacklistedEntryType{list.BlacklistedIPType},
+			Name:      name,
+			CacheTime: 0, // Always reload the data
+		},
+		dataSource: dataFactory,
+	}
+}
+
+//GetMetadata returns the Metadata associated with this blacklist
+func (r *rangeList) GetMetadata() list.Metadata {
+	return r.meta
+}
+
+//SetMetadata sets the Metadata associated with this blacklist
+func (r *rangeList) SetMetadata(meta list.Metadata) {
+	r.meta = meta
+}
+
+//extendedEntries returns the ranges rita-bl can't index and whether the
+//list was read successfully
+func (r *rangeList) extendedEntries() ([]RangeMatch, []Indicator, bool) {
+	return r.ranges, nil, r.loaded
+}
+
+//FetchData sends the single IPs in the list to rita-bl and keeps the
+//CIDR ranges for storeRanges
+func (r *rangeList) FetchData(entryMap list.BlacklistedEntryMap, errorsOut chan<- error) {
+	entryType := r.meta.Types[0]
+	defer close(entryMap[entryType])
+
+	r.ranges = nil
+	r.loaded = false
+
+	reader, err := r.dataSource()
+	if err != nil {
+		errorsOut <- err
+		return
+	}
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		//skip empty and commented lines
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		if strings.Contains(line, "/") {
+			_, network, err := net.ParseCIDR(line)
+			if err != nil {
+				errorsOut <- err
+				continue
+			}
+
+			// single host ranges can use rita-bl's exact match
+			ones, bits := network.Mask.Size()
+			if ones != bits {
+				r.ranges = append(r.ranges, RangeMatch{Range: network.String(), Reason: Reason{List: r.meta.Name}})
+				continue
+			}
+			line = network.IP.String()
+		}
+
+		entryMap[entryType] <- list.NewBlacklistedEntry(line, r)
+	}
+
+	if scanner.Err() != nil {
+		errorsOut <- scanner.Err()
+		return
+	}
+	r.loaded = true
+}
+
+//newRangeIndex builds a rangeIndex over the given ranges. Entries which are
+//not valid CIDR ranges are skipped.
+func newRangeIndex(ranges []RangeMatch) *rangeIndex {
+	index := &rangeIndex{
+		networks: make(map[string][]RangeMatch),
+	}
+
+	seenMasks := make(map[string]struct{})
+	for _, entry := range ranges {
+		_, network, err := net.ParseCIDR(entry.Range)
+		if err != nil {
+			continue
+		}
+
+		key := network.String()
+		index.networks[key] = append(index.networks[key], entry)
+
+		if _, ok := seenMasks[network.Mask.String()]; !ok {
+			seenMasks[network.Mask.String()] = struct{}{}
+			index.masks = append(index.masks, network.Mask)
+		}
+	}
+	return index
+}
+
+//lookup returns every blacklisted range containing ip
+func (r *rangeIndex) lookup(ip net.IP) []RangeMatch {
+	var matches []RangeMatch
+	for _, mask := range r.masks {
+		// Mask returns nil if the mask and ip are of different families
+		masked := ip.Mask(mask)
+		if masked == nil {
+			continue
+		}
+		network := net.IPNet{IP: masked, Mask: mask}
+		matches = append(matches, r.networks[network.String()]...)
+	}
+	return matches
+}
@@ -0,0 +1,124 @@
+package blacklist
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/activecm/rita-bl/list"
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeListFetchData(t *testing.T) {
+	source := "# comment\n\n1.2.3.4\n10.0.0.0/8\n192.168.1.7/32\n2001:db8::/32\nnot/a/range\n"
+	l := newRangeList("custom", func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(source)), nil
+	})
+
+	entryMap := list.NewBlacklistedEntryMap(list.BlacklistedIPType)
+	errorsOut := make(chan error, 10)
+	go l.FetchData(entryMap, errorsOut)
+
+	var entries []string
+	for entry := range entryMap[list.BlacklistedIPType] {
+		entries = append(entries, entry.Index)
+	}
+
+	require.Equal(t, []string{"1.2.3.4", "192.168.1.7"}, entries)
+	require.Equal(t, []RangeMatch{
+		{Range: "10.0.0.0/8", Reason: Reason{List: "custom"}},
+		{Range: "2001:db8::/32", Reason: Reason{List: "custom"}},
+	}, l.ranges)
+	require.Len(t, errorsOut, 1)
+
+	// a bad line doesn't stop the rest of the list from being stored
+	_, _, ok := l.extendedEntries()
+	require.True(t, ok)
+}
+
+func TestRangeIndexLookup(t *testing.T) {
+	index := newRangeIndex([]RangeMatch{
+		{Range: "10.0.0.0/8", Reason: Reason{List: "a"}},
+		{Range: "10.1.0.0/16", Reason: Reason{List: "b"}},
+		{Range: "10.1.0.0/16", Reason: Reason{List: "c"}},
+		{Range: "2001:db8::/32", Reason: Reason{List: "a"}},
+		{Range: "garbage", Reason: Reason{List: "a"}},
+	})
+
+	require.ElementsMatch(t, []RangeMatch{
+		{Range: "10.0.0.0/8", Reason: Reason{List: "a"}},
+		{Range: "10.1.0.0/16", Reason: Reason{List: "b"}},
+		{Range: "10.1.0.0/16", Reason: Reason{List: "c"}},
+	}, index.lookup(net.ParseIP("10.1.2.3")))
+
+	require.Equal(t, []RangeMatch{{Range: "10.0.0.0/8", Reason: Reason{List: "a"}}}, index.lookup(net.ParseIP("10.2.2.3")))
+	require.Equal(t, []RangeMatch{{Range: "2001:db8::/32", Reason: Reason{List: "a"}}}, index.lookup(net.ParseIP("2001:db8::1")))
+	require.Empty(t, index.lookup(net.ParseIP("8.8.8.8")))
+}
+
+func TestMarkBlacklistedRanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rita-blacklist")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	session, err := database.NewEmbeddedSession(filepath.Join(dir, "rita.db"), 0)
+	require.NoError(t, err)
+	defer session.Close()
+
+	db := &database.DB{Session: session}
+	db.SelectDB("dataset")
+	conf := &config.Config{}
+	conf.T.Structure.HostTable = "host"
+	conf.S.Blacklisted.BlacklistDatabase = "rita-bl"
+	r := &repo{res: &resources.Resources{Config: conf, Log: log.New(), DB: db}}
+
+	hostColl := session.DB("dataset").C("host")
+	rangeColl := session.DB("rita-bl").C(rangeCollection)
+	for _, ip := range []string{"10.1.2.3", "10.1.2.4", "192.168.0.1"} {
+		doc := data.UniqueIP{IP: ip}.BSONKey()
+		doc["cid"] = 0
+		require.NoError(t, hostColl.Insert(doc))
+	}
+	require.NoError(t, rangeColl.Insert(RangeMatch{Range: "10.0.0.0/8", Reason: Reason{List: "a"}}))
+	// 10.1.2.4 is also on an exact IP list indexed by rita-bl
+	require.NoError(t, session.DB("rita-bl").C("ip").Insert(bson.M{"index": "10.1.2.4"}))
+
+	type host struct {
+		IP          string       `bson:"ip"`
+		Blacklisted bool         `bson:"blacklisted"`
+		Ranges      []RangeMatch `bson:"bl_ranges"`
+	}
+	stored := func() []host {
+		var hosts []host
+		require.NoError(t, hostColl.Find(nil).Sort("ip").All(&hosts))
+		return hosts
+	}
+
+	r.markBlacklistedRanges()
+	inRange := []RangeMatch{{Range: "10.0.0.0/8", Reason: Reason{List: "a"}}}
+	require.Equal(t, []host{
+		{IP: "10.1.2.3", Blacklisted: true, Ranges: inRange},
+		{IP: "10.1.2.4", Blacklisted: true, Ranges: inRange},
+		{IP: "192.168.0.1"},
+	}, stored())
+
+	// once the range is removed, only the host on an exact IP list stays blacklisted
+	_, err = rangeColl.RemoveAll(nil)
+	require.NoError(t, err)
+	r.markBlacklistedRanges()
+	require.Equal(t, []host{
+		{IP: "10.1.2.3"},
+		{IP: "10.1.2.4", Blacklisted: true},
+		{IP: "192.168.0.1"},
+	}, stored())
+}
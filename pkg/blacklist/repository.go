@@ -32,6 +32,8 @@ type IPResult struct {
 	UniqueConnections int             `bson:"uconn_count"`
 	TotalBytes        int             `bson:"total_bytes"`
 	Peers             []data.UniqueIP `bson:"peers"`
+	Ranges            []RangeMatch    `bson:"bl_ranges"`
+	Reasons           []Reason        `bson:"-"`
 }
 
 //HostnameResult represents a blacklisted hostname and summary
@@ -42,4 +44,15 @@ type HostnameResult struct {
 	UniqueConnections int             `bson:"uconn_count"`
 	TotalBytes        int             `bson:"total_bytes"`
 	ConnectedHosts    []data.UniqueIP `bson:"sources,omitempty"`
+	Reasons           []Reason        `bson:"-"`
+}
+
+//UserAgentResult represents a blacklisted JA3 hash or user agent and
+//the hosts which used it
+type UserAgentResult struct {
+	UserAgent string          `bson:"user_agent"`
+	JA3       bool            `bson:"ja3"`
+	TimesUsed int64           `bson:"seen"`
+	OrigIps   []data.UniqueIP `bson:"orig_ips"`
+	Reasons   []Reason        `bson:"-"`
 }
@@ -1,6 +1,7 @@
 package blacklist
 
 import (
+	"github.com/activecm/rita-bl/list"
 	"github.com/activecm/rita/resources"
 	"github.com/globalsign/mgo/bson"
 )
@@ -104,6 +105,19 @@ func HostnameResults(res *resources.Resources, sort string, limit int, noLimit b
 	var blHosts []HostnameResult
 
 	err := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.DNS.HostnamesTable).Pipe(blHostsQuery).AllowDiskUse().All(&blHosts)
+	if err != nil {
+		return blHosts, err
+	}
+
+	// explain why each hostname was blacklisted
+	var hostnames []string
+	for _, blHost := range blHosts {
+		hostnames = append(hostnames, blHost.Host)
+	}
+	reasons, err := blacklistReasons(res, list.BlacklistedHostnameType, hostnames)
+	for i := range blHosts {
+		blHosts[i].Reasons = reasons[blHosts[i].Host]
+	}
 
 	return blHosts, err
 }
@@ -121,7 +135,7 @@ func SrcIPResults(res *resources.Resources, sort string, limit int, noLimit bool
 //descending order keyed on of {uconn_count, conn_count, total_bytes} depending on the value
 //of sort. limit and noLimit control how many results are returned.
 func DstIPResults(res *resources.Resources, sort string, limit int, noLimit bool) ([]IPResult, error) {
-	return ipResults(res, sort, limit, false, noLimit)
+	return ipResults(res, sort, limit, noLimit, false)
 }
 
 //ipResults implements SrcIPResults and DstIPResults. Set sourceDestFlag to true
@@ -163,6 +177,7 @@ func ipResults(res *resources.Resources, sort string, limit int, noLimit bool, s
 			"ip":           1,
 			"network_uuid": 1,
 			"network_name": 1,
+			"bl_ranges":    1,
 		}},
 		// join on both src/dst and src/dst_network_uuid
 		{"$lookup": bson.M{
@@ -185,6 +200,7 @@ func ipResults(res *resources.Resources, sort string, limit int, noLimit bool, s
 			"ip":                1,
 			"network_uuid":      1,
 			"network_name":      1,
+			"bl_ranges":         1,
 			"peer_ip":           "$uconn." + blPeerField,
 			"peer_network_uuid": "$uconn." + blPeerField + "_network_uuid",
 			"peer_network_name": "$uconn." + blPeerField + "_network_name",
@@ -210,6 +226,7 @@ func ipResults(res *resources.Resources, sort string, limit int, noLimit bool, s
 			// there should only be one network_name in each record
 			// as it comes from the hosts collection
 			"network_name": bson.M{"$last": "$network_name"},
+			"bl_ranges":    bson.M{"$last": "$bl_ranges"},
 			// use one of the network names associated with the network_uuid
 			// for this partial result
 			"peer_network_name": bson.M{"$last": "$peer_network_name"},
@@ -223,6 +240,7 @@ func ipResults(res *resources.Resources, sort string, limit int, noLimit bool, s
 			"ip":           "$_id.ip",
 			"network_uuid": "$_id.network_uuid",
 			"network_name": "$network_name",
+			"bl_ranges":    1,
 			"peer": bson.M{
 				"ip":           "$_id.peer_ip",
 				"network_uuid": "$_id.peer_network_uuid",
@@ -239,9 +257,10 @@ func ipResults(res *resources.Resources, sort string, limit int, noLimit bool, s
 				"network_uuid": "$network_uuid",
 				"network_name": "$network_name",
 			},
-			"peers":  bson.M{"$addToSet": "$peer"},
-			"conns":  bson.M{"$sum": "$conns"},
-			"tbytes": bson.M{"$sum": "$tbytes"},
+			"peers":     bson.M{"$addToSet": "$peer"},
+			"bl_ranges": bson.M{"$last": "$bl_ranges"},
+			"conns":     bson.M{"$sum": "$conns"},
+			"tbytes":    bson.M{"$sum": "$tbytes"},
 		}},
 		// move the id fields back out and add uconn_count
 		{"$project": bson.M{
@@ -250,6 +269,7 @@ func ipResults(res *resources.Resources, sort string, limit int, noLimit bool, s
 			"network_uuid": "$_id.network_uuid",
 			"network_name": "$_id.network_name",
 			"peers":        1,
+			"bl_ranges":    1,
 			"conn_count":   "$conns",
 			"uconn_count":  bson.M{"$size": bson.M{"$ifNull": []interface{}{"$peers", []interface{}{}}}},
 			"total_bytes":  "$tbytes",
@@ -262,7 +282,114 @@ func ipResults(res *resources.Resources, sort string, limit int, noLimit bool, s
 	}
 
 	err := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.Structure.HostTable).Pipe(blIPQuery).AllowDiskUse().All(&blIPs)
+	if err != nil {
+		return blIPs, err
+	}
+
+	// explain why each IP was blacklisted. IPs which only fall
+	// within a blacklisted range carry their reasons in Ranges.
+	var ips []string
+	for _, blIP := range blIPs {
+		ips = append(ips, blIP.Host.IP)
+	}
+	reasons, err := blacklistReasons(res, list.BlacklistedIPType, ips)
+	for i := range blIPs {
+		blIPs[i].Reasons = reasons[blIPs[i].Host.IP]
+	}
 
 	return blIPs, err
 
 }
+
+//UserAgentResults finds the JA3 hashes and user agents in the database which
+//match the indicators read from threat intelligence files, sorted by how many
+//times each was used. limit and noLimit control how many results are returned.
+func UserAgentResults(res *resources.Resources, limit int, noLimit bool) ([]UserAgentResult, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var indicators []Indicator
+	err := ssn.DB(res.Config.S.Blacklisted.BlacklistDatabase).C(indicatorCollection).Find(nil).All(&indicators)
+	if err != nil {
+		return nil, err
+	}
+
+	reasons := make(map[string][]Reason)
+	ja3s := []string{}
+	userAgents := []string{}
+	for _, indicator := range indicators {
+		key := indicator.Type + "|" + indicator.Value
+		if _, ok := reasons[key]; !ok {
+			if indicator.Type == IndicatorJA3 {
+				ja3s = append(ja3s, indicator.Value)
+			} else {
+				userAgents = append(userAgents, indicator.Value)
+			}
+		}
+		reasons[key] = append(reasons[key], indicator.Reason)
+	}
+
+	blAgentsQuery := []bson.M{
+		// JA3 hashes and user agents share the useragent collection
+		{"$match": bson.M{"$or": []bson.M{
+			{"ja3": true, "user_agent": bson.M{"$in": ja3s}},
+			{"ja3": bson.M{"$ne": true}, "user_agent": bson.M{"$in": userAgents}},
+		}}},
+		{"$project": bson.M{
+			"user_agent": 1,
+			"ja3":        1,
+			"seen":       bson.M{"$sum": "$dat.seen"},
+			"orig_ips": bson.M{"$reduce": bson.M{
+				"input":        "$dat.orig_ips",
+				"initialValue": []interface{}{},
+				"in":           bson.M{"$setUnion": []interface{}{"$$value", bson.M{"$ifNull": []interface{}{"$$this", []interface{}{}}}}},
+			}},
+		}},
+		{"$sort": bson.M{"seen": -1}},
+	}
+
+	if !noLimit {
+		blAgentsQuery = append(blAgentsQuery, bson.M{"$limit": limit})
+	}
+
+	var blAgents []UserAgentResult
+
+	err = ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.UserAgent.UserAgentTable).Pipe(blAgentsQuery).AllowDiskUse().All(&blAgents)
+
+	for i := range blAgents {
+		indicatorType := IndicatorUserAgent
+		if blAgents[i].JA3 {
+			indicatorType = IndicatorJA3
+		}
+		blAgents[i].Reasons = reasons[indicatorType+"|"+blAgents[i].UserAgent]
+	}
+
+	return blAgents, err
+}
+
+//blacklistReasons looks up why each of the given indexes was blacklisted in
+//the rita-bl collection holding entryType entries
+func blacklistReasons(res *resources.Resources, entryType list.BlacklistedEntryType, indexes []string) (map[string][]Reason, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	reasons := make(map[string][]Reason)
+	if len(indexes) == 0 {
+		return reasons, nil
+	}
+
+	var entries []struct {
+		Index     string `bson:"index"`
+		List      string `bson:"list"`
+		ExtraData Reason `bson:"extradata"`
+	}
+
+	err := ssn.DB(res.Config.S.Blacklisted.BlacklistDatabase).C(string(entryType)).Find(bson.M{"index": bson.M{"$in": indexes}}).All(&entries)
+
+	for _, entry := range entries {
+		reason := entry.ExtraData
+		reason.List = entry.List
+		reasons[entry.Index] = append(reasons[entry.Index], reason)
+	}
+	return reasons, err
+}
@@ -5,13 +5,16 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"time"
 
 	ritaBL "github.com/activecm/rita-bl"
 	ritaBLdb "github.com/activecm/rita-bl/database"
 	"github.com/activecm/rita-bl/list"
 	"github.com/activecm/rita-bl/sources/lists"
 	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
 	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -50,10 +53,13 @@ func buildBlacklistReferenceCollection(res *resources.Resources) {
 	// set current dataset name
 	currentDB := res.DB.GetSelectedDB()
 
-	// User option set in yaml file, if enabled
+	// The embedded backend keeps the blacklist alongside the datasets.
+	// Otherwise, if the user option is set in the yaml file,
 	// RITA will verify the MongoDB certificate's hostname and validity
 	// otherwise run a normal request to create a database
-	if res.Config.S.MongoDB.TLS.Enabled {
+	if res.Config.S.Storage.Backend == database.EmbeddedBackend {
+		blDatabase = newStoreHandle(res.DB.Session, res.Config.S.Blacklisted.BlacklistDatabase)
+	} else if res.Config.S.MongoDB.TLS.Enabled {
 		blDatabase, err = ritaBLdb.NewSecureMongoDB(
 			res.Config.S.MongoDB.ConnectionString,
 			res.Config.R.MongoDB.AuthMechanismParsed,
@@ -85,15 +91,30 @@ func buildBlacklistReferenceCollection(res *resources.Resources) {
 	)
 
 	//send blacklist source lists
-	ritaBL.SetLists(getSourceLists(res.Config)...)
+	sourceLists, extendedLists := getSourceLists(res.Config)
+	ritaBL.SetLists(sourceLists...)
 
 	//update the lists
 	ritaBL.Update()
 
+	//store the entries rita-bl can't index
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+	err = storeExtendedEntries(ssn.DB(res.Config.S.Blacklisted.BlacklistDatabase), extendedLists, extendedListNames(res.Config))
+	if err != nil {
+		res.Log.Error(err)
+		fmt.Println("\t[!] Could not update blacklisted IP ranges and indicators")
+	}
 }
 
-//getSourceLists gathers the blacklists to check against
-func getSourceLists(conf *config.Config) []list.List {
+//getSourceLists gathers the blacklists to check against. The lists which
+//hold entries rita-bl can't index are also returned separately so those
+//entries can be stored.
+func getSourceLists(conf *config.Config) ([]list.List, []extendedList) {
+	if conf.S.Blacklisted.Offline {
+		return getOfflineSourceLists(conf)
+	}
+
 	//build up the lists
 	var blacklists []list.List
 	//use prebuilt lists
@@ -104,20 +125,146 @@ func getSourceLists(conf *config.Config) []list.List {
 		blacklists = append(blacklists, lists.NewFeodoList())
 	}
 	//use custom lists
-	ipLists := buildCustomBlacklists(
-		list.BlacklistedIPType,
-		conf.S.Blacklisted.IPBlacklists,
-	)
+	var extendedLists []extendedList
+	for _, path := range conf.S.Blacklisted.IPBlacklists {
+		extendedLists = append(extendedLists, newRangeList(path, tryOpenFileThenURL(path)))
+	}
 
 	hostLists := buildCustomBlacklists(
 		list.BlacklistedHostnameType,
 		conf.S.Blacklisted.HostnameBlacklists,
 	)
 
-	blacklists = append(blacklists, ipLists...)
 	blacklists = append(blacklists, hostLists...)
 
-	return blacklists
+	return appendIntelLists(conf, blacklists, extendedLists)
+}
+
+//getOfflineSourceLists gathers the blacklists to check against without
+//accessing the network. Lists which would be fetched over the network are
+//read from the snapshot directory instead.
+func getOfflineSourceLists(conf *config.Config) ([]list.List, []extendedList) {
+	snap, err := openSnapshot(conf.S.Blacklisted.SnapshotDirectory)
+	if err != nil {
+		fmt.Printf("\t[!] No blacklist snapshot found in %s\n", conf.S.Blacklisted.SnapshotDirectory)
+	} else {
+		fmt.Printf("\t[-] Using blacklist snapshot from %s\n", time.Unix(snap.manifest.Created, 0).Format(time.RFC1123))
+	}
+
+	var blacklists []list.List
+	//use prebuilt lists as of the snapshot
+	if conf.S.Blacklisted.UseDNSBH {
+		if source, ok := snap.source("dns-bh", list.BlacklistedHostnameType); ok {
+			blacklists = append(blacklists, lists.NewLineSeparatedList(list.BlacklistedHostnameType, "dns-bh", 0, source))
+		}
+	}
+	if conf.S.Blacklisted.UseFeodo {
+		if source, ok := snap.source("feodo tracker", list.BlacklistedIPType); ok {
+			blacklists = append(blacklists, lists.NewLineSeparatedList(list.BlacklistedIPType, "feodo tracker", 0, source))
+		}
+	}
+	//use custom lists, reading urls from the snapshot
+	var extendedLists []extendedList
+	for _, path := range conf.S.Blacklisted.IPBlacklists {
+		if !isRemoteList(path) {
+			extendedLists = append(extendedLists, newRangeList(path, tryOpenFileThenURL(path)))
+		} else if source, ok := snap.source(path, list.BlacklistedIPType); ok {
+			extendedLists = append(extendedLists, newRangeList(path, source))
+		}
+	}
+	for _, path := range conf.S.Blacklisted.HostnameBlacklists {
+		if !isRemoteList(path) {
+			blacklists = append(blacklists, buildCustomBlacklists(list.BlacklistedHostnameType, []string{path})...)
+		} else if source, ok := snap.source(path, list.BlacklistedHostnameType); ok {
+			blacklists = append(blacklists, lists.NewLineSeparatedList(list.BlacklistedHostnameType, path, 0, source))
+		}
+	}
+
+	return appendIntelLists(conf, blacklists, extendedLists)
+}
+
+//appendIntelLists adds the threat intelligence files to the blacklists
+func appendIntelLists(conf *config.Config, blacklists []list.List, extendedLists []extendedList) ([]list.List, []extendedList) {
+	//use threat intelligence files
+	for _, path := range conf.S.Blacklisted.STIXBundles {
+		extendedLists = append(extendedLists, newSTIXList(path))
+	}
+	for _, path := range conf.S.Blacklisted.MISPEvents {
+		extendedLists = append(extendedLists, newMISPList(path))
+	}
+
+	for _, extended := range extendedLists {
+		blacklists = append(blacklists, extended)
+	}
+
+	return blacklists, extendedLists
+}
+
+//storeExtendedEntries replaces the CIDR ranges and indicators stored for
+//each list with the entries just read from it. A list which could not be
+//read keeps its previous entries, while the entries of lists which are no
+//longer configured are removed.
+func storeExtendedEntries(blDB database.Database, extendedLists []extendedList, configured []string) error {
+	for _, extended := range extendedLists {
+		name := extended.GetMetadata().Name
+		ranges, indicators, ok := extended.extendedEntries()
+		if !ok {
+			fmt.Printf("\t[!] Could not read blacklist %s, keeping its previous entries\n", name)
+			continue
+		}
+
+		var rangeDocs, indicatorDocs []interface{}
+		for _, ipRange := range ranges {
+			rangeDocs = append(rangeDocs, ipRange)
+		}
+		for _, indicator := range indicators {
+			indicatorDocs = append(indicatorDocs, indicator)
+		}
+
+		if err := replaceListEntries(blDB.C(rangeCollection), name, rangeDocs); err != nil {
+			return err
+		}
+		if err := replaceListEntries(blDB.C(indicatorCollection), name, indicatorDocs); err != nil {
+			return err
+		}
+	}
+
+	// a nil slice would be stored as null rather than an empty array
+	if configured == nil {
+		configured = []string{}
+	}
+	for _, collection := range []string{rangeCollection, indicatorCollection} {
+		_, err := blDB.C(collection).RemoveAll(bson.M{"list": bson.M{"$nin": configured}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//extendedListNames returns the names of the configured lists which may
+//hold entries rita-bl can't index
+func extendedListNames(conf *config.Config) []string {
+	var names []string
+	names = append(names, conf.S.Blacklisted.IPBlacklists...)
+	names = append(names, conf.S.Blacklisted.STIXBundles...)
+	names = append(names, conf.S.Blacklisted.MISPEvents...)
+	return names
+}
+
+//replaceListEntries replaces the documents in the collection which came
+//from the named list with docs
+func replaceListEntries(coll database.Collection, name string, docs []interface{}) error {
+	_, err := coll.RemoveAll(bson.M{"list": name})
+	if err != nil || len(docs) == 0 {
+		return err
+	}
+
+	bulk := coll.Bulk()
+	bulk.Unordered()
+	bulk.Insert(docs...)
+	_, err = bulk.Run()
+	return err
 }
 
 //buildCustomBlacklists gathers a custom blacklist from a url or file path
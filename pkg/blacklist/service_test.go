@@ -0,0 +1,69 @@
+package blacklist
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/activecm/rita-bl/list"
+	"github.com/activecm/rita/database"
+	"github.com/stretchr/testify/require"
+)
+
+//fetchedRangeList reads a range list the way rita-bl does during an update
+func fetchedRangeList(name string, source string, fail bool) *rangeList {
+	l := newRangeList(name, func() (io.ReadCloser, error) {
+		if fail {
+			return nil, errors.New("unreachable")
+		}
+		return ioutil.NopCloser(strings.NewReader(source)), nil
+	})
+
+	entryMap := list.NewBlacklistedEntryMap(list.BlacklistedIPType)
+	errorsOut := make(chan error, 10)
+	go l.FetchData(entryMap, errorsOut)
+	for range entryMap[list.BlacklistedIPType] {
+	}
+	return l
+}
+
+func TestStoreExtendedEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rita-blacklist")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	session, err := database.NewEmbeddedSession(filepath.Join(dir, "rita.db"), 0)
+	require.NoError(t, err)
+	defer session.Close()
+	blDB := session.DB("rita-bl")
+
+	stored := func() []RangeMatch {
+		var ranges []RangeMatch
+		require.NoError(t, blDB.C(rangeCollection).Find(nil).Sort("range").All(&ranges))
+		return ranges
+	}
+
+	err = storeExtendedEntries(blDB, []extendedList{
+		fetchedRangeList("a", "10.0.0.0/8\n", false),
+		fetchedRangeList("b", "172.16.0.0/12\n", false),
+		fetchedRangeList("c", "192.168.0.0/16\n", false),
+	}, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Len(t, stored(), 3)
+
+	// list a changed, list b could not be fetched, and list c was removed
+	err = storeExtendedEntries(blDB, []extendedList{
+		fetchedRangeList("a", "10.1.0.0/16\n", false),
+		fetchedRangeList("b", "", true),
+	}, []string{"a", "b"})
+	require.NoError(t, err)
+
+	require.Equal(t, []RangeMatch{
+		{Range: "10.1.0.0/16", Reason: Reason{List: "a"}},
+		{Range: "172.16.0.0/12", Reason: Reason{List: "b"}},
+	}, stored())
+}
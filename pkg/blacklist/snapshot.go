@@ -0,0 +1,331 @@
+package blacklist
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/activecm/rita-bl/list"
+	"github.com/activecm/rita-bl/sources/lists"
+	"github.com/activecm/rita/config"
+)
+
+//snapshotManifestFile names the manifest inside a snapshot bundle and
+//the snapshot directory
+const snapshotManifestFile = "manifest.json"
+
+type (
+	//SnapshotManifest describes the blacklist feeds held in a snapshot
+	SnapshotManifest struct {
+		Created     int64          `json:"created"`
+		RITAVersion string         `json:"rita_version"`
+		Feeds       []SnapshotFeed `json:"feeds"`
+	}
+
+	//SnapshotFeed records the entries of a single type fetched from a
+	//blacklist feed, when they were fetched, and a version hash of the entries
+	SnapshotFeed struct {
+		Name    string `json:"name"`
+		Type    string `json:"type"`
+		File    string `json:"file"`
+		Fetched int64  `json:"fetched"`
+		Version string `json:"version"`
+		Entries int    `json:"entries"`
+	}
+
+	//snapshot is a snapshot loaded from disk for offline mode
+	snapshot struct {
+		dir      string
+		manifest SnapshotManifest
+	}
+)
+
+//isRemoteList returns true if a custom blacklist must be fetched over the
+//network, mirroring the fallback in tryOpenFileThenURL
+func isRemoteList(path string) bool {
+	_, err := os.Stat(path)
+	return err != nil
+}
+
+//remoteLists gathers the blacklists which are fetched over the network
+func remoteLists(conf *config.Config) []list.List {
+	var remote []list.List
+	if conf.S.Blacklisted.UseDNSBH {
+		remote = append(remote, lists.NewDNSBHList())
+	}
+	if conf.S.Blacklisted.UseFeodo {
+		remote = append(remote, lists.NewFeodoList())
+	}
+	for _, path := range conf.S.Blacklisted.IPBlacklists {
+		if isRemoteList(path) {
+			remote = append(remote, newRangeList(path, tryOpenFileThenURL(path)))
+		}
+	}
+	for _, path := range conf.S.Blacklisted.HostnameBlacklists {
+		if isRemoteList(path) {
+			remote = append(remote, buildCustomBlacklists(list.BlacklistedHostnameType, []string{path})...)
+		}
+	}
+	return remote
+}
+
+//ExportSnapshot fetches every blacklist feed which requires network access and
+//writes the entries to a gzipped tar bundle at bundlePath along with a manifest.
+//The bundle may be loaded on an offline machine with ImportSnapshot.
+func ExportSnapshot(conf *config.Config, bundlePath string) (SnapshotManifest, error) {
+	manifest := SnapshotManifest{
+		Created:     time.Now().Unix(),
+		RITAVersion: conf.S.Version,
+	}
+
+	files := make(map[string][]byte)
+	for i, feed := range remoteLists(conf) {
+		entries, err := fetchEntries(feed)
+		if err != nil {
+			return manifest, fmt.Errorf("could not fetch %s: %v", feed.GetMetadata().Name, err)
+		}
+
+		fetched := time.Now().Unix()
+		for _, entryType := range feed.GetMetadata().Types {
+			contents := []byte(strings.Join(entries[entryType], "\n") + "\n")
+			hash := sha256.Sum256(contents)
+			fileName := "feed-" + strconv.Itoa(i) + "-" + string(entryType) + ".txt"
+
+			files[fileName] = contents
+			manifest.Feeds = append(manifest.Feeds, SnapshotFeed{
+				Name:    feed.GetMetadata().Name,
+				Type:    string(entryType),
+				File:    fileName,
+				Fetched: fetched,
+				Version: hex.EncodeToString(hash[:]),
+				Entries: len(entries[entryType]),
+			})
+		}
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+	files[snapshotManifestFile] = manifestJSON
+
+	return manifest, writeBundle(bundlePath, files)
+}
+
+//fetchEntries reads every entry from a blacklist, including the CIDR ranges
+//held back from rita-bl
+func fetchEntries(feed list.List) (map[list.BlacklistedEntryType][]string, error) {
+	errorsOut := make(chan error)
+	var fetchErrs []string
+	errsDone := make(chan struct{})
+	go func() {
+		for err := range errorsOut {
+			fetchErrs = append(fetchErrs, err.Error())
+		}
+		close(errsDone)
+	}()
+
+	entryMap := list.FetchAndValidateEntries(feed, errorsOut)
+
+	// each entry type must be read concurrently
+	entries := make(map[list.BlacklistedEntryType][]string)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for entryType, entryChannel := range entryMap {
+		wg.Add(1)
+		go func(entryType list.BlacklistedEntryType, entryChannel chan list.BlacklistedEntry) {
+			var values []string
+			for entry := range entryChannel {
+				values = append(values, entry.Index)
+			}
+			mutex.Lock()
+			entries[entryType] = values
+			mutex.Unlock()
+			wg.Done()
+		}(entryType, entryChannel)
+	}
+	wg.Wait()
+
+	close(errorsOut)
+	<-errsDone
+
+	if extended, ok := feed.(extendedList); ok {
+		ranges, _, _ := extended.extendedEntries()
+		for _, ipRange := range ranges {
+			entries[list.BlacklistedIPType] = append(entries[list.BlacklistedIPType], ipRange.Range)
+		}
+	}
+
+	// a feed which produced nothing but errors could not be reached
+	total := 0
+	for _, values := range entries {
+		total += len(values)
+	}
+	if total == 0 && len(fetchErrs) > 0 {
+		return nil, errors.New(strings.Join(fetchErrs, "; "))
+	}
+	return entries, nil
+}
+
+//writeBundle writes files to a gzipped tar archive
+func writeBundle(bundlePath string, files map[string][]byte) error {
+	bundle, err := os.Create(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer bundle.Close()
+
+	gz := gzip.NewWriter(bundle)
+	tw := tar.NewWriter(gz)
+
+	for name, contents := range files {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(contents)),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(contents); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+//ImportSnapshot unpacks a bundle created by ExportSnapshot into the snapshot
+//directory set in the config, replacing any previous snapshot
+func ImportSnapshot(conf *config.Config, bundlePath string) (SnapshotManifest, error) {
+	var manifest SnapshotManifest
+
+	bundle, err := os.Open(bundlePath)
+	if err != nil {
+		return manifest, err
+	}
+	defer bundle.Close()
+
+	gz, err := gzip.NewReader(bundle)
+	if err != nil {
+		return manifest, err
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return manifest, err
+		}
+		contents, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return manifest, err
+		}
+		// only plain file names are expected in a bundle
+		files[filepath.Base(header.Name)] = contents
+	}
+
+	manifestJSON, ok := files[snapshotManifestFile]
+	if !ok {
+		return manifest, errors.New("bundle does not contain a manifest")
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return manifest, err
+	}
+
+	// verify every feed before replacing the existing snapshot
+	for _, feed := range manifest.Feeds {
+		contents, ok := files[feed.File]
+		if !ok {
+			return manifest, fmt.Errorf("bundle is missing %s for %s", feed.File, feed.Name)
+		}
+		hash := sha256.Sum256(contents)
+		if hex.EncodeToString(hash[:]) != feed.Version {
+			return manifest, fmt.Errorf("%s for %s does not match its version hash", feed.File, feed.Name)
+		}
+	}
+
+	dir := conf.S.Blacklisted.SnapshotDirectory
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return manifest, err
+	}
+
+	// remove the previous snapshot's feeds
+	if previous, err := loadSnapshotManifest(dir); err == nil {
+		for _, feed := range previous.Feeds {
+			os.Remove(filepath.Join(dir, filepath.Base(feed.File)))
+		}
+	}
+
+	for _, feed := range manifest.Feeds {
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.Base(feed.File)), files[feed.File], 0644); err != nil {
+			return manifest, err
+		}
+	}
+
+	// write the manifest last so a partial import is never loaded
+	return manifest, ioutil.WriteFile(filepath.Join(dir, snapshotManifestFile), manifestJSON, 0644)
+}
+
+//loadSnapshotManifest reads the manifest of the snapshot in dir
+func loadSnapshotManifest(dir string) (SnapshotManifest, error) {
+	var manifest SnapshotManifest
+	manifestJSON, err := ioutil.ReadFile(filepath.Join(dir, snapshotManifestFile))
+	if err != nil {
+		return manifest, err
+	}
+	err = json.Unmarshal(manifestJSON, &manifest)
+	return manifest, err
+}
+
+//openSnapshot loads the snapshot in dir for use in offline mode
+func openSnapshot(dir string) (*snapshot, error) {
+	manifest, err := loadSnapshotManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{dir: dir, manifest: manifest}, nil
+}
+
+//source returns a data source reading a feed's entries of the given type
+//from the snapshot. Feeds missing from the snapshot are reported and skipped.
+func (s *snapshot) source(name string, entryType list.BlacklistedEntryType) (func() (io.ReadCloser, error), bool) {
+	if s != nil {
+		for _, feed := range s.manifest.Feeds {
+			if feed.Name == name && feed.Type == string(entryType) {
+				path := filepath.Join(s.dir, filepath.Base(feed.File))
+				return func() (io.ReadCloser, error) {
+					contents, err := ioutil.ReadFile(path)
+					if err != nil {
+						return nil, err
+					}
+					return ioutil.NopCloser(bytes.NewReader(contents)), nil
+				}, true
+			}
+		}
+	}
+	fmt.Printf("\t[!] Blacklist %s is not in the offline snapshot, skipping\n", name)
+	return nil, false
+}
@@ -0,0 +1,76 @@
+package blacklist
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/activecm/rita-bl/list"
+	"github.com/activecm/rita/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotExportImport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "1.2.3.4\n10.0.0.0/8\n")
+	}))
+	defer server.Close()
+
+	tmp, err := ioutil.TempDir("", "rita-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	conf := &config.Config{}
+	conf.S.Version = "test"
+	conf.S.Blacklisted.IPBlacklists = []string{server.URL}
+	conf.S.Blacklisted.SnapshotDirectory = filepath.Join(tmp, "snapshots")
+	bundle := filepath.Join(tmp, "bundle.tar.gz")
+
+	exported, err := ExportSnapshot(conf, bundle)
+	require.NoError(t, err)
+	require.Len(t, exported.Feeds, 1)
+	require.Equal(t, server.URL, exported.Feeds[0].Name)
+	require.Equal(t, 2, exported.Feeds[0].Entries)
+
+	// the snapshot must be used without contacting the server
+	server.Close()
+
+	imported, err := ImportSnapshot(conf, bundle)
+	require.NoError(t, err)
+	require.Equal(t, exported, imported)
+
+	conf.S.Blacklisted.Offline = true
+	blacklists, extendedLists := getSourceLists(conf)
+	require.Len(t, blacklists, 1)
+	require.Len(t, extendedLists, 1)
+
+	entries, err := fetchEntries(blacklists[0])
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.2.3.4", "10.0.0.0/8"}, entries[list.BlacklistedIPType])
+}
+
+func TestSnapshotImportRejectsModifiedFeed(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "rita-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	manifest := []byte(`{"created": 1, "feeds": [{"name": "a", "type": "ip", "file": "feed-0-ip.txt", "version": "0000"}]}`)
+	bundle := filepath.Join(tmp, "bundle.tar.gz")
+	require.NoError(t, writeBundle(bundle, map[string][]byte{
+		snapshotManifestFile: manifest,
+		"feed-0-ip.txt":      []byte("1.2.3.4\n"),
+	}))
+
+	conf := &config.Config{}
+	conf.S.Blacklisted.SnapshotDirectory = filepath.Join(tmp, "snapshots")
+
+	_, err = ImportSnapshot(conf, bundle)
+	require.Error(t, err)
+
+	_, err = os.Stat(conf.S.Blacklisted.SnapshotDirectory)
+	require.True(t, os.IsNotExist(err))
+}
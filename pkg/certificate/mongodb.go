@@ -4,9 +4,9 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/activecm/rita/database"
 	"github.com/activecm/rita/resources"
 	"github.com/activecm/rita/util"
-	"github.com/globalsign/mgo"
 	"github.com/vbauerster/mpb"
 	"github.com/vbauerster/mpb/decor"
 )
@@ -39,7 +39,7 @@ func (r *repo) CreateIndexes() error {
 		}
 	}
 
-	indexes := []mgo.Index{
+	indexes := []database.Index{
 		{Key: []string{"ip", "network_uuid"}, Unique: true},
 		{Key: []string{"dat.seen"}},
 	}
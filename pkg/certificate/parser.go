@@ -0,0 +1,59 @@
+package certificate
+
+import (
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/util"
+)
+
+//parser gathers the hosts which presented invalid certificates in a batch
+//of ssl records
+type parser struct {
+	certMap map[string]*Input
+}
+
+//NewParser creates a parser which gathers the hosts presenting invalid
+//certificates
+func NewParser() analysis.Parser {
+	return &parser{certMap: make(map[string]*Input)}
+}
+
+//Parse records the validation problem and client of a session with an
+//invalid certificate
+func (p *parser) Parse(record *analysis.Record) {
+	if record.Filtered || !record.SSL.InvalidCert() {
+		return
+	}
+
+	dstKey := record.Dst.MapKey()
+	if _, ok := p.certMap[dstKey]; !ok {
+		p.certMap[dstKey] = &Input{
+			Host: record.Dst,
+		}
+	}
+	cert := p.certMap[dstKey]
+	cert.Seen++
+
+	status := record.SSL.ValidationStatus
+	if !util.StringInSlice(status, cert.InvalidCerts) {
+		cert.InvalidCerts = append(cert.InvalidCerts, status)
+	}
+	// add src of ssl request to unique array
+	cert.OrigIps.Insert(record.Src)
+}
+
+//Flush returns the hosts gathered from the batch along with the port,
+//protocol, and service tuples of every uconn to them
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	for _, uconn := range batch.Uconns {
+		cert, ok := p.certMap[uconn.Hosts.UniqueDstIP.Unpair().MapKey()]
+		if !ok {
+			continue
+		}
+		for _, tuple := range uconn.Tuples {
+			if !util.StringInSlice(tuple, cert.Tuples) {
+				cert.Tuples = append(cert.Tuples, tuple)
+			}
+		}
+	}
+	return p.certMap
+}
@@ -0,0 +1,90 @@
+package data
+
+import (
+	"sort"
+	"strconv"
+)
+
+//sparkBlocks are the glyphs used to draw a score between 0 and 1
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+type (
+	//HistoryEntry is the score a result received when a chunk was analyzed,
+	//along with the dataset's latest timestamp at that point which orders
+	//the entries since chunk IDs are reused by rolling datasets
+	HistoryEntry struct {
+		Score float64 `bson:"score"`
+		TS    int64   `bson:"ts"`
+	}
+
+	//ScoreHistory maps the IDs of the chunks a result was scored in to the
+	//score it received. Entries are removed along with their chunk.
+	ScoreHistory map[string]HistoryEntry
+
+	//Trend summarizes a ScoreHistory in the order the chunks were analyzed
+	Trend struct {
+		FirstSeen int       // chunk the result was first scored in
+		Scores    []float64 // oldest first
+		Last      float64   // latest score
+		Delta     float64   // change in score since the previous chunk
+	}
+)
+
+//HistoryField returns the field a result's score for the given chunk is
+//stored in
+func HistoryField(cid int) string {
+	return "history." + strconv.Itoa(cid)
+}
+
+//Trend orders the history and summarizes how the score changed
+func (h ScoreHistory) Trend() Trend {
+	type chunkEntry struct {
+		cid int
+		HistoryEntry
+	}
+
+	var entries []chunkEntry
+	for key, entry := range h {
+		cid, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, chunkEntry{cid, entry})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].TS != entries[j].TS {
+			return entries[i].TS < entries[j].TS
+		}
+		return entries[i].cid < entries[j].cid
+	})
+
+	trend := Trend{FirstSeen: -1}
+	for _, entry := range entries {
+		trend.Scores = append(trend.Scores, entry.Score)
+	}
+	if len(entries) == 0 {
+		return trend
+	}
+
+	trend.FirstSeen = entries[0].cid
+	trend.Last = trend.Scores[len(trend.Scores)-1]
+	if len(trend.Scores) > 1 {
+		trend.Delta = trend.Last - trend.Scores[len(trend.Scores)-2]
+	}
+	return trend
+}
+
+//Sparkline draws the scores, which range from 0 to 1, as a row of bars
+func (t Trend) Sparkline() string {
+	line := make([]rune, len(t.Scores))
+	for i, score := range t.Scores {
+		level := int(score*float64(len(sparkBlocks)-1) + 0.5)
+		if level < 0 {
+			level = 0
+		} else if level >= len(sparkBlocks) {
+			level = len(sparkBlocks) - 1
+		}
+		line[i] = sparkBlocks[level]
+	}
+	return string(line)
+}
@@ -0,0 +1,33 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreHistoryTrend(t *testing.T) {
+	// chunk 0 was replaced after chunk 2 in a rolling dataset
+	history := ScoreHistory{
+		"0": HistoryEntry{Score: 0.9, TS: 3000},
+		"1": HistoryEntry{Score: 0.4, TS: 1000},
+		"2": HistoryEntry{Score: 0.6, TS: 2000},
+	}
+
+	trend := history.Trend()
+	assert.Equal(t, 1, trend.FirstSeen)
+	assert.Equal(t, []float64{0.4, 0.6, 0.9}, trend.Scores)
+	assert.Equal(t, 0.9, trend.Last)
+	assert.InDelta(t, 0.3, trend.Delta, 0.0001)
+	assert.Equal(t, "▄▅▇", trend.Sparkline())
+
+	trend = ScoreHistory{"3": HistoryEntry{Score: 1, TS: 1000}}.Trend()
+	assert.Equal(t, 3, trend.FirstSeen)
+	assert.Equal(t, 0.0, trend.Delta)
+	assert.Equal(t, "█", trend.Sparkline())
+
+	// results scored before histories were kept
+	trend = ScoreHistory(nil).Trend()
+	assert.Equal(t, -1, trend.FirstSeen)
+	assert.Equal(t, "", trend.Sparkline())
+}
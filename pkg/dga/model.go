@@ -0,0 +1,109 @@
+package dga
+
+import (
+	"math"
+	"strings"
+)
+
+//corpus is a list of common English words and popular domain labels which is
+//used to build the character bigram model that legitimate names are compared against.
+//It is embedded so scoring works on air-gapped sensors.
+const corpus = `
+the be to of and a in that have it for not on with he as you do at this but his by
+from they we say her she or an will my one all would there their what so up out if
+about who get which go me when make can like time no just him know take people into
+year your good some could them see other than then now look only come its over think
+also back after use two how our work first well way even new want because any these
+give day most us is are was were been has had did said each many much more very still
+world life hand part child eye woman place case week company system program question
+government number night point home water room mother area money story fact month lot
+right study book job word business issue side kind head house service friend father
+power hour game line end member law car city community name president team minute idea
+kid body information school face others level office door health person art war history
+party result change morning reason research girl guy moment air teacher force education
+foot boy age policy process music market sense nation plan college interest death
+experience effect class control care field development role effort rate heart drug show
+leader light voice wife police mind price report decision son view relationship town
+road arm difference value building action model season society tax director position
+player record paper space ground form event official matter center couple site project
+activity star table need court oil situation cost industry figure street image phone data
+picture practice piece land product doctor wall patient worker news test movie north love
+support technology step baby computer type attention film tree source organization hair
+window evidence population site network security cloud server client mail online
+store shop search video news social media weather sport travel bank finance health
+google youtube facebook amazon wikipedia twitter instagram linkedin microsoft apple
+netflix yahoo reddit bing office live windows update akamai edge cloudflare fastly
+adobe dropbox github gitlab stackoverflow paypal ebay walmart target bestbuy spotify
+pinterest tumblr wordpress blogger medium slack zoom salesforce oracle cisco intel
+nvidia samsung sony dell lenovo ibm mozilla firefox chrome android gstatic doubleclick
+googleapis googleusercontent msftncsi skype outlook hotmail icloud itunes aws amazonaws
+azure digicert verisign letsencrypt symantec mcafee norton kaspersky sophos avast
+espn cnn nytimes washingtonpost bbc reuters bloomberg forbes weather accuweather yelp
+tripadvisor booking expedia airbnb uber lyft craigslist indeed glassdoor zillow imdb
+twitch discord whatsapp telegram signal snapchat tiktok vimeo soundcloud pandora hulu
+disney espn nfl nba mlb fox abc nbc cbs npr pbs wikimedia archive mozilla ubuntu debian
+redhat centos fedora python golang java javascript node npm docker kubernetes
+analytics metrics telemetry tracking ads adservice advertising content static assets
+images img cdn api app apps login account accounts auth secure portal admin dashboard
+download downloads update updates mirror repo packages support help docs blog forum
+community shop cart checkout payment payments billing invoice customer customers
+mobile desktop device devices calendar contacts photos drive storage backup sync share
+connect connectivity check status health monitor alert notification push message
+messages chat voice call meet conference event events ticket tickets order orders
+delivery shipping tracking partner partners vendor vendors marketing sales service
+`
+
+//bigramLogProb holds the log probability of each character following another.
+//'^' marks the start of a name and '$' marks the end.
+var bigramLogProb map[string]float64
+
+//unseenLogProb is the log probability assigned to bigrams which never
+//occur in the corpus
+var unseenLogProb float64
+
+func init() {
+	counts := make(map[string]float64)
+	firstCounts := make(map[byte]float64)
+
+	for _, word := range strings.Fields(corpus) {
+		padded := "^" + word + "$"
+		for i := 0; i < len(padded)-1; i++ {
+			counts[padded[i:i+2]]++
+			firstCounts[padded[i]]++
+		}
+	}
+
+	// add one smoothing over the name alphabet (letters, digits, and hyphen)
+	// plus the end of name marker
+	const alphabetSize = 26 + 10 + 1 + 1
+
+	bigramLogProb = make(map[string]float64, len(counts))
+	for bigram, count := range counts {
+		bigramLogProb[bigram] = math.Log((count + 1) / (firstCounts[bigram[0]] + alphabetSize))
+	}
+
+	// the most common first character bounds the probability of a bigram
+	// that was never seen
+	maxFirst := 0.0
+	for _, count := range firstCounts {
+		if count > maxFirst {
+			maxFirst = count
+		}
+	}
+	unseenLogProb = math.Log(1 / (maxFirst + alphabetSize))
+}
+
+//bigramLikelihood returns the average log probability of the character
+//bigrams in a label according to the embedded model
+func bigramLikelihood(label string) float64 {
+	padded := "^" + label + "$"
+	sum := 0.0
+	for i := 0; i < len(padded)-1; i++ {
+		if logProb, ok := bigramLogProb[padded[i:i+2]]; ok {
+			sum += logProb
+		} else {
+			sum += unseenLogProb
+		}
+	}
+	return sum / float64(len(padded)-1)
+}
@@ -0,0 +1,81 @@
+package dga
+
+import (
+	"math"
+	"strings"
+
+	"github.com/activecm/rita/util"
+)
+
+//minLabelLength is the shortest label that is scored. Shorter labels
+//don't carry enough characters for the statistics to be meaningful.
+const minLabelLength = 6
+
+//Score returns the likelihood that a fully qualified domain name was
+//generated by a domain generation algorithm. The score ranges from 0 to 1
+//and is computed entirely offline from the label of the registered domain.
+func Score(fqdn string) float64 {
+	label := registeredLabel(fqdn)
+	if len(label) < minLabelLength {
+		return 0
+	}
+
+	//names made of common English/domain character pairs are more likely to
+	//be legitimate. The average log probability for real names sits above
+	//-3 while random strings sit near -5
+	bigramScore := util.Clamp((-bigramLikelihood(label) - 3.0) / 2.0)
+
+	//random strings use more of the alphabet than words do
+	entropyScore := util.Clamp((util.ShannonEntropy(label) - 2.5) / 1.5)
+
+	//random strings have long runs of consonants and few vowels
+	consonantScore := util.Clamp((consonantRatio(label) - 0.6) / 0.3)
+
+	//digits mixed in with letters are uncommon in legitimate names
+	digitScore := util.Clamp(digitRatio(label) / 0.3)
+
+	//weighted score average
+	score := 0.5*bigramScore + 0.2*entropyScore + 0.2*consonantScore + 0.1*digitScore
+
+	return math.Ceil(score*1000) / 1000
+}
+
+//registeredLabel returns the label of the registered domain without the public suffix,
+//e.g. "example" for "www.example.co.uk"
+func registeredLabel(fqdn string) string {
+	registered, _ := util.SplitRegisteredDomain(fqdn)
+	if dot := strings.Index(registered, "."); dot != -1 {
+		return registered[:dot]
+	}
+	return registered
+}
+
+//consonantRatio returns the ratio of consonants to letters in a label
+func consonantRatio(label string) float64 {
+	letters := 0
+	consonants := 0
+	for _, char := range label {
+		if char < 'a' || char > 'z' {
+			continue
+		}
+		letters++
+		if !strings.ContainsRune("aeiouy", char) {
+			consonants++
+		}
+	}
+	if letters == 0 {
+		return 0
+	}
+	return float64(consonants) / float64(letters)
+}
+
+//digitRatio returns the ratio of digits to characters in a label
+func digitRatio(label string) float64 {
+	digits := 0
+	for _, char := range label {
+		if char >= '0' && char <= '9' {
+			digits++
+		}
+	}
+	return float64(digits) / float64(len(label))
+}
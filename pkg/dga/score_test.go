@@ -0,0 +1,30 @@
+package dga
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScore(t *testing.T) {
+	legitimate := []string{
+		"www.google.com", "facebook.com", "stackoverflow.com",
+		"mail.washingtonpost.com", "cheapflights.co.uk", "ocsp.digicert.com",
+	}
+	generated := []string{
+		"kqxzjvbnwprt.com", "a8f7d9e2c1b3.net", "xjw3kz9qpl.info",
+		"qwpmnbzrxtyv.ru", "mhnkjlkqsdcxw.org",
+	}
+
+	for _, name := range legitimate {
+		require.True(t, Score(name) < 0.5, name)
+	}
+	for _, name := range generated {
+		require.True(t, Score(name) > 0.6, name)
+	}
+}
+
+func TestScoreShortLabel(t *testing.T) {
+	require.Equal(t, 0.0, Score("xq.com"))
+	require.Equal(t, 0.0, Score(""))
+}
@@ -0,0 +1,139 @@
+package diff
+
+import (
+	"github.com/activecm/rita/pkg/beacon"
+	"github.com/activecm/rita/pkg/blacklist"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/host"
+	"github.com/activecm/rita/pkg/uconn"
+	"github.com/activecm/rita/pkg/useragent"
+	"github.com/activecm/rita/resources"
+)
+
+//longConnThresh is the shortest connection in seconds compared as a long
+//connection, matching show-long-connections
+const longConnThresh = 60
+
+type (
+	//Options control which changes in value are significant
+	Options struct {
+		ScoreChange float64 // minimum change in a beacon's score
+		CountChange float64 // minimum change in a count or duration, as a fraction of the larger value
+	}
+
+	//kind is a type of result compared between datasets
+	kind struct {
+		name   string
+		metric string
+		score  bool // values are scores rather than counts or durations
+		load   func(res *resources.Resources) ([]Item, error)
+	}
+)
+
+//kinds lists the results compared between datasets
+var kinds = []kind{
+	{name: "Beacons", metric: "Score", score: true, load: beacons},
+	{name: "Blacklisted Hosts", metric: "Connections", load: blacklisted},
+	{name: "User Agents", metric: "Times Used", load: userAgents},
+	{name: "Long Connections", metric: "Max Duration", load: longConnections},
+	{name: "External Destinations", metric: "Unique Connections", load: destinations},
+}
+
+//Datasets compares the results of two datasets. The database selected in res
+//is changed as each dataset is read.
+func Datasets(res *resources.Resources, dbA string, dbB string, opts Options) ([]Section, error) {
+	var sections []Section
+	for _, k := range kinds {
+		res.DB.SelectDB(dbA)
+		before, err := k.load(res)
+		if err != nil {
+			return nil, err
+		}
+
+		res.DB.SelectDB(dbB)
+		after, err := k.load(res)
+		if err != nil {
+			return nil, err
+		}
+
+		significant := RelativeChange(opts.CountChange)
+		if k.score {
+			significant = AbsoluteChange(opts.ScoreChange)
+		}
+		sections = append(sections, Compare(k.name, k.metric, before, after, significant))
+	}
+	return sections, nil
+}
+
+//pairItem identifies a result between two hosts
+func pairItem(pair data.UniqueIPPair, value float64) Item {
+	return Item{
+		Key:   pair.MapKey(),
+		Label: pair.SrcIP + " -> " + pair.DstIP,
+		Value: value,
+	}
+}
+
+func beacons(res *resources.Resources) ([]Item, error) {
+	results, err := beacon.Results(res, 0)
+	var items []Item
+	for _, result := range results {
+		items = append(items, pairItem(result.UniqueIPPair, result.Score))
+	}
+	return items, err
+}
+
+func blacklisted(res *resources.Resources) ([]Item, error) {
+	var items []Item
+	for _, source := range []bool{true, false} {
+		load, direction := blacklist.DstIPResults, " (destination)"
+		if source {
+			load, direction = blacklist.SrcIPResults, " (source)"
+		}
+
+		results, err := load(res, "conn_count", 0, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			items = append(items, Item{
+				Key:   result.Host.MapKey() + direction,
+				Label: result.Host.IP + direction,
+				Value: float64(result.Connections),
+			})
+		}
+	}
+
+	results, err := blacklist.HostnameResults(res, "conn_count", 0, true)
+	for _, result := range results {
+		items = append(items, Item{Key: result.Host, Label: result.Host, Value: float64(result.Connections)})
+	}
+	return items, err
+}
+
+func userAgents(res *resources.Resources) ([]Item, error) {
+	results, err := useragent.Results(res, -1, 0, true)
+	var items []Item
+	for _, result := range results {
+		items = append(items, Item{Key: result.UserAgent, Label: result.UserAgent, Value: float64(result.TimesUsed)})
+	}
+	return items, err
+}
+
+func longConnections(res *resources.Resources) ([]Item, error) {
+	results, err := uconn.LongConnResults(res, longConnThresh, 0, true)
+	var items []Item
+	for _, result := range results {
+		items = append(items, pairItem(result.UniqueIPPair, result.MaxDuration))
+	}
+	return items, err
+}
+
+func destinations(res *resources.Resources) ([]Item, error) {
+	results, err := host.DestinationResults(res)
+	var items []Item
+	for _, result := range results {
+		items = append(items, Item{Key: result.MapKey(), Label: result.IP, Value: float64(result.UniqueConnections)})
+	}
+	return items, err
+}
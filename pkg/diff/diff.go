@@ -0,0 +1,107 @@
+package diff
+
+import (
+	"math"
+	"sort"
+)
+
+//Statuses of a result which differs between two datasets
+const (
+	New     = "new"
+	Gone    = "gone"
+	Changed = "changed"
+)
+
+type (
+	//Item is a result found in a dataset. Results from two datasets are
+	//matched by Key, and their Values are compared to find significant changes.
+	Item struct {
+		Key   string
+		Label string
+		Value float64
+	}
+
+	//Change is a result which is new, gone, or significantly changed in the
+	//second dataset
+	Change struct {
+		Result string  `json:"result"`
+		Status string  `json:"status"`
+		Before float64 `json:"before"` // value in the first dataset, 0 if new
+		After  float64 `json:"after"`  // value in the second dataset, 0 if gone
+		Delta  float64 `json:"delta"`
+	}
+
+	//Section holds the changes to one kind of result
+	Section struct {
+		Name    string   `json:"name"`
+		Metric  string   `json:"metric"` // describes the values being compared
+		Changes []Change `json:"changes"`
+	}
+
+	//Significance reports whether a value changed enough to be reported
+	Significance func(before, after float64) bool
+)
+
+//AbsoluteChange reports values which changed by at least min
+func AbsoluteChange(min float64) Significance {
+	return func(before, after float64) bool {
+		return before != after && math.Abs(after-before) >= min
+	}
+}
+
+//RelativeChange reports values which changed by at least the given fraction
+//of the larger value
+func RelativeChange(fraction float64) Significance {
+	return func(before, after float64) bool {
+		return before != after && math.Abs(after-before) >= fraction*math.Max(math.Abs(before), math.Abs(after))
+	}
+}
+
+//Compare finds the results which are new, gone, or significantly changed in
+//the second dataset. New results are listed first, then changed and gone
+//results, each ordered by how much they changed.
+func Compare(name string, metric string, before []Item, after []Item, significant Significance) Section {
+	section := Section{Name: name, Metric: metric, Changes: []Change{}}
+
+	beforeItems := index(before)
+	afterItems := index(after)
+
+	for key, a := range afterItems {
+		b, ok := beforeItems[key]
+		if !ok {
+			section.Changes = append(section.Changes, Change{Result: a.Label, Status: New, After: a.Value, Delta: a.Value})
+		} else if significant(b.Value, a.Value) {
+			section.Changes = append(section.Changes, Change{Result: a.Label, Status: Changed, Before: b.Value, After: a.Value, Delta: a.Value - b.Value})
+		}
+	}
+	for key, b := range beforeItems {
+		if _, ok := afterItems[key]; !ok {
+			section.Changes = append(section.Changes, Change{Result: b.Label, Status: Gone, Before: b.Value, Delta: -b.Value})
+		}
+	}
+
+	rank := map[string]int{New: 0, Changed: 1, Gone: 2}
+	sort.Slice(section.Changes, func(i, j int) bool {
+		ci, cj := section.Changes[i], section.Changes[j]
+		if ci.Status != cj.Status {
+			return rank[ci.Status] < rank[cj.Status]
+		}
+		if math.Abs(ci.Delta) != math.Abs(cj.Delta) {
+			return math.Abs(ci.Delta) > math.Abs(cj.Delta)
+		}
+		return ci.Result < cj.Result
+	})
+	return section
+}
+
+//index maps items to their keys, keeping the largest value when a key
+//is repeated
+func index(items []Item) map[string]Item {
+	indexed := make(map[string]Item, len(items))
+	for _, item := range items {
+		if existing, ok := indexed[item.Key]; !ok || item.Value > existing.Value {
+			indexed[item.Key] = item
+		}
+	}
+	return indexed
+}
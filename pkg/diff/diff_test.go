@@ -0,0 +1,49 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	before := []Item{
+		{Key: "a", Label: "A", Value: 0.9},
+		{Key: "b", Label: "B", Value: 0.5},
+		{Key: "c", Label: "C", Value: 0.5},
+		{Key: "d", Label: "D", Value: 0.7},
+	}
+	after := []Item{
+		{Key: "a", Label: "A", Value: 0.95}, // insignificant change
+		{Key: "b", Label: "B", Value: 0.8},
+		{Key: "c", Label: "C", Value: 0.2},
+		{Key: "e", Label: "E", Value: 0.6},
+		{Key: "e", Label: "E", Value: 0.4}, // repeated keys keep the largest value
+	}
+
+	section := Compare("Beacons", "Score", before, after, AbsoluteChange(0.1))
+	require.Equal(t, "Beacons", section.Name)
+
+	var results, statuses []string
+	for _, change := range section.Changes {
+		results = append(results, change.Result)
+		statuses = append(statuses, change.Status)
+	}
+	require.Equal(t, []string{"E", "B", "C", "D"}, results)
+	require.Equal(t, []string{New, Changed, Changed, Gone}, statuses)
+	require.InDelta(t, 0.6, section.Changes[0].After, 0.0001)
+	require.InDelta(t, -0.7, section.Changes[3].Delta, 0.0001)
+
+	// identical datasets have no changes
+	section = Compare("Beacons", "Score", before, before, AbsoluteChange(0))
+	require.Empty(t, section.Changes)
+	require.NotNil(t, section.Changes)
+}
+
+func TestRelativeChange(t *testing.T) {
+	significant := RelativeChange(0.5)
+	require.True(t, significant(10, 25))
+	require.True(t, significant(25, 10))
+	require.False(t, significant(10, 15))
+	require.False(t, significant(0, 0))
+}
@@ -0,0 +1,157 @@
+package dnsfailure
+
+import (
+	"math"
+	"sync"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/util"
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+	//analyzer : structure for dns failure analysis
+	analyzer struct {
+		chunk            int            //current chunk (0 if not on rolling analysis)
+		db               *database.DB   // provides access to MongoDB
+		conf             *config.Config // contains details needed to access MongoDB
+		analyzedCallback func(*update)  // called on each analyzed result
+		closedCallback   func()         // called when .close() is called and no more calls to analyzedCallback will be made
+		analysisChannel  chan *Input    // holds unanalyzed data
+		analysisWg       sync.WaitGroup // wait for analysis to finish
+	}
+)
+
+//newAnalyzer creates a new collector for scoring failed dns lookups
+func newAnalyzer(chunk int, db *database.DB, conf *config.Config, analyzedCallback func(*update), closedCallback func()) *analyzer {
+	return &analyzer{
+		chunk:            chunk,
+		db:               db,
+		conf:             conf,
+		analyzedCallback: analyzedCallback,
+		closedCallback:   closedCallback,
+		analysisChannel:  make(chan *Input),
+	}
+}
+
+//collect sends a client or registered domain to be analyzed
+func (a *analyzer) collect(data *Input) {
+	a.analysisChannel <- data
+}
+
+//close waits for the collector to finish
+func (a *analyzer) close() {
+	close(a.analysisChannel)
+	a.analysisWg.Wait()
+	a.closedCallback()
+}
+
+//start kicks off a new analysis thread
+func (a *analyzer) start() {
+	a.analysisWg.Add(1)
+	go func() {
+		ssn := a.db.Session.Copy()
+		defer ssn.Close()
+
+		for entry := range a.analysisChannel {
+
+			batch := chunk{
+				QueryCount:        entry.QueryCount,
+				NXDomainCount:     entry.NXDomainCount,
+				ServFailCount:     entry.ServFailCount,
+				FailedDomainCount: int64(len(entry.FailedDomains)),
+				ClientCount:       int64(len(entry.Clients)),
+				CID:               a.chunk,
+			}
+
+			// the statistics from previous batches and chunks are stored in the
+			// dat array of the record. Merge them with the current batch so
+			// rolling datasets are scored as a whole.
+			var stored struct {
+				Dat []chunk `bson:"dat"`
+			}
+
+			_ = ssn.DB(a.db.GetSelectedDB()).C(a.conf.T.DNS.DNSFailureTable).Find(entry.BSONKey()).One(&stored)
+
+			merged := mergeChunks(append(stored.Dat, batch))
+
+			query := bson.M{
+				"$push": bson.M{"dat": batch},
+			}
+
+			set := bson.M{
+				"query_count":         merged.QueryCount,
+				"nxdomain_count":      merged.NXDomainCount,
+				"servfail_count":      merged.ServFailCount,
+				"failed_domain_count": merged.FailedDomainCount,
+				"client_count":        merged.ClientCount,
+				"cid":                 a.chunk,
+			}
+
+			if entry.Type == Client {
+				set["client.network_name"] = entry.Client.NetworkName
+			}
+
+			if merged.QueryCount >= int64(a.conf.S.DNSFailure.MinimumQueryCount) {
+				failureRatio, score := scoreChunk(entry.Type, merged, a.conf.S.DNSFailure.FailedDomainThresh)
+				set["failure_ratio"] = failureRatio
+				set["score"] = score
+			} else {
+				// clear out any stale scores in case chunks were removed
+				// from a rolling dataset
+				query["$unset"] = bson.M{"failure_ratio": 1, "score": 1}
+			}
+
+			query["$set"] = set
+
+			a.analyzedCallback(&update{
+				selector: entry.BSONKey(),
+				query:    query,
+			})
+		}
+		a.analysisWg.Done()
+	}()
+}
+
+//mergeChunks combines the statistics of several chunks. The distinct failed
+//domain and client counts are summed across chunks, so a domain or client seen
+//in more than one chunk is counted once per chunk.
+func mergeChunks(chunks []chunk) chunk {
+	var merged chunk
+
+	for _, c := range chunks {
+		merged.QueryCount += c.QueryCount
+		merged.NXDomainCount += c.NXDomainCount
+		merged.ServFailCount += c.ServFailCount
+		merged.FailedDomainCount += c.FailedDomainCount
+		merged.ClientCount += c.ClientCount
+	}
+
+	return merged
+}
+
+//scoreChunk computes the ratio of failed lookups and the overall failure
+//score of a client or registered domain
+func scoreChunk(recordType string, c chunk, failedDomainThresh int) (float64, float64) {
+	failureRatio := 0.0
+	if c.QueryCount > 0 {
+		failureRatio = float64(c.NXDomainCount+c.ServFailCount) / float64(c.QueryCount)
+	}
+
+	var spreadScore float64
+	if recordType == Client {
+		//malware cycling through dead domains fails to resolve many distinct
+		//registered domains, while a single misconfigured lookup fails for one.
+		//failedDomainThresh or more failed domains receives a full score
+		spreadScore = util.Clamp(float64(c.FailedDomainCount) / math.Max(1, float64(failedDomainThresh)))
+	} else {
+		//a dead domain which is looked up over and over again is more
+		//suspicious than a single typo. 1000 or more lookups receives a full score
+		spreadScore = util.Clamp(math.Log10(float64(c.QueryCount)) / 3.0)
+	}
+
+	score := math.Ceil(failureRatio*spreadScore*1000) / 1000
+
+	return math.Ceil(failureRatio*1000) / 1000, score
+}
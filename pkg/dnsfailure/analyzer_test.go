@@ -0,0 +1,57 @@
+package dnsfailure
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrack(t *testing.T) {
+	client := data.UniqueIP{IP: "10.0.0.1"}
+
+	clientInput := &Input{Type: Client, Client: client}
+	clientInput.Track(client, "a.com", "NOERROR")
+	clientInput.Track(client, "b.com", "NXDOMAIN")
+	clientInput.Track(client, "c.com", "SERVFAIL")
+	clientInput.Track(client, "b.com", "NXDOMAIN")
+
+	require.Equal(t, int64(4), clientInput.QueryCount)
+	require.Equal(t, int64(2), clientInput.NXDomainCount)
+	require.Equal(t, int64(1), clientInput.ServFailCount)
+	require.Len(t, clientInput.FailedDomains, 2)
+
+	domainInput := &Input{Type: Domain, Domain: "b.com"}
+	domainInput.Track(client, "b.com", "NXDOMAIN")
+	domainInput.Track(data.UniqueIP{IP: "10.0.0.2"}, "b.com", "NXDOMAIN")
+
+	require.Equal(t, int64(2), domainInput.NXDomainCount)
+	require.Len(t, domainInput.Clients, 2)
+	require.Nil(t, domainInput.FailedDomains)
+}
+
+func TestScoreChunk(t *testing.T) {
+	chunks := []chunk{
+		{QueryCount: 50, NXDomainCount: 20, FailedDomainCount: 15, CID: 0},
+		{QueryCount: 50, NXDomainCount: 20, ServFailCount: 10, FailedDomainCount: 15, CID: 1},
+	}
+	merged := mergeChunks(chunks)
+	require.Equal(t, chunk{QueryCount: 100, NXDomainCount: 40, ServFailCount: 10, FailedDomainCount: 30}, merged)
+
+	// a client failing to resolve many distinct domains
+	ratio, cycling := scoreChunk(Client, merged, 20)
+	require.InDelta(t, 0.5, ratio, 0.001)
+	require.InDelta(t, 0.5, cycling, 0.001)
+
+	// a client failing to resolve a single domain
+	_, typo := scoreChunk(Client, chunk{QueryCount: 100, NXDomainCount: 50, FailedDomainCount: 1}, 20)
+	require.True(t, typo < cycling)
+
+	// a client with no failures
+	_, healthy := scoreChunk(Client, chunk{QueryCount: 100, FailedDomainCount: 0}, 20)
+	require.Equal(t, 0.0, healthy)
+
+	// a dead domain looked up many times
+	_, dead := scoreChunk(Domain, chunk{QueryCount: 1000, NXDomainCount: 1000}, 20)
+	require.Equal(t, 1.0, dead)
+}
@@ -0,0 +1,51 @@
+package dnsfailure
+
+import (
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/util"
+)
+
+//parser tracks the response codes of each client and registered domain in a
+//batch of dns records
+type parser struct {
+	dnsFailureMap map[string]*Input
+}
+
+//NewParser creates a parser which tracks the response codes of each client
+//and registered domain
+func NewParser() analysis.Parser {
+	return &parser{dnsFailureMap: make(map[string]*Input)}
+}
+
+//Parse tracks a query's response code against its client and registered
+//domain
+func (p *parser) Parse(record *analysis.Record) {
+	if record.Filtered || !record.IsLookup() {
+		return
+	}
+
+	registeredDomain, _ := util.SplitRegisteredDomain(record.FQDN)
+
+	clientFailureKey := Client + record.Src.MapKey()
+	if _, ok := p.dnsFailureMap[clientFailureKey]; !ok {
+		p.dnsFailureMap[clientFailureKey] = &Input{
+			Type:   Client,
+			Client: record.Src,
+		}
+	}
+	p.dnsFailureMap[clientFailureKey].Track(record.Src, registeredDomain, record.DNS.RCodeName)
+
+	domainFailureKey := Domain + registeredDomain
+	if _, ok := p.dnsFailureMap[domainFailureKey]; !ok {
+		p.dnsFailureMap[domainFailureKey] = &Input{
+			Type:   Domain,
+			Domain: registeredDomain,
+		}
+	}
+	p.dnsFailureMap[domainFailureKey].Track(record.Src, registeredDomain, record.DNS.RCodeName)
+}
+
+//Flush returns the response codes gathered from the batch
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	return p.dnsFailureMap
+}
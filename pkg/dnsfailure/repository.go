@@ -0,0 +1,116 @@
+package dnsfailure
+
+import (
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	//Client records hold the failed lookups made by a single DNS client
+	Client = "client"
+	//Domain records hold the failed lookups made for a single registered domain
+	Domain = "domain"
+)
+
+type (
+
+	// Repository for dnsFailure collection
+	Repository interface {
+		CreateIndexes() error
+		Upsert(failureMap map[string]*Input)
+	}
+
+	//update ....
+	update struct {
+		selector bson.M
+		query    bson.M
+	}
+
+	//chunk holds the lookup statistics gathered for a client or registered
+	//domain in a single import batch. Chunks are stored in the dat array of
+	//each dnsFailure record so rolling datasets can be analyzed as a whole.
+	chunk struct {
+		QueryCount        int64 `bson:"count"`
+		NXDomainCount     int64 `bson:"nxdomain_count"`
+		ServFailCount     int64 `bson:"servfail_count"`
+		FailedDomainCount int64 `bson:"failed_domain_count"`
+		ClientCount       int64 `bson:"client_count"`
+		CID               int   `bson:"cid"`
+	}
+
+	//Result represents the failed lookup statistics and score for a client
+	//or a registered domain. Client results count the distinct registered
+	//domains which failed to resolve, while domain results count the clients
+	//which looked the domain up.
+	Result struct {
+		Type              string        `bson:"type"`
+		Client            data.UniqueIP `bson:"client"`
+		Domain            string        `bson:"domain"`
+		QueryCount        int64         `bson:"query_count"`
+		NXDomainCount     int64         `bson:"nxdomain_count"`
+		ServFailCount     int64         `bson:"servfail_count"`
+		FailureRatio      float64       `bson:"failure_ratio"`
+		FailedDomainCount int64         `bson:"failed_domain_count"`
+		ClientCount       int64         `bson:"client_count"`
+		Score             float64       `bson:"score"`
+	}
+
+	//Input structure for sending data to the analyzer. Client inputs hold
+	//every lookup made by a DNS client along with the registered domains
+	//which failed to resolve. Domain inputs hold every lookup made for a
+	//registered domain along with the clients which made them.
+	Input struct {
+		Type          string
+		Client        data.UniqueIP
+		Domain        string
+		QueryCount    int64
+		NXDomainCount int64
+		ServFailCount int64
+		FailedDomains map[string]struct{}
+		Clients       data.UniqueIPSet
+	}
+)
+
+//Track records a lookup made by client for the registered domain along with
+//its response code name
+func (in *Input) Track(client data.UniqueIP, domain string, rcodeName string) {
+	in.QueryCount++
+
+	failed := true
+	switch rcodeName {
+	case "NXDOMAIN":
+		in.NXDomainCount++
+	case "SERVFAIL":
+		in.ServFailCount++
+	default:
+		failed = false
+	}
+
+	if in.Type == Domain {
+		in.Clients.Insert(client)
+		return
+	}
+
+	if failed {
+		if in.FailedDomains == nil {
+			in.FailedDomains = make(map[string]struct{})
+		}
+		in.FailedDomains[domain] = struct{}{}
+	}
+}
+
+//BSONKey generates a BSON map which may be used to index a given client or domain
+func (in *Input) BSONKey() bson.M {
+	if in.Type == Domain {
+		return bson.M{
+			"type":   in.Type,
+			"domain": in.Domain,
+		}
+	}
+
+	return bson.M{
+		"type":                in.Type,
+		"client.ip":           in.Client.IP,
+		"client.network_uuid": in.Client.NetworkUUID,
+	}
+}
@@ -0,0 +1,28 @@
+package dnsfailure
+
+import (
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//Results returns DNS clients and registered domains with failed lookups,
+//sorted by failure score.
+//limit and noLimit control how many results are returned.
+func Results(res *resources.Resources, limit int, noLimit bool) ([]Result, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var failureResults []Result
+
+	failureQuery := bson.M{"score": bson.M{"$gt": 0}}
+
+	query := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.DNS.DNSFailureTable).Find(failureQuery).Sort("-score", "-query_count")
+
+	if !noLimit {
+		query = query.Limit(limit)
+	}
+
+	err := query.All(&failureResults)
+
+	return failureResults, err
+}
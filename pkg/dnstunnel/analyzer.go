@@ -0,0 +1,219 @@
+package dnstunnel
+
+import (
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/util"
+	"github.com/globalsign/mgo/bson"
+)
+
+//MaxExamples is the number of example queries stored for each client/domain pair
+const MaxExamples = 5
+
+//tunnelQueryTypes are the query types commonly used to carry data in DNS tunnels
+var tunnelQueryTypes = []string{"TXT", "NULL", "CNAME"}
+
+type (
+	//analyzer : structure for dns tunnel analysis
+	analyzer struct {
+		chunk            int            //current chunk (0 if not on rolling analysis)
+		db               *database.DB   // provides access to MongoDB
+		conf             *config.Config // contains details needed to access MongoDB
+		analyzedCallback func(*update)  // called on each analyzed result
+		closedCallback   func()         // called when .close() is called and no more calls to analyzedCallback will be made
+		analysisChannel  chan *Input    // holds unanalyzed data
+		analysisWg       sync.WaitGroup // wait for analysis to finish
+	}
+)
+
+//newAnalyzer creates a new collector for scoring dns tunnels
+func newAnalyzer(chunk int, db *database.DB, conf *config.Config, analyzedCallback func(*update), closedCallback func()) *analyzer {
+	return &analyzer{
+		chunk:            chunk,
+		db:               db,
+		conf:             conf,
+		analyzedCallback: analyzedCallback,
+		closedCallback:   closedCallback,
+		analysisChannel:  make(chan *Input),
+	}
+}
+
+//collect sends a client/domain pair to be analyzed
+func (a *analyzer) collect(data *Input) {
+	a.analysisChannel <- data
+}
+
+//close waits for the collector to finish
+func (a *analyzer) close() {
+	close(a.analysisChannel)
+	a.analysisWg.Wait()
+	a.closedCallback()
+}
+
+//start kicks off a new analysis thread
+func (a *analyzer) start() {
+	a.analysisWg.Add(1)
+	go func() {
+		ssn := a.db.Session.Copy()
+		defer ssn.Close()
+
+		for entry := range a.analysisChannel {
+
+			batch := newChunk(entry, a.chunk)
+
+			// the statistics from previous batches and chunks are stored in the
+			// dat array of the record. Merge them with the current batch so
+			// rolling datasets are scored as a whole.
+			var stored struct {
+				Dat []chunk `bson:"dat"`
+			}
+
+			_ = ssn.DB(a.db.GetSelectedDB()).C(a.conf.T.DNS.DNSTunnelTable).Find(entry.Hosts.BSONKey()).One(&stored)
+
+			merged := mergeChunks(append(stored.Dat, batch))
+
+			query := bson.M{
+				"$push": bson.M{"dat": batch},
+			}
+
+			set := bson.M{
+				"src_network_name": entry.Hosts.SrcNetworkName,
+				"query_count":      merged.QueryCount,
+				"subdomain_count":  merged.SubdomainCount,
+				"examples":         merged.Examples,
+				"cid":              a.chunk,
+			}
+
+			if merged.QueryCount >= int64(a.conf.S.DNSTunnel.MinimumQueryCount) {
+				avgLabelLength, avgEntropy, tunnelRatio, score := scoreChunk(merged)
+				set["avg_label_length"] = avgLabelLength
+				set["max_label_length"] = merged.LabelLengthMax
+				set["avg_entropy"] = avgEntropy
+				set["tunnel_qtype_ratio"] = tunnelRatio
+				set["score"] = score
+			} else {
+				// clear out any stale scores in case chunks were removed
+				// from a rolling dataset
+				query["$unset"] = bson.M{"score": 1}
+			}
+
+			query["$set"] = set
+
+			a.analyzedCallback(&update{
+				selector: entry.Hosts.BSONKey(),
+				query:    query,
+			})
+		}
+		a.analysisWg.Done()
+	}()
+}
+
+//newChunk gathers the label and query statistics for the queries
+//parsed in the current import batch
+func newChunk(entry *Input, cid int) chunk {
+	c := chunk{
+		QueryCount:      entry.QueryCount,
+		SubdomainCount:  int64(len(entry.Subdomains)),
+		QueryTypeCounts: entry.QueryTypeCounts,
+		Examples:        entry.Examples,
+		CID:             cid,
+	}
+
+	if c.QueryTypeCounts == nil {
+		c.QueryTypeCounts = make(map[string]int64)
+	}
+
+	for subdomain := range entry.Subdomains {
+		labels := strings.Split(subdomain, ".")
+		for _, label := range labels {
+			length := int64(len(label))
+			c.LabelCount++
+			c.LabelLengthSum += length
+			if length > c.LabelLengthMax {
+				c.LabelLengthMax = length
+			}
+		}
+		c.EntropySum += util.ShannonEntropy(strings.Join(labels, ""))
+	}
+
+	return c
+}
+
+//mergeChunks combines the statistics of several chunks. The unique subdomain
+//count is summed across chunks, so a subdomain queried in more than one
+//chunk is counted once per chunk.
+func mergeChunks(chunks []chunk) chunk {
+	merged := chunk{QueryTypeCounts: make(map[string]int64)}
+
+	for _, c := range chunks {
+		merged.QueryCount += c.QueryCount
+		merged.SubdomainCount += c.SubdomainCount
+		merged.LabelCount += c.LabelCount
+		merged.LabelLengthSum += c.LabelLengthSum
+		merged.EntropySum += c.EntropySum
+		if c.LabelLengthMax > merged.LabelLengthMax {
+			merged.LabelLengthMax = c.LabelLengthMax
+		}
+		for qtype, count := range c.QueryTypeCounts {
+			merged.QueryTypeCounts[qtype] += count
+		}
+		for _, example := range c.Examples {
+			if len(merged.Examples) < MaxExamples && !util.StringInSlice(example, merged.Examples) {
+				merged.Examples = append(merged.Examples, example)
+			}
+		}
+	}
+
+	return merged
+}
+
+//scoreChunk computes the average label length, average subdomain entropy,
+//ratio of tunnel friendly query types, and the overall tunneling score
+//of a client/domain pair
+func scoreChunk(c chunk) (float64, float64, float64, float64) {
+	avgLabelLength := 0.0
+	if c.LabelCount > 0 {
+		avgLabelLength = float64(c.LabelLengthSum) / float64(c.LabelCount)
+	}
+
+	avgEntropy := 0.0
+	if c.SubdomainCount > 0 {
+		avgEntropy = c.EntropySum / float64(c.SubdomainCount)
+	}
+
+	tunnelQueries := int64(0)
+	for _, qtype := range tunnelQueryTypes {
+		tunnelQueries += c.QueryTypeCounts[qtype]
+	}
+	tunnelRatio := 0.0
+	if c.QueryCount > 0 {
+		tunnelRatio = float64(tunnelQueries) / float64(c.QueryCount)
+	}
+
+	//tunnels encode data in the subdomains, so nearly every query is unique.
+	//1000 or more unique subdomains receives a full score
+	cardinalityScore := util.Clamp(math.Log10(float64(c.SubdomainCount)+1) / 3.0)
+
+	//regular hostnames have short labels while tunnels pack labels
+	//close to the 63 character limit
+	labelLengthScore := util.Clamp((avgLabelLength - 10.0) / 40.0)
+
+	//encoded data is close to random. English-like names sit below 3 bits
+	//per character while base32/base64 payloads exceed 4 bits per character
+	entropyScore := util.Clamp((avgEntropy - 2.5) / 2.0)
+
+	//10000 or more queries receives a full score
+	volumeScore := util.Clamp(math.Log10(float64(c.QueryCount)) / 4.0)
+
+	//score numerators
+	sum := cardinalityScore + labelLengthScore + entropyScore + tunnelRatio + volumeScore
+
+	//score average
+	score := math.Ceil((sum/5.0)*1000) / 1000
+
+	return avgLabelLength, avgEntropy, tunnelRatio, score
+}
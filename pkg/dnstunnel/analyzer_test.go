@@ -0,0 +1,54 @@
+package dnstunnel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreChunk(t *testing.T) {
+	benign := mergeChunks([]chunk{newChunk(&Input{
+		Subdomains:      map[string]struct{}{"www": {}, "mail": {}},
+		QueryTypeCounts: map[string]int64{"A": 400, "AAAA": 100},
+		QueryCount:      500,
+	}, 0)})
+
+	tunnelSubs := make(map[string]struct{})
+	for _, sub := range []string{
+		"mfrggzdfmztwq2lknnwg23tpobyxe43uov3ho6dzpi.aebagbafaydqqcikbmga2dqpcaireeyu",
+		"culbmgazdsnbyha3dmnzqgm3tenbzgu2dmnrvgy3dm.nzyg45dnzlqg5ttmnjygmzdgmbwgm4te",
+		"gezdgnbvgy3tqojqgezdgnbvgy3tqojqgezdgnbvgy.3tqojqgezdgnbvgy3tqojqgezdgnbv",
+	} {
+		tunnelSubs[sub] = struct{}{}
+	}
+
+	tunnel := mergeChunks([]chunk{newChunk(&Input{
+		Subdomains:      tunnelSubs,
+		QueryTypeCounts: map[string]int64{"TXT": 450, "A": 50},
+		QueryCount:      500,
+	}, 0)})
+
+	_, _, benignRatio, benignScore := scoreChunk(benign)
+	avgLabelLength, avgEntropy, tunnelRatio, tunnelScore := scoreChunk(tunnel)
+
+	require.Equal(t, 0.0, benignRatio)
+	require.InDelta(t, 0.9, tunnelRatio, 0.0001)
+	require.True(t, avgLabelLength > 30)
+	require.True(t, avgEntropy > 3.5)
+	require.True(t, tunnelScore > benignScore)
+	require.True(t, tunnelScore <= 1)
+}
+
+func TestMergeChunks(t *testing.T) {
+	merged := mergeChunks([]chunk{
+		{QueryCount: 10, SubdomainCount: 2, LabelLengthMax: 5, QueryTypeCounts: map[string]int64{"A": 10}, Examples: []string{"a.b.com"}},
+		{QueryCount: 5, SubdomainCount: 1, LabelLengthMax: 9, QueryTypeCounts: map[string]int64{"A": 3, "TXT": 2}, Examples: []string{"a.b.com", "c.b.com"}},
+	})
+
+	require.Equal(t, int64(15), merged.QueryCount)
+	require.Equal(t, int64(3), merged.SubdomainCount)
+	require.Equal(t, int64(9), merged.LabelLengthMax)
+	require.Equal(t, int64(13), merged.QueryTypeCounts["A"])
+	require.Equal(t, int64(2), merged.QueryTypeCounts["TXT"])
+	require.Equal(t, []string{"a.b.com", "c.b.com"}, merged.Examples)
+}
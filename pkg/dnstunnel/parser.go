@@ -0,0 +1,59 @@
+package dnstunnel
+
+import (
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/util"
+)
+
+//parser groups the queries in a batch of dns records by client and
+//registered domain
+type parser struct {
+	dnsTunnelMap map[string]*Input
+}
+
+//NewParser creates a parser which groups queries by client and registered
+//domain
+func NewParser() analysis.Parser {
+	return &parser{dnsTunnelMap: make(map[string]*Input)}
+}
+
+//Parse counts a query against its client and registered domain along with
+//the subdomain and query type it used
+func (p *parser) Parse(record *analysis.Record) {
+	if record.Filtered || !record.IsLookup() {
+		return
+	}
+
+	domain := record.FQDN
+	registeredDomain, subdomain := util.SplitRegisteredDomain(domain)
+	srcDomainPair := NewUniqueSrcDomainPair(record.Src, registeredDomain)
+	srcDomainKey := srcDomainPair.MapKey()
+
+	if _, ok := p.dnsTunnelMap[srcDomainKey]; !ok {
+		p.dnsTunnelMap[srcDomainKey] = &Input{
+			Hosts:           srcDomainPair,
+			Subdomains:      make(map[string]struct{}),
+			QueryTypeCounts: make(map[string]int64),
+		}
+	}
+
+	tunnel := p.dnsTunnelMap[srcDomainKey]
+	tunnel.QueryCount++
+	tunnel.QueryTypeCounts[record.DNS.QTypeName]++
+
+	if subdomain != "" {
+		if _, ok := tunnel.Subdomains[subdomain]; !ok {
+			tunnel.Subdomains[subdomain] = struct{}{}
+
+			// keep a few of the queries around as examples
+			if len(tunnel.Examples) < MaxExamples {
+				tunnel.Examples = append(tunnel.Examples, domain)
+			}
+		}
+	}
+}
+
+//Flush returns the queries gathered from the batch
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	return p.dnsTunnelMap
+}
@@ -0,0 +1,108 @@
+package dnstunnel
+
+import (
+	"strings"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+
+	// Repository for dnsTunnel collection
+	Repository interface {
+		CreateIndexes() error
+		Upsert(tunnelMap map[string]*Input)
+	}
+
+	//update ....
+	update struct {
+		selector bson.M
+		query    bson.M
+	}
+
+	//chunk holds the statistics gathered for a client/domain pair in
+	//a single import batch. Chunks are stored in the dat array of each
+	//dnsTunnel record so rolling datasets can be analyzed as a whole.
+	chunk struct {
+		QueryCount      int64            `bson:"count"`
+		SubdomainCount  int64            `bson:"subdomain_count"`
+		LabelCount      int64            `bson:"label_count"`
+		LabelLengthSum  int64            `bson:"label_length_sum"`
+		LabelLengthMax  int64            `bson:"label_length_max"`
+		EntropySum      float64          `bson:"entropy_sum"`
+		QueryTypeCounts map[string]int64 `bson:"qtypes"`
+		Examples        []string         `bson:"examples"`
+		CID             int              `bson:"cid"`
+	}
+
+	//Result represents the DNS tunneling statistics and score for
+	//a client and a registered domain
+	Result struct {
+		data.UniqueSrcIP     `bson:",inline"`
+		Domain               string   `bson:"domain"`
+		QueryCount           int64    `bson:"query_count"`
+		SubdomainCount       int64    `bson:"subdomain_count"`
+		AvgLabelLength       float64  `bson:"avg_label_length"`
+		MaxLabelLength       int64    `bson:"max_label_length"`
+		AvgEntropy           float64  `bson:"avg_entropy"`
+		TunnelQueryTypeRatio float64  `bson:"tunnel_qtype_ratio"`
+		Examples             []string `bson:"examples"`
+		Score                float64  `bson:"score"`
+	}
+
+	//UniqueSrcDomainPair is used to make a tuple of
+	// Src IP/UUID/Name and a registered domain
+	UniqueSrcDomainPair struct {
+		data.UniqueSrcIP `bson:",inline"`
+		Domain           string `bson:"domain"`
+	}
+
+	//Input structure for sending data
+	//to the analyzer. Contains a tuple of
+	// Src IP/UUID/Name and the registered domain being queried. Contains
+	// the set of unique subdomains queried, a count of the queries per
+	// query type, a handful of example queries, and the total query count.
+	Input struct {
+		Hosts           UniqueSrcDomainPair
+		Subdomains      map[string]struct{}
+		QueryTypeCounts map[string]int64
+		Examples        []string
+		QueryCount      int64
+	}
+)
+
+//NewUniqueSrcDomainPair binds a source UniqueIP to a registered domain.
+func NewUniqueSrcDomainPair(source data.UniqueIP, domain string) UniqueSrcDomainPair {
+	return UniqueSrcDomainPair{
+		UniqueSrcIP: source.AsSrc(),
+		Domain:      domain,
+	}
+}
+
+//MapKey generates a string which may be used to index a src/domain pair. Concatenates IP, UUID, and domain.
+func (p UniqueSrcDomainPair) MapKey() string {
+	var builder strings.Builder
+
+	srcUUIDLen := 1 + len(p.SrcNetworkUUID.Data)
+
+	builder.Grow(len(p.SrcIP) + srcUUIDLen + len(p.Domain))
+	builder.WriteString(p.SrcIP)
+	builder.WriteByte(p.SrcNetworkUUID.Kind)
+	builder.Write(p.SrcNetworkUUID.Data)
+
+	builder.WriteString(p.Domain)
+
+	return builder.String()
+}
+
+//BSONKey generates a BSON map which may be used to index a given src/domain pair
+//Includes IP and Network UUID.
+func (p UniqueSrcDomainPair) BSONKey() bson.M {
+	key := bson.M{
+		"src":              p.SrcIP,
+		"src_network_uuid": p.SrcNetworkUUID,
+		"domain":           p.Domain,
+	}
+	return key
+}
@@ -0,0 +1,25 @@
+package dnstunnel
+
+import (
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//Results returns client/domain pairs which show signs of DNS tunneling, sorted by score.
+//limit and noLimit control how many results are returned.
+func Results(res *resources.Resources, limit int, noLimit bool) ([]Result, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var tunnelResults []Result
+
+	query := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.DNS.DNSTunnelTable).Find(bson.M{"score": bson.M{"$gt": 0}}).Sort("-score")
+
+	if !noLimit {
+		query = query.Limit(limit)
+	}
+
+	err := query.All(&tunnelResults)
+
+	return tunnelResults, err
+}
@@ -0,0 +1,186 @@
+package domainfronting
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/util"
+)
+
+type (
+	//Correlator joins the TLS sessions and HTTP requests seen while parsing
+	//so the server name of each session can be compared with its Host header.
+	//Correlator is not safe for concurrent use.
+	Correlator struct {
+		sessions   map[string]*tlsSession         // TLS sessions by Zeek connection UID
+		pairs      map[string][]*tlsSession       // TLS sessions by connection pair and destination port
+		servedSNIs map[string]map[string]struct{} // server names seen on each destination
+		requests   []httpRequest                  // HTTP requests awaiting correlation
+	}
+
+	tlsSession struct {
+		hosts data.UniqueIPPair
+		ts    int64
+		sni   string
+	}
+
+	httpRequest struct {
+		uid   string
+		hosts data.UniqueIPPair
+		port  int
+		ts    int64
+		host  string
+	}
+)
+
+// NewCorrelator creates an empty Correlator
+func NewCorrelator() *Correlator {
+	return &Correlator{
+		sessions:   make(map[string]*tlsSession),
+		pairs:      make(map[string][]*tlsSession),
+		servedSNIs: make(map[string]map[string]struct{}),
+	}
+}
+
+// AddTLS records a TLS session to the given destination port and the server
+// name (if any) the client sent
+func (c *Correlator) AddTLS(uid string, hosts data.UniqueIPPair, port int, ts int64, sni string) {
+	session := &tlsSession{hosts: hosts, ts: ts, sni: normalizeHost(sni)}
+
+	if uid != "" {
+		c.sessions[uid] = session
+	}
+	pairKey := portPairKey(hosts, port)
+	c.pairs[pairKey] = append(c.pairs[pairKey], session)
+
+	if session.sni != "" {
+		dstKey := hosts.UniqueDstIP.Unpair().MapKey()
+		if _, ok := c.servedSNIs[dstKey]; !ok {
+			c.servedSNIs[dstKey] = make(map[string]struct{})
+		}
+		c.servedSNIs[dstKey][session.sni] = struct{}{}
+	}
+}
+
+// AddHTTP records an HTTP request to the given destination port and its Host header
+func (c *Correlator) AddHTTP(uid string, hosts data.UniqueIPPair, port int, ts int64, host string) {
+	host = normalizeHost(host)
+	if host == "" {
+		return
+	}
+	c.requests = append(c.requests, httpRequest{uid: uid, hosts: hosts, port: port, ts: ts, host: host})
+}
+
+// Correlate matches each HTTP request with the TLS session sharing its UID, or
+// failing that, the closest TLS session on the same connection pair and
+// destination port no more than window seconds away. Plain HTTP and TLS
+// sessions to the same server on different ports are unrelated, so they are
+// never correlated. Requests whose Host header belongs to a different
+// registered domain than the server name are reported as Mismatch findings.
+// TLS sessions without a server name sent to a destination serving at least
+// minServedSNIs server names are reported as NoSNI findings.
+func (c *Correlator) Correlate(window int64, minServedSNIs int) map[string]*Input {
+	findings := make(map[string]*Input)
+
+	for _, sessions := range c.pairs {
+		sort.Slice(sessions, func(i, j int) bool { return sessions[i].ts < sessions[j].ts })
+	}
+
+	for _, request := range c.requests {
+		session, ok := c.sessions[request.uid]
+		if !ok {
+			session = closestSession(c.pairs[portPairKey(request.hosts, request.port)], request.ts, window)
+		}
+		if session == nil || session.sni == "" || !mismatched(session.sni, request.host) {
+			continue
+		}
+
+		key := Mismatch + session.hosts.MapKey() + session.sni + "|" + request.host
+		if _, ok := findings[key]; !ok {
+			findings[key] = &Input{
+				Type:     Mismatch,
+				Hosts:    session.hosts,
+				SNI:      session.sni,
+				HTTPHost: request.host,
+			}
+		}
+		findings[key].ConnectionCount++
+	}
+
+	for _, sessions := range c.pairs {
+		for _, session := range sessions {
+			if session.sni != "" {
+				continue
+			}
+			served := len(c.servedSNIs[session.hosts.UniqueDstIP.Unpair().MapKey()])
+			if served < minServedSNIs {
+				// every session in the pair shares the same destination
+				break
+			}
+
+			key := NoSNI + session.hosts.MapKey()
+			if _, ok := findings[key]; !ok {
+				findings[key] = &Input{
+					Type:           NoSNI,
+					Hosts:          session.hosts,
+					ServedSNICount: served,
+				}
+			}
+			findings[key].ConnectionCount++
+		}
+	}
+
+	return findings
+}
+
+// portPairKey groups the sessions on a connection pair by destination port
+func portPairKey(hosts data.UniqueIPPair, port int) string {
+	return hosts.MapKey() + ":" + strconv.Itoa(port)
+}
+
+// closestSession returns the session nearest in time to ts, provided it is
+// within window seconds. sessions must be sorted by timestamp.
+func closestSession(sessions []*tlsSession, ts int64, window int64) *tlsSession {
+	if window <= 0 || len(sessions) == 0 {
+		return nil
+	}
+
+	i := sort.Search(len(sessions), func(i int) bool { return sessions[i].ts >= ts })
+
+	var closest *tlsSession
+	if i < len(sessions) && sessions[i].ts-ts <= window {
+		closest = sessions[i]
+	}
+	if i > 0 && ts-sessions[i-1].ts <= window &&
+		(closest == nil || ts-sessions[i-1].ts < closest.ts-ts) {
+		closest = sessions[i-1]
+	}
+	return closest
+}
+
+// mismatched returns true if the server name and HTTP host belong to
+// different registered domains. Host headers holding IP addresses are ignored.
+func mismatched(sni string, host string) bool {
+	if net.ParseIP(host) != nil {
+		return false
+	}
+	sniDomain, _ := util.SplitRegisteredDomain(sni)
+	hostDomain, _ := util.SplitRegisteredDomain(host)
+	return sniDomain != hostDomain
+}
+
+// normalizeHost lower cases a server name or Host header and strips any
+// port number and trailing dot
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "-" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
@@ -0,0 +1,83 @@
+package domainfronting
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func pair(src, dst string) data.UniqueIPPair {
+	return data.NewUniqueIPPair(data.UniqueIP{IP: src}, data.UniqueIP{IP: dst})
+}
+
+func TestCorrelateMismatch(t *testing.T) {
+	c := NewCorrelator()
+	cdn := pair("10.0.0.1", "151.101.1.1")
+
+	// same session, different registered domains
+	c.AddTLS("C1", cdn, 443, 100, "allowed.example.com")
+	c.AddHTTP("C1", cdn, 443, 100, "hidden.attacker.net:443")
+
+	// same session, same registered domain
+	c.AddTLS("C2", cdn, 443, 200, "www.example.com")
+	c.AddHTTP("C2", cdn, 443, 200, "static.example.com")
+
+	// no shared UID, but close enough in time on the same pair
+	c.AddTLS("C3", cdn, 443, 300, "allowed.example.com")
+	c.AddHTTP("", cdn, 443, 302, "HIDDEN.attacker.net")
+
+	// no shared UID and too far apart
+	c.AddHTTP("", cdn, 443, 1000, "other.attacker.net")
+
+	// Host header holding an IP address
+	c.AddTLS("C4", cdn, 443, 400, "allowed.example.com")
+	c.AddHTTP("C4", cdn, 443, 400, "151.101.1.1")
+
+	findings := c.Correlate(5, 10)
+	require.Len(t, findings, 1)
+
+	for _, finding := range findings {
+		require.Equal(t, Mismatch, finding.Type)
+		require.Equal(t, "allowed.example.com", finding.SNI)
+		require.Equal(t, "hidden.attacker.net", finding.HTTPHost)
+		require.Equal(t, int64(2), finding.ConnectionCount)
+	}
+}
+
+func TestCorrelatePorts(t *testing.T) {
+	c := NewCorrelator()
+	cdn := pair("10.0.0.1", "151.101.1.1")
+
+	// plain HTTP to a CDN alongside HTTPS to another site it serves
+	c.AddTLS("C1", cdn, 443, 100, "www.example.com")
+	c.AddHTTP("C2", cdn, 80, 101, "cdn.other.net")
+
+	require.Empty(t, c.Correlate(5, 10))
+}
+
+func TestCorrelateNoSNI(t *testing.T) {
+	c := NewCorrelator()
+
+	// a destination serving many server names
+	for i := 0; i < 10; i++ {
+		c.AddTLS("", pair("10.0.0."+strconv.Itoa(i+2), "151.101.1.1"), 443, 100, "site"+strconv.Itoa(i)+".com")
+	}
+	c.AddTLS("", pair("10.0.0.1", "151.101.1.1"), 443, 200, "")
+	c.AddTLS("", pair("10.0.0.1", "151.101.1.1"), 8443, 300, "-")
+
+	// a destination serving a single server name
+	c.AddTLS("", pair("10.0.0.1", "8.8.8.8"), 443, 100, "dns.google")
+	c.AddTLS("", pair("10.0.0.1", "8.8.8.8"), 443, 200, "")
+
+	findings := c.Correlate(5, 10)
+	require.Len(t, findings, 1)
+
+	for _, finding := range findings {
+		require.Equal(t, NoSNI, finding.Type)
+		require.Equal(t, "151.101.1.1", finding.Hosts.DstIP)
+		require.Equal(t, 10, finding.ServedSNICount)
+		require.Equal(t, int64(2), finding.ConnectionCount)
+	}
+}
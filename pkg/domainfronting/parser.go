@@ -0,0 +1,54 @@
+package domainfronting
+
+import (
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/pkg/analysis"
+)
+
+//parser joins the TLS sessions and HTTP requests in a batch of ssl and http
+//records to compare server names with Host headers
+type parser struct {
+	filter        *analysis.Filter
+	window        int64
+	minServedSNIs int
+	correlator    *Correlator
+}
+
+//NewParser creates a parser which compares the server names of TLS sessions
+//with the Host headers of the HTTP requests they carried
+func NewParser(conf *config.Config, filter *analysis.Filter) analysis.Parser {
+	return &parser{
+		filter:        filter,
+		window:        int64(conf.S.DomainFronting.CorrelationWindow),
+		minServedSNIs: conf.S.DomainFronting.MinimumServedSNIs,
+		correlator:    NewCorrelator(),
+	}
+}
+
+//Parse records the TLS sessions and the HTTP requests
+func (p *parser) Parse(record *analysis.Record) {
+	if record.Filtered {
+		return
+	}
+
+	switch record.Log {
+	case analysis.HTTPLog:
+		// proxied CONNECT requests carry the target in the Host header and
+		// are handled by the proxy beacon analysis
+		if record.HTTP.Method != "CONNECT" {
+			p.correlator.AddHTTP(record.HTTP.UID, record.Hosts, record.HTTP.DestinationPort, record.HTTP.TimeStamp, record.FQDN)
+		}
+
+	case analysis.SSLLog:
+		// record the session so its server name can be compared with the
+		// Host header of any HTTP requests it carried
+		if !p.filter.FilterDomain(record.FQDN) {
+			p.correlator.AddTLS(record.SSL.UID, record.Hosts, record.SSL.DestinationPort, record.SSL.TimeStamp, record.FQDN)
+		}
+	}
+}
+
+//Flush returns the fronted sessions gathered from the batch
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	return p.correlator.Correlate(p.window, p.minServedSNIs)
+}
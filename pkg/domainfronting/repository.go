@@ -0,0 +1,63 @@
+package domainfronting
+
+import (
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	//Mismatch is a TLS session whose server name does not match the HTTP Host
+	//header seen in the same session
+	Mismatch = "mismatch"
+	//NoSNI is a TLS session without a server name sent to an IP which serves
+	//many other server names
+	NoSNI = "no_sni"
+)
+
+type (
+
+	// Repository for domainFronting collection
+	Repository interface {
+		CreateIndexes() error
+		Upsert(frontingMap map[string]*Input)
+	}
+
+	//update ....
+	update struct {
+		selector bson.M
+		query    bson.M
+	}
+
+	//Result represents a connection pair which may be hiding its true
+	//destination behind a shared front end
+	Result struct {
+		data.UniqueIPPair `bson:",inline"`
+		Type              string `bson:"type"`
+		SNI               string `bson:"sni"`
+		HTTPHost          string `bson:"http_host"`
+		ServedSNICount    int    `bson:"served_sni_count"`
+		ConnectionCount   int64  `bson:"connection_count"`
+	}
+
+	//Input structure for sending data to the analyzer. Contains the connection
+	//pair, the TLS server name and HTTP Host header which disagreed (Mismatch),
+	//or the number of server names served by the destination (NoSNI).
+	Input struct {
+		Type            string
+		Hosts           data.UniqueIPPair
+		SNI             string
+		HTTPHost        string
+		ServedSNICount  int
+		ConnectionCount int64
+	}
+)
+
+// BSONKey generates a BSON map which may be used to index a given connection
+// pair, finding type, server name, and HTTP host
+func (in *Input) BSONKey() bson.M {
+	key := in.Hosts.BSONKey()
+	key["type"] = in.Type
+	key["sni"] = in.SNI
+	key["http_host"] = in.HTTPHost
+	return key
+}
@@ -0,0 +1,41 @@
+package domainfronting
+
+import (
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+// Results returns connection pairs which may be using domain fronting, sorted
+// by connection count. limit and noLimit control how many results are returned.
+func Results(res *resources.Resources, limit int, noLimit bool) ([]Result, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var frontingResults []Result
+
+	frontingQuery := []bson.M{
+		{"$project": bson.M{
+			"src":              1,
+			"src_network_uuid": 1,
+			"src_network_name": 1,
+			"dst":              1,
+			"dst_network_uuid": 1,
+			"dst_network_name": 1,
+			"type":             1,
+			"sni":              1,
+			"http_host":        1,
+			"served_sni_count": 1,
+			"connection_count": bson.M{"$sum": "$dat.count"},
+		}},
+		{"$match": bson.M{"connection_count": bson.M{"$gt": 0}}},
+		{"$sort": bson.M{"connection_count": -1}},
+	}
+
+	if !noLimit {
+		frontingQuery = append(frontingQuery, bson.M{"$limit": limit})
+	}
+
+	err := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.DomainFronting.DomainFrontingTable).Pipe(frontingQuery).AllowDiskUse().All(&frontingResults)
+
+	return frontingResults, err
+}
@@ -0,0 +1,179 @@
+package exfil
+
+import (
+	"math"
+	"sync"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/util"
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+	//analyzer : structure for exfil analysis
+	analyzer struct {
+		chunk            int            //current chunk (0 if not on rolling analysis)
+		db               *database.DB   // provides access to MongoDB
+		conf             *config.Config // contains details needed to access MongoDB
+		analyzedCallback func(*update)  // called on each analyzed result
+		closedCallback   func()         // called when .close() is called and no more calls to analyzedCallback will be made
+		analysisChannel  chan *Input    // holds unanalyzed data
+		analysisWg       sync.WaitGroup // wait for analysis to finish
+	}
+)
+
+//newAnalyzer creates a new collector for scoring outbound volume
+func newAnalyzer(chunk int, db *database.DB, conf *config.Config, analyzedCallback func(*update), closedCallback func()) *analyzer {
+	return &analyzer{
+		chunk:            chunk,
+		db:               db,
+		conf:             conf,
+		analyzedCallback: analyzedCallback,
+		closedCallback:   closedCallback,
+		analysisChannel:  make(chan *Input),
+	}
+}
+
+//collect sends a host or pair to be analyzed
+func (a *analyzer) collect(data *Input) {
+	a.analysisChannel <- data
+}
+
+//close waits for the collector to finish
+func (a *analyzer) close() {
+	close(a.analysisChannel)
+	a.analysisWg.Wait()
+	a.closedCallback()
+}
+
+//start kicks off a new analysis thread
+func (a *analyzer) start() {
+	a.analysisWg.Add(1)
+	go func() {
+		ssn := a.db.Session.Copy()
+		defer ssn.Close()
+
+		for entry := range a.analysisChannel {
+
+			// the bytes exchanged in each chunk are stored in the dat array
+			// so the current chunk can be compared against the history
+			var stored struct {
+				Dat []chunk `bson:"dat"`
+			}
+
+			_ = ssn.DB(a.db.GetSelectedDB()).C(a.conf.T.Exfil.ExfilTable).Find(entry.BSONKey()).One(&stored)
+
+			dat := addToChunk(stored.Dat, entry, a.chunk)
+
+			var sent, received, count int64
+			for _, c := range dat {
+				sent += c.BytesSent
+				received += c.BytesReceived
+				count += c.ConnectionCount
+			}
+
+			uploadRatio, deviation, score := scoreHistory(dat, a.chunk)
+
+			set := bson.M{
+				"local.network_name": entry.Local.NetworkName,
+				"bytes_sent":         sent,
+				"bytes_received":     received,
+				"connection_count":   count,
+				"upload_ratio":       uploadRatio,
+				"deviation":          deviation,
+				"score":              score,
+				"dat":                dat,
+				"cid":                a.chunk,
+			}
+
+			if entry.Type == Pair {
+				set["remote.network_name"] = entry.Remote.NetworkName
+			}
+
+			a.analyzedCallback(&update{
+				selector: entry.BSONKey(),
+				query:    bson.M{"$set": set},
+			})
+		}
+		a.analysisWg.Done()
+	}()
+}
+
+//addToChunk adds the bytes from the current batch to the entry for the
+//current chunk, creating the entry if this is the first batch in the chunk
+func addToChunk(dat []chunk, entry *Input, cid int) []chunk {
+	for i := range dat {
+		if dat[i].CID == cid {
+			dat[i].BytesSent += entry.BytesSent
+			dat[i].BytesReceived += entry.BytesReceived
+			dat[i].ConnectionCount += entry.ConnectionCount
+			return dat
+		}
+	}
+
+	return append(dat, chunk{
+		BytesSent:       entry.BytesSent,
+		BytesReceived:   entry.BytesReceived,
+		ConnectionCount: entry.ConnectionCount,
+		CID:             cid,
+	})
+}
+
+//scoreHistory computes the upload ratio of the current chunk, how many standard
+//deviations the bytes sent in the current chunk are above the previous chunks,
+//and the overall exfil score
+func scoreHistory(dat []chunk, cid int) (float64, float64, float64) {
+	var current chunk
+	var history []float64
+	for _, c := range dat {
+		if c.CID == cid {
+			current = c
+		} else {
+			history = append(history, float64(c.BytesSent))
+		}
+	}
+
+	uploadRatio := 0.0
+	if current.BytesSent+current.BytesReceived > 0 {
+		uploadRatio = float64(current.BytesSent) / float64(current.BytesSent+current.BytesReceived)
+	}
+
+	// compare the current chunk against the previous chunks. At least two
+	// previous chunks are needed to get a meaningful spread.
+	deviation := 0.0
+	if len(history) >= 2 {
+		mean := 0.0
+		for _, sent := range history {
+			mean += sent
+		}
+		mean /= float64(len(history))
+
+		variance := 0.0
+		for _, sent := range history {
+			variance += (sent - mean) * (sent - mean)
+		}
+		stdDev := math.Sqrt(variance / float64(len(history)))
+
+		// avoid dividing by zero when the history is perfectly flat
+		deviation = (float64(current.BytesSent) - mean) / math.Max(stdDev, 1)
+		deviation = math.Ceil(deviation*1000) / 1000
+	}
+
+	//sending less than 1MB receives no score, 10GB or more receives a full score
+	volumeScore := util.Clamp((math.Log10(math.Max(float64(current.BytesSent), 1)) - 6.0) / 4.0)
+
+	//hosts which mostly download receive no score, hosts which only upload receive a full score
+	ratioScore := util.Clamp((uploadRatio - 0.5) / 0.5)
+
+	//sending three or more standard deviations above the history receives a full score
+	deviationScore := util.Clamp(deviation / 3.0)
+
+	//score numerators
+	sum := volumeScore + ratioScore + deviationScore
+
+	//score average
+	score := math.Ceil((sum/3.0)*1000) / 1000
+
+	return math.Ceil(uploadRatio*1000) / 1000, deviation, score
+}
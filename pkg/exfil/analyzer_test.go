@@ -0,0 +1,46 @@
+package exfil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddToChunk(t *testing.T) {
+	dat := []chunk{{BytesSent: 10, BytesReceived: 20, ConnectionCount: 1, CID: 0}}
+
+	// a second batch in the same chunk adds to the existing entry
+	dat = addToChunk(dat, &Input{BytesSent: 5, BytesReceived: 5, ConnectionCount: 2}, 0)
+	require.Equal(t, []chunk{{BytesSent: 15, BytesReceived: 25, ConnectionCount: 3, CID: 0}}, dat)
+
+	// a new chunk creates a new entry
+	dat = addToChunk(dat, &Input{BytesSent: 1, BytesReceived: 2, ConnectionCount: 3}, 1)
+	require.Len(t, dat, 2)
+	require.Equal(t, chunk{BytesSent: 1, BytesReceived: 2, ConnectionCount: 3, CID: 1}, dat[1])
+}
+
+func TestScoreHistory(t *testing.T) {
+	history := []chunk{
+		{BytesSent: 1000000, BytesReceived: 9000000, CID: 0},
+		{BytesSent: 1200000, BytesReceived: 9000000, CID: 1},
+		{BytesSent: 800000, BytesReceived: 9000000, CID: 2},
+	}
+
+	// a chunk in line with the history
+	normal := append(history, chunk{BytesSent: 1000000, BytesReceived: 9000000, CID: 3})
+	normalRatio, normalDeviation, normalScore := scoreHistory(normal, 3)
+	require.InDelta(t, 0.1, normalRatio, 0.001)
+	require.InDelta(t, 0, normalDeviation, 0.001)
+
+	// a chunk with a large upload
+	upload := append(history, chunk{BytesSent: 5000000000, BytesReceived: 9000000, CID: 3})
+	uploadRatio, uploadDeviation, uploadScore := scoreHistory(upload, 3)
+	require.True(t, uploadRatio > 0.99)
+	require.True(t, uploadDeviation > 3)
+	require.True(t, uploadScore > normalScore)
+	require.True(t, uploadScore <= 1)
+
+	// without history there is no deviation
+	_, deviation, _ := scoreHistory([]chunk{{BytesSent: 100, CID: 0}}, 0)
+	require.Equal(t, 0.0, deviation)
+}
@@ -0,0 +1,77 @@
+package exfil
+
+import (
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	//Host records track everything an internal host sent to external hosts
+	Host = "host"
+	//Pair records track what an internal host sent to a single external host
+	Pair = "pair"
+)
+
+type (
+
+	// Repository for exfil collection
+	Repository interface {
+		CreateIndexes() error
+		Upsert(exfilMap map[string]*Input)
+	}
+
+	//update ....
+	update struct {
+		selector bson.M
+		query    bson.M
+	}
+
+	//chunk holds the bytes exchanged in a single chunk
+	chunk struct {
+		BytesSent       int64 `bson:"bytes_sent"`
+		BytesReceived   int64 `bson:"bytes_received"`
+		ConnectionCount int64 `bson:"count"`
+		CID             int   `bson:"cid"`
+	}
+
+	//Result represents the outbound volume of an internal host, or of an
+	//internal host and an external peer, and how unusual that volume is
+	Result struct {
+		Type            string        `bson:"type"`
+		Local           data.UniqueIP `bson:"local"`
+		Remote          data.UniqueIP `bson:"remote"`
+		BytesSent       int64         `bson:"bytes_sent"`
+		BytesReceived   int64         `bson:"bytes_received"`
+		ConnectionCount int64         `bson:"connection_count"`
+		UploadRatio     float64       `bson:"upload_ratio"`
+		Deviation       float64       `bson:"deviation"`
+		Score           float64       `bson:"score"`
+	}
+
+	//Input structure for sending data to the analyzer. Host inputs hold the bytes
+	//an internal host exchanged with every external host, while pair inputs hold
+	//the bytes an internal host exchanged with a single external host.
+	Input struct {
+		Type            string
+		Local           data.UniqueIP
+		Remote          data.UniqueIP
+		BytesSent       int64
+		BytesReceived   int64
+		ConnectionCount int64
+	}
+)
+
+//BSONKey generates a BSON map which may be used to index a given exfil record
+func (in *Input) BSONKey() bson.M {
+	key := bson.M{
+		"type":               in.Type,
+		"local.ip":           in.Local.IP,
+		"local.network_uuid": in.Local.NetworkUUID,
+	}
+
+	if in.Type == Pair {
+		key["remote.ip"] = in.Remote.IP
+		key["remote.network_uuid"] = in.Remote.NetworkUUID
+	}
+	return key
+}
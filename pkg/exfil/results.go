@@ -0,0 +1,31 @@
+package exfil
+
+import (
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//Results returns internal hosts and internal/external pairs which sent at least
+//the configured minimum number of bytes, sorted by exfil score.
+//limit and noLimit control how many results are returned.
+func Results(res *resources.Resources, limit int, noLimit bool) ([]Result, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var exfilResults []Result
+
+	exfilQuery := bson.M{
+		"score":      bson.M{"$gt": 0},
+		"bytes_sent": bson.M{"$gte": res.Config.S.Exfil.MinimumBytes},
+	}
+
+	query := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.Exfil.ExfilTable).Find(exfilQuery).Sort("-score", "-bytes_sent")
+
+	if !noLimit {
+		query = query.Limit(limit)
+	}
+
+	err := query.All(&exfilResults)
+
+	return exfilResults, err
+}
@@ -0,0 +1,53 @@
+package exfil
+
+import (
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/uconn"
+)
+
+//FromUconns totals the bytes each internal host exchanged with external hosts
+//over the given uconns, both overall and with each external host
+func FromUconns(uconnMap map[string]*uconn.Input) map[string]*Input {
+	exfilMap := make(map[string]*Input)
+
+	for _, entry := range uconnMap {
+		// only connections between internal and external hosts are considered,
+		// with the bytes counted from the point of view of the internal host
+		var local, remote data.UniqueIP
+		var sent, received int64
+		if entry.IsLocalSrc && !entry.IsLocalDst {
+			local, remote = entry.Hosts.UniqueSrcIP.Unpair(), entry.Hosts.UniqueDstIP.Unpair()
+			sent, received = entry.OrigBytes, entry.RespBytes
+		} else if entry.IsLocalDst && !entry.IsLocalSrc {
+			local, remote = entry.Hosts.UniqueDstIP.Unpair(), entry.Hosts.UniqueSrcIP.Unpair()
+			sent, received = entry.RespBytes, entry.OrigBytes
+		} else {
+			continue
+		}
+
+		localKey := local.MapKey()
+		if _, ok := exfilMap[Host+localKey]; !ok {
+			exfilMap[Host+localKey] = &Input{
+				Type:  Host,
+				Local: local,
+			}
+		}
+		exfilMap[Host+localKey].BytesSent += sent
+		exfilMap[Host+localKey].BytesReceived += received
+		exfilMap[Host+localKey].ConnectionCount += entry.ConnectionCount
+
+		pairKey := Pair + localKey + remote.MapKey()
+		if _, ok := exfilMap[pairKey]; !ok {
+			exfilMap[pairKey] = &Input{
+				Type:   Pair,
+				Local:  local,
+				Remote: remote,
+			}
+		}
+		exfilMap[pairKey].BytesSent += sent
+		exfilMap[pairKey].BytesReceived += received
+		exfilMap[pairKey].ConnectionCount += entry.ConnectionCount
+	}
+
+	return exfilMap
+}
@@ -104,8 +104,10 @@ func (a *analyzer) start() {
 
 					output.query = bson.M{
 						"$push": bson.M{"dat": bson.M{
-							"visited": data.count,
-							"cid":     a.chunk,
+							"visited":  data.count,
+							"nxdomain": data.nxdomain,
+							"servfail": data.servfail,
+							"cid":      a.chunk,
 						}},
 						"$set": bson.M{
 							"cid": a.chunk,
@@ -140,13 +142,19 @@ func (a *analyzer) start() {
 						// subdomain count, only the visited count as the subdomain count is unique
 						if alreadyCountedSubsFlag {
 							output.query = bson.M{
-								"$inc": bson.M{"dat.$.visited": data.count},
+								"$inc": bson.M{
+									"dat.$.visited":  data.count,
+									"dat.$.nxdomain": data.nxdomain,
+									"dat.$.servfail": data.servfail,
+								},
 							}
 						} else {
 							output.query = bson.M{
 								"$inc": bson.M{
 									"subdomain_count": 1,
 									"dat.$.visited":   data.count,
+									"dat.$.nxdomain":  data.nxdomain,
+									"dat.$.servfail":  data.servfail,
 								},
 							}
 						}
@@ -166,8 +174,10 @@ func (a *analyzer) start() {
 							output.query = bson.M{
 								"$set": bson.M{"cid": a.chunk},
 								"$push": bson.M{"dat": bson.M{
-									"visited": data.count,
-									"cid":     a.chunk,
+									"visited":  data.count,
+									"nxdomain": data.nxdomain,
+									"servfail": data.servfail,
+									"cid":      a.chunk,
 								}},
 							}
 						} else {
@@ -177,8 +187,10 @@ func (a *analyzer) start() {
 									"subdomain_count": 1,
 								},
 								"$push": bson.M{"dat": bson.M{
-									"visited": data.count,
-									"cid":     a.chunk,
+									"visited":  data.count,
+									"nxdomain": data.nxdomain,
+									"servfail": data.servfail,
+									"cid":      a.chunk,
 								}},
 							}
 						}
@@ -4,9 +4,9 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/activecm/rita/database"
 	"github.com/activecm/rita/resources"
 	"github.com/activecm/rita/util"
-	"github.com/globalsign/mgo"
 	"github.com/vbauerster/mpb"
 	"github.com/vbauerster/mpb/decor"
 )
@@ -41,7 +41,7 @@ func (r *repo) CreateIndexes() error {
 	}
 
 	// set desired indexes
-	indexes := []mgo.Index{
+	indexes := []database.Index{
 		{Key: []string{"domain"}, Unique: true},
 		// {Key: []string{"visited"}},
 		{Key: []string{"subdomain_count"}},
@@ -57,7 +57,7 @@ func (r *repo) CreateIndexes() error {
 }
 
 //Upsert loops through every domain ....
-func (r *repo) Upsert(domainMap map[string]int) {
+func (r *repo) Upsert(domainMap map[string]*Input) {
 
 	//Create the workers
 	writerWorker := newWriter(r.res.Config.T.DNS.ExplodedDNSTable, r.res.DB, r.res.Config, r.res.Log)
@@ -87,7 +87,7 @@ func (r *repo) Upsert(domainMap map[string]int) {
 	)
 
 	// loop over map entries
-	for entry, count := range domainMap {
+	for entry, counts := range domainMap {
 		start := time.Now()
 		//Mongo Index key is limited to a size of 1024 https://docs.mongodb.com/v3.4/reference/limits/#index-limitations
 		//  so if the key is too large, we should cut it back, this is rough but
@@ -95,7 +95,7 @@ func (r *repo) Upsert(domainMap map[string]int) {
 		if len(entry) > 1024 {
 			entry = entry[:800]
 		}
-		analyzerWorker.collect(domain{entry, count})
+		analyzerWorker.collect(domain{entry, counts.Count, counts.NXDomainCount, counts.ServFailCount})
 		bar.IncrBy(1, time.Since(start))
 	}
 
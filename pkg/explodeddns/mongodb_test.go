@@ -19,11 +19,11 @@ var testTargetDB = "tmp_test_db"
 
 var testRepo Repository
 
-var testExplodedDNS = map[string]int{
-	"a.b.activecountermeasures.com":   123,
-	"x.a.b.activecountermeasures.com": 38,
-	"activecountermeasures.com":       1,
-	"google.com":                      912,
+var testExplodedDNS = map[string]*Input{
+	"a.b.activecountermeasures.com":   {Count: 123},
+	"x.a.b.activecountermeasures.com": {Count: 38, NXDomainCount: 38},
+	"activecountermeasures.com":       {Count: 1},
+	"google.com":                      {Count: 912, ServFailCount: 2},
 }
 
 func TestUpdateDomains(t *testing.T) {
@@ -0,0 +1,39 @@
+package explodeddns
+
+import (
+	"github.com/activecm/rita/pkg/analysis"
+)
+
+//parser counts the queries for each domain in a batch of dns records
+type parser struct {
+	explodeddnsMap map[string]*Input
+}
+
+//NewParser creates a parser which counts the queries for each domain
+func NewParser() analysis.Parser {
+	return &parser{explodeddnsMap: make(map[string]*Input)}
+}
+
+//Parse counts a query and whether it failed
+func (p *parser) Parse(record *analysis.Record) {
+	if record.Filtered {
+		return
+	}
+
+	domain := record.FQDN
+	if _, ok := p.explodeddnsMap[domain]; !ok {
+		p.explodeddnsMap[domain] = &Input{}
+	}
+	p.explodeddnsMap[domain].Count++
+	switch record.DNS.RCodeName {
+	case "NXDOMAIN":
+		p.explodeddnsMap[domain].NXDomainCount++
+	case "SERVFAIL":
+		p.explodeddnsMap[domain].ServFailCount++
+	}
+}
+
+//Flush returns the query counts gathered from the batch
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	return p.explodeddnsMap
+}
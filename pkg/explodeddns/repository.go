@@ -4,7 +4,7 @@ package explodeddns
 type Repository interface {
 	CreateIndexes() error
 	// Upsert(explodedDNS *parsetypes.ExplodedDNS) error
-	Upsert(domainMap map[string]int)
+	Upsert(domainMap map[string]*Input)
 }
 
 //update ....
@@ -13,10 +13,20 @@ type update struct {
 	query    interface{}
 }
 
+//Input holds how many times a domain was queried in the current import
+//and how many of those queries failed with NXDOMAIN or SERVFAIL
+type Input struct {
+	Count         int64
+	NXDomainCount int64
+	ServFailCount int64
+}
+
 //domain ....
 type domain struct {
-	name  string
-	count int
+	name     string
+	count    int64
+	nxdomain int64
+	servfail int64
 }
 
 //dns ....
@@ -27,10 +37,12 @@ type dns struct {
 }
 
 //Result represents a hostname, how many subdomains were found
-//for that hostname, and how many times that hostname and its subdomains
-//were looked up.
+//for that hostname, how many times that hostname and its subdomains
+//were looked up, and how many of those lookups failed.
 type Result struct {
 	Domain         string `bson:"domain"`
 	SubdomainCount int64  `bson:"subdomain_count"`
 	Visited        int64  `bson:"visited"`
+	NXDomainCount  int64  `bson:"nxdomain"`
+	ServFailCount  int64  `bson:"servfail"`
 }
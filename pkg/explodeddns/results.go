@@ -15,16 +15,26 @@ func Results(res *resources.Resources, limit int, noLimit bool) ([]Result, error
 
 	explodedDNSQuery := []bson.M{
 		bson.M{"$unwind": "$dat"},
-		bson.M{"$project": bson.M{"domain": 1, "subdomain_count": 1, "visited": "$dat.visited"}},
+		bson.M{"$project": bson.M{
+			"domain":          1,
+			"subdomain_count": 1,
+			"visited":         "$dat.visited",
+			"nxdomain":        "$dat.nxdomain",
+			"servfail":        "$dat.servfail",
+		}},
 		bson.M{"$group": bson.M{
 			"_id":             "$domain",
 			"visited":         bson.M{"$sum": "$visited"},
+			"nxdomain":        bson.M{"$sum": "$nxdomain"},
+			"servfail":        bson.M{"$sum": "$servfail"},
 			"subdomain_count": bson.M{"$first": "$subdomain_count"},
 		}},
 		bson.M{"$project": bson.M{
 			"_id":             0,
 			"domain":          "$_id",
 			"visited":         1,
+			"nxdomain":        1,
+			"servfail":        1,
 			"subdomain_count": 1,
 		}},
 		bson.M{"$sort": bson.M{"visited": -1}},
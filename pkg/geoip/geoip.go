@@ -0,0 +1,129 @@
+package geoip
+
+import (
+	"net"
+	"strings"
+
+	"github.com/activecm/rita/config"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+type (
+	//Info holds the autonomous system and country an IP address belongs to
+	Info struct {
+		ASN     uint   `bson:"asn,omitempty"`
+		ASOrg   string `bson:"as_org,omitempty"`
+		Country string `bson:"country,omitempty"`
+	}
+
+	//Reader looks up IP addresses in MaxMind format ASN and country databases
+	Reader struct {
+		asn     *maxminddb.Reader
+		country *maxminddb.Reader
+	}
+
+	//Filter selects IP addresses by autonomous system number and country.
+	//Zero values match every IP address.
+	Filter struct {
+		ASN     uint
+		Country string
+	}
+
+	//asnRecord is the subset of a GeoLite2/GeoIP2 ASN record used by RITA
+	asnRecord struct {
+		Number uint   `maxminddb:"autonomous_system_number"`
+		Org    string `maxminddb:"autonomous_system_organization"`
+	}
+
+	//countryRecord is the subset of a GeoLite2/GeoIP2 country record used by RITA
+	countryRecord struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+		RegisteredCountry struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"registered_country"`
+	}
+)
+
+//NewReader opens the ASN and country databases set in the config. Either
+//database may be left unset. A nil Reader is returned if enrichment is disabled.
+func NewReader(conf *config.Config) (*Reader, error) {
+	if !conf.S.GeoIP.Enabled {
+		return nil, nil
+	}
+
+	r := &Reader{}
+	var err error
+	if conf.S.GeoIP.ASNDatabase != "" {
+		r.asn, err = maxminddb.Open(conf.S.GeoIP.ASNDatabase)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if conf.S.GeoIP.CountryDatabase != "" {
+		r.country, err = maxminddb.Open(conf.S.GeoIP.CountryDatabase)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+//Lookup returns the autonomous system and country of an IP address. The
+//returned bool is false if neither database holds the address.
+func (r *Reader) Lookup(ipStr string) (Info, bool) {
+	var info Info
+	ip := net.ParseIP(ipStr)
+	if r == nil || ip == nil {
+		return info, false
+	}
+
+	if r.asn != nil {
+		var record asnRecord
+		if err := r.asn.Lookup(ip, &record); err == nil {
+			info.ASN = record.Number
+			info.ASOrg = record.Org
+		}
+	}
+	if r.country != nil {
+		var record countryRecord
+		if err := r.country.Lookup(ip, &record); err == nil {
+			info.Country = record.Country.ISOCode
+			if info.Country == "" {
+				info.Country = record.RegisteredCountry.ISOCode
+			}
+		}
+	}
+	return info, info != Info{}
+}
+
+//Close releases the databases held by the Reader
+func (r *Reader) Close() {
+	if r == nil {
+		return
+	}
+	if r.asn != nil {
+		r.asn.Close()
+	}
+	if r.country != nil {
+		r.country.Close()
+	}
+}
+
+//Active returns true if the Filter excludes any IP addresses
+func (f Filter) Active() bool {
+	return f.ASN != 0 || f.Country != ""
+}
+
+//Matches returns true if info satisfies the Filter
+func (f Filter) Matches(info Info) bool {
+	if f.ASN != 0 && info.ASN != f.ASN {
+		return false
+	}
+	if f.Country != "" && !strings.EqualFold(info.Country, f.Country) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package geoip
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterMatches(t *testing.T) {
+	info := Info{ASN: 15169, ASOrg: "GOOGLE", Country: "US"}
+
+	require.False(t, Filter{}.Active())
+	require.True(t, Filter{}.Matches(info))
+	require.True(t, Filter{}.Matches(Info{}))
+
+	require.True(t, Filter{ASN: 15169}.Matches(info))
+	require.False(t, Filter{ASN: 13335}.Matches(info))
+
+	require.True(t, Filter{Country: "us"}.Matches(info))
+	require.False(t, Filter{Country: "DE"}.Matches(info))
+	require.False(t, Filter{Country: "US"}.Matches(Info{}))
+
+	require.True(t, Filter{ASN: 15169, Country: "US"}.Matches(info))
+	require.False(t, Filter{ASN: 15169, Country: "DE"}.Matches(info))
+}
+
+func TestNilReader(t *testing.T) {
+	var r *Reader
+	info, ok := r.Lookup("8.8.8.8")
+	require.False(t, ok)
+	require.Equal(t, Info{}, info)
+	r.Close()
+}
+
+func TestReaderLookup(t *testing.T) {
+	// the test databases hold 8.8.8.0/24 and 1.1.1.0/24 in the ASN database and
+	// 8.8.8.0/24 and 81.2.69.0/24, with only a registered country, in the
+	// country database
+	conf := &config.Config{}
+	conf.S.GeoIP.Enabled = true
+	conf.S.GeoIP.ASNDatabase = filepath.Join("testdata", "asn.mmdb")
+	conf.S.GeoIP.CountryDatabase = filepath.Join("testdata", "country.mmdb")
+
+	r, err := NewReader(conf)
+	require.NoError(t, err)
+	defer r.Close()
+
+	tests := []struct {
+		ip    string
+		info  Info
+		found bool
+	}{
+		{"8.8.8.8", Info{ASN: 15169, ASOrg: "GOOGLE", Country: "US"}, true},
+		{"1.1.1.1", Info{ASN: 13335, ASOrg: "CLOUDFLARENET"}, true},
+		{"81.2.69.160", Info{Country: "GB"}, true},
+		{"10.0.0.1", Info{}, false},
+		{"2001:db8::1", Info{}, false},
+		{"not an ip", Info{}, false},
+	}
+
+	for _, test := range tests {
+		info, ok := r.Lookup(test.ip)
+		require.Equal(t, test.found, ok, test.ip)
+		require.Equal(t, test.info, info, test.ip)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	conf := &config.Config{}
+	conf.S.GeoIP.ASNDatabase = filepath.Join("testdata", "asn.mmdb")
+
+	// enrichment is disabled
+	r, err := NewReader(conf)
+	require.NoError(t, err)
+	require.Nil(t, r)
+
+	// only an ASN database is set
+	conf.S.GeoIP.Enabled = true
+	r, err = NewReader(conf)
+	require.NoError(t, err)
+	info, ok := r.Lookup("8.8.8.8")
+	require.True(t, ok)
+	require.Equal(t, Info{ASN: 15169, ASOrg: "GOOGLE"}, info)
+	r.Close()
+
+	conf.S.GeoIP.CountryDatabase = filepath.Join("testdata", "missing.mmdb")
+	_, err = NewReader(conf)
+	require.Error(t, err)
+}
@@ -4,8 +4,8 @@ import (
 	"github.com/activecm/rita/config"
 	"github.com/activecm/rita/database"
 	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/geoip"
 
-	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 	log "github.com/sirupsen/logrus"
 
@@ -22,6 +22,7 @@ type (
 		conf             *config.Config // contains details needed to access MongoDB
 		db               *database.DB   // provides access to MongoDB
 		log              *log.Logger    // logger for writing out errors and warnings
+		geo              *geoip.Reader  // looks up the ASN and country of external hosts (nil if disabled)
 		analyzedCallback func(update)   // called on each analyzed result
 		closedCallback   func()         // called when .close() is called and no more calls to analyzedCallback will be made
 		analysisChannel  chan *Input    // holds unanalyzed data
@@ -30,13 +31,14 @@ type (
 )
 
 //newAnalyzer creates a new collector for gathering data
-func newAnalyzer(chunk int, conf *config.Config, db *database.DB, log *log.Logger, analyzedCallback func(update), closedCallback func()) *analyzer {
+func newAnalyzer(chunk int, conf *config.Config, db *database.DB, log *log.Logger, geo *geoip.Reader, analyzedCallback func(update), closedCallback func()) *analyzer {
 	return &analyzer{
 		chunk:            chunk,
 		chunkStr:         strconv.Itoa(chunk),
 		conf:             conf,
 		log:              log,
 		db:               db,
+		geo:              geo,
 		analyzedCallback: analyzedCallback,
 		closedCallback:   closedCallback,
 		analysisChannel:  make(chan *Input),
@@ -100,7 +102,14 @@ func (a *analyzer) start() {
 					}
 				}
 
-				output = standardQuery(a.chunk, a.chunkStr, datum.Host, datum.IsLocal, datum.IP4, datum.IP4Bin, datum.MaxDuration, maxDNSQueryRes, datum.UntrustedAppConnCount, datum.CountSrc, datum.CountDst, blacklisted, newRecordFlag)
+				output = standardQuery(a.chunk, a.chunkStr, datum.Host, datum.IsLocal, datum.IP4, datum.IP4Bin, datum.MaxDuration, maxDNSQueryRes, datum.UntrustedAppConnCount, datum.CountSrc, datum.CountDst, datum.BytesSent, datum.BytesReceived, blacklisted, newRecordFlag)
+
+				// annotate external hosts with their ASN and country
+				if !datum.IsLocal {
+					if info, ok := a.geo.Lookup(datum.Host.IP); ok {
+						output.query["$set"].(bson.M)["geo"] = info
+					}
+				}
 
 				// set to writer channel
 				a.analyzedCallback(output)
@@ -113,7 +122,7 @@ func (a *analyzer) start() {
 }
 
 //shouldInsertNewRecord returns true if a host entry with the current CID does not exist in the database
-func (a *analyzer) shouldInsertNewHostRecord(ssn *mgo.Session, host data.UniqueIP) bool {
+func (a *analyzer) shouldInsertNewHostRecord(ssn database.Session, host data.UniqueIP) bool {
 	var hostCIDs []struct {
 		CID int `bson:"cid"`
 	}
@@ -163,7 +172,7 @@ func buildExplodedDNSArray(dnsQueryCounts map[string]int64) []explodedDNS {
 }
 
 //writeExplodedDNSEntries pushes the explodedDNS results for the current import session into a host entry int the database
-func (a *analyzer) writeExplodedDNSEntries(ssn *mgo.Session, host data.UniqueIP, explodedDNSEntries []explodedDNS, newRecordFlag bool) {
+func (a *analyzer) writeExplodedDNSEntries(ssn database.Session, host data.UniqueIP, explodedDNSEntries []explodedDNS, newRecordFlag bool) {
 
 	// push the host exploded dns results into this host's dat array
 	var input update
@@ -251,7 +260,7 @@ func maxDNSQueryCountQuery(host data.UniqueIP) []bson.M {
 }
 
 //standardQuery ...
-func standardQuery(chunk int, chunkStr string, ip data.UniqueIP, local bool, ip4 bool, ip4bin int64, maxdur float64, maxDNSQueryCount explodedDNS, untrustedACC int64, countSrc int, countDst int, blacklisted bool, newFlag bool) update {
+func standardQuery(chunk int, chunkStr string, ip data.UniqueIP, local bool, ip4 bool, ip4bin int64, maxdur float64, maxDNSQueryCount explodedDNS, untrustedACC int64, countSrc int, countDst int, bytesSent int64, bytesReceived int64, blacklisted bool, newFlag bool) update {
 	var output update
 
 	// create query
@@ -271,10 +280,12 @@ func standardQuery(chunk int, chunkStr string, ip data.UniqueIP, local bool, ip4
 			"dat": bson.M{
 				"$each": []bson.M{
 					{
-						"count_src":  countSrc,
-						"count_dst":  countDst,
-						"upps_count": untrustedACC,
-						"cid":        chunk,
+						"count_src":      countSrc,
+						"count_dst":      countDst,
+						"upps_count":     untrustedACC,
+						"bytes_sent":     bytesSent,
+						"bytes_received": bytesReceived,
+						"cid":            chunk,
 					},
 					{
 						"max_dns": maxDNSQueryCount,
@@ -290,9 +301,11 @@ func standardQuery(chunk int, chunkStr string, ip data.UniqueIP, local bool, ip4
 	} else {
 
 		query["$inc"] = bson.M{
-			"dat.$.count_src":  countSrc,
-			"dat.$.count_dst":  countDst,
-			"dat.$.upps_count": untrustedACC,
+			"dat.$.count_src":      countSrc,
+			"dat.$.count_dst":      countDst,
+			"dat.$.upps_count":     untrustedACC,
+			"dat.$.bytes_sent":     bytesSent,
+			"dat.$.bytes_received": bytesReceived,
 		}
 
 		query["$push"] = bson.M{
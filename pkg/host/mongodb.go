@@ -1,12 +1,14 @@
 package host
 
 import (
+	"fmt"
 	"runtime"
 	"time"
 
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/geoip"
 	"github.com/activecm/rita/resources"
 	"github.com/activecm/rita/util"
-	"github.com/globalsign/mgo"
 	"github.com/vbauerster/mpb"
 	"github.com/vbauerster/mpb/decor"
 )
@@ -30,7 +32,7 @@ func (r *repo) CreateIndexes() error {
 
 	// create hosts collection
 	// Desired indexes
-	indexes := []mgo.Index{
+	indexes := []database.Index{
 		{Key: []string{"ip"}}, //TODO[AGENT]: Determine if this index is needed
 		{Key: []string{"ip", "network_uuid"}, Unique: true},
 		{Key: []string{"local"}},
@@ -49,6 +51,14 @@ func (r *repo) CreateIndexes() error {
 //Upsert loops through every domain ....
 func (r *repo) Upsert(hostMap map[string]*Input) {
 
+	// open the GeoIP databases, continuing without enrichment if they can't be read
+	geo, err := geoip.NewReader(r.res.Config)
+	if err != nil {
+		r.res.Log.WithField("Module", "host").Error(err)
+		fmt.Println("\t[!] Could not open the GeoIP databases, external hosts will not be enriched")
+	}
+	defer geo.Close()
+
 	//Create the workers
 	writerWorker := newWriter(r.res.Config.T.Structure.HostTable, r.res.DB, r.res.Config, r.res.Log)
 
@@ -57,6 +67,7 @@ func (r *repo) Upsert(hostMap map[string]*Input) {
 		r.res.Config,
 		r.res.DB,
 		r.res.Log,
+		geo,
 		writerWorker.collect,
 		writerWorker.close,
 	)
@@ -25,6 +25,8 @@ type Input struct {
 	CountDst              int
 	ConnectionCount       int64
 	TotalBytes            int64
+	BytesSent             int64
+	BytesReceived         int64
 	MaxDuration           float64
 	TotalDuration         float64
 	DNSQueryCount         map[string]int64
@@ -35,6 +37,13 @@ type Input struct {
 	IP4Bin                int64
 }
 
+//DestinationResult represents an external host and the number of unique
+//connections it received
+type DestinationResult struct {
+	data.UniqueIP     `bson:",inline"`
+	UniqueConnections int64 `bson:"uconn_count"`
+}
+
 // explodedDNS is structure for host exploded dns results
 type explodedDNS struct {
 	Query string `bson:"query"`
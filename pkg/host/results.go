@@ -0,0 +1,59 @@
+package host
+
+import (
+	"github.com/activecm/rita/pkg/geoip"
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//GeoResults returns the ASN and country recorded for each of the given
+//external IPs, keyed by IP. IPs without GeoIP details are left out.
+func GeoResults(res *resources.Resources, ips []string) (map[string]geoip.Info, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var hostResults []struct {
+		IP  string     `bson:"ip"`
+		Geo geoip.Info `bson:"geo"`
+	}
+
+	err := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.Structure.HostTable).
+		Find(bson.M{
+			"ip":    bson.M{"$in": ips},
+			"local": false,
+			"geo":   bson.M{"$exists": true},
+		}).
+		Select(bson.M{"ip": 1, "geo": 1}).
+		All(&hostResults)
+
+	geo := make(map[string]geoip.Info, len(hostResults))
+	for _, result := range hostResults {
+		geo[result.IP] = result.Geo
+	}
+	return geo, err
+}
+
+//DestinationResults returns every external host which received connections
+//in the dataset along with the number of unique connections it received
+func DestinationResults(res *resources.Resources) ([]DestinationResult, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var destinationResults []DestinationResult
+
+	destinationQuery := []bson.M{
+		{"$match": bson.M{"local": false}},
+		{"$project": bson.M{
+			"_id":          0,
+			"ip":           1,
+			"network_uuid": 1,
+			"network_name": 1,
+			"uconn_count":  bson.M{"$sum": "$dat.count_dst"},
+		}},
+		{"$match": bson.M{"uconn_count": bson.M{"$gt": 0}}},
+	}
+
+	err := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.Structure.HostTable).Pipe(destinationQuery).AllowDiskUse().All(&destinationResults)
+
+	return destinationResults, err
+}
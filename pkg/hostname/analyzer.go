@@ -7,6 +7,7 @@ import (
 
 	"github.com/activecm/rita/config"
 	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/dga"
 	"github.com/globalsign/mgo/bson"
 )
 
@@ -78,32 +79,29 @@ func (a *analyzer) start() {
 			var output update
 
 			// create query
+			set := bson.M{"cid": a.chunk}
+
+			// flag as blacklisted if blacklisted
 			if blacklistFlag {
-				// flag as blacklisted if blacklisted
-				output.query = bson.M{
-					"$push": bson.M{
-						"dat": bson.M{
-							"ips":     data.ResolvedIPs,
-							"src_ips": data.ClientIPs,
-							"cid":     a.chunk,
-						},
-					},
-					"$set": bson.M{
-						"blacklisted": true,
-						"cid":         a.chunk,
-					},
-				}
-			} else {
-				output.query = bson.M{
-					"$push": bson.M{
-						"dat": bson.M{
-							"ips":     data.ResolvedIPs,
-							"src_ips": data.ClientIPs,
-							"cid":     a.chunk,
-						},
+				set["blacklisted"] = true
+			}
+
+			// score the hostname for signs of a domain generation algorithm
+			if a.conf.S.DGA.Enabled {
+				set["dga_score"] = dga.Score(data.Host)
+			}
+
+			output.query = bson.M{
+				"$push": bson.M{
+					"dat": bson.M{
+						"ips":            data.ResolvedIPs,
+						"src_ips":        data.ClientIPs,
+						"query_count":    data.QueryCount,
+						"nxdomain_count": data.NXDomains,
+						"cid":            a.chunk,
 					},
-					"$set": bson.M{"cid": a.chunk},
-				}
+				},
+				"$set": set,
 			}
 
 			// create selector for output
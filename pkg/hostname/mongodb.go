@@ -4,9 +4,9 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/activecm/rita/database"
 	"github.com/activecm/rita/resources"
 	"github.com/activecm/rita/util"
-	"github.com/globalsign/mgo"
 	"github.com/vbauerster/mpb"
 	"github.com/vbauerster/mpb/decor"
 )
@@ -40,9 +40,10 @@ func (r *repo) CreateIndexes() error {
 	}
 
 	// set desired indexes
-	indexes := []mgo.Index{
+	indexes := []database.Index{
 		{Key: []string{"host"}, Unique: true},
 		{Key: []string{"dat.ips.ip", "dat.ips.network_uuid"}},
+		{Key: []string{"-dga_score"}},
 	}
 
 	// create collection
@@ -0,0 +1,59 @@
+package hostname
+
+import (
+	"net"
+
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/pkg/data"
+)
+
+//parser gathers the clients and resolved IPs of each hostname in a batch of
+//dns records
+type parser struct {
+	hostnameMap map[string]*Input
+}
+
+//NewParser creates a parser which gathers the clients and resolved IPs of
+//each hostname
+func NewParser() analysis.Parser {
+	return &parser{hostnameMap: make(map[string]*Input)}
+}
+
+//Parse records the client of a query and the IPs it resolved to
+func (p *parser) Parse(record *analysis.Record) {
+	if record.Filtered {
+		return
+	}
+
+	dns := record.DNS
+	domain := record.FQDN
+
+	// initialize the hostname input objects for new hostnames
+	if _, ok := p.hostnameMap[domain]; !ok {
+		p.hostnameMap[domain] = &Input{
+			Host: domain,
+		}
+	}
+
+	p.hostnameMap[domain].ClientIPs.Insert(record.Src)
+	p.hostnameMap[domain].QueryCount++
+	if dns.RCodeName == "NXDOMAIN" {
+		p.hostnameMap[domain].NXDomains++
+	}
+
+	if dns.QTypeName == "A" {
+		for _, answer := range dns.Answers {
+			answerIP := net.ParseIP(answer)
+			// Check if answer is an IP address and store it if it is
+			if answerIP != nil {
+				answerUniqIP := data.NewUniqueIP(answerIP, dns.AgentUUID, dns.AgentHostname)
+				p.hostnameMap[domain].ResolvedIPs.Insert(answerUniqIP)
+			}
+		}
+	}
+}
+
+//Flush returns the hostnames gathered from the batch
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	return p.hostnameMap
+}
@@ -23,6 +23,19 @@ type (
 		Host        string           //A hostname
 		ResolvedIPs data.UniqueIPSet //Set of resolved UniqueIPs associated with a given hostname
 		ClientIPs   data.UniqueIPSet //Set of DNS Client UniqueIPs which issued queries for a given hostname
+		QueryCount  int64            //Number of DNS queries issued for a given hostname
+		NXDomains   int64            //Number of DNS queries for a given hostname answered with NXDOMAIN
+	}
+
+	//DGAResult represents a hostname which looks algorithmically generated,
+	//the clients which queried it, and how often the queries failed with NXDOMAIN
+	DGAResult struct {
+		Host          string          `bson:"host"`
+		Score         float64         `bson:"dga_score"`
+		Clients       []data.UniqueIP `bson:"clients"`
+		QueryCount    int64           `bson:"query_count"`
+		NXDomainCount int64           `bson:"nxdomain_count"`
+		NXDomainRatio float64         `bson:"nxdomain_ratio"`
 	}
 
 	//FqdnInput ....
@@ -35,6 +48,5 @@ type (
 		TotalBytes      int64
 		TsList          []int64
 		OrigBytesList   []int64
-		DstBSONList     []bson.M // set of resolved UniqueDstIPs since we need it in that format
 	}
 )
@@ -0,0 +1,255 @@
+package hostname
+
+import (
+	"sort"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//DGAResults finds hostnames with a DGA score at or above the configured threshold,
+//along with the clients which queried them and their NXDOMAIN ratio.
+//limit and noLimit control how many results are returned.
+func DGAResults(res *resources.Resources, limit int, noLimit bool) ([]DGAResult, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var dgaResults []DGAResult
+
+	dgaQuery := []bson.M{
+		{"$match": bson.M{"dga_score": bson.M{"$gte": res.Config.S.DGA.ScoreThreshold}}},
+		{"$project": bson.M{
+			"_id":       0,
+			"host":      1,
+			"dga_score": 1,
+			// merge the clients across chunks
+			"clients": bson.M{
+				"$reduce": bson.M{
+					"input":        "$dat.src_ips",
+					"initialValue": []interface{}{},
+					"in":           bson.M{"$setUnion": []interface{}{"$$value", "$$this"}},
+				},
+			},
+			"query_count":    bson.M{"$sum": "$dat.query_count"},
+			"nxdomain_count": bson.M{"$sum": "$dat.nxdomain_count"},
+		}},
+		{"$addFields": bson.M{
+			"nxdomain_ratio": bson.M{"$cond": bson.M{
+				"if":   bson.M{"$gt": []interface{}{"$query_count", 0}},
+				"then": bson.M{"$divide": []interface{}{"$nxdomain_count", "$query_count"}},
+				"else": 0,
+			}},
+		}},
+		{"$sort": bson.M{"dga_score": -1}},
+	}
+
+	if !noLimit {
+		dgaQuery = append(dgaQuery, bson.M{"$limit": limit})
+	}
+
+	err := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.DNS.HostnamesTable).Pipe(dgaQuery).AllowDiskUse().All(&dgaResults)
+
+	return dgaResults, err
+}
+
+//Resolutions records the hostnames which resolved to a set of IPs along
+//with the clients which queried each hostname
+type Resolutions struct {
+	hosts map[string][]resolvedHost // keyed by the MapKey of the resolved IP
+}
+
+//resolvedHost is a hostname and the MapKeys of the clients which queried it
+type resolvedHost struct {
+	host    string
+	clients map[string]bool
+}
+
+//ResolvedHostnames loads the hostnames which resolved to any of the given IPs
+//during the dataset window
+func ResolvedHostnames(res *resources.Resources, ips []string) (*Resolutions, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	wanted := make(map[string]bool)
+	var ipStrs []string
+	for _, ip := range ips {
+		if !wanted[ip] {
+			wanted[ip] = true
+			ipStrs = append(ipStrs, ip)
+		}
+	}
+
+	resolutions := &Resolutions{hosts: make(map[string][]resolvedHost)}
+	if len(ipStrs) == 0 {
+		return resolutions, nil
+	}
+
+	resolvedQuery := []bson.M{
+		{"$match": bson.M{"dat.ips.ip": bson.M{"$in": ipStrs}}},
+		{"$project": bson.M{
+			"_id":  0,
+			"host": 1,
+			// merge the resolved ips and clients across chunks
+			"ips": bson.M{
+				"$reduce": bson.M{
+					"input":        "$dat.ips",
+					"initialValue": []interface{}{},
+					"in":           bson.M{"$setUnion": []interface{}{"$$value", "$$this"}},
+				},
+			},
+			"clients": bson.M{
+				"$reduce": bson.M{
+					"input":        "$dat.src_ips",
+					"initialValue": []interface{}{},
+					"in":           bson.M{"$setUnion": []interface{}{"$$value", "$$this"}},
+				},
+			},
+		}},
+	}
+
+	var hostnameResults []struct {
+		Host    string          `bson:"host"`
+		IPs     []data.UniqueIP `bson:"ips"`
+		Clients []data.UniqueIP `bson:"clients"`
+	}
+
+	err := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.DNS.HostnamesTable).Pipe(resolvedQuery).AllowDiskUse().All(&hostnameResults)
+	if err != nil {
+		return resolutions, err
+	}
+
+	for _, result := range hostnameResults {
+		resolved := resolvedHost{host: result.Host, clients: make(map[string]bool)}
+		for _, client := range result.Clients {
+			resolved.clients[client.MapKey()] = true
+		}
+
+		// the same ip may be listed under several network names
+		added := make(map[string]bool)
+		for _, ip := range result.IPs {
+			key := ip.MapKey()
+			if wanted[ip.IP] && !added[key] {
+				added[key] = true
+				resolutions.hosts[key] = append(resolutions.hosts[key], resolved)
+			}
+		}
+	}
+
+	return resolutions, nil
+}
+
+//Hostnames returns the sorted hostnames which resolved to dst. If src queried
+//any of them, only the hostnames src queried are returned.
+func (r *Resolutions) Hostnames(src data.UniqueIP, dst data.UniqueIP) []string {
+	if r == nil {
+		return nil
+	}
+
+	var queried, all []string
+	for _, resolved := range r.hosts[dst.MapKey()] {
+		all = append(all, resolved.host)
+		if resolved.clients[src.MapKey()] {
+			queried = append(queried, resolved.host)
+		}
+	}
+
+	if len(queried) > 0 {
+		all = queried
+	}
+	sort.Strings(all)
+	return all
+}
+
+//ResolvedIPs returns the distinct IP addresses the given hostnames resolved
+//to during the dataset window
+func ResolvedIPs(res *resources.Resources, hosts []string) ([]string, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var ips []string
+	if len(hosts) == 0 {
+		return ips, nil
+	}
+
+	resolvedQuery := []bson.M{
+		{"$match": bson.M{"host": bson.M{"$in": hosts}}},
+		{"$unwind": "$dat"},
+		{"$unwind": "$dat.ips"},
+		{"$group": bson.M{"_id": "$dat.ips.ip"}},
+	}
+
+	var ipResults []struct {
+		IP string `bson:"_id"`
+	}
+
+	err := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.DNS.HostnamesTable).Pipe(resolvedQuery).AllowDiskUse().All(&ipResults)
+	if err != nil {
+		return ips, err
+	}
+
+	for _, result := range ipResults {
+		ips = append(ips, result.IP)
+	}
+	return ips, nil
+}
+
+//StoredInputs loads every hostname in the dataset along with the IPs it
+//resolved to and the clients which queried it so the modules built from them
+//can be re-analyzed
+func StoredInputs(res *resources.Resources) (map[string]*Input, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	// merges the sets stored in each chunk into a single set
+	mergeSets := func(field string) bson.M {
+		return bson.M{
+			"$reduce": bson.M{
+				"input":        field,
+				"initialValue": []interface{}{},
+				"in":           bson.M{"$setUnion": []interface{}{"$$value", "$$this"}},
+			},
+		}
+	}
+
+	storedQuery := []bson.M{
+		{"$project": bson.M{
+			"_id":            0,
+			"host":           1,
+			"ips":            mergeSets("$dat.ips"),
+			"clients":        mergeSets("$dat.src_ips"),
+			"query_count":    bson.M{"$sum": "$dat.query_count"},
+			"nxdomain_count": bson.M{"$sum": "$dat.nxdomain_count"},
+		}},
+	}
+
+	var stored struct {
+		Host          string          `bson:"host"`
+		IPs           []data.UniqueIP `bson:"ips"`
+		Clients       []data.UniqueIP `bson:"clients"`
+		QueryCount    int64           `bson:"query_count"`
+		NXDomainCount int64           `bson:"nxdomain_count"`
+	}
+
+	hostnameMap := make(map[string]*Input)
+	iter := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.DNS.HostnamesTable).Pipe(storedQuery).AllowDiskUse().Iter()
+	for iter.Next(&stored) {
+		entry := &Input{
+			Host:       stored.Host,
+			QueryCount: stored.QueryCount,
+			NXDomains:  stored.NXDomainCount,
+		}
+		for _, ip := range stored.IPs {
+			entry.ResolvedIPs.Insert(ip)
+		}
+		for _, client := range stored.Clients {
+			entry.ClientIPs.Insert(client)
+		}
+		hostnameMap[stored.Host] = entry
+
+		// clear the sets so they aren't appended to by the next document
+		stored.IPs, stored.Clients = nil, nil
+	}
+
+	return hostnameMap, iter.Close()
+}
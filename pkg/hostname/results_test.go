@@ -0,0 +1,36 @@
+package hostname
+
+import (
+	"net"
+	"testing"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolutionsHostnames(t *testing.T) {
+	client := data.NewUniqueIP(net.ParseIP("10.0.0.1"), "", "")
+	other := data.NewUniqueIP(net.ParseIP("10.0.0.2"), "", "")
+	dst := data.NewUniqueIP(net.ParseIP("93.184.216.34"), "", "")
+	unknown := data.NewUniqueIP(net.ParseIP("198.51.100.1"), "", "")
+
+	r := &Resolutions{hosts: map[string][]resolvedHost{
+		dst.MapKey(): {
+			{host: "www.example.com", clients: map[string]bool{client.MapKey(): true}},
+			{host: "cdn.example.net", clients: map[string]bool{other.MapKey(): true}},
+			{host: "example.com", clients: map[string]bool{client.MapKey(): true, other.MapKey(): true}},
+		},
+	}}
+
+	// only the names the source queried are attributed to it
+	require.Equal(t, []string{"example.com", "www.example.com"}, r.Hostnames(client, dst))
+
+	// fall back to every name which resolved to the destination
+	third := data.NewUniqueIP(net.ParseIP("10.0.0.3"), "", "")
+	require.Equal(t, []string{"cdn.example.net", "example.com", "www.example.com"}, r.Hostnames(third, dst))
+
+	require.Empty(t, r.Hostnames(client, unknown))
+
+	var none *Resolutions
+	require.Nil(t, none.Hostnames(client, dst))
+}
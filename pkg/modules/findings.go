@@ -0,0 +1,388 @@
+package modules
+
+import (
+	"fmt"
+
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/pkg/beacon"
+	"github.com/activecm/rita/pkg/beaconfqdn"
+	"github.com/activecm/rita/pkg/beaconproxy"
+	"github.com/activecm/rita/pkg/beaconsni"
+	"github.com/activecm/rita/pkg/blacklist"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/dnsfailure"
+	"github.com/activecm/rita/pkg/dnstunnel"
+	"github.com/activecm/rita/pkg/domainfronting"
+	"github.com/activecm/rita/pkg/exfil"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/activecm/rita/pkg/prevalence"
+	"github.com/activecm/rita/pkg/resolverbypass"
+	"github.com/activecm/rita/pkg/scan"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/pkg/uconn"
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//longConnThresh is the minimum connection duration (in seconds) reported as
+//a long connection finding. longConnCeiling is the duration at which a long
+//connection finding receives the maximum score.
+const (
+	longConnThresh  = 3600
+	longConnCeiling = 86400
+)
+
+//the kinds of findings scored by the threat module. Each WeightKey is the
+//finding's key under Threat.Weights in the config.
+var (
+	beaconFinding          = analysis.FindingType{Name: "beacon", WeightKey: "Beacon", Weight: 1.0}
+	beaconFQDNFinding      = analysis.FindingType{Name: "beacon_fqdn", WeightKey: "BeaconFQDN", Weight: 0.9}
+	beaconProxyFinding     = analysis.FindingType{Name: "beacon_proxy", WeightKey: "BeaconProxy", Weight: 0.9}
+	beaconSNIFinding       = analysis.FindingType{Name: "beacon_sni", WeightKey: "BeaconSNI", Weight: 0.9}
+	strobeFinding          = analysis.FindingType{Name: "strobe", WeightKey: "Strobe", Weight: 0.5}
+	longConnectionFinding  = analysis.FindingType{Name: "long_connection", WeightKey: "LongConnection", Weight: 0.5}
+	blacklistedFinding     = analysis.FindingType{Name: "blacklisted", WeightKey: "Blacklisted", Weight: 1.0}
+	invalidCertFinding     = analysis.FindingType{Name: "invalid_cert", WeightKey: "InvalidCertificate", Weight: 0.4}
+	rareSignatureFinding   = analysis.FindingType{Name: "rare_signature", WeightKey: "RareSignature", Weight: 0.3}
+	dnsTunnelFinding       = analysis.FindingType{Name: "dns_tunnel", WeightKey: "DNSTunnel", Weight: 0.9}
+	dnsFailureFinding      = analysis.FindingType{Name: "dns_failure", WeightKey: "DNSFailure", Weight: 0.5}
+	resolverBypassFinding  = analysis.FindingType{Name: "resolver_bypass", WeightKey: "ResolverBypass", Weight: 0.6}
+	domainFrontingFinding  = analysis.FindingType{Name: "domain_fronting", WeightKey: "DomainFronting", Weight: 0.8}
+	dgaFinding             = analysis.FindingType{Name: "dga", WeightKey: "DGA", Weight: 0.6}
+	scanFinding            = analysis.FindingType{Name: "scan", WeightKey: "Scan", Weight: 0.7}
+	exfilFinding           = analysis.FindingType{Name: "exfil", WeightKey: "Exfil", Weight: 0.8}
+	rareDestinationFinding = analysis.FindingType{Name: "rare_destination", WeightKey: "RareDestination", Weight: 0.3}
+)
+
+func beaconFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	beacons, err := beacon.Results(res, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []analysis.Finding
+	for _, b := range beacons {
+		findings = append(findings, analysis.Finding{
+			Host: b.UniqueSrcIP.Unpair(), Type: beaconFinding.Name, Score: b.Score, Detail: b.DstIP,
+			Match: suppression.Finding{Src: b.SrcIP, Dst: b.DstIP},
+		})
+	}
+
+	strobes, err := beacon.StrobeResults(res, -1, 0, true)
+	if err != nil {
+		return findings, err
+	}
+	for _, s := range strobes {
+		findings = append(findings, analysis.Finding{
+			Host: s.UniqueSrcIP.Unpair(), Type: strobeFinding.Name, Score: 1,
+			Detail: fmt.Sprintf("%s (%d connections)", s.DstIP, s.ConnectionCount),
+			Match:  suppression.Finding{Src: s.SrcIP, Dst: s.DstIP},
+		})
+	}
+
+	// the beacon analyzer flags the hosts which were sent invalid certificates
+	var hosts []struct {
+		data.UniqueIP `bson:",inline"`
+		Dat           []struct {
+			ICert int           `bson:"icert"`
+			ICDst data.UniqueIP `bson:"icdst"`
+		} `bson:"dat"`
+	}
+	err = hostFlags(res, "icert", &hosts, "dat.icdst")
+
+	for _, host := range hosts {
+		for _, entry := range host.Dat {
+			if entry.ICert == 1 {
+				findings = append(findings, analysis.Finding{
+					Host: host.UniqueIP, Type: invalidCertFinding.Name, Score: 1, Detail: entry.ICDst.IP,
+					Match: suppression.Finding{Src: host.IP, Dst: entry.ICDst.IP},
+				})
+			}
+		}
+	}
+	return findings, err
+}
+
+func fqdnBeaconFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	beacons, err := beaconfqdn.Results(res, 0)
+
+	var findings []analysis.Finding
+	for _, b := range beacons {
+		src := data.UniqueIP{IP: b.SrcIP, NetworkUUID: b.SrcNetworkUUID, NetworkName: b.SrcNetworkName}
+		findings = append(findings, analysis.Finding{
+			Host: src, Type: beaconFQDNFinding.Name, Score: b.Score, Detail: b.FQDN,
+			Match: suppression.Finding{Src: b.SrcIP, FQDN: b.FQDN},
+		})
+	}
+	return findings, err
+}
+
+func proxyBeaconFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	beacons, err := beaconproxy.Results(res, 0)
+
+	var findings []analysis.Finding
+	for _, b := range beacons {
+		src := data.UniqueIP{IP: b.SrcIP, NetworkUUID: b.SrcNetworkUUID, NetworkName: b.SrcNetworkName}
+		findings = append(findings, analysis.Finding{
+			Host: src, Type: beaconProxyFinding.Name, Score: b.Score,
+			Detail: fmt.Sprintf("%s via %s", b.FQDN, b.DstIP),
+			Match:  suppression.Finding{Src: b.SrcIP, Dst: b.DstIP, FQDN: b.FQDN},
+		})
+	}
+	return findings, err
+}
+
+func sniBeaconFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	beacons, err := beaconsni.Results(res, 0)
+
+	var findings []analysis.Finding
+	for _, b := range beacons {
+		findings = append(findings, analysis.Finding{
+			Host: b.UniqueSrcIP.Unpair(), Type: beaconSNIFinding.Name, Score: b.Score, Detail: b.SNI,
+			Match: suppression.Finding{Src: b.SrcIP, FQDN: b.SNI, JA3: b.JA3},
+		})
+	}
+	return findings, err
+}
+
+func longConnectionFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	conns, err := uconn.LongConnResults(res, longConnThresh, 0, true)
+
+	var findings []analysis.Finding
+	for _, l := range conns {
+		findings = append(findings, analysis.Finding{
+			Host: l.UniqueSrcIP.Unpair(), Type: longConnectionFinding.Name, Score: l.MaxDuration / longConnCeiling,
+			Detail: fmt.Sprintf("%s (%.0fs)", l.DstIP, l.MaxDuration),
+			Match:  suppression.Finding{Src: l.SrcIP, Dst: l.DstIP},
+		})
+	}
+	return findings, err
+}
+
+//rareSignatureFindings gathers the rare signature flags recorded against
+//each host by the useragent module
+func rareSignatureFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	var hosts []struct {
+		data.UniqueIP `bson:",inline"`
+		Dat           []struct {
+			RSigC int    `bson:"rsigc"`
+			RSig  string `bson:"rsig"`
+		} `bson:"dat"`
+	}
+	err := hostFlags(res, "rsigc", &hosts, "dat.rsig")
+
+	var findings []analysis.Finding
+	for _, host := range hosts {
+		for _, entry := range host.Dat {
+			if entry.RSigC == 1 {
+				// the host doesn't record whether the signature is a JA3 hash
+				findings = append(findings, analysis.Finding{
+					Host: host.UniqueIP, Type: rareSignatureFinding.Name, Score: 1, Detail: entry.RSig,
+					Match: suppression.Finding{Src: host.IP, UserAgent: entry.RSig, JA3: entry.RSig},
+				})
+			}
+		}
+	}
+	return findings, err
+}
+
+//hostFlags reads the hosts with the given flag set in one of their chunks,
+//selecting the flag along with the given fields
+func hostFlags(res *resources.Resources, flag string, hosts interface{}, fields ...string) error {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	selector := bson.M{"ip": 1, "network_uuid": 1, "network_name": 1, "dat." + flag: 1}
+	for _, field := range fields {
+		selector[field] = 1
+	}
+
+	return ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.Structure.HostTable).
+		Find(bson.M{"dat." + flag: 1}).Select(selector).All(hosts)
+}
+
+func blacklistFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	var findings []analysis.Finding
+	var firstErr error
+	keep := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	dstIPs, err := blacklist.DstIPResults(res, "conn_count", 0, true)
+	keep(err)
+	for _, bl := range dstIPs {
+		for _, peer := range bl.Peers {
+			findings = append(findings, analysis.Finding{
+				Host: peer, Type: blacklistedFinding.Name, Score: 1, Detail: "connected to " + bl.Host.IP,
+				Match: suppression.Finding{Src: peer.IP, Dst: bl.Host.IP},
+			})
+		}
+	}
+
+	srcIPs, err := blacklist.SrcIPResults(res, "conn_count", 0, true)
+	keep(err)
+	for _, bl := range srcIPs {
+		for _, peer := range bl.Peers {
+			findings = append(findings, analysis.Finding{
+				Host: peer, Type: blacklistedFinding.Name, Score: 1, Detail: "contacted by " + bl.Host.IP,
+				Match: suppression.Finding{Src: bl.Host.IP, Dst: peer.IP},
+			})
+		}
+	}
+
+	hostnames, err := blacklist.HostnameResults(res, "conn_count", 0, true)
+	keep(err)
+	for _, bl := range hostnames {
+		for _, src := range bl.ConnectedHosts {
+			findings = append(findings, analysis.Finding{
+				Host: src, Type: blacklistedFinding.Name, Score: 1, Detail: "connected to " + bl.Host,
+				Match: suppression.Finding{Src: src.IP, FQDN: bl.Host},
+			})
+		}
+	}
+
+	return findings, firstErr
+}
+
+func dnsTunnelFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	tunnels, err := dnstunnel.Results(res, 0, true)
+
+	var findings []analysis.Finding
+	for _, t := range tunnels {
+		findings = append(findings, analysis.Finding{
+			Host: t.UniqueSrcIP.Unpair(), Type: dnsTunnelFinding.Name, Score: t.Score, Detail: t.Domain,
+			Match: suppression.Finding{Src: t.SrcIP, FQDN: t.Domain},
+		})
+	}
+	return findings, err
+}
+
+func dnsFailureFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	failures, err := dnsfailure.Results(res, 0, true)
+
+	var findings []analysis.Finding
+	for _, d := range failures {
+		if d.Type != dnsfailure.Client {
+			continue
+		}
+		findings = append(findings, analysis.Finding{
+			Host: d.Client, Type: dnsFailureFinding.Name, Score: d.Score,
+			Detail: fmt.Sprintf("%d domains failed to resolve", d.FailedDomainCount),
+			Match:  suppression.Finding{Src: d.Client.IP, FQDN: d.Domain},
+		})
+	}
+	return findings, err
+}
+
+func resolverBypassFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	bypasses, err := resolverbypass.Results(res, 0, true)
+
+	var findings []analysis.Finding
+	for _, b := range bypasses {
+		findings = append(findings, analysis.Finding{
+			Host: b.UniqueSrcIP.Unpair(), Type: resolverBypassFinding.Name, Score: 1,
+			Detail: fmt.Sprintf("%s to %s (%d connections)", b.Method, b.DstIP, b.ConnectionCount),
+			Match:  suppression.Finding{Src: b.SrcIP, Dst: b.DstIP},
+		})
+	}
+	return findings, err
+}
+
+func domainFrontingFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	fronts, err := domainfronting.Results(res, 0, true)
+
+	var findings []analysis.Finding
+	for _, f := range fronts {
+		finding := analysis.Finding{
+			Host:  f.UniqueSrcIP.Unpair(),
+			Type:  domainFrontingFinding.Name,
+			Match: suppression.Finding{Src: f.SrcIP, Dst: f.DstIP, FQDN: f.SNI},
+		}
+
+		// a server name disagreeing with the Host header is a much stronger
+		// signal than a missing server name
+		if f.Type == domainfronting.Mismatch {
+			finding.Score = 1
+			finding.Detail = fmt.Sprintf("%s fronted by %s via %s", f.HTTPHost, f.SNI, f.DstIP)
+		} else {
+			finding.Score = 0.5
+			finding.Detail = fmt.Sprintf("no SNI to %s serving %d names", f.DstIP, f.ServedSNICount)
+		}
+		findings = append(findings, finding)
+	}
+	return findings, err
+}
+
+func dgaFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	hostnames, err := hostname.DGAResults(res, 0, true)
+
+	var findings []analysis.Finding
+	for _, h := range hostnames {
+		for _, client := range h.Clients {
+			findings = append(findings, analysis.Finding{
+				Host: client, Type: dgaFinding.Name, Score: h.Score, Detail: h.Host,
+				Match: suppression.Finding{Src: client.IP, FQDN: h.Host},
+			})
+		}
+	}
+	return findings, err
+}
+
+func scanFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	scans, err := scan.Results(res, 0, true)
+
+	var findings []analysis.Finding
+	for _, s := range scans {
+		detail := fmt.Sprintf("%d ports on %s", s.TargetCount, s.DstIP)
+		if s.Type == scan.Horizontal {
+			detail = fmt.Sprintf("%d hosts on %d/%s", s.TargetCount, s.Port, s.Proto)
+		}
+		findings = append(findings, analysis.Finding{
+			Host: s.UniqueSrcIP.Unpair(), Type: scanFinding.Name, Score: s.Score, Detail: detail,
+			Match: suppression.Finding{Src: s.SrcIP, Dst: s.DstIP},
+		})
+	}
+	return findings, err
+}
+
+func exfilFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	results, err := exfil.Results(res, 0, true)
+
+	var findings []analysis.Finding
+	for _, e := range results {
+		detail := fmt.Sprintf("%d bytes sent to %s", e.BytesSent, e.Remote.IP)
+		if e.Type == exfil.Host {
+			detail = fmt.Sprintf("%d bytes sent in total", e.BytesSent)
+		}
+		findings = append(findings, analysis.Finding{
+			Host: e.Local, Type: exfilFinding.Name, Score: e.Score, Detail: detail,
+			Match: suppression.Finding{Src: e.Local.IP, Dst: e.Remote.IP},
+		})
+	}
+	return findings, err
+}
+
+func rareDestinationFindings(res *resources.Resources) ([]analysis.Finding, error) {
+	destinations, err := prevalence.RareResults(res, 0, true)
+	// nothing is new until there's an earlier chunk to compare against
+	if err == prevalence.ErrNoPreviousChunk {
+		return nil, nil
+	}
+
+	var findings []analysis.Finding
+	for _, d := range destinations {
+		detail := d.IP
+		if d.Type == prevalence.FQDN {
+			detail = d.FQDN
+		}
+		for _, client := range d.Clients {
+			findings = append(findings, analysis.Finding{
+				Host: client, Type: rareDestinationFinding.Name, Score: 1, Detail: detail,
+				Match: suppression.Finding{Src: client.IP, Dst: d.IP, FQDN: d.FQDN},
+			})
+		}
+	}
+	return findings, err
+}
@@ -0,0 +1,434 @@
+package modules
+
+import (
+	"fmt"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/pkg/baseline"
+	"github.com/activecm/rita/pkg/beacon"
+	"github.com/activecm/rita/pkg/beaconfqdn"
+	"github.com/activecm/rita/pkg/beaconproxy"
+	"github.com/activecm/rita/pkg/beaconsni"
+	"github.com/activecm/rita/pkg/blacklist"
+	"github.com/activecm/rita/pkg/certificate"
+	"github.com/activecm/rita/pkg/dnsfailure"
+	"github.com/activecm/rita/pkg/dnstunnel"
+	"github.com/activecm/rita/pkg/domainfronting"
+	"github.com/activecm/rita/pkg/exfil"
+	"github.com/activecm/rita/pkg/explodeddns"
+	"github.com/activecm/rita/pkg/host"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/activecm/rita/pkg/prevalence"
+	"github.com/activecm/rita/pkg/resolverbypass"
+	"github.com/activecm/rita/pkg/scan"
+	"github.com/activecm/rita/pkg/threat"
+	"github.com/activecm/rita/pkg/uconn"
+	"github.com/activecm/rita/pkg/useragent"
+	"github.com/activecm/rita/reporting"
+	"github.com/activecm/rita/resources"
+)
+
+// noLocalTrafficHint is printed when a batch holds no hosts or uconns
+const noLocalTrafficHint = "\t\t[!!] No local network traffic found, please check " +
+	"InternalSubnets in your RITA config (/etc/rita/config.yaml)"
+
+// init registers the analysis modules run by the importer. New detectors
+// are added here: Parse hands the detector the records it reads, Scored and
+// Collect feed its findings to the threat module, and Show adds its show
+// command.
+func init() {
+	analysis.Register(&analysis.Definition{
+		ModuleName: "host",
+		LogInputs:  []analysis.LogType{analysis.ConnLog, analysis.DNSLog, analysis.HTTPLog, analysis.SSLLog},
+		Tables:     func(conf *config.Config) []string { return []string{conf.T.Structure.HostTable} },
+		Removal:    analysis.RemoveChunk,
+		Indexes:    func(res *resources.Resources) error { return host.NewMongoRepository(res).CreateIndexes() },
+		HasData:    func(batch *analysis.Batch) bool { return len(batch.Hosts) > 0 },
+		Label:      "Host",
+		Hint:       noLocalTrafficHint,
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			host.NewMongoRepository(res).Upsert(batch.Hosts)
+		},
+	})
+
+	// uconns must be built before the beacon modules, which also need the
+	// dataset's timestamp range
+	analysis.Register(&analysis.Definition{
+		ModuleName:   "uconn",
+		LogInputs:    []analysis.LogType{analysis.ConnLog, analysis.SSLLog},
+		Dependencies: []string{"host"},
+		Tables:       func(conf *config.Config) []string { return []string{conf.T.Structure.UniqueConnTable} },
+		Removal:      analysis.RemoveChunk,
+		Scored:       []analysis.FindingType{longConnectionFinding},
+		Collect:      longConnectionFindings,
+		Indexes:      func(res *resources.Resources) error { return uconn.NewMongoRepository(res).CreateIndexes() },
+		HasData:      func(batch *analysis.Batch) bool { return len(batch.Uconns) > 0 },
+		Label:        "Uconn",
+		Hint:         noLocalTrafficHint,
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			uconn.NewMongoRepository(res).Upsert(batch.Uconns)
+			uconn.UpdateTimestampRange(res)
+		},
+		Report: []analysis.Page{{Name: "long connections", Render: reporting.LongConnsPage}},
+		Load: func(res *resources.Resources, batch *analysis.Batch) (err error) {
+			batch.Uconns, err = uconn.StoredInputs(res)
+			return err
+		},
+	})
+
+	// exploded DNS must be built before hostnames
+	analysis.Register(&analysis.Definition{
+		ModuleName: "explodeddns",
+		LogInputs:  []analysis.LogType{analysis.DNSLog},
+		IsEnabled:  func(conf *config.Config) bool { return conf.S.DNS.Enabled },
+		Tables:     func(conf *config.Config) []string { return []string{conf.T.DNS.ExplodedDNSTable} },
+		Removal:    analysis.RemoveChunk,
+		Parse: func(res *resources.Resources, filter *analysis.Filter) analysis.Parser {
+			return explodeddns.NewParser()
+		},
+		Indexes: func(res *resources.Resources) error { return explodeddns.NewMongoRepository(res).CreateIndexes() },
+		Label:   "DNS",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			explodeddns.NewMongoRepository(res).Upsert(batch.Result("explodeddns").(map[string]*explodeddns.Input))
+		},
+		Report: []analysis.Page{{Name: "DNS", Render: reporting.DNSPage}},
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName:   "hostname",
+		LogInputs:    []analysis.LogType{analysis.DNSLog},
+		Dependencies: []string{"explodeddns"},
+		Tables:       func(conf *config.Config) []string { return []string{conf.T.DNS.HostnamesTable} },
+		Removal:      analysis.RemoveChunk,
+		Scored:       []analysis.FindingType{dgaFinding},
+		Collect:      dgaFindings,
+		Parse:        func(res *resources.Resources, filter *analysis.Filter) analysis.Parser { return hostname.NewParser() },
+		Indexes:      func(res *resources.Resources) error { return hostname.NewMongoRepository(res).CreateIndexes() },
+		Label:        "Hostname",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			hostname.NewMongoRepository(res).Upsert(batch.Result("hostname").(map[string]*hostname.Input))
+		},
+		Load: func(res *resources.Resources, batch *analysis.Batch) error {
+			hostnames, err := hostname.StoredInputs(res)
+			batch.Store("hostname", hostnames)
+			return err
+		},
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName: "dnstunnel",
+		LogInputs:  []analysis.LogType{analysis.DNSLog},
+		IsEnabled:  func(conf *config.Config) bool { return conf.S.DNSTunnel.Enabled },
+		Tables:     func(conf *config.Config) []string { return []string{conf.T.DNS.DNSTunnelTable} },
+		Removal:    analysis.RemoveChunk,
+		Scored:     []analysis.FindingType{dnsTunnelFinding},
+		Collect:    dnsTunnelFindings,
+		Parse:      func(res *resources.Resources, filter *analysis.Filter) analysis.Parser { return dnstunnel.NewParser() },
+		Indexes:    func(res *resources.Resources) error { return dnstunnel.NewMongoRepository(res).CreateIndexes() },
+		Label:      "DNS Tunnel",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			dnstunnel.NewMongoRepository(res).Upsert(batch.Result("dnstunnel").(map[string]*dnstunnel.Input))
+		},
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName: "dnsfailure",
+		LogInputs:  []analysis.LogType{analysis.DNSLog},
+		IsEnabled:  func(conf *config.Config) bool { return conf.S.DNSFailure.Enabled },
+		Tables:     func(conf *config.Config) []string { return []string{conf.T.DNS.DNSFailureTable} },
+		Removal:    analysis.RemoveChunk,
+		Scored:     []analysis.FindingType{dnsFailureFinding},
+		Collect:    dnsFailureFindings,
+		Parse:      func(res *resources.Resources, filter *analysis.Filter) analysis.Parser { return dnsfailure.NewParser() },
+		Indexes:    func(res *resources.Resources) error { return dnsfailure.NewMongoRepository(res).CreateIndexes() },
+		Label:      "DNS Failure",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			dnsfailure.NewMongoRepository(res).Upsert(batch.Result("dnsfailure").(map[string]*dnsfailure.Input))
+		},
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName:   "beacon",
+		LogInputs:    []analysis.LogType{analysis.ConnLog},
+		Dependencies: []string{"uconn"},
+		IsEnabled:    func(conf *config.Config) bool { return conf.S.Beacon.Enabled },
+		Tables:       func(conf *config.Config) []string { return []string{conf.T.Beacon.BeaconTable} },
+		Removal:      analysis.RemoveChunk,
+		Scored:       []analysis.FindingType{beaconFinding, strobeFinding, invalidCertFinding},
+		Collect:      beaconFindings,
+		Indexes:      func(res *resources.Resources) error { return beacon.NewMongoRepository(res).CreateIndexes() },
+		HasData:      func(batch *analysis.Batch) bool { return len(batch.Uconns) > 0 },
+		Label:        "Beacon",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			beacon.NewMongoRepository(res).Upsert(batch.Uconns)
+		},
+		Report: []analysis.Page{
+			{Name: "beacons", Render: reporting.BeaconsPage},
+			{Name: "strobes", Render: reporting.StrobesPage},
+		},
+		Reset: func(res *resources.Resources) error { return beacon.NewMongoRepository(res).Reset() },
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName:   "beaconfqdn",
+		LogInputs:    []analysis.LogType{analysis.ConnLog, analysis.DNSLog},
+		Dependencies: []string{"uconn", "hostname"},
+		IsEnabled:    func(conf *config.Config) bool { return conf.S.BeaconFQDN.Enabled },
+		Tables:       func(conf *config.Config) []string { return []string{conf.T.BeaconFQDN.BeaconFQDNTable} },
+		Removal:      analysis.RemoveChunk,
+		Scored:       []analysis.FindingType{beaconFQDNFinding},
+		Collect:      fqdnBeaconFindings,
+		Indexes:      func(res *resources.Resources) error { return beaconfqdn.NewMongoRepository(res).CreateIndexes() },
+		HasData:      func(batch *analysis.Batch) bool { return batch.Len("hostname") > 0 },
+		Label:        "FQDN Beacon",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			beaconfqdn.NewMongoRepository(res).Upsert(batch.Result("hostname").(map[string]*hostname.Input))
+		},
+		Report: []analysis.Page{{Name: "FQDN beacons", Render: reporting.BeaconsFQDNPage}},
+		Reset:  func(res *resources.Resources) error { return beaconfqdn.NewMongoRepository(res).Reset() },
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName:   "beaconproxy",
+		LogInputs:    []analysis.LogType{analysis.HTTPLog, analysis.SSLLog},
+		Dependencies: []string{"uconn"},
+		IsEnabled:    func(conf *config.Config) bool { return conf.S.BeaconProxy.Enabled },
+		Tables:       func(conf *config.Config) []string { return []string{conf.T.BeaconProxy.BeaconProxyTable} },
+		Removal:      analysis.RemoveChunk,
+		Scored:       []analysis.FindingType{beaconProxyFinding},
+		Collect:      proxyBeaconFindings,
+		Parse: func(res *resources.Resources, filter *analysis.Filter) analysis.Parser {
+			return beaconproxy.NewParser(filter)
+		},
+		Indexes: func(res *resources.Resources) error { return beaconproxy.NewMongoRepository(res).CreateIndexes() },
+		Label:   "Proxy Beacon",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			beaconproxy.NewMongoRepository(res).Upsert(batch.Result("beaconproxy").(map[string]*beaconproxy.Input))
+		},
+		Report: []analysis.Page{{Name: "proxy beacons", Render: reporting.BeaconsProxyPage}},
+		Load: func(res *resources.Resources, batch *analysis.Batch) error {
+			stored, err := beaconproxy.StoredInputs(res)
+			batch.Store("beaconproxy", stored)
+			return err
+		},
+		Reset: func(res *resources.Resources) error { return beaconproxy.NewMongoRepository(res).Reset() },
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName:   "beaconsni",
+		LogInputs:    []analysis.LogType{analysis.SSLLog},
+		Dependencies: []string{"uconn"},
+		IsEnabled:    func(conf *config.Config) bool { return conf.S.BeaconSNI.Enabled },
+		Tables:       func(conf *config.Config) []string { return []string{conf.T.BeaconSNI.BeaconSNITable} },
+		Removal:      analysis.RemoveChunk,
+		Scored:       []analysis.FindingType{beaconSNIFinding},
+		Collect:      sniBeaconFindings,
+		Parse: func(res *resources.Resources, filter *analysis.Filter) analysis.Parser {
+			return beaconsni.NewParser(filter)
+		},
+		Indexes: func(res *resources.Resources) error { return beaconsni.NewMongoRepository(res).CreateIndexes() },
+		Label:   "SNI Beacon",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			beaconsni.NewMongoRepository(res).Upsert(batch.Result("beaconsni").(map[string]*beaconsni.Input))
+		},
+		Report: []analysis.Page{{Name: "SNI beacons", Render: reporting.BeaconsSNIPage}},
+		Load: func(res *resources.Resources, batch *analysis.Batch) error {
+			stored, err := beaconsni.StoredInputs(res)
+			batch.Store("beaconsni", stored)
+			return err
+		},
+		Reset: func(res *resources.Resources) error { return beaconsni.NewMongoRepository(res).Reset() },
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName: "useragent",
+		LogInputs:  []analysis.LogType{analysis.HTTPLog, analysis.SSLLog},
+		IsEnabled:  func(conf *config.Config) bool { return conf.S.UserAgent.Enabled },
+		Tables:     func(conf *config.Config) []string { return []string{conf.T.UserAgent.UserAgentTable} },
+		Removal:    analysis.RemoveChunk,
+		Scored:     []analysis.FindingType{rareSignatureFinding},
+		Collect:    rareSignatureFindings,
+		Parse:      func(res *resources.Resources, filter *analysis.Filter) analysis.Parser { return useragent.NewParser() },
+		Indexes:    func(res *resources.Resources) error { return useragent.NewMongoRepository(res).CreateIndexes() },
+		Label:      "UserAgent",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			useragent.NewMongoRepository(res).Upsert(batch.Result("useragent").(map[string]*useragent.Input))
+		},
+		Report: []analysis.Page{{Name: "user agents", Render: reporting.UserAgentsPage}},
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName: "certificate",
+		LogInputs:  []analysis.LogType{analysis.SSLLog},
+		Tables:     func(conf *config.Config) []string { return []string{conf.T.Cert.CertificateTable} },
+		Removal:    analysis.RemoveChunk,
+		Parse: func(res *resources.Resources, filter *analysis.Filter) analysis.Parser {
+			return certificate.NewParser()
+		},
+		Indexes: func(res *resources.Resources) error { return certificate.NewMongoRepository(res).CreateIndexes() },
+		Label:   "invalid certificate",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			certificate.NewMongoRepository(res).Upsert(batch.Result("certificate").(map[string]*certificate.Input))
+		},
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName: "scan",
+		LogInputs:  []analysis.LogType{analysis.ConnLog},
+		IsEnabled:  func(conf *config.Config) bool { return conf.S.Scan.Enabled },
+		Tables:     func(conf *config.Config) []string { return []string{conf.T.Scan.ScanTable} },
+		Removal:    analysis.RemoveChunk,
+		Scored:     []analysis.FindingType{scanFinding},
+		Collect:    scanFindings,
+		Parse: func(res *resources.Resources, filter *analysis.Filter) analysis.Parser {
+			return scan.NewParser(res.Config, filter)
+		},
+		Indexes: func(res *resources.Resources) error { return scan.NewMongoRepository(res).CreateIndexes() },
+		Label:   "Scan",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			scan.NewMongoRepository(res).Upsert(batch.Result("scan").(map[string]*scan.Input))
+		},
+		Show: &analysis.Table{
+			Command: "show-scans",
+			Usage:   "Print hosts which performed port scans or host sweeps",
+			Header:  scan.Headers,
+			Rows:    scan.Rows,
+		},
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName: "exfil",
+		LogInputs:  []analysis.LogType{analysis.ConnLog},
+		IsEnabled:  func(conf *config.Config) bool { return conf.S.Exfil.Enabled },
+		Tables:     func(conf *config.Config) []string { return []string{conf.T.Exfil.ExfilTable} },
+		Removal:    analysis.RemoveChunk,
+		Scored:     []analysis.FindingType{exfilFinding},
+		Collect:    exfilFindings,
+		Indexes:    func(res *resources.Resources) error { return exfil.NewMongoRepository(res).CreateIndexes() },
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			exfilMap := exfil.FromUconns(batch.Uconns)
+			if len(exfilMap) == 0 {
+				fmt.Println("\t[!] No Exfil data to analyze")
+				return
+			}
+			exfil.NewMongoRepository(res).Upsert(exfilMap)
+		},
+		Report: []analysis.Page{{Name: "exfil", Render: reporting.ExfilPage}},
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName: "prevalence",
+		LogInputs:  []analysis.LogType{analysis.ConnLog, analysis.DNSLog},
+		IsEnabled:  func(conf *config.Config) bool { return conf.S.Prevalence.Enabled },
+		Tables:     func(conf *config.Config) []string { return []string{conf.T.Prevalence.PrevalenceTable} },
+		Removal:    analysis.RemoveChunk,
+		Scored:     []analysis.FindingType{rareDestinationFinding},
+		Collect:    rareDestinationFindings,
+		Parse: func(res *resources.Resources, filter *analysis.Filter) analysis.Parser {
+			return prevalence.NewParser(filter)
+		},
+		Indexes: func(res *resources.Resources) error { return prevalence.NewMongoRepository(res).CreateIndexes() },
+		Label:   "Prevalence",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			prevalence.NewMongoRepository(res).Upsert(batch.Result("prevalence").(map[string]*prevalence.Input))
+		},
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName: "resolverbypass",
+		LogInputs:  []analysis.LogType{analysis.ConnLog, analysis.SSLLog},
+		IsEnabled: func(conf *config.Config) bool {
+			return conf.S.DNS.Enabled && len(conf.S.DNS.ApprovedResolvers) > 0
+		},
+		Tables:  func(conf *config.Config) []string { return []string{conf.T.DNS.ResolverBypassTable} },
+		Removal: analysis.RemoveChunk,
+		Scored:  []analysis.FindingType{resolverBypassFinding},
+		Collect: resolverBypassFindings,
+		Parse: func(res *resources.Resources, filter *analysis.Filter) analysis.Parser {
+			return resolverbypass.NewParser(res.Config, filter)
+		},
+		Indexes: func(res *resources.Resources) error { return resolverbypass.NewMongoRepository(res).CreateIndexes() },
+		Label:   "Resolver Bypass",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			resolverbypass.NewMongoRepository(res).Upsert(batch.Result("resolverbypass").(map[string]*resolverbypass.Input))
+		},
+	})
+
+	analysis.Register(&analysis.Definition{
+		ModuleName: "domainfronting",
+		LogInputs:  []analysis.LogType{analysis.HTTPLog, analysis.SSLLog},
+		IsEnabled:  func(conf *config.Config) bool { return conf.S.DomainFronting.Enabled },
+		Tables:     func(conf *config.Config) []string { return []string{conf.T.DomainFronting.DomainFrontingTable} },
+		Removal:    analysis.RemoveChunk,
+		Scored:     []analysis.FindingType{domainFrontingFinding},
+		Collect:    domainFrontingFindings,
+		Parse: func(res *resources.Resources, filter *analysis.Filter) analysis.Parser {
+			return domainfronting.NewParser(res.Config, filter)
+		},
+		Indexes: func(res *resources.Resources) error { return domainfronting.NewMongoRepository(res).CreateIndexes() },
+		Label:   "Domain Fronting",
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			domainfronting.NewMongoRepository(res).Upsert(batch.Result("domainfronting").(map[string]*domainfronting.Input))
+		},
+	})
+
+	// blacklisted peers are recorded in the hosts collection
+	analysis.Register(&analysis.Definition{
+		ModuleName:   "blacklist",
+		LogInputs:    []analysis.LogType{analysis.ConnLog},
+		Dependencies: []string{"host", "uconn"},
+		Tables:       func(conf *config.Config) []string { return []string{conf.T.Structure.HostTable} },
+		Removal:      analysis.Derived,
+		Scored:       []analysis.FindingType{blacklistedFinding},
+		Collect:      blacklistFindings,
+		Indexes:      func(res *resources.Resources) error { return blacklist.NewMongoRepository(res).CreateIndexes() },
+		HasData:      func(batch *analysis.Batch) bool { return len(batch.Hosts) > 0 },
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			blacklist.NewMongoRepository(res).Upsert()
+		},
+		Report: []analysis.Page{
+			{Name: "blacklist-source", Render: reporting.BLSourceIPsPage},
+			{Name: "blacklist-destination", Render: reporting.BLDestIPsPage},
+			{Name: "blacklist-hostnames", Render: reporting.BLHostnamesPage},
+		},
+	})
+
+	// host baselines are recorded in the hosts collection
+	analysis.Register(&analysis.Definition{
+		ModuleName:   "baseline",
+		LogInputs:    []analysis.LogType{analysis.ConnLog, analysis.DNSLog},
+		Dependencies: []string{"host", "uconn"},
+		IsEnabled:    func(conf *config.Config) bool { return conf.S.Baseline.Enabled },
+		Tables:       func(conf *config.Config) []string { return []string{conf.T.Structure.HostTable} },
+		Removal:      analysis.Derived,
+		ChunkArrays:  []string{baseline.DatField},
+		Indexes:      func(res *resources.Resources) error { return baseline.NewMongoRepository(res).CreateIndexes() },
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			baselineMap := baseline.FromBatch(batch.Hosts, batch.Uconns)
+			if len(baselineMap) == 0 {
+				fmt.Println("\t[!] No Baseline data to analyze")
+				return
+			}
+			baseline.NewMongoRepository(res).Upsert(baselineMap)
+		},
+	})
+
+	// the threat table is rebuilt from the results of every other module
+	analysis.Register(&analysis.Definition{
+		ModuleName:    "threat",
+		AfterFindings: true,
+		IsEnabled:     func(conf *config.Config) bool { return conf.S.Threat.Enabled },
+		Tables:        func(conf *config.Config) []string { return []string{conf.T.Threat.ThreatTable} },
+		Removal:       analysis.Rebuild,
+		Indexes:       func(res *resources.Resources) error { return threat.NewMongoRepository(res).CreateIndexes() },
+		Upsert: func(res *resources.Resources, batch *analysis.Batch) {
+			threatMap := threat.Findings(res)
+			if len(threatMap) == 0 {
+				fmt.Println("\t[!] No Threat data to analyze")
+				return
+			}
+			threat.NewMongoRepository(res).Upsert(threatMap)
+		},
+	})
+}
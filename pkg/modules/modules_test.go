@@ -0,0 +1,38 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleRegistry(t *testing.T) {
+	// panics if a dependency is missing or circular
+	modules := analysis.Registered()
+
+	position := make(map[string]int)
+	for i, module := range modules {
+		position[module.Name()] = i
+	}
+
+	require.True(t, position["host"] < position["uconn"], "host runs before uconn")
+	require.True(t, position["uconn"] < position["beacon"], "uconn runs before beacon")
+	require.True(t, position["explodeddns"] < position["hostname"], "explodeddns runs before hostname")
+	require.True(t, position["hostname"] < position["beaconfqdn"], "hostname runs before beaconfqdn")
+	require.Equal(t, len(modules)-1, position["threat"])
+
+	// the threat module scores every module reporting findings
+	threat, ok := analysis.Lookup("threat")
+	require.True(t, ok)
+	var findings []string
+	for _, module := range modules {
+		if module.ReportsFindings() {
+			findings = append(findings, module.Name())
+		}
+	}
+	require.ElementsMatch(t, findings, threat.After())
+	require.Contains(t, findings, "beacon")
+	require.Contains(t, findings, "blacklist")
+	require.NotContains(t, findings, "baseline")
+}
@@ -0,0 +1,88 @@
+package prevalence
+
+import (
+	"sync"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+	//analyzer : structure for prevalence analysis
+	analyzer struct {
+		chunk            int            //current chunk (0 if not on rolling analysis)
+		db               *database.DB   // provides access to MongoDB
+		conf             *config.Config // contains details needed to access MongoDB
+		analyzedCallback func(*update)  // called on each analyzed result
+		closedCallback   func()         // called when .close() is called and no more calls to analyzedCallback will be made
+		analysisChannel  chan *Input    // holds unanalyzed data
+		analysisWg       sync.WaitGroup // wait for analysis to finish
+	}
+)
+
+//newAnalyzer creates a new collector for tracking destination prevalence
+func newAnalyzer(chunk int, db *database.DB, conf *config.Config, analyzedCallback func(*update), closedCallback func()) *analyzer {
+	return &analyzer{
+		chunk:            chunk,
+		db:               db,
+		conf:             conf,
+		analyzedCallback: analyzedCallback,
+		closedCallback:   closedCallback,
+		analysisChannel:  make(chan *Input),
+	}
+}
+
+//collect sends a destination to be analyzed
+func (a *analyzer) collect(data *Input) {
+	a.analysisChannel <- data
+}
+
+//close waits for the collector to finish
+func (a *analyzer) close() {
+	close(a.analysisChannel)
+	a.analysisWg.Wait()
+	a.closedCallback()
+}
+
+//start kicks off a new analysis thread
+func (a *analyzer) start() {
+	a.analysisWg.Add(1)
+	go func() {
+
+		for entry := range a.analysisChannel {
+
+			a.analyzedCallback(&update{
+				selector: entry.BSONKey(),
+				query:    a.query(entry),
+			})
+		}
+		a.analysisWg.Done()
+	}()
+}
+
+//query builds the update recording a destination's contacts in the current import
+func (a *analyzer) query(entry *Input) bson.M {
+	set := bson.M{"cid": a.chunk}
+	if entry.Type == IP {
+		set["network_name"] = entry.IP.NetworkName
+	}
+
+	// each import pushes the clients and time range it saw. The distinct
+	// client count is computed across every chunk still in the dataset, so it
+	// remains accurate when chunks are removed. The first seen time is kept
+	// outside of the chunks so removing the chunk a destination was first
+	// seen in doesn't make it look new.
+	return bson.M{
+		"$push": bson.M{
+			"dat": bson.M{
+				"clients": entry.Clients,
+				"ts_min":  entry.TsMin,
+				"ts_max":  entry.TsMax,
+				"cid":     a.chunk,
+			},
+		},
+		"$min": bson.M{"first_seen": entry.TsMin},
+		"$set": set,
+	}
+}
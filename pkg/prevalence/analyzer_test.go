@@ -0,0 +1,35 @@
+package prevalence
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	var updates []*update
+	a := newAnalyzer(2, nil, nil, func(u *update) { updates = append(updates, u) }, func() {})
+	a.start()
+
+	dst := &Input{Type: IP, IP: data.UniqueIP{IP: "203.0.113.10", NetworkName: "Public"}}
+	dst.Track(data.UniqueIP{IP: "10.0.0.1"}, 200)
+	dst.Track(data.UniqueIP{IP: "10.0.0.2"}, 100)
+	a.collect(dst)
+	a.close()
+
+	require.Len(t, updates, 1)
+	require.Equal(t, dst.BSONKey(), updates[0].selector)
+
+	query := updates[0].query
+	require.Equal(t, bson.M{"cid": 2, "network_name": "Public"}, query["$set"])
+	// the first seen time outlives the chunks it was recorded in
+	require.Equal(t, bson.M{"first_seen": int64(100)}, query["$min"])
+
+	chunk := query["$push"].(bson.M)["dat"].(bson.M)
+	require.Equal(t, 2, chunk["cid"])
+	require.Equal(t, int64(100), chunk["ts_min"])
+	require.Equal(t, int64(200), chunk["ts_max"])
+	require.Len(t, chunk["clients"], 2)
+}
@@ -0,0 +1,62 @@
+package prevalence
+
+import (
+	"github.com/activecm/rita/pkg/analysis"
+)
+
+//parser tracks the internal hosts which contacted each external IP in a
+//batch of conn records and which queried each FQDN in a batch of dns records
+type parser struct {
+	filter        *analysis.Filter
+	prevalenceMap map[string]*Input
+}
+
+//NewParser creates a parser which tracks the internal hosts contacting each
+//external IP and FQDN
+func NewParser(filter *analysis.Filter) analysis.Parser {
+	return &parser{
+		filter:        filter,
+		prevalenceMap: make(map[string]*Input),
+	}
+}
+
+//Parse records the internal client of a connection to an external IP or a
+//query for an FQDN
+func (p *parser) Parse(record *analysis.Record) {
+	if record.Filtered || !p.filter.IsInternal(record.SrcIP) {
+		return
+	}
+
+	switch record.Log {
+	case analysis.ConnLog:
+		if p.filter.IsInternal(record.DstIP) {
+			return
+		}
+		prevalenceKey := IP + record.Dst.MapKey()
+		if _, ok := p.prevalenceMap[prevalenceKey]; !ok {
+			p.prevalenceMap[prevalenceKey] = &Input{
+				Type: IP,
+				IP:   record.Dst,
+			}
+		}
+		p.prevalenceMap[prevalenceKey].Track(record.Src, record.Conn.TimeStamp)
+
+	case analysis.DNSLog:
+		if !record.IsLookup() {
+			return
+		}
+		prevalenceKey := FQDN + record.FQDN
+		if _, ok := p.prevalenceMap[prevalenceKey]; !ok {
+			p.prevalenceMap[prevalenceKey] = &Input{
+				Type: FQDN,
+				FQDN: record.FQDN,
+			}
+		}
+		p.prevalenceMap[prevalenceKey].Track(record.Src, record.DNS.TimeStamp)
+	}
+}
+
+//Flush returns the destinations gathered from the batch
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	return p.prevalenceMap
+}
@@ -0,0 +1,81 @@
+package prevalence
+
+import (
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	//IP destinations are external addresses contacted by internal hosts
+	IP = "ip"
+	//FQDN destinations are hostnames queried by internal hosts
+	FQDN = "fqdn"
+)
+
+type (
+
+	// Repository for prevalence collection
+	Repository interface {
+		CreateIndexes() error
+		Upsert(prevalenceMap map[string]*Input)
+	}
+
+	//update ....
+	update struct {
+		selector bson.M
+		query    bson.M
+	}
+
+	//Result represents an external destination, when it was first and last
+	//seen, and the internal hosts which contacted it
+	Result struct {
+		Type        string          `bson:"type"`
+		IP          string          `bson:"ip"`
+		NetworkName string          `bson:"network_name"`
+		FQDN        string          `bson:"fqdn"`
+		FirstSeen   int64           `bson:"first_seen"`
+		LastSeen    int64           `bson:"last_seen"`
+		Clients     []data.UniqueIP `bson:"clients"`
+		ClientCount int64           `bson:"client_count"`
+	}
+
+	//Input structure for sending data to the analyzer. Contains either an
+	//external IP or an FQDN, the set of internal hosts which contacted it, and
+	//the first and last times it was contacted in the current import.
+	Input struct {
+		Type    string
+		IP      data.UniqueIP
+		FQDN    string
+		Clients data.UniqueIPSet
+		TsMin   int64
+		TsMax   int64
+	}
+)
+
+//Track records a contact between an internal client and the destination
+func (in *Input) Track(client data.UniqueIP, ts int64) {
+	in.Clients.Insert(client)
+
+	if in.TsMin == 0 || ts < in.TsMin {
+		in.TsMin = ts
+	}
+	if ts > in.TsMax {
+		in.TsMax = ts
+	}
+}
+
+//BSONKey generates a BSON map which may be used to index a given destination
+func (in *Input) BSONKey() bson.M {
+	if in.Type == FQDN {
+		return bson.M{
+			"type": in.Type,
+			"fqdn": in.FQDN,
+		}
+	}
+
+	return bson.M{
+		"type":         in.Type,
+		"ip":           in.IP.IP,
+		"network_uuid": in.IP.NetworkUUID,
+	}
+}
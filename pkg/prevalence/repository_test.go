@@ -0,0 +1,35 @@
+package prevalence
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/util"
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrack(t *testing.T) {
+	in := &Input{Type: FQDN, FQDN: "example.com"}
+	client := data.UniqueIP{IP: "10.0.0.1"}
+
+	in.Track(client, 50)
+	in.Track(client, 20)
+	in.Track(data.UniqueIP{IP: "10.0.0.2"}, 80)
+
+	require.Equal(t, int64(20), in.TsMin)
+	require.Equal(t, int64(80), in.TsMax)
+	require.Len(t, in.Clients, 2)
+}
+
+func TestBSONKey(t *testing.T) {
+	fqdn := &Input{Type: FQDN, FQDN: "example.com"}
+	require.Equal(t, bson.M{"type": FQDN, "fqdn": "example.com"}, fqdn.BSONKey())
+
+	ip := &Input{Type: IP, IP: data.UniqueIP{IP: "203.0.113.10", NetworkUUID: util.PublicNetworkUUID}}
+	require.Equal(t, bson.M{
+		"type":         IP,
+		"ip":           "203.0.113.10",
+		"network_uuid": util.PublicNetworkUUID,
+	}, ip.BSONKey())
+}
@@ -0,0 +1,100 @@
+package prevalence
+
+import (
+	"errors"
+
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//ErrNoPreviousChunk is returned when every destination was first seen in the
+//current chunk, leaving nothing to tell new destinations apart from old ones
+var ErrNoPreviousChunk = errors.New("rare destinations require data from before the current chunk")
+
+//RareResults finds external destinations which were first contacted in the
+//current chunk by no more than the configured number of internal hosts. The
+//results are sorted by first seen time, oldest first.
+//limit and noLimit control how many results are returned.
+func RareResults(res *resources.Resources, limit int, noLimit bool) ([]Result, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	_, _, currentChunk, _, err := res.MetaDB.GetRollingSettings(res.DB.GetSelectedDB())
+	if err != nil {
+		return nil, err
+	}
+
+	coll := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.Prevalence.PrevalenceTable)
+
+	return rareDestinations(coll, currentChunk, res.Config.S.Prevalence.RareClientThresh, limit, noLimit)
+}
+
+//rareDestinations finds the destinations in coll which were first seen in
+//the current chunk by no more than clientThresh internal hosts
+func rareDestinations(coll database.Collection, currentChunk int, clientThresh int, limit int, noLimit bool) ([]Result, error) {
+	// find when the current chunk started
+	var chunkStart struct {
+		TsMin int64 `bson:"ts_min"`
+	}
+	err := coll.Pipe([]bson.M{
+		{"$match": bson.M{"dat.cid": currentChunk}},
+		{"$unwind": "$dat"},
+		{"$match": bson.M{"dat.cid": currentChunk}},
+		{"$group": bson.M{"_id": nil, "ts_min": bson.M{"$min": "$dat.ts_min"}}},
+	}).AllowDiskUse().One(&chunkStart)
+	if err == database.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// every destination is new if nothing was seen before the current chunk
+	older, err := coll.Find(bson.M{"first_seen": bson.M{"$lt": chunkStart.TsMin}}).Limit(1).Count()
+	if err != nil {
+		return nil, err
+	}
+	if older == 0 {
+		return nil, ErrNoPreviousChunk
+	}
+
+	var rareResults []Result
+
+	rareQuery := []bson.M{
+		// destinations first seen before the current chunk started are not new,
+		// even if the chunk they were seen in has since been removed
+		{"$match": bson.M{
+			"dat.cid":    currentChunk,
+			"first_seen": bson.M{"$gte": chunkStart.TsMin},
+		}},
+		{"$project": bson.M{
+			"_id":          0,
+			"type":         1,
+			"ip":           1,
+			"network_name": 1,
+			"fqdn":         1,
+			"first_seen":   1,
+			"last_seen":    bson.M{"$max": "$dat.ts_max"},
+			// merge the clients across batches
+			"clients": bson.M{
+				"$reduce": bson.M{
+					"input":        "$dat.clients",
+					"initialValue": []interface{}{},
+					"in":           bson.M{"$setUnion": []interface{}{"$$value", "$$this"}},
+				},
+			},
+		}},
+		{"$addFields": bson.M{"client_count": bson.M{"$size": "$clients"}}},
+		{"$match": bson.M{"client_count": bson.M{"$lte": clientThresh}}},
+		{"$sort": bson.M{"first_seen": 1}},
+	}
+
+	if !noLimit {
+		rareQuery = append(rareQuery, bson.M{"$limit": limit})
+	}
+
+	err = coll.Pipe(rareQuery).AllowDiskUse().All(&rareResults)
+
+	return rareResults, err
+}
@@ -0,0 +1,72 @@
+package prevalence
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/require"
+)
+
+//importChunk stores the destinations contacted in a chunk the same way the analyzer does
+func importChunk(t *testing.T, coll database.Collection, cid int, entries ...*Input) {
+	a := newAnalyzer(cid, nil, nil, nil, nil)
+	for _, entry := range entries {
+		_, err := coll.Upsert(entry.BSONKey(), a.query(entry))
+		require.NoError(t, err)
+	}
+}
+
+func destination(fqdn string, ts int64, clients ...string) *Input {
+	in := &Input{Type: FQDN, FQDN: fqdn}
+	for _, client := range clients {
+		in.Track(data.UniqueIP{IP: client}, ts)
+	}
+	return in
+}
+
+func TestRareDestinations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rita-prevalence")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	session, err := database.NewEmbeddedSession(filepath.Join(dir, "rita.db"), 0)
+	require.NoError(t, err)
+	defer session.Close()
+	coll := session.DB("test").C("prevalence")
+
+	importChunk(t, coll, 0,
+		destination("old.example.com", 100, "10.0.0.1"),
+		destination("popular.example.com", 100, "10.0.0.1", "10.0.0.2"),
+	)
+
+	// with a single chunk there is nothing to compare against
+	_, err = rareDestinations(coll, 0, 1, 0, true)
+	require.Equal(t, ErrNoPreviousChunk, err)
+
+	importChunk(t, coll, 1,
+		destination("old.example.com", 200, "10.0.0.1"),
+		destination("new.example.com", 250, "10.0.0.3"),
+		destination("busy.example.com", 220, "10.0.0.1", "10.0.0.2"),
+	)
+
+	results, err := rareDestinations(coll, 1, 1, 0, true)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "new.example.com", results[0].FQDN)
+	require.Equal(t, int64(250), results[0].FirstSeen)
+	require.Equal(t, int64(1), results[0].ClientCount)
+
+	// removing the first chunk doesn't make the destinations seen in it new
+	_, err = coll.UpdateAll(bson.M{"dat.cid": 0}, bson.M{"$pull": bson.M{"dat": bson.M{"cid": 0}}})
+	require.NoError(t, err)
+
+	results, err = rareDestinations(coll, 1, 1, 0, true)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "new.example.com", results[0].FQDN)
+}
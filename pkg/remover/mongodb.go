@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"runtime"
 
+	"github.com/activecm/rita/pkg/analysis"
 	"github.com/activecm/rita/resources"
 	"github.com/activecm/rita/util"
 	"github.com/globalsign/mgo/bson"
@@ -88,23 +89,16 @@ func (r *remover) reduceDNSSubCount(cid int) error {
 // within current documents
 func (r *remover) removeOutdatedCIDs(cid int) error {
 
-	// list of all analysis modules (including hostnames and exploded dns, because
-	// they still need to have this done, the previous loop was for updating existing
-	// documents due to to the special case in how that data is updated and stored.
-	modules := []string{
-		r.res.Config.T.Beacon.BeaconTable,
-		r.res.Config.T.BeaconFQDN.BeaconFQDNTable,
-		r.res.Config.T.Structure.HostTable,
-		r.res.Config.T.Structure.UniqueConnTable,
-		r.res.Config.T.DNS.ExplodedDNSTable,
-		r.res.Config.T.DNS.HostnamesTable,
-		r.res.Config.T.Cert.CertificateTable,
-		r.res.Config.T.UserAgent.UserAgentTable,
-	}
+	// collections of every analysis module which stores its results by chunk
+	// (including hostnames and exploded dns, because they still need to have
+	// this done, the previous loop was for updating existing documents due to
+	// to the special case in how that data is updated and stored.
+	modules := analysis.ChunkedCollections(r.res.Config)
 
 	//Create the workers
 	writerWorker := newCIDRemover(
 		cid,
+		analysis.ChunkFields(r.res.Config),
 		r.res.DB,
 		r.res.Config,
 		r.res.Log,
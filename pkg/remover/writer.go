@@ -6,26 +6,29 @@ import (
 
 	"github.com/activecm/rita/config"
 	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/data"
 	"github.com/globalsign/mgo/bson"
 	log "github.com/sirupsen/logrus"
 )
 
 type (
 	writer struct {
-		cid               int            // chuck id for deletion
-		db                *database.DB   // provides access to MongoDB
-		conf              *config.Config // contains details needed to access MongoDB
-		log               *log.Logger    // main logger for RITA
-		cidRemoverChannel chan string    // holds target collection names
-		updaterChannel    chan update    // holds update queries
-		writeWg           sync.WaitGroup // wait for writing to finish
+		cid               int                 // chuck id for deletion
+		chunkFields       map[string][]string // arrays besides dat holding chunk entries by collection
+		db                *database.DB        // provides access to MongoDB
+		conf              *config.Config      // contains details needed to access MongoDB
+		log               *log.Logger         // main logger for RITA
+		cidRemoverChannel chan string         // holds target collection names
+		updaterChannel    chan update         // holds update queries
+		writeWg           sync.WaitGroup      // wait for writing to finish
 	}
 )
 
 //newCIDRemover creates a new writer object to write output data
-func newCIDRemover(cid int, db *database.DB, conf *config.Config, log *log.Logger) *writer {
+func newCIDRemover(cid int, chunkFields map[string][]string, db *database.DB, conf *config.Config, log *log.Logger) *writer {
 	return &writer{
 		cid:               cid,
+		chunkFields:       chunkFields,
 		db:                db,
 		conf:              conf,
 		log:               log,
@@ -73,6 +76,9 @@ func (w *writer) startCIDRemover() {
 		ssn := w.db.Session.Copy()
 		defer ssn.Close()
 
+		// field holding the target chunk's entry in each score history
+		historyField := data.HistoryField(w.cid)
+
 		for data := range w.cidRemoverChannel {
 
 			//delete the ENTIRE record if it hasn't been updated since the chunk we are trying to remove
@@ -89,14 +95,29 @@ func (w *writer) startCIDRemover() {
 
 			// this ONLY deletes a specific chunk's DATA from a record that HAS been updated recently and doesn't need to be completely
 			// removed - only the target chunk's stats should be removed from it
-			info, err = ssn.DB(w.db.GetSelectedDB()).C(data).UpdateAll(bson.M{"dat.cid": w.cid}, bson.M{"$pull": bson.M{"dat": bson.M{"cid": w.cid}}})
+			datFields := append([]string{"dat"}, w.chunkFields[data]...)
+			for _, datField := range datFields {
+				info, err = ssn.DB(w.db.GetSelectedDB()).C(data).UpdateAll(bson.M{datField + ".cid": w.cid}, bson.M{"$pull": bson.M{datField: bson.M{"cid": w.cid}}})
+				if err != nil ||
+					((info.Updated == 0) && (info.Removed == 0) && (info.Matched != 0)) {
+					w.log.WithFields(log.Fields{
+						"Module":  "remover",
+						"Info":    info,
+						"Data":    data,
+						"Message": "failed to delete chunk",
+					}).Error(err)
+				}
+			}
+
+			// drop the target chunk's score from the score history of the remaining records
+			info, err = ssn.DB(w.db.GetSelectedDB()).C(data).UpdateAll(bson.M{historyField: bson.M{"$exists": true}}, bson.M{"$unset": bson.M{historyField: ""}})
 			if err != nil ||
 				((info.Updated == 0) && (info.Removed == 0) && (info.Matched != 0)) {
 				w.log.WithFields(log.Fields{
 					"Module":  "remover",
 					"Info":    info,
 					"Data":    data,
-					"Message": "failed to delete chunk",
+					"Message": "failed to delete chunk score history",
 				}).Error(err)
 			}
 
@@ -0,0 +1,82 @@
+package resolverbypass
+
+import (
+	"sync"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+	//analyzer : structure for resolver bypass analysis
+	analyzer struct {
+		chunk            int            //current chunk (0 if not on rolling analysis)
+		db               *database.DB   // provides access to MongoDB
+		conf             *config.Config // contains details needed to access MongoDB
+		analyzedCallback func(*update)  // called on each analyzed result
+		closedCallback   func()         // called when .close() is called and no more calls to analyzedCallback will be made
+		analysisChannel  chan *Input    // holds unanalyzed data
+		analysisWg       sync.WaitGroup // wait for analysis to finish
+	}
+)
+
+//newAnalyzer creates a new collector for tracking resolver bypasses
+func newAnalyzer(chunk int, db *database.DB, conf *config.Config, analyzedCallback func(*update), closedCallback func()) *analyzer {
+	return &analyzer{
+		chunk:            chunk,
+		db:               db,
+		conf:             conf,
+		analyzedCallback: analyzedCallback,
+		closedCallback:   closedCallback,
+		analysisChannel:  make(chan *Input),
+	}
+}
+
+//collect sends a client and resolver pair to be analyzed
+func (a *analyzer) collect(data *Input) {
+	a.analysisChannel <- data
+}
+
+//close waits for the collector to finish
+func (a *analyzer) close() {
+	close(a.analysisChannel)
+	a.analysisWg.Wait()
+	a.closedCallback()
+}
+
+//start kicks off a new analysis thread
+func (a *analyzer) start() {
+	a.analysisWg.Add(1)
+	go func() {
+		for entry := range a.analysisChannel {
+
+			query := bson.M{
+				"$set": bson.M{
+					"src_network_name": entry.Hosts.SrcNetworkName,
+					"dst_network_name": entry.Hosts.DstNetworkName,
+					"cid":              a.chunk,
+				},
+				"$push": bson.M{
+					"dat": bson.M{
+						"count":  entry.ConnectionCount,
+						"tbytes": entry.TotalBytes,
+						"cid":    a.chunk,
+					},
+				},
+			}
+
+			if len(entry.ServerNames) > 0 {
+				query["$addToSet"] = bson.M{
+					"server_names": bson.M{"$each": entry.ServerNames},
+				}
+			}
+
+			a.analyzedCallback(&update{
+				selector: entry.BSONKey(),
+				query:    query,
+			})
+		}
+		a.analysisWg.Done()
+	}()
+}
@@ -0,0 +1,38 @@
+package resolverbypass
+
+import (
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/require"
+)
+
+func analyze(t *testing.T, chunk int, entry *Input) bson.M {
+	var output *update
+	a := newAnalyzer(chunk, nil, &config.Config{}, func(u *update) { output = u }, func() {})
+	a.start()
+	a.collect(entry)
+	a.close()
+
+	require.NotNil(t, output)
+	require.Equal(t, entry.BSONKey(), output.selector)
+	return output.query
+}
+
+func TestAnalyze(t *testing.T) {
+	hosts := data.NewUniqueIPPair(data.UniqueIP{IP: "10.0.0.1"}, data.UniqueIP{IP: "8.8.8.8"})
+
+	// each chunk's traffic is stored separately so it can be removed
+	query := analyze(t, 2, &Input{Hosts: hosts, Method: DNS, ConnectionCount: 3, TotalBytes: 480})
+	require.Equal(t, bson.M{"count": int64(3), "tbytes": int64(480), "cid": 2}, query["$push"].(bson.M)["dat"])
+	require.Equal(t, 2, query["$set"].(bson.M)["cid"])
+	require.NotContains(t, query, "$addToSet")
+
+	// the server names of DoH traffic are merged with the stored names
+	doh := &Input{Hosts: hosts, Method: DoH, ServerNames: []string{"dns.google"}, ConnectionCount: 1}
+	query = analyze(t, 0, doh)
+	require.Equal(t, DoH, doh.BSONKey()["method"])
+	require.Equal(t, bson.M{"server_names": bson.M{"$each": []string{"dns.google"}}}, query["$addToSet"])
+}
@@ -0,0 +1,116 @@
+package resolverbypass
+
+import (
+	"net"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/util"
+)
+
+//parser gathers the DNS traffic internal hosts sent to resolvers other than
+//the approved resolvers in a batch of conn and ssl records
+type parser struct {
+	filter            *analysis.Filter
+	approvedResolvers []*net.IPNet
+	dohServers        []string
+	bypassMap         map[string]*Input
+}
+
+//NewParser creates a parser which gathers the resolver bypasses in a batch of
+//conn and ssl records
+func NewParser(conf *config.Config, filter *analysis.Filter) analysis.Parser {
+	return &parser{
+		filter:            filter,
+		approvedResolvers: util.ParseSubnets(conf.S.DNS.ApprovedResolvers),
+		dohServers:        conf.S.DNS.DoHServers,
+		bypassMap:         make(map[string]*Input),
+	}
+}
+
+//Parse tracks plain DNS and DNS over TLS connections in conn records and
+//DNS over HTTPS sessions with well known DoH servers in ssl records
+func (p *parser) Parse(record *analysis.Record) {
+	// resolver bypass detection has its own filter so DNS sent to an
+	// unapproved internal resolver is not dropped as internal traffic
+	if p.filterPair(record.SrcIP, record.DstIP) {
+		return
+	}
+
+	switch record.Log {
+	case analysis.ConnLog:
+		conn := record.Conn
+		method := ""
+		if conn.DestinationPort == 53 && (conn.Proto == "udp" || conn.Proto == "tcp") {
+			method = DNS
+		} else if conn.DestinationPort == 853 && conn.Proto == "tcp" {
+			method = DoT
+		}
+		if method == "" {
+			return
+		}
+
+		bypass := p.get(record, method)
+		bypass.ConnectionCount++
+		bypass.TotalBytes += conn.OrigIPBytes + conn.RespIPBytes
+
+	case analysis.SSLLog:
+		// track DNS over HTTPS sent to well known DoH servers instead of
+		// the approved resolvers
+		host := record.FQDN
+		if host == "" || !util.ContainsDomain(p.dohServers, host) {
+			return
+		}
+
+		bypass := p.get(record, DoH)
+		bypass.ConnectionCount++
+		if !util.StringInSlice(host, bypass.ServerNames) {
+			bypass.ServerNames = append(bypass.ServerNames, host)
+		}
+	}
+}
+
+//get returns the bypass of a client and resolver using the given method,
+//creating it if needed
+func (p *parser) get(record *analysis.Record, method string) *Input {
+	bypassKey := method + record.Hosts.MapKey()
+	if _, ok := p.bypassMap[bypassKey]; !ok {
+		p.bypassMap[bypassKey] = &Input{
+			Hosts:  record.Hosts,
+			Method: method,
+		}
+	}
+	return p.bypassMap[bypassKey]
+}
+
+//Flush returns the resolver bypasses gathered from the batch
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	return p.bypassMap
+}
+
+// filterPair returns true if a connection pair is filtered/excluded from
+// resolver bypass detection. This is determined by the following rules, in order:
+//   1. Filtered if no approved resolvers are configured
+//   2. Filtered if the source is not internal
+//   3. Filtered if either IP is an approved resolver
+//   4. Filtered if either IP is filtered by FilterSingleIP
+//   5. Not filtered in all other cases
+func (p *parser) filterPair(srcIP net.IP, dstIP net.IP) bool {
+	// without approved resolvers, there is nothing to bypass
+	if len(p.approvedResolvers) == 0 {
+		return true
+	}
+
+	// only internal clients are expected to use the approved resolvers
+	if !p.filter.IsInternal(srcIP) {
+		return true
+	}
+
+	// the approved resolvers may forward queries anywhere, and clients
+	// may query them directly
+	if util.ContainsIP(p.approvedResolvers, srcIP) || util.ContainsIP(p.approvedResolvers, dstIP) {
+		return true
+	}
+
+	return p.filter.FilterSingleIP(srcIP) || p.filter.FilterSingleIP(dstIP)
+}
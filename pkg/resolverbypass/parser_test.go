@@ -0,0 +1,150 @@
+package resolverbypass
+
+import (
+	"net"
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/parser/parsetypes"
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCase struct {
+	src string
+	dst string
+	out bool
+	msg string
+}
+
+func TestFilterPair(t *testing.T) {
+	conf := &config.Config{}
+	conf.S.Filtering.InternalSubnets = []string{"10.0.0.0/8"}
+	conf.S.Filtering.NeverInclude = []string{"10.0.0.2/32"}
+
+	internal := "10.0.0.0"
+	internalNever := "10.0.0.2"
+	resolver := "10.0.0.53"
+	internalOther := "10.0.0.54"
+	external := "8.8.8.8"
+
+	p := NewParser(conf, analysis.NewFilter(conf)).(*parser)
+
+	testCases := []testCase{
+		{internal, external, true, "pairs should be filtered when no approved resolvers are configured"},
+	}
+
+	for _, test := range testCases {
+		output := p.filterPair(net.ParseIP(test.src), net.ParseIP(test.dst))
+		assert.Equal(t, test.out, output, test.msg)
+	}
+
+	conf.S.DNS.ApprovedResolvers = []string{"10.0.0.53/32"}
+	p = NewParser(conf, analysis.NewFilter(conf)).(*parser)
+
+	testCases = []testCase{
+		{internal, resolver, true, "queries to an approved resolver should be filtered"},
+		{resolver, external, true, "queries forwarded by an approved resolver should be filtered"},
+		{internal, external, false, "queries to an external resolver should not be filtered"},
+		{internal, internalOther, false, "queries to an unapproved internal resolver should not be filtered"},
+		{external, internal, true, "queries from external clients should be filtered"},
+		{internalNever, external, true, "NeverInclude should be applied"},
+	}
+
+	for _, test := range testCases {
+		output := p.filterPair(net.ParseIP(test.src), net.ParseIP(test.dst))
+		assert.Equal(t, test.out, output, test.msg)
+	}
+}
+
+//testConfig approves a single internal resolver and a single DoH server
+func testConfig() *config.Config {
+	conf := &config.Config{}
+	conf.S.Filtering.InternalSubnets = []string{"10.0.0.0/8"}
+	conf.S.DNS.ApprovedResolvers = []string{"10.0.0.53/32"}
+	conf.S.DNS.DoHServers = []string{"dns.google", "*.cloudflare-dns.com"}
+	return conf
+}
+
+//parseLogs runs the parser over a batch of conn and ssl records
+func parseLogs(conf *config.Config, logs ...interface{}) map[string]*Input {
+	filter := analysis.NewFilter(conf)
+	p := NewParser(conf, filter)
+	for _, datum := range logs {
+		p.Parse(analysis.NewRecord(filter, datum))
+	}
+	return p.Flush(&analysis.Batch{}).(map[string]*Input)
+}
+
+//bypassesByMethod indexes the parsed bypasses by method and resolver
+func bypassesByMethod(bypassMap map[string]*Input) map[string]*Input {
+	bypasses := make(map[string]*Input)
+	for _, bypass := range bypassMap {
+		bypasses[bypass.Method+" "+bypass.Hosts.DstIP] = bypass
+	}
+	return bypasses
+}
+
+func TestParse(t *testing.T) {
+	conn := func(dst string, port int, proto string) *parsetypes.Conn {
+		return &parsetypes.Conn{
+			Source:          "10.0.0.1",
+			Destination:     dst,
+			DestinationPort: port,
+			Proto:           proto,
+			OrigIPBytes:     60,
+			RespIPBytes:     100,
+		}
+	}
+	ssl := func(dst string, serverName string) *parsetypes.SSL {
+		return &parsetypes.SSL{Source: "10.0.0.1", Destination: dst, ServerName: serverName}
+	}
+
+	bypasses := bypassesByMethod(parseLogs(testConfig(),
+		// plain DNS over udp and tcp is tracked together
+		conn("8.8.8.8", 53, "udp"),
+		conn("8.8.8.8", 53, "tcp"),
+		// DNS over TLS
+		conn("1.1.1.1", 853, "tcp"),
+		// DNS over HTTPS to well known DoH servers
+		ssl("8.8.4.4", "dns.google"),
+		ssl("8.8.4.4", "dns.google"),
+		ssl("104.16.248.249", "mozilla.cloudflare-dns.com"),
+		// the approved resolver is not a bypass
+		conn("10.0.0.53", 53, "udp"),
+		ssl("10.0.0.53", "dns.google"),
+		// neither is other traffic to a resolver
+		conn("8.8.8.8", 443, "tcp"),
+		conn("1.1.1.1", 853, "udp"),
+		ssl("8.8.4.4", "www.google.com"),
+	))
+
+	require.Len(t, bypasses, 4)
+
+	dns := bypasses["dns 8.8.8.8"]
+	require.NotNil(t, dns)
+	require.Equal(t, "10.0.0.1", dns.Hosts.SrcIP)
+	require.Equal(t, int64(2), dns.ConnectionCount)
+	require.Equal(t, int64(320), dns.TotalBytes)
+	require.Empty(t, dns.ServerNames)
+
+	dot := bypasses["dot 1.1.1.1"]
+	require.NotNil(t, dot)
+	require.Equal(t, int64(1), dot.ConnectionCount)
+	require.Equal(t, int64(160), dot.TotalBytes)
+
+	doh := bypasses["doh 8.8.4.4"]
+	require.NotNil(t, doh)
+	require.Equal(t, int64(2), doh.ConnectionCount)
+	require.Equal(t, []string{"dns.google"}, doh.ServerNames)
+
+	doh = bypasses["doh 104.16.248.249"]
+	require.NotNil(t, doh)
+	require.Equal(t, []string{"mozilla.cloudflare-dns.com"}, doh.ServerNames)
+
+	// without approved resolvers nothing is a bypass
+	conf := testConfig()
+	conf.S.DNS.ApprovedResolvers = nil
+	require.Empty(t, parseLogs(conf, conn("8.8.8.8", 53, "udp"), ssl("8.8.4.4", "dns.google")))
+}
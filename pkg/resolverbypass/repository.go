@@ -0,0 +1,59 @@
+package resolverbypass
+
+import (
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	//DNS is plain DNS sent over 53/udp or 53/tcp
+	DNS = "dns"
+	//DoT is DNS over TLS sent over 853/tcp
+	DoT = "dot"
+	//DoH is DNS over HTTPS sent to a well known DoH server name
+	DoH = "doh"
+)
+
+type (
+
+	// Repository for resolverBypass collection
+	Repository interface {
+		CreateIndexes() error
+		Upsert(bypassMap map[string]*Input)
+	}
+
+	//update ....
+	update struct {
+		selector bson.M
+		query    bson.M
+	}
+
+	//Result represents an internal host which sent DNS queries to a resolver
+	//other than the approved resolvers
+	Result struct {
+		data.UniqueIPPair `bson:",inline"`
+		Method            string   `bson:"method"`
+		ServerNames       []string `bson:"server_names"`
+		ConnectionCount   int64    `bson:"connection_count"`
+		TotalBytes        int64    `bson:"total_bytes"`
+	}
+
+	//Input structure for sending data to the analyzer. Contains the internal
+	//client and unapproved resolver, how DNS was sent to the resolver, and the
+	//volume of DNS traffic. ServerNames holds the TLS server names of DoH traffic.
+	Input struct {
+		Hosts           data.UniqueIPPair
+		Method          string
+		ServerNames     []string
+		ConnectionCount int64
+		TotalBytes      int64
+	}
+)
+
+//BSONKey generates a BSON map which may be used to index a given client,
+//resolver, and method
+func (in *Input) BSONKey() bson.M {
+	key := in.Hosts.BSONKey()
+	key["method"] = in.Method
+	return key
+}
@@ -0,0 +1,41 @@
+package resolverbypass
+
+import (
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//Results returns internal hosts which sent DNS queries to resolvers other than
+//the approved resolvers, sorted by connection count.
+//limit and noLimit control how many results are returned.
+func Results(res *resources.Resources, limit int, noLimit bool) ([]Result, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var bypassResults []Result
+
+	bypassQuery := []bson.M{
+		{"$project": bson.M{
+			"src":              1,
+			"src_network_uuid": 1,
+			"src_network_name": 1,
+			"dst":              1,
+			"dst_network_uuid": 1,
+			"dst_network_name": 1,
+			"method":           1,
+			"server_names":     1,
+			"connection_count": bson.M{"$sum": "$dat.count"},
+			"total_bytes":      bson.M{"$sum": "$dat.tbytes"},
+		}},
+		{"$match": bson.M{"connection_count": bson.M{"$gt": 0}}},
+		{"$sort": bson.M{"connection_count": -1}},
+	}
+
+	if !noLimit {
+		bypassQuery = append(bypassQuery, bson.M{"$limit": limit})
+	}
+
+	err := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.DNS.ResolverBypassTable).Pipe(bypassQuery).AllowDiskUse().All(&bypassResults)
+
+	return bypassResults, err
+}
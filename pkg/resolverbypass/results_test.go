@@ -0,0 +1,74 @@
+package resolverbypass
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/parser/parsetypes"
+	"github.com/activecm/rita/resources"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rita-resolverbypass")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := testConfig()
+	conf.S.Storage.Backend = database.EmbeddedBackend
+	conf.S.Storage.Path = filepath.Join(dir, "rita.db")
+	conf.T.DNS.ResolverBypassTable = "resolverBypass"
+	db, err := database.NewDB(conf, logrus.New())
+	require.NoError(t, err)
+	defer db.Session.Close()
+	db.SelectDB("dataset")
+
+	res := &resources.Resources{Config: conf, Log: logrus.New(), DB: db}
+	r := NewMongoRepository(res)
+	require.NoError(t, r.CreateIndexes())
+
+	dns := &parsetypes.Conn{Source: "10.0.0.1", Destination: "8.8.8.8", DestinationPort: 53, Proto: "udp", OrigIPBytes: 60, RespIPBytes: 100}
+	dot := &parsetypes.Conn{Source: "10.0.0.2", Destination: "1.1.1.1", DestinationPort: 853, Proto: "tcp", OrigIPBytes: 500, RespIPBytes: 500}
+	approved := &parsetypes.Conn{Source: "10.0.0.1", Destination: "10.0.0.53", DestinationPort: 53, Proto: "udp"}
+	google := &parsetypes.SSL{Source: "10.0.0.3", Destination: "8.8.4.4", ServerName: "dns.google"}
+	cloudflare := &parsetypes.SSL{Source: "10.0.0.3", Destination: "8.8.4.4", ServerName: "mozilla.cloudflare-dns.com"}
+
+	conf.S.Rolling.CurrentChunk = 0
+	r.Upsert(parseLogs(conf, dns, dns, dns, dot, approved, approved, approved, approved, google))
+	conf.S.Rolling.CurrentChunk = 1
+	r.Upsert(parseLogs(conf, dns, google, cloudflare, approved))
+
+	results, err := Results(res, 0, true)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	// the traffic from every chunk is totaled, most connections first
+	require.Equal(t, DNS, results[0].Method)
+	require.Equal(t, "10.0.0.1", results[0].SrcIP)
+	require.Equal(t, "8.8.8.8", results[0].DstIP)
+	require.Equal(t, int64(4), results[0].ConnectionCount)
+	require.Equal(t, int64(640), results[0].TotalBytes)
+
+	require.Equal(t, DoH, results[1].Method)
+	require.Equal(t, "10.0.0.3", results[1].SrcIP)
+	require.Equal(t, int64(3), results[1].ConnectionCount)
+	require.ElementsMatch(t, []string{"dns.google", "mozilla.cloudflare-dns.com"}, results[1].ServerNames)
+
+	require.Equal(t, DoT, results[2].Method)
+	require.Equal(t, "10.0.0.2", results[2].SrcIP)
+	require.Equal(t, int64(1000), results[2].TotalBytes)
+
+	// queries to the approved resolver are never stored
+	for _, result := range results {
+		require.NotEqual(t, "10.0.0.53", result.DstIP)
+	}
+
+	results, err = Results(res, 1, false)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, DNS, results[0].Method)
+}
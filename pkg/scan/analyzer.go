@@ -0,0 +1,203 @@
+package scan
+
+import (
+	"math"
+	"sync"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/util"
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+	//analyzer : structure for scan analysis
+	analyzer struct {
+		chunk            int            //current chunk (0 if not on rolling analysis)
+		db               *database.DB   // provides access to MongoDB
+		conf             *config.Config // contains details needed to access MongoDB
+		analyzedCallback func(*update)  // called on each analyzed result
+		closedCallback   func()         // called when .close() is called and no more calls to analyzedCallback will be made
+		analysisChannel  chan *Input    // holds unanalyzed data
+		analysisWg       sync.WaitGroup // wait for analysis to finish
+	}
+)
+
+//newAnalyzer creates a new collector for scoring scans
+func newAnalyzer(chunk int, db *database.DB, conf *config.Config, analyzedCallback func(*update), closedCallback func()) *analyzer {
+	return &analyzer{
+		chunk:            chunk,
+		db:               db,
+		conf:             conf,
+		analyzedCallback: analyzedCallback,
+		closedCallback:   closedCallback,
+		analysisChannel:  make(chan *Input),
+	}
+}
+
+//collect sends a potential scan to be analyzed
+func (a *analyzer) collect(data *Input) {
+	a.analysisChannel <- data
+}
+
+//close waits for the collector to finish
+func (a *analyzer) close() {
+	close(a.analysisChannel)
+	a.analysisWg.Wait()
+	a.closedCallback()
+}
+
+//start kicks off a new analysis thread
+func (a *analyzer) start() {
+	a.analysisWg.Add(1)
+	go func() {
+		ssn := a.db.Session.Copy()
+		defer ssn.Close()
+
+		for entry := range a.analysisChannel {
+
+			batch := chunk{
+				Targets:         entry.targetList(),
+				ConnectionCount: entry.ConnectionCount,
+				FailedCount:     entry.FailedCount,
+				TsMin:           entry.TsMin,
+				TsMax:           entry.TsMax,
+				CID:             a.chunk,
+			}
+
+			// the targets and statistics from previous batches and chunks are
+			// stored in the dat array of the record. Merge them with the current
+			// batch so slow scans spread across batches and rolling datasets are
+			// analyzed as a whole.
+			var stored struct {
+				Dat []chunk `bson:"dat"`
+			}
+
+			_ = ssn.DB(a.db.GetSelectedDB()).C(a.conf.T.Scan.ScanTable).Find(entry.BSONKey()).One(&stored)
+
+			chunks := append(stored.Dat, batch)
+			merged, targetCount := mergeChunks(chunks)
+
+			failedRatio, duration, rate, score := scoreChunk(merged, targetCount, peakRate(chunks))
+
+			// potential scans which haven't probed enough targets are kept so
+			// later batches can add to them, but aren't scored
+			if targetCount < int64(a.threshold(entry.Type)) {
+				score = 0
+			}
+
+			set := bson.M{
+				"src_network_name": entry.Src.NetworkName,
+				"target_count":     targetCount,
+				"connection_count": merged.ConnectionCount,
+				"failed_count":     merged.FailedCount,
+				"failed_ratio":     failedRatio,
+				"duration":         duration,
+				"rate":             rate,
+				"score":            score,
+				"cid":              a.chunk,
+			}
+
+			if entry.Type == Vertical {
+				set["dst_network_name"] = entry.Dst.NetworkName
+			}
+
+			a.analyzedCallback(&update{
+				selector: entry.BSONKey(),
+				query: bson.M{
+					"$push": bson.M{"dat": batch},
+					"$set":  set,
+				},
+			})
+		}
+		a.analysisWg.Done()
+	}()
+}
+
+//threshold returns the number of targets a scan of the given type must probe
+//before it is scored
+func (a *analyzer) threshold(scanType string) int {
+	if scanType == Vertical {
+		return a.conf.S.Scan.VerticalPortThresh
+	}
+	return a.conf.S.Scan.HorizontalHostThresh
+}
+
+//mergeChunks combines the statistics of several chunks and returns them
+//along with the number of distinct targets probed across the chunks.
+//The merged chunk's targets hold the distinct targets.
+func mergeChunks(chunks []chunk) (chunk, int64) {
+	var merged chunk
+	targets := make(map[string]struct{})
+
+	for i, c := range chunks {
+		for _, target := range c.Targets {
+			if _, ok := targets[target]; !ok {
+				targets[target] = struct{}{}
+				merged.Targets = append(merged.Targets, target)
+			}
+		}
+		merged.ConnectionCount += c.ConnectionCount
+		merged.FailedCount += c.FailedCount
+		if i == 0 || c.TsMin < merged.TsMin {
+			merged.TsMin = c.TsMin
+		}
+		if c.TsMax > merged.TsMax {
+			merged.TsMax = c.TsMax
+		}
+	}
+
+	return merged, int64(len(targets))
+}
+
+//targetRate returns the number of targets probed per minute. Scans finishing
+//within a second are treated as taking a second.
+func targetRate(targetCount int64, duration int64) float64 {
+	return float64(targetCount) / (math.Max(float64(duration), 1) / 60.0)
+}
+
+//peakRate returns the number of distinct targets probed per minute in the
+//chunk with the fastest scan. Each chunk's rate is computed on its own so a
+//scan repeated in later chunks of a rolling dataset isn't spread over the
+//time between the chunks.
+func peakRate(chunks []chunk) float64 {
+	byCID := make(map[int][]chunk)
+	for _, c := range chunks {
+		byCID[c.CID] = append(byCID[c.CID], c)
+	}
+
+	rate := 0.0
+	for _, cidChunks := range byCID {
+		merged, targetCount := mergeChunks(cidChunks)
+		rate = math.Max(rate, targetRate(targetCount, merged.TsMax-merged.TsMin))
+	}
+	return rate
+}
+
+//scoreChunk computes the ratio of failed connection attempts, the duration of
+//the scan in seconds, the number of targets probed per minute, and the overall
+//scan score for a chunk which probed targetCount distinct targets at the
+//given rate
+func scoreChunk(c chunk, targetCount int64, rate float64) (float64, int64, float64, float64) {
+	failedRatio := 0.0
+	if c.ConnectionCount > 0 {
+		failedRatio = float64(c.FailedCount) / float64(c.ConnectionCount)
+	}
+
+	duration := c.TsMax - c.TsMin
+
+	//1000 or more targets receives a full score
+	breadthScore := util.Clamp(math.Log10(float64(targetCount)) / 3.0)
+
+	//probing 100 or more targets per minute receives a full score, while
+	//slow scans probing a single target per minute receive none
+	rateScore := util.Clamp(math.Log10(rate) / 2.0)
+
+	//score numerators
+	sum := breadthScore + failedRatio + rateScore
+
+	//score average
+	score := math.Ceil((sum/3.0)*1000) / 1000
+
+	return failedRatio, duration, math.Ceil(rate*1000) / 1000, score
+}
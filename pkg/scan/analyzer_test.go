@@ -0,0 +1,86 @@
+package scan
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreChunk(t *testing.T) {
+	// 1000 ports in 10 seconds with almost every attempt rejected
+	fast := chunk{ConnectionCount: 1000, FailedCount: 990, TsMin: 100, TsMax: 110}
+	// 100 ports spread over a day with most attempts succeeding
+	slow := chunk{ConnectionCount: 1000, FailedCount: 100, TsMin: 0, TsMax: 86400}
+
+	failedRatio, duration, _, fastScore := scoreChunk(fast, 1000, peakRate([]chunk{fast}))
+	require.InDelta(t, 0.99, failedRatio, 0.0001)
+	require.Equal(t, int64(10), duration)
+
+	_, _, slowRate, slowScore := scoreChunk(slow, 100, targetRate(100, slow.TsMax-slow.TsMin))
+	require.True(t, slowRate < 1)
+	require.True(t, fastScore > slowScore)
+	require.True(t, fastScore <= 1)
+}
+
+func TestRepeatedScan(t *testing.T) {
+	// the same scan of 100 ports in a minute in three hourly chunks
+	var chunks []chunk
+	for cid := 0; cid < 3; cid++ {
+		start := int64(cid * 3600)
+		chunks = append(chunks, chunk{
+			Targets:         make([]string, 100),
+			ConnectionCount: 100,
+			FailedCount:     90,
+			TsMin:           start,
+			TsMax:           start + 60,
+			CID:             cid,
+		})
+		for port := range chunks[cid].Targets {
+			chunks[cid].Targets[port] = strconv.Itoa(port) + ":tcp"
+		}
+	}
+
+	merged, targetCount := mergeChunks(chunks[:1])
+	_, _, firstRate, firstScore := scoreChunk(merged, targetCount, peakRate(chunks[:1]))
+
+	// repeating the scan in later chunks doesn't lower its rate or score
+	merged, targetCount = mergeChunks(chunks)
+	_, duration, rate, score := scoreChunk(merged, targetCount, peakRate(chunks))
+	require.Equal(t, int64(7260), duration)
+	require.Equal(t, firstRate, rate)
+	require.Equal(t, firstScore, score)
+}
+
+func TestMergeChunks(t *testing.T) {
+	merged, targetCount := mergeChunks([]chunk{
+		{Targets: []string{"22:tcp", "80:tcp"}, ConnectionCount: 20, FailedCount: 5, TsMin: 50, TsMax: 60},
+		{Targets: []string{"80:tcp", "443:tcp"}, ConnectionCount: 5, FailedCount: 5, TsMin: 10, TsMax: 20},
+	})
+
+	// a target probed in both chunks is only counted once
+	require.Equal(t, int64(3), targetCount)
+	require.Equal(t, chunk{
+		Targets:         []string{"22:tcp", "80:tcp", "443:tcp"},
+		ConnectionCount: 25,
+		FailedCount:     10,
+		TsMin:           10,
+		TsMax:           60,
+	}, merged)
+}
+
+func TestMergeSlowScan(t *testing.T) {
+	// a sweep probing a few new hosts in each batch stays under the
+	// threshold in every batch but not once the batches are combined
+	var chunks []chunk
+	for batch := 0; batch < 5; batch++ {
+		in := &Input{Type: Horizontal, Targets: make(map[string]struct{})}
+		for host := 0; host < 4; host++ {
+			in.Targets[string(rune('a'+batch))+string(rune('a'+host))] = struct{}{}
+		}
+		chunks = append(chunks, chunk{Targets: in.targetList(), ConnectionCount: 4})
+	}
+
+	_, targetCount := mergeChunks(chunks)
+	require.Equal(t, int64(20), targetCount)
+}
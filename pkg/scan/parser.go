@@ -0,0 +1,109 @@
+package scan
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/util"
+)
+
+//parser tracks the ports probed per source-destination pair and the
+//destinations probed per source-port pair in a batch of conn records
+type parser struct {
+	filter           *analysis.Filter
+	includeInternal  bool
+	failedConnStates []string
+	scanMap          map[string]*Input
+}
+
+//NewParser creates a parser which gathers the scans in a batch of conn
+//records
+func NewParser(conf *config.Config, filter *analysis.Filter) analysis.Parser {
+	return &parser{
+		filter:           filter,
+		includeInternal:  conf.S.Scan.IncludeInternal,
+		failedConnStates: conf.S.Scan.FailedConnStates,
+		scanMap:          make(map[string]*Input),
+	}
+}
+
+//Parse tracks a connection attempt in the vertical scan of its destination
+//and the horizontal sweep of its port
+func (p *parser) Parse(record *analysis.Record) {
+	// scan detection has its own filter so internal scans can be analyzed
+	// without affecting the other modules
+	if p.filterPair(record.SrcIP, record.DstIP) {
+		return
+	}
+
+	conn := record.Conn
+	failed := util.StringInSlice(conn.ConnState, p.failedConnStates)
+	portProto := strconv.Itoa(conn.DestinationPort) + ":" + conn.Proto
+
+	// vertical scans track the ports probed on a single destination
+	verticalKey := Vertical + record.Hosts.MapKey()
+	if _, ok := p.scanMap[verticalKey]; !ok {
+		p.scanMap[verticalKey] = &Input{
+			Type:    Vertical,
+			Src:     record.Src,
+			Dst:     record.Dst,
+			Targets: make(map[string]struct{}),
+		}
+	}
+	p.scanMap[verticalKey].Targets[portProto] = struct{}{}
+	p.scanMap[verticalKey].Track(conn.TimeStamp, failed)
+
+	// horizontal sweeps track the destinations probed on a single port
+	horizontalKey := Horizontal + record.Src.MapKey() + portProto
+	if _, ok := p.scanMap[horizontalKey]; !ok {
+		p.scanMap[horizontalKey] = &Input{
+			Type:    Horizontal,
+			Src:     record.Src,
+			Port:    conn.DestinationPort,
+			Proto:   conn.Proto,
+			Targets: make(map[string]struct{}),
+		}
+	}
+	p.scanMap[horizontalKey].Targets[TargetKey(record.Dst)] = struct{}{}
+	p.scanMap[horizontalKey].Track(conn.TimeStamp, failed)
+}
+
+//Flush returns the scans gathered from the batch
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	return p.scanMap
+}
+
+// filterPair returns true if a connection pair is filtered/excluded from
+// scan detection. This is determined by the following rules, in order:
+//   1. Not filtered if FilterConnPair does not filter the pair
+//   2. Filtered if internal scan detection is not enabled
+//   3. Filtered if either IP is on the NeverInclude list
+//   4. Not filtered if both IPs are internal
+//   5. Filtered in all other cases
+func (p *parser) filterPair(srcIP net.IP, dstIP net.IP) bool {
+	// if the pair is kept for the other analysis modules, keep it for scans too
+	if !p.filter.FilterConnPair(srcIP, dstIP) {
+		return false
+	}
+
+	// if internal scan detection is not enabled, follow the connection filter
+	if !p.includeInternal {
+		return true
+	}
+
+	// if either IP is on the NeverInclude list, filter applies. Neither IP
+	// is on the AlwaysInclude list since the pair was filtered above.
+	if p.filter.FilterSingleIP(srcIP) || p.filter.FilterSingleIP(dstIP) {
+		return true
+	}
+
+	// keep internal to internal connections
+	if p.filter.IsInternal(srcIP) && p.filter.IsInternal(dstIP) {
+		return false
+	}
+
+	// default to filter the connection pair
+	return true
+}
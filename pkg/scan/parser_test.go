@@ -0,0 +1,58 @@
+package scan
+
+import (
+	"net"
+	"testing"
+
+	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCase struct {
+	src string
+	dst string
+	out bool
+	msg string
+}
+
+func TestFilterPair(t *testing.T) {
+	conf := &config.Config{}
+	conf.S.Filtering.InternalSubnets = []string{"10.0.0.0/8"}
+	conf.S.Filtering.AlwaysInclude = []string{"1.1.1.1/32"}
+	conf.S.Filtering.NeverInclude = []string{"10.0.0.2/32"}
+
+	internal := "10.0.0.0"
+	internalNever := "10.0.0.2"
+	external := "1.1.1.0"
+	externalAlways := "1.1.1.1"
+
+	p := NewParser(conf, analysis.NewFilter(conf)).(*parser)
+
+	testCases := []testCase{
+		{internal, internal, true, "internal to internal should be filtered when internal scans are not enabled"},
+		{internal, external, false, "internal to external should not be filtered"},
+		{external, externalAlways, false, "AlwaysInclude should override external to external filter"},
+		{external, external, true, "external to external should be filtered"},
+	}
+
+	for _, test := range testCases {
+		output := p.filterPair(net.ParseIP(test.src), net.ParseIP(test.dst))
+		assert.Equal(t, test.out, output, test.msg)
+	}
+
+	conf.S.Scan.IncludeInternal = true
+	p = NewParser(conf, analysis.NewFilter(conf)).(*parser)
+
+	testCases = []testCase{
+		{internal, internal, false, "internal to internal should not be filtered when internal scans are enabled"},
+		{internal, internalNever, true, "NeverInclude should override internal scans"},
+		{internal, external, false, "internal to external should not be filtered"},
+		{external, external, true, "external to external should be filtered"},
+	}
+
+	for _, test := range testCases {
+		output := p.filterPair(net.ParseIP(test.src), net.ParseIP(test.dst))
+		assert.Equal(t, test.out, output, test.msg)
+	}
+}
@@ -0,0 +1,127 @@
+package scan
+
+import (
+	"encoding/hex"
+	"sort"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	//Vertical scans probe many ports on a single host
+	Vertical = "vertical"
+	//Horizontal sweeps probe a single port across many hosts
+	Horizontal = "horizontal"
+)
+
+type (
+
+	// Repository for scan collection
+	Repository interface {
+		CreateIndexes() error
+		Upsert(scanMap map[string]*Input)
+	}
+
+	//update ....
+	update struct {
+		selector bson.M
+		query    bson.M
+	}
+
+	//chunk holds the statistics gathered for a scan in a single
+	//import batch. Chunks are stored in the dat array of each scan record
+	//so rolling datasets can be analyzed as a whole.
+	chunk struct {
+		Targets         []string `bson:"target_set"`
+		ConnectionCount int64    `bson:"count"`
+		FailedCount     int64    `bson:"failed"`
+		TsMin           int64    `bson:"ts_min"`
+		TsMax           int64    `bson:"ts_max"`
+		CID             int      `bson:"cid"`
+	}
+
+	//Result represents a vertical scan or horizontal sweep. Vertical scans
+	//have a destination and count ports as targets. Horizontal sweeps have
+	//a port and protocol and count destination hosts as targets.
+	Result struct {
+		Type             string `bson:"type"`
+		data.UniqueSrcIP `bson:",inline"`
+		data.UniqueDstIP `bson:",inline"`
+		Port             int     `bson:"port"`
+		Proto            string  `bson:"proto"`
+		TargetCount      int64   `bson:"target_count"`
+		ConnectionCount  int64   `bson:"connection_count"`
+		FailedCount      int64   `bson:"failed_count"`
+		FailedRatio      float64 `bson:"failed_ratio"`
+		Duration         int64   `bson:"duration"`
+		Rate             float64 `bson:"rate"`
+		Score            float64 `bson:"score"`
+	}
+
+	//Input structure for sending data to the analyzer. Vertical scans are
+	//keyed on a source and destination and track the set of ports probed.
+	//Horizontal sweeps are keyed on a source, port, and protocol and track the
+	//set of destinations probed. Both track the number of connection attempts,
+	//how many of those attempts failed, and when the scan started and stopped.
+	Input struct {
+		Type            string
+		Src             data.UniqueIP
+		Dst             data.UniqueIP
+		Port            int
+		Proto           string
+		Targets         map[string]struct{}
+		ConnectionCount int64
+		FailedCount     int64
+		TsMin           int64
+		TsMax           int64
+	}
+)
+
+//BSONKey generates a BSON map which may be used to index a given scan
+func (in *Input) BSONKey() bson.M {
+	key := bson.M{
+		"type":             in.Type,
+		"src":              in.Src.IP,
+		"src_network_uuid": in.Src.NetworkUUID,
+	}
+
+	if in.Type == Vertical {
+		key["dst"] = in.Dst.IP
+		key["dst_network_uuid"] = in.Dst.NetworkUUID
+	} else {
+		key["port"] = in.Port
+		key["proto"] = in.Proto
+	}
+	return key
+}
+
+//TargetKey returns the key a destination is tracked under by horizontal sweeps
+func TargetKey(dst data.UniqueIP) string {
+	return dst.IP + "/" + hex.EncodeToString(dst.NetworkUUID.Data)
+}
+
+//targetList returns the targets probed by a potential scan in a stable order
+func (in *Input) targetList() []string {
+	targets := make([]string, 0, len(in.Targets))
+	for target := range in.Targets {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+//Track records a connection attempt made as part of a potential scan
+func (in *Input) Track(ts int64, failed bool) {
+	if in.ConnectionCount == 0 || ts < in.TsMin {
+		in.TsMin = ts
+	}
+	if ts > in.TsMax {
+		in.TsMax = ts
+	}
+
+	in.ConnectionCount++
+	if failed {
+		in.FailedCount++
+	}
+}
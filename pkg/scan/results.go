@@ -0,0 +1,25 @@
+package scan
+
+import (
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//Results returns vertical scans and horizontal sweeps sorted by score.
+//limit and noLimit control how many results are returned.
+func Results(res *resources.Resources, limit int, noLimit bool) ([]Result, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var scanResults []Result
+
+	query := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.Scan.ScanTable).Find(bson.M{"score": bson.M{"$gt": 0}}).Sort("-score")
+
+	if !noLimit {
+		query = query.Limit(limit)
+	}
+
+	err := query.All(&scanResults)
+
+	return scanResults, err
+}
@@ -0,0 +1,67 @@
+package scan
+
+import (
+	"strconv"
+
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+)
+
+//Headers lists the columns printed by show-scans
+func Headers(showNetNames bool) []string {
+	headerFields := []string{"Score", "Type"}
+	if showNetNames {
+		headerFields = append(headerFields, "Source Network", "Source IP", "Destination Network", "Destination IP")
+	} else {
+		headerFields = append(headerFields, "Source IP", "Destination IP")
+	}
+	return append(headerFields,
+		"Port:Protocol", "Targets", "Connections", "Failed Ratio",
+		"Duration", "Targets Per Minute",
+	)
+}
+
+//Rows returns the scans printed by show-scans sorted by score
+func Rows(res *resources.Resources, limit int, noLimit bool, showNetNames bool) ([]analysis.Row, error) {
+	data, err := Results(res, limit, noLimit)
+
+	var rows []analysis.Row
+	for _, d := range data {
+		rows = append(rows, analysis.Row{
+			Cells: row(d, showNetNames),
+			Match: suppression.Finding{Src: d.SrcIP, Dst: d.DstIP},
+		})
+	}
+	return rows, err
+}
+
+func row(d Result, showNetNames bool) []string {
+	// vertical scans target ports on a single destination while
+	// horizontal sweeps target destinations on a single port
+	dst := d.DstIP
+	dstNetwork := d.DstNetworkName
+	portProto := strconv.Itoa(d.Port) + ":" + d.Proto
+	if d.Type == Vertical {
+		portProto = "*"
+	} else {
+		dst = "*"
+		dstNetwork = "*"
+	}
+
+	row := []string{formatFloat(d.Score), d.Type}
+	if showNetNames {
+		row = append(row, d.SrcNetworkName, d.SrcIP, dstNetwork, dst)
+	} else {
+		row = append(row, d.SrcIP, dst)
+	}
+	return append(row,
+		portProto, strconv.FormatInt(d.TargetCount, 10), strconv.FormatInt(d.ConnectionCount, 10),
+		formatFloat(d.FailedRatio), strconv.FormatInt(d.Duration, 10), formatFloat(d.Rate),
+	)
+}
+
+//formatFloat formats a float the same way as the other show commands
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', 6, 64)
+}
@@ -0,0 +1,176 @@
+package suppression
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/activecm/rita/database"
+)
+
+type (
+	//Finding describes a result from an analysis module in terms of the
+	//criteria a suppression may match on. Fields which don't apply to a
+	//module are left empty.
+	Finding struct {
+		Src       string
+		Dst       string
+		FQDN      string
+		UserAgent string
+		JA3       string
+	}
+
+	//Matcher checks findings against the active suppressions
+	Matcher struct {
+		rules  []rule
+		hidden int
+	}
+
+	//rule is a parsed suppression
+	rule struct {
+		src       *net.IPNet
+		dst       *net.IPNet
+		fqdn      string
+		useragent string
+		ja3       string
+	}
+)
+
+//UserAgentFinding describes a user agent, or a JA3 hash if ja3 is set,
+//which is stored alongside user agents
+func UserAgentFinding(signature string, ja3 bool) Finding {
+	if ja3 {
+		return Finding{JA3: signature}
+	}
+	return Finding{UserAgent: signature}
+}
+
+//NewMatcher creates a Matcher from the suppressions which have not expired
+//as of now. Suppressions with criteria which can't be parsed are skipped.
+func NewMatcher(suppressions []database.Suppression, now time.Time) *Matcher {
+	m := &Matcher{}
+	for _, s := range suppressions {
+		if !s.Expires.After(now) {
+			continue
+		}
+
+		r := rule{
+			fqdn:      normalizeFQDN(s.FQDN),
+			useragent: s.UserAgent,
+			ja3:       strings.ToLower(s.JA3),
+		}
+		var err error
+		if s.SrcCIDR != "" {
+			if r.src, err = ParseCIDR(s.SrcCIDR); err != nil {
+				continue
+			}
+		}
+		if s.DstCIDR != "" {
+			if r.dst, err = ParseCIDR(s.DstCIDR); err != nil {
+				continue
+			}
+		}
+		m.rules = append(m.rules, r)
+	}
+	return m
+}
+
+//Suppressed returns true if any active suppression matches the finding.
+//A nil Matcher suppresses nothing.
+func (m *Matcher) Suppressed(f Finding) bool {
+	if m == nil {
+		return false
+	}
+	for _, r := range m.rules {
+		if r.matches(f) {
+			m.hidden++
+			return true
+		}
+	}
+	return false
+}
+
+//Hidden returns how many findings have been suppressed by the Matcher
+func (m *Matcher) Hidden() int {
+	if m == nil {
+		return 0
+	}
+	return m.hidden
+}
+
+//matches returns true if every criteria set on the rule matches the finding
+func (r rule) matches(f Finding) bool {
+	if r.src != nil && !containsIP(r.src, f.Src) {
+		return false
+	}
+	if r.dst != nil && !containsIP(r.dst, f.Dst) {
+		return false
+	}
+	if r.fqdn != "" {
+		fqdn := normalizeFQDN(f.FQDN)
+		if fqdn != r.fqdn && !strings.HasSuffix(fqdn, "."+r.fqdn) {
+			return false
+		}
+	}
+	if r.useragent != "" && f.UserAgent != r.useragent {
+		return false
+	}
+	if r.ja3 != "" && strings.ToLower(f.JA3) != r.ja3 {
+		return false
+	}
+	return true
+}
+
+//containsIP returns true if ipStr is a valid IP address within network
+func containsIP(network *net.IPNet, ipStr string) bool {
+	ip := net.ParseIP(ipStr)
+	return ip != nil && network.Contains(ip)
+}
+
+//normalizeFQDN lowercases a domain and strips wildcard and root labels
+func normalizeFQDN(fqdn string) string {
+	fqdn = strings.ToLower(strings.TrimSpace(fqdn))
+	fqdn = strings.TrimPrefix(fqdn, "*.")
+	return strings.TrimSuffix(fqdn, ".")
+}
+
+//ParseCIDR parses a CIDR range. A single IP address is treated as a range
+//containing only that address.
+func ParseCIDR(cidr string) (*net.IPNet, error) {
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, errors.New("invalid IP address or CIDR range: " + cidr)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(cidr)
+	return network, err
+}
+
+//ParseExpiry parses when a suppression should expire. Dates (2006-01-02),
+//RFC 3339 timestamps, and durations from now such as 72h or 30d are accepted.
+func ParseExpiry(expiry string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", expiry, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, expiry); err == nil {
+		return t, nil
+	}
+	if strings.HasSuffix(expiry, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(expiry, "d"))
+		if err == nil && days > 0 {
+			return now.AddDate(0, 0, days), nil
+		}
+	} else if d, err := time.ParseDuration(expiry); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+	return time.Time{}, errors.New("invalid expiry: " + expiry)
+}
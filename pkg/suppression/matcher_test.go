@@ -0,0 +1,67 @@
+package suppression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/activecm/rita/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcherSuppressed(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	active := now.Add(time.Hour)
+
+	m := NewMatcher([]database.Suppression{
+		{SrcCIDR: "10.0.0.0/8", DstCIDR: "203.0.113.5", Expires: active},
+		{FQDN: "*.update.example.com", Expires: active},
+		{JA3: "ABCDEF", Expires: active},
+		{UserAgent: "telemetry/1.0", SrcCIDR: "192.168.1.0/24", Expires: active},
+		{FQDN: "expired.com", Expires: now},
+		{SrcCIDR: "not a range", Expires: active},
+	}, now)
+
+	require.True(t, m.Suppressed(Finding{Src: "10.1.2.3", Dst: "203.0.113.5"}))
+	require.False(t, m.Suppressed(Finding{Src: "10.1.2.3", Dst: "203.0.113.6"}))
+	require.False(t, m.Suppressed(Finding{Src: "10.1.2.3"}))
+
+	require.True(t, m.Suppressed(Finding{FQDN: "update.example.com"}))
+	require.True(t, m.Suppressed(Finding{Src: "1.2.3.4", FQDN: "CDN.Update.Example.com."}))
+	require.False(t, m.Suppressed(Finding{FQDN: "notupdate.example.com"}))
+
+	require.True(t, m.Suppressed(Finding{JA3: "abcdef"}))
+
+	require.True(t, m.Suppressed(Finding{Src: "192.168.1.7", UserAgent: "telemetry/1.0"}))
+	require.False(t, m.Suppressed(Finding{Src: "192.168.2.7", UserAgent: "telemetry/1.0"}))
+
+	require.False(t, m.Suppressed(Finding{FQDN: "expired.com"}))
+	require.Equal(t, 5, m.Hidden())
+
+	var none *Matcher
+	require.False(t, none.Suppressed(Finding{Src: "10.1.2.3", Dst: "203.0.113.5"}))
+	require.Equal(t, 0, none.Hidden())
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	expiry, err := ParseExpiry("30d", now)
+	require.NoError(t, err)
+	require.Equal(t, now.AddDate(0, 0, 30), expiry)
+
+	expiry, err = ParseExpiry("12h", now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(12*time.Hour), expiry)
+
+	expiry, err = ParseExpiry("2020-12-31T00:00:00Z", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC), expiry)
+
+	_, err = ParseExpiry("2020-12-31", now)
+	require.NoError(t, err)
+
+	for _, invalid := range []string{"", "-5d", "soon", "0h"} {
+		_, err = ParseExpiry(invalid, now)
+		require.Error(t, err, invalid)
+	}
+}
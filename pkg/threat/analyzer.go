@@ -0,0 +1,155 @@
+package threat
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/util"
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+	//analyzer : structure for threat analysis
+	analyzer struct {
+		chunk            int                // current chunk (0 if not on rolling analysis)
+		tsMax            int64              // max timestamp for the whole dataset
+		weights          map[string]float64 // weight of each module's findings
+		analyzedCallback func(*update)      // called on each analyzed result
+		closedCallback   func()             // called when .close() is called and no more calls to analyzedCallback will be made
+		analysisChannel  chan *Input        // holds unanalyzed data
+		analysisWg       sync.WaitGroup     // wait for analysis to finish
+	}
+)
+
+//newAnalyzer creates a new collector for scoring internal hosts
+func newAnalyzer(chunk int, tsMax int64, weights map[string]float64, analyzedCallback func(*update), closedCallback func()) *analyzer {
+	return &analyzer{
+		chunk:            chunk,
+		tsMax:            tsMax,
+		weights:          weights,
+		analyzedCallback: analyzedCallback,
+		closedCallback:   closedCallback,
+		analysisChannel:  make(chan *Input),
+	}
+}
+
+//collect sends a host's findings to be analyzed
+func (a *analyzer) collect(data *Input) {
+	a.analysisChannel <- data
+}
+
+//close waits for the collector to finish
+func (a *analyzer) close() {
+	close(a.analysisChannel)
+	a.analysisWg.Wait()
+	a.closedCallback()
+}
+
+//start kicks off a new analysis thread
+func (a *analyzer) start() {
+	a.analysisWg.Add(1)
+	go func() {
+		for entry := range a.analysisChannel {
+			a.analyzedCallback(&update{
+				selector: entry.Host.BSONKey(),
+				query:    a.query(entry),
+			})
+		}
+		a.analysisWg.Done()
+	}()
+}
+
+//query scores a host's findings and builds the update for its record
+func (a *analyzer) query(entry *Input) bson.M {
+	findings := summarize(entry.Findings, a.weights)
+	score := scoreFindings(findings, a.weights)
+
+	return bson.M{
+		"$set": bson.M{
+			"network_name": entry.Host.NetworkName,
+			"score":        score,
+			"findings":     findings,
+			"cid":          a.chunk,
+
+			// keep the score from each chunk to show how it changes
+			data.HistoryField(a.chunk): data.HistoryEntry{Score: score, TS: a.tsMax},
+		},
+	}
+}
+
+//clearQuery returns the update for a stored host which no longer has any
+//findings. The host's score drops to zero while its score history is kept
+//so the trend continues if the host is flagged again. A nil query means the
+//host has no score left in its history and should be removed.
+func clearQuery(history data.ScoreHistory, chunk int, tsMax int64) bson.M {
+	current := strconv.Itoa(chunk)
+	flagged := false
+	for cid, past := range history {
+		if cid != current && past.Score > 0 {
+			flagged = true
+			break
+		}
+	}
+	if !flagged {
+		return nil
+	}
+
+	return bson.M{
+		"$set": bson.M{
+			"score":                  0.0,
+			"findings":               []Finding{},
+			"cid":                    chunk,
+			data.HistoryField(chunk): data.HistoryEntry{Score: 0, TS: tsMax},
+		},
+	}
+}
+
+//summarize keeps the highest scoring finding from each module, counting how
+//many findings the module reported. The summary is ordered by how much each
+//module contributes to the threat score.
+func summarize(findings []Finding, weights map[string]float64) []Finding {
+	best := make(map[string]*Finding)
+	for i := range findings {
+		f := findings[i]
+		if b, ok := best[f.Module]; ok {
+			b.Count += f.Count
+			if f.Score > b.Score {
+				b.Score, b.Detail = f.Score, f.Detail
+			}
+			continue
+		}
+		best[f.Module] = &f
+	}
+
+	var summary []Finding
+	for _, f := range best {
+		summary = append(summary, *f)
+	}
+
+	sort.Slice(summary, func(i, j int) bool {
+		ci := util.Clamp(weights[summary[i].Module]) * summary[i].Score
+		cj := util.Clamp(weights[summary[j].Module]) * summary[j].Score
+		if ci != cj {
+			return ci > cj
+		}
+		return summary[i].Module < summary[j].Module
+	})
+
+	return summary
+}
+
+//scoreFindings combines the findings from each module into a single score.
+//Each module's weighted score is treated as an independent likelihood that
+//the host is compromised, so a single strong finding ranks a host highly and
+//every additional finding raises the score further without exceeding 1.
+func scoreFindings(summary []Finding, weights map[string]float64) float64 {
+	benign := 1.0
+	for _, f := range summary {
+		benign *= 1 - util.Clamp(weights[f.Module])*util.Clamp(f.Score)
+	}
+
+	return math.Ceil((1-benign)*1000) / 1000
+}
@@ -0,0 +1,119 @@
+package threat
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/require"
+)
+
+var testWeights = map[string]float64{
+	"beacon":         1.0,
+	"blacklisted":    1.0,
+	"rare_signature": 0.3,
+	"scan":           0.5,
+}
+
+func TestSummarize(t *testing.T) {
+	findings := []Finding{
+		{Module: "scan", Score: 0.9, Detail: "scan", Count: 1},
+		{Module: "beacon", Score: 0.6, Detail: "1.1.1.1", Count: 1},
+		{Module: "beacon", Score: 0.8, Detail: "2.2.2.2", Count: 1},
+		{Module: "rare_signature", Score: 1, Detail: "curl", Count: 1},
+	}
+
+	summary := summarize(findings, testWeights)
+
+	// one finding per module, ordered by weighted contribution
+	require.Len(t, summary, 3)
+	require.Equal(t, Finding{Module: "beacon", Score: 0.8, Detail: "2.2.2.2", Count: 2}, summary[0])
+	require.Equal(t, "scan", summary[1].Module)
+	require.Equal(t, "rare_signature", summary[2].Module)
+}
+
+func TestScoreFindings(t *testing.T) {
+	single := scoreFindings([]Finding{{Module: "beacon", Score: 0.8}}, testWeights)
+	require.InDelta(t, 0.8, single, 0.002)
+
+	// additional findings raise the score
+	combined := scoreFindings([]Finding{
+		{Module: "beacon", Score: 0.8},
+		{Module: "rare_signature", Score: 1},
+	}, testWeights)
+	require.True(t, combined > single)
+	require.True(t, combined <= 1)
+
+	// modules without a weight do not contribute
+	unweighted := scoreFindings([]Finding{{Module: "dga", Score: 1}}, testWeights)
+	require.Equal(t, 0.0, unweighted)
+
+	// a blacklisted finding at full weight dominates
+	blacklisted := scoreFindings([]Finding{{Module: "blacklisted", Score: 1}}, testWeights)
+	require.Equal(t, 1.0, blacklisted)
+}
+
+func TestClearQuery(t *testing.T) {
+	// a host flagged in an earlier chunk keeps its history with a zero score
+	history := data.ScoreHistory{
+		"0": data.HistoryEntry{Score: 0.7, TS: 100},
+		"1": data.HistoryEntry{Score: 0.9, TS: 200},
+	}
+	query := clearQuery(history, 1, 300)
+	require.NotNil(t, query)
+	set := query["$set"].(bson.M)
+	require.Equal(t, 0.0, set["score"])
+	require.Equal(t, data.HistoryEntry{Score: 0, TS: 300}, set[data.HistoryField(1)])
+
+	// only the current chunk scored the host, so there is no trend to keep
+	require.Nil(t, clearQuery(data.ScoreHistory{"1": data.HistoryEntry{Score: 0.9, TS: 200}}, 1, 300))
+	require.Nil(t, clearQuery(data.ScoreHistory{"0": data.HistoryEntry{Score: 0, TS: 100}}, 1, 300))
+}
+
+func TestClearStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rita-threat")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	session, err := database.NewEmbeddedSession(filepath.Join(dir, "rita.db"), 0)
+	require.NoError(t, err)
+	defer session.Close()
+	coll := session.DB("test").C("threat")
+
+	flagged := data.UniqueIP{IP: "10.0.0.1"}
+	returning := data.UniqueIP{IP: "10.0.0.2"}
+	dropped := data.UniqueIP{IP: "10.0.0.3"}
+
+	// score the hosts in chunk 0, then only the first host in chunk 1
+	a := newAnalyzer(0, 100, testWeights, nil, nil)
+	for _, host := range []data.UniqueIP{flagged, returning} {
+		_, err = coll.Upsert(host.BSONKey(), a.query(&Input{Host: host, Findings: []Finding{{Module: "beacon", Score: 0.8, Count: 1}}}))
+		require.NoError(t, err)
+	}
+	a = newAnalyzer(1, 200, testWeights, nil, nil)
+	for _, host := range []data.UniqueIP{flagged, dropped} {
+		_, err = coll.Upsert(host.BSONKey(), a.query(&Input{Host: host, Findings: []Finding{{Module: "beacon", Score: 0.8, Count: 1}}}))
+		require.NoError(t, err)
+	}
+
+	// chunk 1 is rescored after a suppression hides the dropped host's findings
+	threatMap := map[string]*Input{flagged.MapKey(): {Host: flagged}}
+	require.NoError(t, clearStale(coll, threatMap, 1, 200))
+
+	var stored []Result
+	require.NoError(t, coll.Find(nil).Sort("ip").All(&stored))
+	require.Len(t, stored, 2)
+
+	// the host flagged in chunk 0 keeps its history
+	require.Equal(t, returning.IP, stored[1].IP)
+	require.Equal(t, 0.0, stored[1].Score)
+	require.Empty(t, stored[1].Findings)
+	require.Equal(t, []float64{0.8, 0}, stored[1].History.Trend().Scores)
+
+	// the host which was only ever flagged in the current chunk is removed
+	require.Equal(t, flagged.IP, stored[0].IP)
+}
@@ -0,0 +1,83 @@
+package threat
+
+import (
+	"net"
+	"time"
+
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/resources"
+	"github.com/activecm/rita/util"
+	log "github.com/sirupsen/logrus"
+)
+
+type collector struct {
+	res        *resources.Resources
+	internal   []*net.IPNet
+	suppressed *suppression.Matcher
+	threatMap  map[string]*Input
+}
+
+//Findings gathers the findings of every enabled analysis module from the
+//selected database and groups them by the internal host they implicate.
+//Findings hidden by an active suppression are left out.
+func Findings(res *resources.Resources) map[string]*Input {
+	c := &collector{
+		res:       res,
+		internal:  util.ParseSubnets(res.Config.S.Filtering.InternalSubnets),
+		threatMap: make(map[string]*Input),
+	}
+
+	suppressions, err := res.MetaDB.GetSuppressions()
+	if err != nil {
+		c.logError("suppressions", err)
+	}
+	c.suppressed = suppression.NewMatcher(suppressions, time.Now())
+
+	for _, module := range analysis.Modules(res.Config) {
+		if !module.ReportsFindings() {
+			continue
+		}
+		// a module failing part way through still reports what it gathered
+		findings, err := module.Findings(res)
+		if err != nil {
+			c.logError(module.Name(), err)
+		}
+		for _, finding := range findings {
+			c.add(finding)
+		}
+	}
+
+	return c.threatMap
+}
+
+//add records a finding against its host if the host is internal and the
+//finding isn't suppressed
+func (c *collector) add(finding analysis.Finding) {
+	host := finding.Host
+	if !util.ContainsIP(c.internal, net.ParseIP(host.IP)) || c.suppressed.Suppressed(finding.Match) {
+		return
+	}
+
+	key := host.MapKey()
+	if _, ok := c.threatMap[key]; !ok {
+		c.threatMap[key] = &Input{Host: host}
+	}
+
+	c.threatMap[key].Findings = append(c.threatMap[key].Findings, Finding{
+		Module: finding.Type,
+		Score:  util.Clamp(finding.Score),
+		Detail: finding.Detail,
+		Count:  1,
+	})
+}
+
+//logError records a failure to gather findings from a module. The remaining
+//modules are still used to score each host.
+func (c *collector) logError(module string, err error) {
+	c.res.Log.WithFields(log.Fields{
+		"Module":  "threat",
+		"Source":  module,
+		"Message": "failed to gather findings",
+	}).Error(err)
+}
@@ -0,0 +1,39 @@
+package threat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectorSkipsSuppressed(t *testing.T) {
+	now := time.Now()
+	c := &collector{
+		internal: util.ParseSubnets([]string{"10.0.0.0/8"}),
+		suppressed: suppression.NewMatcher([]database.Suppression{
+			{FQDN: "updates.example.com", Expires: now.Add(time.Hour)},
+		}, now),
+		threatMap: make(map[string]*Input),
+	}
+
+	host := data.UniqueIP{IP: "10.0.0.1"}
+	c.add(analysis.Finding{Host: host, Type: "beacon_fqdn", Score: 0.9, Detail: "updates.example.com",
+		Match: suppression.Finding{Src: host.IP, FQDN: "cdn.updates.example.com"}})
+	c.add(analysis.Finding{Host: host, Type: "beacon_fqdn", Score: 0.7, Detail: "c2.example.net",
+		Match: suppression.Finding{Src: host.IP, FQDN: "c2.example.net"}})
+
+	// external hosts are never scored
+	c.add(analysis.Finding{Host: data.UniqueIP{IP: "203.0.113.1"}, Type: "scan", Score: 1, Detail: "scan",
+		Match: suppression.Finding{Src: "203.0.113.1"}})
+
+	require.Len(t, c.threatMap, 1)
+	findings := c.threatMap[host.MapKey()].Findings
+	require.Len(t, findings, 1)
+	require.Equal(t, "c2.example.net", findings[0].Detail)
+}
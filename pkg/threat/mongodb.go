@@ -0,0 +1,155 @@
+package threat
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/activecm/rita/database"
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/resources"
+	"github.com/activecm/rita/util"
+	"github.com/globalsign/mgo/bson"
+	log "github.com/sirupsen/logrus"
+	"github.com/vbauerster/mpb"
+	"github.com/vbauerster/mpb/decor"
+)
+
+type repo struct {
+	res *resources.Resources
+}
+
+//NewMongoRepository create new repository
+func NewMongoRepository(res *resources.Resources) Repository {
+	return &repo{
+		res: res,
+	}
+}
+
+//CreateIndexes ....
+func (r *repo) CreateIndexes() error {
+	session := r.res.DB.Session.Copy()
+	defer session.Close()
+
+	// set collection name
+	collectionName := r.res.Config.T.Threat.ThreatTable
+
+	// check if collection already exists
+	names, _ := session.DB(r.res.DB.GetSelectedDB()).CollectionNames()
+
+	// if collection exists, we don't need to do anything else
+	for _, name := range names {
+		if name == collectionName {
+			return nil
+		}
+	}
+
+	// set desired indexes
+	indexes := []database.Index{
+		{Key: []string{"ip", "network_uuid"}, Unique: true},
+		{Key: []string{"-score"}},
+	}
+
+	// create collection
+	err := r.res.DB.CreateCollection(collectionName, indexes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//Upsert scores every internal host with findings. The findings are gathered
+//from the whole dataset, so each host's score and findings are replaced while
+//its score history is extended. Hosts which no longer have findings are
+//cleared afterwards.
+func (r *repo) Upsert(threatMap map[string]*Input) {
+	chunk := r.res.Config.S.Rolling.CurrentChunk
+	_, tsMax, _ := r.res.MetaDB.GetTSRange(r.res.DB.GetSelectedDB())
+
+	//Create the workers
+	writerWorker := newWriter(r.res.Config.T.Threat.ThreatTable, r.res.DB, r.res.Config, r.res.Log)
+
+	analyzerWorker := newAnalyzer(
+		chunk,
+		tsMax,
+		analysis.Weights(r.res.Config),
+		writerWorker.collect,
+		writerWorker.close,
+	)
+
+	//kick off the threaded goroutines
+	for i := 0; i < util.Max(1, runtime.NumCPU()/2); i++ {
+		analyzerWorker.start()
+		writerWorker.start()
+	}
+
+	// progress bar for troubleshooting
+	p := mpb.New(mpb.WithWidth(20))
+	bar := p.AddBar(int64(len(threatMap)),
+		mpb.PrependDecorators(
+			decor.Name("\t[-] Threat Analysis:", decor.WC{W: 30, C: decor.DidentRight}),
+			decor.CountersNoUnit(" %d / %d ", decor.WCSyncWidth),
+		),
+		mpb.AppendDecorators(decor.Percentage()),
+	)
+
+	// loop over map entries
+	for _, entry := range threatMap {
+		start := time.Now()
+		analyzerWorker.collect(entry)
+		bar.IncrBy(1, time.Since(start))
+	}
+
+	p.Wait()
+
+	// start the closing cascade (this will also close the other channels)
+	analyzerWorker.close()
+
+	session := r.res.DB.Session.Copy()
+	defer session.Close()
+	coll := session.DB(r.res.DB.GetSelectedDB()).C(r.res.Config.T.Threat.ThreatTable)
+
+	if err := clearStale(coll, threatMap, chunk, tsMax); err != nil {
+		r.res.Log.WithFields(log.Fields{
+			"Module":  "threat",
+			"Message": "failed to clear hosts without findings",
+		}).Error(err)
+	}
+}
+
+//clearStale updates the stored hosts which are missing from threatMap since
+//they no longer have any findings
+func clearStale(coll database.Collection, threatMap map[string]*Input, chunk int, tsMax int64) error {
+	type storedHost struct {
+		data.UniqueIP `bson:",inline"`
+		History       data.ScoreHistory `bson:"history"`
+	}
+
+	var stale []storedHost
+	var stored storedHost
+	iter := coll.Find(nil).Select(bson.M{"ip": 1, "network_uuid": 1, "history": 1}).Iter()
+	for iter.Next(&stored) {
+		if _, ok := threatMap[stored.UniqueIP.MapKey()]; !ok {
+			stale = append(stale, stored)
+		}
+		stored = storedHost{}
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	for _, host := range stale {
+		query := clearQuery(host.History, chunk, tsMax)
+		var err error
+		if query == nil {
+			err = coll.Remove(host.UniqueIP.BSONKey())
+		} else {
+			err = coll.Update(host.UniqueIP.BSONKey(), query)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package threat
+
+import (
+	"github.com/activecm/rita/pkg/data"
+	"github.com/globalsign/mgo/bson"
+)
+
+type (
+
+	// Repository for threat collection
+	Repository interface {
+		CreateIndexes() error
+		Upsert(threatMap map[string]*Input)
+	}
+
+	//update ....
+	update struct {
+		selector bson.M
+		query    bson.M
+	}
+
+	//Finding is a single result from another analysis module which implicates
+	//an internal host. Score is the module's own score scaled to 0-1 and Count
+	//is the number of findings the module reported for the host.
+	Finding struct {
+		Module string  `bson:"module"`
+		Score  float64 `bson:"score"`
+		Detail string  `bson:"detail"`
+		Count  int64   `bson:"count"`
+	}
+
+	//Result represents an internal host, its composite threat score, and the
+	//strongest finding from each module which contributed to the score
+	Result struct {
+		data.UniqueIP `bson:",inline"`
+		Score         float64           `bson:"score"`
+		Findings      []Finding         `bson:"findings"`
+		History       data.ScoreHistory `bson:"history"`
+	}
+
+	//Input structure for sending data to the analyzer. Holds every finding
+	//reported against an internal host.
+	Input struct {
+		Host     data.UniqueIP
+		Findings []Finding
+	}
+)
@@ -0,0 +1,26 @@
+package threat
+
+import (
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+)
+
+//Results returns internal hosts sorted by their composite threat score,
+//along with the findings which contributed to each score.
+//limit and noLimit control how many results are returned.
+func Results(res *resources.Resources, limit int, noLimit bool) ([]Result, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var threatResults []Result
+
+	query := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.Threat.ThreatTable).Find(bson.M{"score": bson.M{"$gt": 0}}).Sort("-score")
+
+	if !noLimit {
+		query = query.Limit(limit)
+	}
+
+	err := query.All(&threatResults)
+
+	return threatResults, err
+}
@@ -68,6 +68,10 @@ func (a *analyzer) start() {
 				datum.Tuples = datum.Tuples[:5]
 			}
 
+			if len(datum.UnexpectedTuples) > 5 {
+				datum.UnexpectedTuples = datum.UnexpectedTuples[:5]
+			}
+
 			// if this connection qualifies to be a strobe with the current number
 			// of connections in the current datum, don't store bytes and ts.
 			// it will not qualify to be downgraded to a beacon until this chunk is
@@ -81,15 +85,18 @@ func (a *analyzer) start() {
 				}
 				query["$push"] = bson.M{
 					"dat": bson.M{
-						"count":  datum.ConnectionCount,
-						"bytes":  []interface{}{},
-						"ts":     []interface{}{},
-						"tuples": datum.Tuples,
-						"icerts": datum.InvalidCertFlag,
-						"maxdur": datum.MaxDuration,
-						"tbytes": datum.TotalBytes,
-						"tdur":   datum.TotalDuration,
-						"cid":    a.chunk,
+						"count":   datum.ConnectionCount,
+						"bytes":   []interface{}{},
+						"ts":      []interface{}{},
+						"tuples":  datum.Tuples,
+						"utuples": datum.UnexpectedTuples,
+						"icerts":  datum.InvalidCertFlag,
+						"maxdur":  datum.MaxDuration,
+						"tbytes":  datum.TotalBytes,
+						"obytes":  datum.OrigBytes,
+						"rbytes":  datum.RespBytes,
+						"tdur":    datum.TotalDuration,
+						"cid":     a.chunk,
 					},
 				}
 			} else {
@@ -100,15 +107,18 @@ func (a *analyzer) start() {
 				}
 				query["$push"] = bson.M{
 					"dat": bson.M{
-						"count":  datum.ConnectionCount,
-						"bytes":  datum.OrigBytesList,
-						"ts":     datum.TsList,
-						"tuples": datum.Tuples,
-						"icerts": datum.InvalidCertFlag,
-						"maxdur": datum.MaxDuration,
-						"tbytes": datum.TotalBytes,
-						"tdur":   datum.TotalDuration,
-						"cid":    a.chunk,
+						"count":   datum.ConnectionCount,
+						"bytes":   datum.OrigBytesList,
+						"ts":      datum.TsList,
+						"tuples":  datum.Tuples,
+						"utuples": datum.UnexpectedTuples,
+						"icerts":  datum.InvalidCertFlag,
+						"maxdur":  datum.MaxDuration,
+						"tbytes":  datum.TotalBytes,
+						"obytes":  datum.OrigBytes,
+						"rbytes":  datum.RespBytes,
+						"tdur":    datum.TotalDuration,
+						"cid":     a.chunk,
 					},
 				}
 			}
@@ -30,11 +30,15 @@ type Input struct {
 	IsLocalSrc      bool
 	IsLocalDst      bool
 	TotalBytes      int64
+	OrigBytes       int64
+	RespBytes       int64
 	MaxDuration     float64
 	TotalDuration   float64
 	TsList          []int64
 	OrigBytesList   []int64
 	Tuples          []string
+	// UnexpectedTuples holds the tuples whose service was not expected on the port
+	UnexpectedTuples []string
 	// InvalidCerts    []string
 	InvalidCertFlag bool
 	UPPSFlag        bool
@@ -47,3 +51,12 @@ type LongConnResult struct {
 	MaxDuration       float64  `bson:"maxdur"`
 	Tuples            []string `bson:"tuples"`
 }
+
+//UnexpectedServiceResult represents a pair of hosts that communicated using
+//a service on a port and protocol where it was not expected.
+type UnexpectedServiceResult struct {
+	data.UniqueIPPair `bson:",inline"`
+	ConnectionCount   int64    `bson:"connection_count"`
+	UnexpectedTuples  []string `bson:"unexpected_tuples"`
+	Tuples            []string `bson:"tuples"`
+}
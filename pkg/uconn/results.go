@@ -1,6 +1,7 @@
 package uconn
 
 import (
+	"github.com/activecm/rita/pkg/data"
 	"github.com/activecm/rita/resources"
 	"github.com/globalsign/mgo/bson"
 )
@@ -62,3 +63,99 @@ func LongConnResults(res *resources.Resources, thresh int, limit int, noLimit bo
 	return longConnResults, err
 
 }
+
+//UnexpectedServiceResults returns pairs of hosts which communicated using a
+//service on a port and protocol where it was not expected. The results will be
+//sorted, descending by connection count.
+//limit and noLimit control how many results are returned.
+func UnexpectedServiceResults(res *resources.Resources, limit int, noLimit bool) ([]UnexpectedServiceResult, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	var unexpectedResults []UnexpectedServiceResult
+
+	// merges the tuple lists stored in each chunk into a single set
+	mergeTuples := func(field string) bson.M {
+		return bson.M{
+			"$reduce": bson.M{
+				"input":        field,
+				"initialValue": []interface{}{},
+				"in": bson.M{"$setUnion": []interface{}{
+					"$$value",
+					bson.M{"$ifNull": []interface{}{"$$this", []interface{}{}}},
+				}},
+			},
+		}
+	}
+
+	unexpectedQuery := []bson.M{
+		{"$match": bson.M{"dat.utuples.0": bson.M{"$exists": true}}},
+		{"$project": bson.M{
+			"src":               1,
+			"src_network_uuid":  1,
+			"src_network_name":  1,
+			"dst":               1,
+			"dst_network_uuid":  1,
+			"dst_network_name":  1,
+			"connection_count":  bson.M{"$sum": "$dat.count"},
+			"unexpected_tuples": mergeTuples("$dat.utuples"),
+			"tuples":            mergeTuples("$dat.tuples"),
+		}},
+		{"$sort": bson.M{"connection_count": -1}},
+	}
+
+	if !noLimit {
+		unexpectedQuery = append(unexpectedQuery, bson.M{"$limit": limit})
+	}
+
+	err := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.Structure.UniqueConnTable).Pipe(unexpectedQuery).AllowDiskUse().All(&unexpectedResults)
+
+	return unexpectedResults, err
+}
+
+//StoredInputs loads the host pair and connection totals of every uconn in
+//the dataset so the modules built from them can be re-analyzed. The
+//timestamps and bytes lists are left in the collection since the analyzers
+//which need them query it directly.
+func StoredInputs(res *resources.Resources) (map[string]*Input, error) {
+	ssn := res.DB.Session.Copy()
+	defer ssn.Close()
+
+	storedQuery := []bson.M{
+		bson.M{"$project": bson.M{
+			"_id":              0,
+			"src":              1,
+			"src_network_uuid": 1,
+			"src_network_name": 1,
+			"dst":              1,
+			"dst_network_uuid": 1,
+			"dst_network_name": 1,
+			"count":            bson.M{"$sum": "$dat.count"},
+			"tbytes":           bson.M{"$sum": "$dat.tbytes"},
+			"obytes":           bson.M{"$sum": "$dat.obytes"},
+			"rbytes":           bson.M{"$sum": "$dat.rbytes"},
+		}},
+	}
+
+	var stored struct {
+		data.UniqueIPPair `bson:",inline"`
+		Count             int64 `bson:"count"`
+		TBytes            int64 `bson:"tbytes"`
+		OBytes            int64 `bson:"obytes"`
+		RBytes            int64 `bson:"rbytes"`
+	}
+
+	uconnMap := make(map[string]*Input)
+	iter := ssn.DB(res.DB.GetSelectedDB()).C(res.Config.T.Structure.UniqueConnTable).Pipe(storedQuery).AllowDiskUse().Iter()
+	for iter.Next(&stored) {
+		uconnMap[stored.UniqueIPPair.MapKey()] = &Input{
+			Hosts:           stored.UniqueIPPair,
+			ConnectionCount: stored.Count,
+			TotalBytes:      stored.TBytes,
+			OrigBytes:       stored.OBytes,
+			RespBytes:       stored.RBytes,
+		}
+	}
+
+	return uconnMap, iter.Close()
+}
@@ -0,0 +1,90 @@
+package uconn
+
+import (
+	"github.com/activecm/rita/resources"
+	"github.com/globalsign/mgo/bson"
+	log "github.com/sirupsen/logrus"
+)
+
+//UpdateTimestampRange records the earliest and latest connection timestamps
+//in the dataset in the MetaDB
+func UpdateTimestampRange(res *resources.Resources) {
+	session := res.DB.Session.Copy()
+	defer session.Close()
+
+	// set collection name
+	collectionName := res.Config.T.Structure.UniqueConnTable
+
+	// check if collection already exists
+	names, _ := session.DB(res.DB.GetSelectedDB()).CollectionNames()
+
+	exists := false
+	// make sure collection exists
+	for _, name := range names {
+		if name == collectionName {
+			exists = true
+			break
+		}
+	}
+
+	if !exists {
+		return
+	}
+
+	// Build query for aggregation
+	timestampMinQuery := []bson.M{
+		{"$project": bson.M{"_id": 0, "ts": "$dat.ts"}},
+		{"$unwind": "$ts"},
+		{"$unwind": "$ts"}, // Not an error, must unwind it twice
+		{"$sort": bson.M{"ts": 1}},
+		{"$limit": 1},
+	}
+
+	var resultMin struct {
+		Timestamp int64 `bson:"ts"`
+	}
+
+	// get iminimum timestamp
+	// sort by the timestamp, limit it to 1 (only returns first result)
+	err := session.DB(res.DB.GetSelectedDB()).C(collectionName).Pipe(timestampMinQuery).AllowDiskUse().One(&resultMin)
+
+	if err != nil {
+		res.Log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Could not retrieve minimum timestamp:", err)
+		return
+	}
+
+	// Build query for aggregation
+	timestampMaxQuery := []bson.M{
+		{"$project": bson.M{"_id": 0, "ts": "$dat.ts"}},
+		{"$unwind": "$ts"},
+		{"$unwind": "$ts"}, // Not an error, must unwind it twice
+		{"$sort": bson.M{"ts": -1}},
+		{"$limit": 1},
+	}
+
+	var resultMax struct {
+		Timestamp int64 `bson:"ts"`
+	}
+
+	// get max timestamp
+	// sort by the timestamp, limit it to 1 (only returns first result)
+	err = session.DB(res.DB.GetSelectedDB()).C(collectionName).Pipe(timestampMaxQuery).AllowDiskUse().One(&resultMax)
+
+	if err != nil {
+		res.Log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Could not retrieve maximum timestamp:", err)
+		return
+	}
+
+	// set range in metadatabase
+	err = res.MetaDB.AddTSRange(res.DB.GetSelectedDB(), resultMin.Timestamp, resultMax.Timestamp)
+	if err != nil {
+		res.Log.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Could not set ts range in metadatabase: ", err)
+	}
+
+}
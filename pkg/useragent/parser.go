@@ -0,0 +1,70 @@
+package useragent
+
+import (
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/util"
+)
+
+//parser gathers the user agents in a batch of http records and the JA3
+//hashes in a batch of ssl records
+type parser struct {
+	useragentMap map[string]*Input
+}
+
+//NewParser creates a parser which gathers user agents and JA3 hashes
+func NewParser() analysis.Parser {
+	return &parser{useragentMap: make(map[string]*Input)}
+}
+
+//Parse records the user agent of an HTTP request or the JA3 hash of a TLS
+//session along with its source and the host it was sent to
+func (p *parser) Parse(record *analysis.Record) {
+	switch record.Log {
+	case analysis.HTTPLog:
+		if record.Filtered {
+			return
+		}
+		name := record.HTTP.UserAgent
+		if name == "" {
+			name = "Empty user agent string"
+		}
+		p.add(name, false, record)
+
+	case analysis.SSLLog:
+		// JA3 hashes are gathered from every session, including those
+		// excluded by the filters
+		name := record.SSL.JA3
+		if name == "" {
+			name = "No JA3 hash generated"
+		}
+		p.add(name, true, record)
+	}
+}
+
+//add counts a sighting of a user agent or JA3 hash
+func (p *parser) add(name string, ja3 bool, record *analysis.Record) {
+	// create record if it doesn't exist
+	if _, ok := p.useragentMap[name]; !ok {
+		p.useragentMap[name] = &Input{
+			Name: name,
+			JA3:  ja3,
+		}
+	}
+	useragent := p.useragentMap[name]
+
+	// increment times seen count
+	useragent.Seen++
+
+	// add src of useragent request to unique array
+	useragent.OrigIps.Insert(record.Src)
+
+	// add request string to unique array
+	if !util.StringInSlice(record.FQDN, useragent.Requests) {
+		useragent.Requests = append(useragent.Requests, record.FQDN)
+	}
+}
+
+//Flush returns the user agents gathered from the batch
+func (p *parser) Flush(batch *analysis.Batch) interface{} {
+	return p.useragentMap
+}
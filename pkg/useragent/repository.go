@@ -31,5 +31,6 @@ type Input struct {
 //was seen in the dataset
 type Result struct {
 	UserAgent string `bson:"user_agent"`
+	JA3       bool   `bson:"ja3"`
 	TimesUsed int64  `bson:"seen"`
 }
@@ -16,15 +16,17 @@ func Results(res *resources.Resources, sortDirection, limit int, noLimit bool) (
 	var useragentResults []Result
 
 	useragentQuery := []bson.M{
-		bson.M{"$project": bson.M{"user_agent": 1, "seen": "$dat.seen"}},
+		bson.M{"$project": bson.M{"user_agent": 1, "ja3": 1, "seen": "$dat.seen"}},
 		bson.M{"$unwind": "$seen"},
 		bson.M{"$group": bson.M{
 			"_id":  "$user_agent",
+			"ja3":  bson.M{"$first": "$ja3"},
 			"seen": bson.M{"$sum": "$seen"},
 		}},
 		bson.M{"$project": bson.M{
 			"_id":        0,
 			"user_agent": "$_id",
+			"ja3":        1,
 			"seen":       1,
 		}},
 		bson.M{"$sort": bson.M{"seen": sortDirection}},
@@ -0,0 +1,65 @@
+package reporting
+
+import "github.com/activecm/rita/pkg/analysis"
+
+// The html report pages rendered for the analysis modules
+
+//DNSPage writes the DNS page
+func DNSPage(ctx *analysis.ReportContext) error {
+	return printDNS(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Res)
+}
+
+//BLSourceIPsPage writes the blacklisted source IPs page
+func BLSourceIPsPage(ctx *analysis.ReportContext) error {
+	return printBLSourceIPs(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Filter, ctx.Res)
+}
+
+//BLDestIPsPage writes the blacklisted destination IPs page
+func BLDestIPsPage(ctx *analysis.ReportContext) error {
+	return printBLDestIPs(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Filter, ctx.Res)
+}
+
+//BLHostnamesPage writes the blacklisted hostnames page
+func BLHostnamesPage(ctx *analysis.ReportContext) error {
+	return printBLHostnames(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Res)
+}
+
+//BeaconsPage writes the beacons page
+func BeaconsPage(ctx *analysis.ReportContext) error {
+	return printBeacons(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Filter, ctx.Res)
+}
+
+//StrobesPage writes the strobes page
+func StrobesPage(ctx *analysis.ReportContext) error {
+	return printStrobes(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Res)
+}
+
+//BeaconsFQDNPage writes the FQDN beacons page
+func BeaconsFQDNPage(ctx *analysis.ReportContext) error {
+	return printBeaconsFQDN(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Res)
+}
+
+//BeaconsProxyPage writes the proxy beacons page
+func BeaconsProxyPage(ctx *analysis.ReportContext) error {
+	return printBeaconsProxy(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Res)
+}
+
+//BeaconsSNIPage writes the SNI beacons page
+func BeaconsSNIPage(ctx *analysis.ReportContext) error {
+	return printBeaconsSNI(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Res)
+}
+
+//LongConnsPage writes the long connections page
+func LongConnsPage(ctx *analysis.ReportContext) error {
+	return printLongConns(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Filter, ctx.Res)
+}
+
+//ExfilPage writes the exfiltration page
+func ExfilPage(ctx *analysis.ReportContext) error {
+	return printExfil(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Res)
+}
+
+//UserAgentsPage writes the user agents page
+func UserAgentsPage(ctx *analysis.ReportContext) error {
+	return printUserAgents(ctx.DB, ctx.ShowNetNames, ctx.Suppressions, ctx.Res)
+}
@@ -6,11 +6,15 @@ import (
 	"os"
 
 	"github.com/activecm/rita/pkg/beacon"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/geoip"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/reporting/templates"
 	"github.com/activecm/rita/resources"
 )
 
-func printBeacons(db string, showNetNames bool, res *resources.Resources) error {
+func printBeacons(db string, showNetNames bool, sup *suppression.Matcher, filter geoip.Filter, res *resources.Resources) error {
 	var w string
 	f, err := os.Create("beacons.html")
 	if err != nil {
@@ -35,10 +39,39 @@ func printBeacons(db string, showNetNames bool, res *resources.Resources) error
 		return err
 	}
 
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.Finding{Src: d.SrcIP, Dst: d.DstIP}) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
+	var ips []string
+	for _, d := range data {
+		ips = append(ips, d.DstIP)
+	}
+	geo := loadGeo(res, ips)
+	n = 0
+	for _, d := range data {
+		if filter.Matches(geo[d.DstIP]) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
+	ips = ips[:0]
+	for _, d := range data {
+		ips = append(ips, d.DstIP)
+	}
+	resolved := loadResolutions(res, ips)
+
 	if len(data) == 0 {
 		w = ""
 	} else {
-		w, err = getBeaconWriter(data, showNetNames)
+		w, err = getBeaconWriter(data, showNetNames, geo, resolved)
 		if err != nil {
 			return err
 		}
@@ -47,10 +80,11 @@ func printBeacons(db string, showNetNames bool, res *resources.Resources) error
 	return out.Execute(f, &templates.ReportingInfo{DB: db, Writer: template.HTML(w)})
 }
 
-func getBeaconWriter(beacons []beacon.Result, showNetNames bool) (string, error) {
+func getBeaconWriter(beacons []beacon.Result, showNetNames bool, geo map[string]geoip.Info, resolved *hostname.Resolutions) (string, error) {
 	tmpl := "<tr>"
 
 	tmpl += "<td>{{printf \"%.3f\" .Score}}</td>"
+	tmpl += trendCellTmpl
 
 	if showNetNames {
 		tmpl += "<td>{{.SrcNetworkName}}</td><td>{{.DstNetworkName}}</td><td>{{.SrcIP}}</td><td>{{.DstIP}}</td>"
@@ -60,6 +94,8 @@ func getBeaconWriter(beacons []beacon.Result, showNetNames bool) (string, error)
 	tmpl += "<td>{{.Connections}}</td><td>{{printf \"%.3f\" .AvgBytes}}</td><td>"
 	tmpl += "{{.Ts.Range}}</td><td>{{.Ds.Range}}</td><td>{{.Ts.Mode}}</td><td>{{.Ds.Mode}}</td><td>{{.Ts.ModeCount}}</td><td>{{.Ds.ModeCount}}<td>"
 	tmpl += "{{printf \"%.3f\" .Ts.Skew}}</td><td>{{printf \"%.3f\" .Ds.Skew}}</td><td>{{.Ts.Dispersion}}</td><td>{{.Ds.Dispersion}}</td><td>{{.TotalBytes}}</td>"
+	tmpl += geoCellsTmpl
+	tmpl += hostnamesCellTmpl
 	tmpl += "</tr>\n"
 
 	out, err := template.New("beacon").Parse(tmpl)
@@ -70,7 +106,18 @@ func getBeaconWriter(beacons []beacon.Result, showNetNames bool) (string, error)
 	w := new(bytes.Buffer)
 
 	for _, result := range beacons {
-		err = out.Execute(w, result)
+		beaconTmplData := struct {
+			beacon.Result
+			Geo       geoip.Info
+			Hostnames []string
+			Trend     data.Trend
+		}{
+			result, geo[result.DstIP],
+			resolved.Hostnames(result.UniqueSrcIP.Unpair(), result.UniqueDstIP.Unpair()),
+			result.History.Trend(),
+		}
+
+		err = out.Execute(w, beaconTmplData)
 		if err != nil {
 			return "", err
 		}
@@ -6,11 +6,13 @@ import (
 	"os"
 
 	"github.com/activecm/rita/pkg/beaconfqdn"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/reporting/templates"
 	"github.com/activecm/rita/resources"
 )
 
-func printBeaconsFQDN(db string, showNetNames bool, res *resources.Resources) error {
+func printBeaconsFQDN(db string, showNetNames bool, sup *suppression.Matcher, res *resources.Resources) error {
 	var w string
 	f, err := os.Create("beaconsfqdn.html")
 	if err != nil {
@@ -35,6 +37,15 @@ func printBeaconsFQDN(db string, showNetNames bool, res *resources.Resources) er
 		return err
 	}
 
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.Finding{Src: d.SrcIP, FQDN: d.FQDN}) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
 	if len(data) == 0 {
 		w = ""
 	} else {
@@ -51,6 +62,7 @@ func getBeaconFQDNWriter(beaconsFQDN []beaconfqdn.Result, showNetNames bool) (st
 	tmpl := "<tr>"
 
 	tmpl += "<td>{{printf \"%.3f\" .Score}}</td>"
+	tmpl += trendCellTmpl
 
 	if showNetNames {
 		tmpl += "<td>{{.SrcNetworkName}}</td><td>{{.SrcIP}}</td><td>{{.FQDN}}</td>"
@@ -70,7 +82,12 @@ func getBeaconFQDNWriter(beaconsFQDN []beaconfqdn.Result, showNetNames bool) (st
 	w := new(bytes.Buffer)
 
 	for _, result := range beaconsFQDN {
-		err = out.Execute(w, result)
+		beaconTmplData := struct {
+			beaconfqdn.Result
+			Trend data.Trend
+		}{result, result.History.Trend()}
+
+		err = out.Execute(w, beaconTmplData)
 		if err != nil {
 			return "", err
 		}
@@ -6,11 +6,13 @@ import (
 	"os"
 
 	"github.com/activecm/rita/pkg/beaconproxy"
+	"github.com/activecm/rita/pkg/data"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/reporting/templates"
 	"github.com/activecm/rita/resources"
 )
 
-func printBeaconsProxy(db string, showNetNames bool, res *resources.Resources) error {
+func printBeaconsProxy(db string, showNetNames bool, sup *suppression.Matcher, res *resources.Resources) error {
 	var w string
 	f, err := os.Create("beaconsproxy.html")
 	if err != nil {
@@ -35,6 +37,15 @@ func printBeaconsProxy(db string, showNetNames bool, res *resources.Resources) e
 		return err
 	}
 
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.Finding{Src: d.SrcIP, Dst: d.DstIP, FQDN: d.FQDN}) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
 	if len(data) == 0 {
 		w = ""
 	} else {
@@ -51,6 +62,7 @@ func getBeaconProxyWriter(beaconsProxy []beaconproxy.Result, showNetNames bool)
 	tmpl := "<tr>"
 
 	tmpl += "<td>{{printf \"%.3f\" .Score}}</td>"
+	tmpl += trendCellTmpl
 
 	if showNetNames {
 		tmpl += "<td>{{.SrcNetworkName}}</td>"
@@ -76,7 +88,12 @@ func getBeaconProxyWriter(beaconsProxy []beaconproxy.Result, showNetNames bool)
 	w := new(bytes.Buffer)
 
 	for _, result := range beaconsProxy {
-		err = out.Execute(w, result)
+		beaconTmplData := struct {
+			beaconproxy.Result
+			Trend data.Trend
+		}{result, result.History.Trend()}
+
+		err = out.Execute(w, beaconTmplData)
 		if err != nil {
 			return "", err
 		}
@@ -0,0 +1,90 @@
+package reporting
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+
+	"github.com/activecm/rita/pkg/beaconsni"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/reporting/templates"
+	"github.com/activecm/rita/resources"
+)
+
+func printBeaconsSNI(db string, showNetNames bool, sup *suppression.Matcher, res *resources.Resources) error {
+	var w string
+	f, err := os.Create("beaconssni.html")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var beaconsSNITempl string
+	if showNetNames {
+		beaconsSNITempl = templates.BeaconsSNINetNamesTempl
+	} else {
+		beaconsSNITempl = templates.BeaconsSNITempl
+	}
+
+	out, err := template.New("beaconsni.html").Parse(beaconsSNITempl)
+	if err != nil {
+		return err
+	}
+
+	data, err := beaconsni.Results(res, 0)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.Finding{Src: d.SrcIP, FQDN: d.SNI, JA3: d.JA3}) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
+	if len(data) == 0 {
+		w = ""
+	} else {
+		w, err = getBeaconSNIWriter(data, showNetNames)
+		if err != nil {
+			return err
+		}
+	}
+
+	return out.Execute(f, &templates.ReportingInfo{DB: db, Writer: template.HTML(w)})
+}
+
+func getBeaconSNIWriter(beaconsSNI []beaconsni.Result, showNetNames bool) (string, error) {
+	tmpl := "<tr>"
+
+	tmpl += "<td>{{printf \"%.3f\" .Score}}</td>"
+
+	if showNetNames {
+		tmpl += "<td>{{.SrcNetworkName}}</td>"
+	}
+
+	tmpl += "<td>{{.SrcIP}}</td><td>{{.SNI}}</td><td>{{.JA3}}</td>"
+	tmpl += "<td>{{.Connections}}</td><td>{{.DstCount}}</td>"
+	tmpl += "<td>{{.Ts.Range}}</td><td>{{.Ts.Mode}}</td><td>{{.Ts.ModeCount}}</td>"
+	tmpl += "<td>{{printf \"%.3f\" .Ts.Skew}}</td><td>{{.Ts.Dispersion}}</td>"
+	tmpl += "</tr>\n"
+
+	out, err := template.New("beaconsni").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	w := new(bytes.Buffer)
+
+	for _, result := range beaconsSNI {
+		err = out.Execute(w, result)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return w.String(), nil
+}
@@ -5,11 +5,13 @@ import (
 	"os"
 
 	"github.com/activecm/rita/pkg/blacklist"
+	"github.com/activecm/rita/pkg/geoip"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/reporting/templates"
 	"github.com/activecm/rita/resources"
 )
 
-func printBLDestIPs(db string, showNetNames bool, res *resources.Resources) error {
+func printBLDestIPs(db string, showNetNames bool, sup *suppression.Matcher, filter geoip.Filter, res *resources.Resources) error {
 	f, err := os.Create("bl-dest-ips.html")
 	if err != nil {
 		return err
@@ -21,6 +23,29 @@ func printBLDestIPs(db string, showNetNames bool, res *resources.Resources) erro
 		return err
 	}
 
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.Finding{Dst: d.Host.IP}) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
+	var ips []string
+	for _, d := range data {
+		ips = append(ips, d.Host.IP)
+	}
+	geo := loadGeo(res, ips)
+	n = 0
+	for _, d := range data {
+		if filter.Matches(geo[d.Host.IP]) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
 	var blDestIPTempl string
 	if showNetNames {
 		blDestIPTempl = templates.BLDestIPNetNamesTempl
@@ -33,7 +58,7 @@ func printBLDestIPs(db string, showNetNames bool, res *resources.Resources) erro
 		return err
 	}
 
-	w, err := getBLIPWriter(data, showNetNames)
+	w, err := getBLIPWriter(data, showNetNames, geo)
 	if err != nil {
 		return err
 	}
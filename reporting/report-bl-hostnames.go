@@ -8,11 +8,12 @@ import (
 	"strings"
 
 	"github.com/activecm/rita/pkg/blacklist"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/reporting/templates"
 	"github.com/activecm/rita/resources"
 )
 
-func printBLHostnames(db string, showNetNames bool, res *resources.Resources) error {
+func printBLHostnames(db string, showNetNames bool, sup *suppression.Matcher, res *resources.Resources) error {
 	f, err := os.Create("bl-hostnames.html")
 	if err != nil {
 		return err
@@ -24,6 +25,15 @@ func printBLHostnames(db string, showNetNames bool, res *resources.Resources) er
 		return err
 	}
 
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.Finding{FQDN: d.Host}) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
 	out, err := template.New("bl-hostnames.html").Parse(templates.BLHostnameTempl)
 	if err != nil {
 		return err
@@ -8,11 +8,13 @@ import (
 	"strings"
 
 	"github.com/activecm/rita/pkg/blacklist"
+	"github.com/activecm/rita/pkg/geoip"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/reporting/templates"
 	"github.com/activecm/rita/resources"
 )
 
-func printBLSourceIPs(db string, showNetNames bool, res *resources.Resources) error {
+func printBLSourceIPs(db string, showNetNames bool, sup *suppression.Matcher, filter geoip.Filter, res *resources.Resources) error {
 	f, err := os.Create("bl-source-ips.html")
 	if err != nil {
 		return err
@@ -24,6 +26,29 @@ func printBLSourceIPs(db string, showNetNames bool, res *resources.Resources) er
 		return err
 	}
 
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.Finding{Src: d.Host.IP}) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
+	var ips []string
+	for _, d := range data {
+		ips = append(ips, d.Host.IP)
+	}
+	geo := loadGeo(res, ips)
+	n = 0
+	for _, d := range data {
+		if filter.Matches(geo[d.Host.IP]) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
 	var blSourceIPTempl string
 	if showNetNames {
 		blSourceIPTempl = templates.BLSourceIPNetNamesTempl
@@ -36,7 +61,7 @@ func printBLSourceIPs(db string, showNetNames bool, res *resources.Resources) er
 		return err
 	}
 
-	w, err := getBLIPWriter(data, showNetNames)
+	w, err := getBLIPWriter(data, showNetNames, geo)
 	if err != nil {
 		return err
 	}
@@ -44,18 +69,18 @@ func printBLSourceIPs(db string, showNetNames bool, res *resources.Resources) er
 	return out.Execute(f, &templates.ReportingInfo{DB: db, Writer: template.HTML(w)})
 }
 
-func getBLIPWriter(results []blacklist.IPResult, showNetNames bool) (string, error) {
+func getBLIPWriter(results []blacklist.IPResult, showNetNames bool, geo map[string]geoip.Info) (string, error) {
 	var tmpl string
 	if showNetNames {
 		tmpl = "<tr><td>{{.Host.IP}}</td><td>{{.Host.NetworkName}}</td><td>{{.Connections}}</td><td>{{.UniqueConnections}}</td>" +
 			"<td>{{.TotalBytes}}</td>" +
 			"<td>{{range $idx, $host := .ConnectedHostStrs}}{{if $idx}}, {{end}}{{ $host }}{{end}}</td>" +
-			"</tr>\n"
+			geoCellsTmpl + "</tr>\n"
 	} else {
 		tmpl = "<tr><td>{{.Host.IP}}</td><td>{{.Connections}}</td><td>{{.UniqueConnections}}</td>" +
 			"<td>{{.TotalBytes}}</td>" +
 			"<td>{{range $idx, $host := .ConnectedHostStrs}}{{if $idx}}, {{end}}{{ $host }}{{end}}</td>" +
-			"</tr>\n"
+			geoCellsTmpl + "</tr>\n"
 	}
 
 	out, err := template.New("blip").Parse(tmpl)
@@ -87,7 +112,8 @@ func getBLIPWriter(results []blacklist.IPResult, showNetNames bool) (string, err
 		formattedResult := struct {
 			blacklist.IPResult
 			ConnectedHostStrs []string
-		}{result, connectedHostStrs}
+			Geo               geoip.Info
+		}{result, connectedHostStrs, geo[result.Host.IP]}
 
 		err := out.Execute(w, formattedResult)
 		if err != nil {
@@ -0,0 +1,89 @@
+package reporting
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+
+	"github.com/activecm/rita/pkg/exfil"
+	"github.com/activecm/rita/pkg/suppression"
+	"github.com/activecm/rita/reporting/templates"
+	"github.com/activecm/rita/resources"
+)
+
+func printExfil(db string, showNetNames bool, sup *suppression.Matcher, res *resources.Resources) error {
+	f, err := os.Create("exfil.html")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var exfilTempl string
+	if showNetNames {
+		exfilTempl = templates.ExfilNetNamesTempl
+	} else {
+		exfilTempl = templates.ExfilTempl
+	}
+
+	out, err := template.New("exfil.html").Parse(exfilTempl)
+	if err != nil {
+		return err
+	}
+
+	res.DB.SelectDB(db)
+
+	data, err := exfil.Results(res, 1000, false)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.Finding{Src: d.Local.IP, Dst: d.Remote.IP}) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
+	w, err := getExfilWriter(data, showNetNames)
+	if err != nil {
+		return err
+	}
+	return out.Execute(f, &templates.ReportingInfo{DB: db, Writer: template.HTML(w)})
+}
+
+func getExfilWriter(results []exfil.Result, showNetNames bool) (string, error) {
+	tmpl := "<tr><td>{{printf \"%.3f\" .Score}}</td><td>{{.Type}}</td>"
+
+	if showNetNames {
+		tmpl += "<td>{{.Local.NetworkName}}</td>"
+	}
+	tmpl += "<td>{{.Local.IP}}</td>"
+
+	// host results cover every external peer of the internal host
+	if showNetNames {
+		tmpl += "{{if eq .Type \"host\"}}<td>*</td><td>*</td>{{else}}<td>{{.Remote.NetworkName}}</td><td>{{.Remote.IP}}</td>{{end}}"
+	} else {
+		tmpl += "{{if eq .Type \"host\"}}<td>*</td>{{else}}<td>{{.Remote.IP}}</td>{{end}}"
+	}
+
+	tmpl += "<td>{{.BytesSent}}</td><td>{{.BytesReceived}}</td><td>{{printf \"%.3f\" .UploadRatio}}</td>"
+	tmpl += "<td>{{printf \"%.3f\" .Deviation}}</td><td>{{.ConnectionCount}}</td></tr>\n"
+
+	out, err := template.New("exfil").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	w := new(bytes.Buffer)
+
+	for _, result := range results {
+		err = out.Execute(w, result)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return w.String(), nil
+}
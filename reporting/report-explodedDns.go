@@ -6,11 +6,12 @@ import (
 	"os"
 
 	"github.com/activecm/rita/pkg/explodeddns"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/reporting/templates"
 	"github.com/activecm/rita/resources"
 )
 
-func printDNS(db string, showNetNames bool, res *resources.Resources) error {
+func printDNS(db string, showNetNames bool, sup *suppression.Matcher, res *resources.Resources) error {
 	f, err := os.Create("dns.html")
 	if err != nil {
 		return err
@@ -26,6 +27,15 @@ func printDNS(db string, showNetNames bool, res *resources.Resources) error {
 		return err
 	}
 
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.Finding{FQDN: d.Domain}) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
 	out, err := template.New("dns.html").Parse(templates.DNStempl)
 	if err != nil {
 		return err
@@ -40,7 +50,7 @@ func printDNS(db string, showNetNames bool, res *resources.Resources) error {
 }
 
 func getDNSWriter(results []explodeddns.Result) (string, error) {
-	tmpl := "<tr><td>{{.SubdomainCount}}</td><td>{{.Visited}}</td><td>{{.Domain}}</td></tr>\n"
+	tmpl := "<tr><td>{{.SubdomainCount}}</td><td>{{.Visited}}</td><td>{{.NXDomainCount}}</td><td>{{.ServFailCount}}</td><td>{{.Domain}}</td></tr>\n"
 
 	out, err := template.New("dns").Parse(tmpl)
 	if err != nil {
@@ -6,12 +6,15 @@ import (
 	"os"
 	"strings"
 
+	"github.com/activecm/rita/pkg/geoip"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/pkg/uconn"
 	"github.com/activecm/rita/reporting/templates"
 	"github.com/activecm/rita/resources"
 )
 
-func printLongConns(db string, showNetNames bool, res *resources.Resources) error {
+func printLongConns(db string, showNetNames bool, sup *suppression.Matcher, filter geoip.Filter, res *resources.Resources) error {
 	f, err := os.Create("long-conns.html")
 	if err != nil {
 		return err
@@ -38,19 +41,48 @@ func printLongConns(db string, showNetNames bool, res *resources.Resources) erro
 		return err
 	}
 
-	w, err := getLongConnWriter(data, showNetNames)
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.Finding{Src: d.SrcIP, Dst: d.DstIP}) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
+	var ips []string
+	for _, d := range data {
+		ips = append(ips, d.DstIP)
+	}
+	geo := loadGeo(res, ips)
+	n = 0
+	for _, d := range data {
+		if filter.Matches(geo[d.DstIP]) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
+	ips = ips[:0]
+	for _, d := range data {
+		ips = append(ips, d.DstIP)
+	}
+	resolved := loadResolutions(res, ips)
+
+	w, err := getLongConnWriter(data, showNetNames, geo, resolved)
 	if err != nil {
 		return err
 	}
 	return out.Execute(f, &templates.ReportingInfo{DB: db, Writer: template.HTML(w)})
 }
 
-func getLongConnWriter(conns []uconn.LongConnResult, showNetNames bool) (string, error) {
+func getLongConnWriter(conns []uconn.LongConnResult, showNetNames bool, geo map[string]geoip.Info, resolved *hostname.Resolutions) (string, error) {
 	var tmpl string
 	if showNetNames {
-		tmpl = "<tr><td>{{.SrcNetworkName}}</td><td>{{.DstNetworkName}}</td><td>{{.SrcIP}}</td><td>{{.DstIP}}</td><td>{{.TupleStr}}</td><td>{{.MaxDuration}}</td></tr>\n"
+		tmpl = "<tr><td>{{.SrcNetworkName}}</td><td>{{.DstNetworkName}}</td><td>{{.SrcIP}}</td><td>{{.DstIP}}</td><td>{{.TupleStr}}</td><td>{{.MaxDuration}}</td>" + geoCellsTmpl + hostnamesCellTmpl + "</tr>\n"
 	} else {
-		tmpl = "<tr><td>{{.SrcIP}}</td><td>{{.DstIP}}</td><td>{{.TupleStr}}</td><td>{{.MaxDuration}}</td></tr>\n"
+		tmpl = "<tr><td>{{.SrcIP}}</td><td>{{.DstIP}}</td><td>{{.TupleStr}}</td><td>{{.MaxDuration}}</td>" + geoCellsTmpl + hostnamesCellTmpl + "</tr>\n"
 	}
 
 	out, err := template.New("Conn").Parse(tmpl)
@@ -61,8 +93,10 @@ func getLongConnWriter(conns []uconn.LongConnResult, showNetNames bool) (string,
 	for _, conn := range conns {
 		connTmplData := struct {
 			uconn.LongConnResult
-			TupleStr string
-		}{conn, strings.Join(conn.Tuples, ",  ")}
+			TupleStr  string
+			Geo       geoip.Info
+			Hostnames []string
+		}{conn, strings.Join(conn.Tuples, ",  "), geo[conn.DstIP], resolved.Hostnames(conn.UniqueSrcIP.Unpair(), conn.UniqueDstIP.Unpair())}
 
 		err := out.Execute(w, connTmplData)
 		if err != nil {
@@ -6,11 +6,13 @@ import (
 	"os"
 
 	"github.com/activecm/rita/pkg/beacon"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/reporting/templates"
 	"github.com/activecm/rita/resources"
 )
 
-func printStrobes(db string, showNetNames bool, res *resources.Resources) error {
+func printStrobes(db string, showNetNames bool, sup *suppression.Matcher, res *resources.Resources) error {
 	f, err := os.Create("strobes.html")
 	if err != nil {
 		return err
@@ -34,19 +36,34 @@ func printStrobes(db string, showNetNames bool, res *resources.Resources) error
 		return err
 	}
 
-	w, err := getStrobesWriter(data, showNetNames)
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.Finding{Src: d.SrcIP, Dst: d.DstIP}) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
+	var ips []string
+	for _, d := range data {
+		ips = append(ips, d.DstIP)
+	}
+	resolved := loadResolutions(res, ips)
+
+	w, err := getStrobesWriter(data, showNetNames, resolved)
 	if err != nil {
 		return err
 	}
 	return out.Execute(f, &templates.ReportingInfo{DB: db, Writer: template.HTML(w)})
 }
 
-func getStrobesWriter(strobes []beacon.StrobeResult, showNetNames bool) (string, error) {
+func getStrobesWriter(strobes []beacon.StrobeResult, showNetNames bool, resolved *hostname.Resolutions) (string, error) {
 	var tmpl string
 	if showNetNames {
-		tmpl = "<tr><td>{{.SrcNetworkName}}</td><td>{{.DstNetworkName}}</td><td>{{.SrcIP}}</td><td>{{.DstIP}}</td><td>{{.ConnectionCount}}</td></tr>\n"
+		tmpl = "<tr><td>{{.SrcNetworkName}}</td><td>{{.DstNetworkName}}</td><td>{{.SrcIP}}</td><td>{{.DstIP}}</td><td>{{.ConnectionCount}}</td>" + hostnamesCellTmpl + "</tr>\n"
 	} else {
-		tmpl = "<tr><td>{{.SrcIP}}</td><td>{{.DstIP}}</td><td>{{.ConnectionCount}}</td></tr>\n"
+		tmpl = "<tr><td>{{.SrcIP}}</td><td>{{.DstIP}}</td><td>{{.ConnectionCount}}</td>" + hostnamesCellTmpl + "</tr>\n"
 	}
 
 	out, err := template.New("Strobes").Parse(tmpl)
@@ -55,7 +72,12 @@ func getStrobesWriter(strobes []beacon.StrobeResult, showNetNames bool) (string,
 	}
 	w := new(bytes.Buffer)
 	for _, strobe := range strobes {
-		err := out.Execute(w, strobe)
+		strobeTmplData := struct {
+			beacon.StrobeResult
+			Hostnames []string
+		}{strobe, resolved.Hostnames(strobe.UniqueSrcIP.Unpair(), strobe.UniqueDstIP.Unpair())}
+
+		err := out.Execute(w, strobeTmplData)
 		if err != nil {
 			return "", err
 		}
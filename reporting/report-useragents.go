@@ -5,12 +5,13 @@ import (
 	"html/template"
 	"os"
 
+	"github.com/activecm/rita/pkg/suppression"
 	"github.com/activecm/rita/pkg/useragent"
 	"github.com/activecm/rita/reporting/templates"
 	"github.com/activecm/rita/resources"
 )
 
-func printUserAgents(db string, showNetNames bool, res *resources.Resources) error {
+func printUserAgents(db string, showNetNames bool, sup *suppression.Matcher, res *resources.Resources) error {
 	f, err := os.Create("useragents.html")
 	if err != nil {
 		return err
@@ -26,6 +27,15 @@ func printUserAgents(db string, showNetNames bool, res *resources.Resources) err
 		return err
 	}
 
+	n := 0
+	for _, d := range data {
+		if !sup.Suppressed(suppression.UserAgentFinding(d.UserAgent, d.JA3)) {
+			data[n] = d
+			n++
+		}
+	}
+	data = data[:n]
+
 	w, err := getUserAgentsWriter(data)
 	if err != nil {
 		return err
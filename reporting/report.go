@@ -8,6 +8,11 @@ import (
 	"os"
 	"strconv"
 
+	"github.com/activecm/rita/pkg/analysis"
+	"github.com/activecm/rita/pkg/geoip"
+	"github.com/activecm/rita/pkg/host"
+	"github.com/activecm/rita/pkg/hostname"
+	"github.com/activecm/rita/pkg/suppression"
 	htmlTempl "github.com/activecm/rita/reporting/templates"
 	"github.com/activecm/rita/resources"
 	"github.com/activecm/rita/util"
@@ -19,8 +24,10 @@ import (
 // will use HTML templating to write out the results of `rita analyze` into
 // a directory named after the selected dataset, or `rita-html-report` if
 // mupltiple were selected, within the current working directory,
-// mongodb must be running to call this command, will exit on any writing error
-func PrintHTML(dbsIn []string, showNetNames bool, noBrowser bool, res *resources.Resources) error {
+// mongodb must be running to call this command, will exit on any writing error.
+// Results matching the suppressions in sup are left out of the report, as are
+// external IPs which don't match the GeoIP filter.
+func PrintHTML(dbsIn []string, showNetNames bool, noBrowser bool, sup *suppression.Matcher, filter geoip.Filter, res *resources.Resources) error {
 	if len(dbsIn) == 0 {
 		return errors.New("no analyzed databases to report on")
 	}
@@ -73,12 +80,15 @@ func PrintHTML(dbsIn []string, showNetNames bool, noBrowser bool, res *resources
 
 	// Start db iteration
 	for k := range dbs {
-		err = writeDB(dbs[k], wd, showNetNames, res)
+		err = writeDB(dbs[k], wd, showNetNames, sup, filter, res)
 		if err != nil {
 			return err
 		}
 	}
 
+	if sup.Hidden() > 0 {
+		fmt.Printf("[-] Left %d suppressed results out of the report\n", sup.Hidden())
+	}
 	fmt.Println("[-] Wrote outputs, check " + wd + " for files")
 	if !noBrowser {
 		os.Chdir("..")
@@ -127,7 +137,7 @@ func writeDBHomePage(db string) error {
 	return out.Execute(f, htmlTempl.ReportingInfo{DB: db})
 }
 
-func writeDB(db string, wd string, showNetNames bool, res *resources.Resources) error {
+func writeDB(db string, wd string, showNetNames bool, sup *suppression.Matcher, filter geoip.Filter, res *resources.Resources) error {
 	writeDir := wd + "/" + db
 	var err error
 
@@ -149,56 +159,58 @@ func writeDB(db string, wd string, showNetNames bool, res *resources.Resources)
 		fmt.Println("[-] Error writing Home page: " + err.Error())
 	}
 
-	err = printDNS(db, showNetNames, res)
-	if err != nil {
-		fmt.Println("[-] Error writing DNS page: " + err.Error())
-	}
-	err = printBLSourceIPs(db, showNetNames, res)
-	if err != nil {
-		fmt.Println("[-] Error writing blacklist-source page: " + err.Error())
+	ctx := &analysis.ReportContext{
+		DB:           db,
+		ShowNetNames: showNetNames,
+		Suppressions: sup,
+		Filter:       filter,
+		Res:          res,
 	}
-	err = printBLDestIPs(db, showNetNames, res)
-	if err != nil {
-		fmt.Println("[-] Error writing blacklist-destination page: " + err.Error())
-	}
-	err = printBLHostnames(db, showNetNames, res)
-	if err != nil {
-		fmt.Println("[-] Error writing blacklist-hostnames page: " + err.Error())
+	for _, module := range analysis.Registered() {
+		for _, page := range module.Pages() {
+			err = page.Render(ctx)
+			if err != nil {
+				fmt.Println("[-] Error writing " + page.Name + " page: " + err.Error())
+			}
+		}
 	}
 
-	err = printBeacons(db, showNetNames, res)
+	err = os.Chdir("..")
 	if err != nil {
-		fmt.Println("[-] Error writing beacons page: " + err.Error())
+		fmt.Println("[-] Error changing to home directory, but if it got here all the pages are probably written: " + err.Error())
 	}
 
-	err = printBeaconsFQDN(db, showNetNames, res)
-	if err != nil {
-		fmt.Println("[-] Error writing beaconsFQDN page: " + err.Error())
-	}
+	return nil
+}
 
-	err = printBeaconsProxy(db, showNetNames, res)
+//loadGeo reads the GeoIP details recorded for the given IPs. Errors are logged
+//and the report is written without the details.
+func loadGeo(res *resources.Resources, ips []string) map[string]geoip.Info {
+	geo, err := host.GeoResults(res, ips)
 	if err != nil {
-		fmt.Println("[-] Error writing beaconsProxy page: " + err.Error())
+		res.Log.WithField("Module", "reporting").Error(err)
 	}
+	return geo
+}
 
-	err = printStrobes(db, showNetNames, res)
+//loadResolutions reads the hostnames which resolved to the given IPs. Errors
+//are logged and the report is written without the hostnames.
+func loadResolutions(res *resources.Resources, ips []string) *hostname.Resolutions {
+	resolved, err := hostname.ResolvedHostnames(res, ips)
 	if err != nil {
-		fmt.Println("[-] Error writing strobes page: " + err.Error())
+		res.Log.WithField("Module", "reporting").Error(err)
 	}
+	return resolved
+}
 
-	err = printLongConns(db, showNetNames, res)
-	if err != nil {
-		fmt.Println("[-] Error writing long connections page: " + err.Error())
-	}
-	err = printUserAgents(db, showNetNames, res)
-	if err != nil {
-		fmt.Println("[-] Error writing user agents page: " + err.Error())
-	}
+//hostnamesCellTmpl renders the hostnames of a result wrapped with its
+//Hostnames field
+const hostnamesCellTmpl = "<td>{{range $i, $h := .Hostnames}}{{if $i}} {{end}}{{$h}}{{end}}</td>"
 
-	err = os.Chdir("..")
-	if err != nil {
-		fmt.Println("[-] Error changing to home directory, but if it got here all the pages are probably written: " + err.Error())
-	}
+//trendCellTmpl renders a sparkline of a result's score history wrapped with
+//its Trend field
+const trendCellTmpl = "<td title=\"{{if ge .Trend.FirstSeen 0}}First seen in chunk {{.Trend.FirstSeen}}, " +
+	"{{printf \"%+.3f\" .Trend.Delta}} since the previous chunk{{end}}\">{{.Trend.Sparkline}}</td>"
 
-	return nil
-}
+//geoCellsTmpl renders the GeoIP details of a result wrapped with its Geo field
+const geoCellsTmpl = "<td>{{if .Geo.ASN}}AS{{.Geo.ASN}}{{end}}</td><td>{{.Geo.ASOrg}}</td><td>{{.Geo.Country}}</td>"
@@ -26,12 +26,14 @@ var dbHeader = `
   <li><a href="beacons.html">Beacons</a></li>
   <li><a href="beaconsfqdn.html">Beacons FQDN</a></li>
     <li><a href="beaconsproxy.html">Beacons Proxy</a></li>
+    <li><a href="beaconssni.html">Beacons SNI</a></li>
 	<li><a href="strobes.html">Strobes</a></li>
 	<li><a href="dns.html">DNS</a></li>
   <li><a href="bl-source-ips.html">BL Source IPs</a></li>
 	<li><a href="bl-dest-ips.html">BL Dest. IPs</a></li>
 	<li><a href="bl-hostnames.html">BL Hostnames</a></li>
 	<li><a href="long-conns.html">Long Connections</a></li>
+	<li><a href="exfil.html">Exfil</a></li>
 	<li><a href="useragents.html">User Agents</a></li>
 	<li style="float:right">
     <a href="https://github.com/activecm/rita" target="_blank">RITA on
@@ -76,7 +78,7 @@ var Hometempl = homeHeader + `
 var DNStempl = dbHeader + `
 <div class="container">
   <table>
-    <tr><th>Subdomain Count</th><th>Visited</th><th>Domain</th><tr>
+    <tr><th>Subdomain Count</th><th>Visited</th><th>NXDOMAIN</th><th>SERVFAIL</th><th>Domain</th><tr>
     {{.Writer}}
   </table>
 </div>
@@ -93,10 +95,10 @@ var DBhometempl = dbHeader + `
 var BeaconsTempl = dbHeader + `
 <div class="container">
   <table>
-  <tr><th>Score</th><th>Source</th><th>Destination</th><th>Connections</th><th>Avg. Bytes</th><th>
+  <tr><th>Score</th><th>Trend</th><th>Source</th><th>Destination</th><th>Connections</th><th>Avg. Bytes</th><th>
 	Intvl. Range</th><th>Size Range</th><th>Intvl. Mode</th><th>Size Mode</th><th>Intvl. Mode Count</th>
 	<th>Size Mode Count</th><th>Intvl. Skew</th><th>Size Skew</th><th>Intvl. Dispersion</th><th>Size Dispersion
-	</th><th>Total Bytes</th>
+	</th><th>Total Bytes</th><th>ASN</th><th>AS Org</th><th>Country</th><th>Resolved Hostnames</th>
 	</tr>
       {{.Writer}}
   </table>
@@ -108,10 +110,10 @@ var BeaconsNetNamesTempl = dbHeader + `
 <div class="container">
   <table>
   <tr>
-	<th>Score</th><th>Source Network</th><th>Destination Network</th><th>Source</th><th>Destination</th>
+	<th>Score</th><th>Trend</th><th>Source Network</th><th>Destination Network</th><th>Source</th><th>Destination</th>
 	<th>Connections</th><th>Avg. Bytes</th><th>Intvl. Range</th><th>Size Range</th><th>Intvl. Mode</th>
 	<th>Size Mode</th><th>Intvl. Mode Count</th><th>Size Mode Count</th><th>Intvl. Skew</th><th>Size Skew</th>
-	<th>Intvl. Dispersion</th><th>Size Dispersion</th><th>Total Bytes</th>
+	<th>Intvl. Dispersion</th><th>Size Dispersion</th><th>Total Bytes</th><th>ASN</th><th>AS Org</th><th>Country</th><th>Resolved Hostnames</th>
   </tr>
 	{{.Writer}}
   </table>
@@ -122,7 +124,7 @@ var BeaconsNetNamesTempl = dbHeader + `
 var BeaconsFQDNTempl = dbHeader + `
 <div class="container">
   <table>
-  <tr><th>Score</th><th>Source</th><th>FQDN</th><th>Connections</th><th>Avg. Bytes</th><th>
+  <tr><th>Score</th><th>Trend</th><th>Source</th><th>FQDN</th><th>Connections</th><th>Avg. Bytes</th><th>
 	Intvl. Range</th><th>Size Range</th><th>Intvl. Mode</th><th>Size Mode</th><th>Intvl. Mode Count</th>
 	<th>Size Mode Count</th><th>Intvl. Skew</th><th>Size Skew</th><th>Intvl. Dispersion</th><th>Size Dispersion
 	</th></tr>
@@ -136,7 +138,7 @@ var BeaconsFQDNNetNamesTempl = dbHeader + `
 <div class="container">
   <table>
   <tr>
-	<th>Score</th><th>Source Network</th><th>Source</th><th>FQDN</th>
+	<th>Score</th><th>Trend</th><th>Source Network</th><th>Source</th><th>FQDN</th>
 	<th>Connections</th><th>Avg. Bytes</th><th>Intvl. Range</th><th>Size Range</th><th>Intvl. Mode</th>
 	<th>Size Mode</th><th>Intvl. Mode Count</th><th>Size Mode Count</th><th>Intvl. Skew</th><th>Size Skew</th>
 	<th>Intvl. Dispersion</th><th>Size Dispersion</th>
@@ -150,7 +152,7 @@ var BeaconsFQDNNetNamesTempl = dbHeader + `
 var BeaconsProxyTempl = dbHeader + `
 <div class="container">
   <table>
-  <tr><th>Score</th><th>Source</th><th>FQDN</th><th>Proxy IP</th><th>Connections</th>
+  <tr><th>Score</th><th>Trend</th><th>Source</th><th>FQDN</th><th>Proxy IP</th><th>Connections</th>
   <th>Intvl. Range</th><th>Intvl. Mode</th><th>Intvl. Mode Count</th>
 	<th>Intvl. Skew</th><th>Intvl. Dispersion</th></tr>
       {{.Writer}}
@@ -163,7 +165,7 @@ var BeaconsProxyNetNamesTempl = dbHeader + `
 <div class="container">
   <table>
   <tr>
-  <tr><th>Score</th><th>Source Network</th><th>Source</th><th>FQDN</th><th>Proxy Network</th><th>Proxy IP</th>
+  <tr><th>Score</th><th>Trend</th><th>Source Network</th><th>Source</th><th>FQDN</th><th>Proxy Network</th><th>Proxy IP</th>
   <th>Connections</th><th>Intvl. Range</th><th>Intvl. Mode</th><th>Intvl. Mode Count</th>
 	<th>Intvl. Skew</th><th>Intvl. Dispersion</th></tr>
   </tr>
@@ -172,11 +174,35 @@ var BeaconsProxyNetNamesTempl = dbHeader + `
 </div>
 `
 
+// BeaconsSNITempl is our beaconsSNI html template
+var BeaconsSNITempl = dbHeader + `
+<div class="container">
+  <table>
+  <tr><th>Score</th><th>Source</th><th>SNI</th><th>JA3</th><th>Connections</th>
+  <th>Destination IPs</th><th>Intvl. Range</th><th>Intvl. Mode</th><th>Intvl. Mode Count</th>
+	<th>Intvl. Skew</th><th>Intvl. Dispersion</th></tr>
+      {{.Writer}}
+  </table>
+</div>
+`
+
+// BeaconsSNINetNamesTempl is our beaconsSNI html template with network names
+var BeaconsSNINetNamesTempl = dbHeader + `
+<div class="container">
+  <table>
+  <tr><th>Score</th><th>Source Network</th><th>Source</th><th>SNI</th><th>JA3</th>
+  <th>Connections</th><th>Destination IPs</th><th>Intvl. Range</th><th>Intvl. Mode</th>
+	<th>Intvl. Mode Count</th><th>Intvl. Skew</th><th>Intvl. Dispersion</th></tr>
+	{{.Writer}}
+  </table>
+</div>
+`
+
 //StrobesTempl is the strobes html template
 var StrobesTempl = dbHeader + `
 <div class="container">
   <table>
-	<tr><th>Source</th><th>Destination</th><th>Connection Count</th></tr>
+	<tr><th>Source</th><th>Destination</th><th>Connection Count</th><th>Resolved Hostnames</th></tr>
 	  {{.Writer}}
 	</table>
 </div>
@@ -186,7 +212,7 @@ var StrobesTempl = dbHeader + `
 var StrobesNetNamesTempl = dbHeader + `
 <div class="container">
   <table>
-	<tr><th>Source Network</th><th>Destination Network</th><th>Source</th><th>Destination</th><th>Connection Count</th></tr>
+	<tr><th>Source Network</th><th>Destination Network</th><th>Source</th><th>Destination</th><th>Connection Count</th><th>Resolved Hostnames</th></tr>
 	  {{.Writer}}
 	</table>
 </div>
@@ -196,7 +222,7 @@ var StrobesNetNamesTempl = dbHeader + `
 var BLSourceIPTempl = dbHeader + `
 <div class="container">
   <table>
-  <tr><th>IP</th><th>Connections</th><th>Unique Connections</th><th>Total Bytes</th><th>Destinations</th><tr>
+  <tr><th>IP</th><th>Connections</th><th>Unique Connections</th><th>Total Bytes</th><th>Destinations</th><th>ASN</th><th>AS Org</th><th>Country</th><tr>
     {{.Writer}}
   </table>
 </div>
@@ -206,7 +232,7 @@ var BLSourceIPTempl = dbHeader + `
 var BLSourceIPNetNamesTempl = dbHeader + `
 <div class="container">
   <table>
-  <tr><th>IP</th><th>Network</th><th>Connections</th><th>Unique Connections</th><th>Total Bytes</th><th>Destinations</th><tr>
+  <tr><th>IP</th><th>Network</th><th>Connections</th><th>Unique Connections</th><th>Total Bytes</th><th>Destinations</th><th>ASN</th><th>AS Org</th><th>Country</th><tr>
     {{.Writer}}
   </table>
 </div>
@@ -216,7 +242,7 @@ var BLSourceIPNetNamesTempl = dbHeader + `
 var BLDestIPTempl = dbHeader + `
 <div class="container">
   <table>
-  <tr><th>IP</th><th>Connections</th><th>Unique Connections</th><th>Total Bytes</th><th>Sources</th><tr>
+  <tr><th>IP</th><th>Connections</th><th>Unique Connections</th><th>Total Bytes</th><th>Sources</th><th>ASN</th><th>AS Org</th><th>Country</th><tr>
     {{.Writer}}
   </table>
 </div>
@@ -226,7 +252,7 @@ var BLDestIPTempl = dbHeader + `
 var BLDestIPNetNamesTempl = dbHeader + `
 <div class="container">
   <table>
-  <tr><th>IP</th><th>Network</th><th>Connections</th><th>Unique Connections</th><th>Total Bytes</th><th>Sources</th><tr>
+  <tr><th>IP</th><th>Network</th><th>Connections</th><th>Unique Connections</th><th>Total Bytes</th><th>Sources</th><th>ASN</th><th>AS Org</th><th>Country</th><tr>
     {{.Writer}}
   </table>
 </div>
@@ -242,11 +268,34 @@ var BLHostnameTempl = dbHeader + `
 </div>
 `
 
+// ExfilTempl is our exfil html template
+var ExfilTempl = dbHeader + `
+<div class="container">
+  <table>
+	<tr><th>Score</th><th>Type</th><th>Internal</th><th>External</th><th>Bytes Sent</th>
+	<th>Bytes Received</th><th>Upload Ratio</th><th>Chunk Deviation</th><th>Connections</th></tr>
+	  {{.Writer}}
+	</table>
+</div>
+`
+
+// ExfilNetNamesTempl is our exfil html template with network names
+var ExfilNetNamesTempl = dbHeader + `
+<div class="container">
+  <table>
+	<tr><th>Score</th><th>Type</th><th>Internal Network</th><th>Internal</th><th>External Network</th>
+	<th>External</th><th>Bytes Sent</th><th>Bytes Received</th><th>Upload Ratio</th>
+	<th>Chunk Deviation</th><th>Connections</th></tr>
+	  {{.Writer}}
+	</table>
+</div>
+`
+
 // LongConnsTempl is our long connections html template
 var LongConnsTempl = dbHeader + `
 <div class="container">
   <table>
-	<tr><th>Source</th><th>Destination</th><th>DstPort:Protocol:Service</th><th>Duration</th></tr>
+	<tr><th>Source</th><th>Destination</th><th>DstPort:Protocol:Service</th><th>Duration</th><th>ASN</th><th>AS Org</th><th>Country</th><th>Resolved Hostnames</th></tr>
 	  {{.Writer}}
 	</table>
 </div>
@@ -256,7 +305,7 @@ var LongConnsTempl = dbHeader + `
 var LongConnsNetNamesTempl = dbHeader + `
 <div class="container">
   <table>
-	<tr><th>Source Network</th><th>Destination Network</th><th>Source</th><th>Destination</th><th>DstPort:Protocol:Service</th><th>Duration</th></tr>
+	<tr><th>Source Network</th><th>Destination Network</th><th>Source</th><th>Destination</th><th>DstPort:Protocol:Service</th><th>Duration</th><th>ASN</th><th>AS Org</th><th>Country</th><th>Resolved Hostnames</th></tr>
 	  {{.Writer}}
 	</table>
 </div>
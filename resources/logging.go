@@ -10,6 +10,8 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/activecm/rita/config"
+	"github.com/activecm/rita/database"
+	"github.com/globalsign/mgo/bson"
 	"github.com/rifflock/lfshook"
 )
 
@@ -62,3 +64,52 @@ func addFileLogger(logger *log.Logger, logPath string) {
 		log.PanicLevel: path.Join(logPath, logFile),
 	}, nil))
 }
+
+//dbHook is a logrus hook which places log entries in a collection
+//of the configured storage backend
+type dbHook struct {
+	session    database.Session
+	db         string
+	collection string
+}
+
+//newDBHook readies a hook to place logs inside of the given collection
+func newDBHook(session database.Session, db, collection string) *dbHook {
+	return &dbHook{session: session, db: db, collection: collection}
+}
+
+//Fire places a logrus entry into the log collection
+func (h *dbHook) Fire(entry *log.Entry) error {
+	data := make(bson.M)
+	data["Level"] = entry.Level
+	data["Time"] = entry.Time
+	data["Message"] = entry.Message
+
+	for k, v := range entry.Data {
+		if errData, isError := v.(error); log.ErrorKey == k && v != nil && isError {
+			data[k] = errData.Error()
+		} else {
+			data[k] = v
+		}
+	}
+
+	ssn := h.session.Copy()
+	defer ssn.Close()
+	err := ssn.DB(h.db).C(h.collection).Insert(data)
+	if err != nil {
+		return fmt.Errorf("Failed to send log entry to the database: %v", err)
+	}
+	return nil
+}
+
+//Levels returns the logrus levels the hook supports
+func (h *dbHook) Levels() []log.Level {
+	return []log.Level{
+		log.PanicLevel,
+		log.FatalLevel,
+		log.ErrorLevel,
+		log.WarnLevel,
+		log.InfoLevel,
+		log.DebugLevel,
+	}
+}
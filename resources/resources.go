@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/activecm/mgorus"
 	"github.com/activecm/rita/config"
 	"github.com/activecm/rita/database"
 	log "github.com/sirupsen/logrus"
@@ -45,9 +44,7 @@ func InitResources(userConfig string) *Resources {
 	//Begin logging to the metadatabase
 	if conf.S.Log.LogToDB {
 		log.Hooks.Add(
-			mgorus.NewHookerFromSession(
-				db.Session, conf.S.MongoDB.MetaDB, conf.T.Log.RitaLogTable,
-			),
+			newDBHook(db.Session, conf.S.MongoDB.MetaDB, conf.T.Log.RitaLogTable),
 		)
 	}
 
@@ -6,7 +6,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/activecm/mgorus"
 	"github.com/activecm/rita/config"
 	"github.com/activecm/rita/database"
 )
@@ -46,9 +45,7 @@ func InitIntegrationTestingResources(t *testing.T) *Resources {
 	//Begin logging to the metadatabase
 	if conf.S.Log.LogToDB {
 		log.Hooks.Add(
-			mgorus.NewHookerFromSession(
-				db.Session, conf.S.MongoDB.MetaDB, conf.T.Log.RitaLogTable,
-			),
+			newDBHook(db.Session, conf.S.MongoDB.MetaDB, conf.T.Log.RitaLogTable),
 		)
 	}
 
@@ -88,9 +85,7 @@ func InitTestResources() *Resources {
 	//Begin logging to the metadatabase
 	if conf.S.Log.LogToDB {
 		log.Hooks.Add(
-			mgorus.NewHookerFromSession(
-				db.Session, conf.S.MongoDB.MetaDB, conf.T.Log.RitaLogTable,
-			),
+			newDBHook(db.Session, conf.S.MongoDB.MetaDB, conf.T.Log.RitaLogTable),
 		)
 	}
 
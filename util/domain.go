@@ -0,0 +1,38 @@
+package util
+
+import (
+	"net"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+//SplitRegisteredDomain splits a fully qualified domain name into its registered
+//domain (the public suffix plus one label) and the subdomain labels in front of it.
+//If the registered domain cannot be determined, the whole name is returned
+//as the registered domain.
+func SplitRegisteredDomain(fqdn string) (string, string) {
+	fqdn = strings.TrimSuffix(strings.ToLower(fqdn), ".")
+
+	registered, err := publicsuffix.EffectiveTLDPlusOne(fqdn)
+	if err != nil {
+		return fqdn, ""
+	}
+
+	subdomain := strings.TrimSuffix(strings.TrimSuffix(fqdn, registered), ".")
+	return registered, subdomain
+}
+
+//ConnectTarget lower cases the host:port target of a CONNECT request or a
+//server name and strips the port number, IPv6 brackets, and trailing dot
+func ConnectTarget(target string) string {
+	target = strings.ToLower(strings.TrimSpace(target))
+	if target == "-" {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		target = host
+	}
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "["), "]")
+	return strings.TrimSuffix(target, ".")
+}
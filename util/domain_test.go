@@ -0,0 +1,36 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRegisteredDomain(t *testing.T) {
+	tables := []struct {
+		fqdn       string
+		registered string
+		subdomain  string
+	}{
+		{"www.google.com", "google.com", "www"},
+		{"google.com", "google.com", ""},
+		{"a.b.example.co.uk", "example.co.uk", "a.b"},
+		{"MAIL.Example.ORG.", "example.org", "mail"},
+		{"localhost", "localhost", ""},
+		{"", "", ""},
+	}
+
+	for _, test := range tables {
+		registered, subdomain := SplitRegisteredDomain(test.fqdn)
+		require.Equal(t, test.registered, registered, test.fqdn)
+		require.Equal(t, test.subdomain, subdomain, test.fqdn)
+	}
+}
+
+func TestConnectTarget(t *testing.T) {
+	require.Equal(t, "www.example.com", ConnectTarget("WWW.Example.com:443"))
+	require.Equal(t, "www.example.com", ConnectTarget("www.example.com."))
+	require.Equal(t, "93.184.216.34", ConnectTarget("93.184.216.34:8443"))
+	require.Equal(t, "2001:db8::1", ConnectTarget("[2001:db8::1]:443"))
+	require.Equal(t, "", ConnectTarget("-"))
+}
@@ -88,3 +88,39 @@ func StringInSlice(value string, list []string) bool {
 	}
 	return false
 }
+
+//Int64InSlice returns true if the integer is an element of the array
+func Int64InSlice(value int64, list []int64) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
+
+//ShannonEntropy returns the Shannon entropy of a string in bits per character
+func ShannonEntropy(value string) float64 {
+	if len(value) == 0 {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	total := 0
+	for _, char := range value {
+		counts[char]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		freq := float64(count) / float64(total)
+		entropy -= freq * math.Log2(freq)
+	}
+	return entropy
+}
+
+//Clamp restricts a score to the range [0, 1]
+func Clamp(score float64) float64 {
+	return math.Max(0, math.Min(1, score))
+}
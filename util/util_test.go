@@ -108,3 +108,37 @@ func TestStringInSlice(t *testing.T) {
 	}
 
 }
+
+func TestShannonEntropy(t *testing.T) {
+	tables := []struct {
+		val string
+		out float64
+	}{
+		{"", 0},
+		{"aaaa", 0},
+		{"ab", 1},
+		{"abcd", 2},
+		{"aabb", 1},
+	}
+
+	for _, test := range tables {
+		require.InDelta(t, test.out, ShannonEntropy(test.val), 0.0001)
+	}
+}
+
+func TestClamp(t *testing.T) {
+	tables := []struct {
+		val float64
+		out float64
+	}{
+		{-0.5, 0},
+		{0, 0},
+		{0.25, 0.25},
+		{1, 1},
+		{3, 1},
+	}
+
+	for _, test := range tables {
+		require.Equal(t, test.out, Clamp(test.val))
+	}
+}